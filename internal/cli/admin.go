@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"sort"
+	"strings"
+)
+
+// Admin commands run once against the database and exit, the http and asynq servers are never started
+type adminCommand struct {
+	usage string
+	run   func(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error
+}
+
+var adminCommands = map[string]map[string]adminCommand{
+	"user": {
+		"create":         {usage: "--username <name> --display-name <name> [--password <password>] [--role ADMIN|USER] [--dummy]", run: runUserCreate},
+		"reset-password": {usage: "--username <name> [--password <password>]", run: runUserResetPassword},
+		"set-role":       {usage: "--username <name> --role ADMIN|USER", run: runUserSetRole},
+	},
+	"group": {
+		"list": {usage: "[--include-all-groups]", run: runGroupList},
+	},
+	"receipts": {
+		"reprocess": {usage: "--group <id> [--status OPEN|NEEDS_ATTENTION|RESOLVED|DRAFT] [--username <name>]", run: runReceiptsReprocess},
+	},
+	"tasks": {
+		"prune": {usage: "[--older-than-days <days>] [--status SUCCEEDED|FAILED]", run: runTasksPrune},
+	},
+	"config": {
+		"validate": {usage: "", run: runConfigValidate},
+	},
+	"apikey": {
+		"create": {usage: "--username <name> --name <name> [--scope r|w|rw] [--description <text>] [--expires-in-days <days>] [--groups <id,id>]", run: runApiKeyCreate},
+	},
+}
+
+func IsAdminCommand(name string) bool {
+	_, ok := adminCommands[name]
+	return ok
+}
+
+// RunAdminCommand runs args of the form <command> <action> [flags], passwords not passed as flags are read from in
+func RunAdminCommand(args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 || !IsAdminCommand(args[0]) {
+		return errors.New("unknown admin command\n" + buildUsage(""))
+	}
+
+	actions := adminCommands[args[0]]
+	if len(args) < 2 {
+		return fmt.Errorf("missing action\n%s", buildUsage(args[0]))
+	}
+
+	command, ok := actions[args[1]]
+	if !ok {
+		return fmt.Errorf("unknown action %q\n%s", args[1], buildUsage(args[0]))
+	}
+
+	flags := flag.NewFlagSet(args[0]+" "+args[1], flag.ContinueOnError)
+	flags.SetOutput(out)
+	return command.run(flags, args[2:], in, out)
+}
+
+func buildUsage(commandName string) string {
+	lines := make([]string, 0)
+	for name, actions := range adminCommands {
+		if len(commandName) > 0 && name != commandName {
+			continue
+		}
+
+		for action, command := range actions {
+			lines = append(lines, strings.TrimSpace(fmt.Sprintf("  %s %s %s", name, action, command.usage)))
+		}
+	}
+	sort.Strings(lines)
+
+	return "usage:\n  " + strings.Join(lines, "\n  ")
+}
+
+func requireFlags(values map[string]string) error {
+	missing := make([]string, 0)
+	for name, value := range values {
+		if len(value) == 0 {
+			missing = append(missing, "--"+name)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing required flags: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// readPassword uses the flag value when given, otherwise the first line of in, so it stays out of the shell history
+func readPassword(password string, in io.Reader) (string, error) {
+	if len(password) > 0 {
+		return password, nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	if !scanner.Scan() {
+		if scanner.Err() != nil {
+			return "", scanner.Err()
+		}
+		return "", errors.New("password is required, pass --password or write it to stdin")
+	}
+
+	return strings.TrimRight(scanner.Text(), "\r"), nil
+}
+
+func validatorErrorToError(vErr structs.ValidatorError) error {
+	if len(vErr.Errors) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(vErr.Errors))
+	for field, message := range vErr.Errors {
+		messages = append(messages, field+": "+message)
+	}
+	sort.Strings(messages)
+
+	return errors.New(strings.Join(messages, ", "))
+}
+
+func getUserByUsername(username string) (structs.UserView, error) {
+	user, err := repositories.NewUserRepository(nil).GetUserByUsername(username)
+	if err != nil {
+		return structs.UserView{}, fmt.Errorf("user %q not found: %w", username, err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"bytes"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func runTestAdminCommand(input string, args ...string) (string, error) {
+	out := bytes.Buffer{}
+	err := RunAdminCommand(args, strings.NewReader(input), &out)
+	return out.String(), err
+}
+
+func TestShouldRejectUnknownAdminCommands(t *testing.T) {
+	_, err := runTestAdminCommand("", "users", "create")
+	if err == nil || !strings.Contains(err.Error(), "unknown admin command") {
+		utils.PrintTestError(t, err, "unknown admin command")
+	}
+
+	_, err = runTestAdminCommand("", "user", "delete")
+	if err == nil || !strings.Contains(err.Error(), "user set-role") {
+		utils.PrintTestError(t, err, "usage of the user actions")
+	}
+
+	_, err = runTestAdminCommand("", "user", "set-role", "--role", "ADMIN")
+	if err == nil || err.Error() != "missing required flags: --username" {
+		utils.PrintTestError(t, err, "missing required flags: --username")
+	}
+}
+
+func TestShouldCreateUserWithPasswordFromStdin(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	out, err := runTestAdminCommand("secret\n", "user", "create", "--username", "recovery", "--display-name", "Recovery", "--role", "ADMIN")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	var user models.User
+	repositories.GetDB().Where("username = ?", "recovery").First(&user)
+	if user.UserRole != models.ADMIN {
+		utils.PrintTestError(t, user.UserRole, models.ADMIN)
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("secret")) != nil {
+		utils.PrintTestError(t, "password mismatch", "password read from stdin")
+	}
+
+	if !strings.Contains(out, "Created ADMIN user recovery") {
+		utils.PrintTestError(t, out, "Created ADMIN user recovery")
+	}
+
+	var auditLog models.AuditLog
+	repositories.GetDB().Where("action = ?", models.AUDIT_USER_CREATED).First(&auditLog)
+	if auditLog.EntityId != utils.UintToString(user.ID) || !strings.Contains(auditLog.Description, "command line") {
+		utils.PrintTestError(t, auditLog, "user created audit log")
+	}
+
+	if !strings.Contains(auditLog.After, `"username":"recovery"`) || strings.Contains(auditLog.After, user.Password) {
+		utils.PrintTestError(t, auditLog.After, "snapshot of the user without the password hash")
+	}
+
+	_, err = runTestAdminCommand("secret\n", "user", "create", "--username", "recovery", "--display-name", "Recovery")
+	if err == nil || !strings.Contains(err.Error(), "Username already exists") {
+		utils.PrintTestError(t, err, "Username already exists")
+	}
+}
+
+func TestShouldResetPasswordAndRecordAuditLog(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	_, err := runTestAdminCommand("", "user", "reset-password", "--username", "test", "--password", "new-password")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	var user models.User
+	repositories.GetDB().Where("username = ?", "test").First(&user)
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password")) != nil {
+		utils.PrintTestError(t, "password mismatch", "new-password")
+	}
+
+	var auditLogCount int64
+	repositories.GetDB().Model(&models.AuditLog{}).
+		Where("action = ? AND entity_id = ?", models.AUDIT_PASSWORD_RESET, utils.UintToString(user.ID)).
+		Count(&auditLogCount)
+	if auditLogCount != 1 {
+		utils.PrintTestError(t, auditLogCount, 1)
+	}
+
+	_, err = runTestAdminCommand("", "user", "reset-password", "--username", "missing", "--password", "new-password")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		utils.PrintTestError(t, err, "user not found")
+	}
+}
+
+func TestShouldNotResetPasswordOfDummyUser(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	repositories.GetDB().Model(&models.User{}).Where("username = ?", "test").Update("is_dummy_user", true)
+
+	_, err := runTestAdminCommand("", "user", "reset-password", "--username", "test", "--password", "new-password")
+	if err == nil || !strings.Contains(err.Error(), "dummy user") {
+		utils.PrintTestError(t, err, "dummy user error")
+	}
+
+	var user models.User
+	repositories.GetDB().Where("username = ?", "test").First(&user)
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password")) == nil {
+		utils.PrintTestError(t, "password reset", "unchanged password")
+	}
+}
+
+func TestShouldSetUserRole(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	_, err := runTestAdminCommand("", "user", "set-role", "--username", "test1", "--role", "ADMIN")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	user, _ := repositories.NewUserRepository(nil).GetUserByUsername("test1")
+	if user.UserRole != models.ADMIN {
+		utils.PrintTestError(t, user.UserRole, models.ADMIN)
+	}
+
+	_, err = runTestAdminCommand("", "user", "set-role", "--username", "test1", "--role", "OWNER")
+	if err == nil || !strings.Contains(err.Error(), "invalid role") {
+		utils.PrintTestError(t, err, "invalid role")
+	}
+}
+
+func TestShouldListGroupsWithoutAllGroups(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	repositories.NewGroupRepository(nil).CreateAllGroup(1)
+
+	out, err := runTestAdminCommand("", "group", "list")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1 ") || !strings.Contains(lines[1], "test") {
+		utils.PrintTestError(t, out, "header and the two test groups")
+	}
+
+	out, _ = runTestAdminCommand("", "group", "list", "--include-all-groups")
+	if !strings.Contains(out, "All") {
+		utils.PrintTestError(t, out, "all group listed")
+	}
+}
+
+func TestShouldPruneOldSystemTasks(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	db := repositories.GetDB()
+	oldDate := time.Now().AddDate(0, 0, -40)
+
+	oldTask := models.SystemTask{Type: models.QUICK_SCAN, Status: models.SYSTEM_TASK_FAILED, AssociatedEntityType: models.RECEIPT, StartedAt: oldDate}
+	db.Create(&oldTask)
+	db.Model(&oldTask).UpdateColumn("created_at", oldDate)
+
+	oldSucceededTask := models.SystemTask{Type: models.QUICK_SCAN, Status: models.SYSTEM_TASK_SUCCEEDED, AssociatedEntityType: models.RECEIPT, StartedAt: oldDate}
+	db.Create(&oldSucceededTask)
+	db.Model(&oldSucceededTask).UpdateColumn("created_at", oldDate)
+
+	newChildTask := models.SystemTask{
+		Type:                   models.OCR_PROCESSING,
+		Status:                 models.SYSTEM_TASK_SUCCEEDED,
+		AssociatedEntityType:   models.RECEIPT,
+		StartedAt:              time.Now(),
+		AssociatedSystemTaskId: &oldTask.ID,
+	}
+	db.Create(&newChildTask)
+
+	out, err := runTestAdminCommand("", "tasks", "prune", "--older-than-days", "30", "--status", "FAILED")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if !strings.HasPrefix(out, "Deleted 1 system tasks") {
+		utils.PrintTestError(t, out, "Deleted 1 system tasks")
+	}
+
+	var remainingTasks []models.SystemTask
+	db.Order("id").Find(&remainingTasks)
+	if len(remainingTasks) != 2 || remainingTasks[0].ID != oldSucceededTask.ID || remainingTasks[1].AssociatedSystemTaskId != nil {
+		utils.PrintTestError(t, remainingTasks, "old succeeded task and detached child task")
+	}
+}
+
+func TestShouldCreateApiKeyForUser(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	repositories.CreateTestGroupWithUsers()
+
+	out, err := runTestAdminCommand("", "apikey", "create", "--username", "test", "--name", "Backups", "--scope", "r", "--groups", "1")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	apiKeyService := services.NewApiKeyService(nil)
+	apiKey, err := apiKeyService.ValidateV1ApiKey(lines[len(lines)-1])
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if apiKey.Name != "Backups" || apiKey.Scope != "r" || *apiKey.UserID != 1 {
+		utils.PrintTestError(t, apiKey, "read key named Backups for user 1")
+	}
+
+	_, err = runTestAdminCommand("", "apikey", "create", "--username", "test", "--name", "Other", "--groups", "2")
+	if err == nil {
+		utils.PrintTestError(t, err, "error for a group the user is not in")
+	}
+}
+
+func TestShouldNotReprocessReceiptsOfUnknownGroup(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	repositories.GetDB().Model(&models.User{}).Where("id = ?", 1).Update("user_role", models.ADMIN)
+
+	_, err := runTestAdminCommand("", "receipts", "reprocess", "--group", "99")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		utils.PrintTestError(t, err, "group not found")
+	}
+
+	out, err := runTestAdminCommand("", "receipts", "reprocess", "--group", "1", "--status", "DRAFT")
+	if err != nil || !strings.Contains(out, "Reprocessed 0 of 0 receipts in test") {
+		utils.PrintTestError(t, out, "Reprocessed 0 of 0 receipts in test")
+	}
+}
+
+func TestShouldValidatePublicUrlAndExportDirectory(t *testing.T) {
+	t.Setenv("PUBLIC_URL", "receipts.example.com")
+	if checkPublicUrl() == nil {
+		utils.PrintTestError(t, nil, "error for url without scheme")
+	}
+
+	t.Setenv("PUBLIC_URL", "https://receipts.example.com/")
+	if err := checkPublicUrl(); err != nil {
+		utils.PrintTestError(t, err, nil)
+	}
+
+	t.Setenv("EXPORT_DIRECTORY", t.TempDir())
+	if err := checkExportDirectory(); err != nil {
+		utils.PrintTestError(t, err, nil)
+	}
+
+	t.Setenv("EXPORT_DIRECTORY", "/does/not/exist")
+	if checkExportDirectory() == nil {
+		utils.PrintTestError(t, nil, "error for missing directory")
+	}
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"time"
+)
+
+func runApiKeyCreate(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error {
+	username := flags.String("username", "", "user the key acts as")
+	name := flags.String("name", "", "name of the key")
+	description := flags.String("description", "", "description of the key")
+	scope := flags.String("scope", string(models.API_KEY_SCOPE_READ_WRITE), "r, w or rw")
+	expiresInDays := flags.Int("expires-in-days", 0, "days until the key expires, keys without one never expire")
+	groups := flags.String("groups", "", "comma separated ids of the groups the key is restricted to")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	err = requireFlags(map[string]string{"username": *username})
+	if err != nil {
+		return err
+	}
+
+	user, err := getUserByUsername(*username)
+	if err != nil {
+		return err
+	}
+
+	command := commands.UpsertApiKeyCommand{
+		Name:        *name,
+		Description: *description,
+		Scope:       *scope,
+	}
+
+	if *expiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, *expiresInDays)
+		command.ExpiresAt = &expiresAt
+	}
+
+	for _, groupId := range strings.Split(*groups, ",") {
+		groupId = strings.TrimSpace(groupId)
+		if len(groupId) == 0 {
+			continue
+		}
+
+		uintGroupId, err := utils.StringToUint(groupId)
+		if err != nil {
+			return fmt.Errorf("invalid group id %q", groupId)
+		}
+		command.AllowedGroupIds = append(command.AllowedGroupIds, uintGroupId)
+	}
+
+	err = validatorErrorToError(command.Validate())
+	if err != nil {
+		return err
+	}
+
+	// Keys are hashed with the pepper, which the server creates on its first start
+	err = services.NewPepperService(nil).InitPepper()
+	if err != nil {
+		return err
+	}
+
+	apiKeyService := services.NewApiKeyService(nil)
+	err = apiKeyService.ValidateAllowedGroupIds(user.ID, command.AllowedGroupIds)
+	if err != nil {
+		return err
+	}
+
+	generatedKey, err := apiKeyService.CreateApiKey(user.ID, command)
+	if err != nil {
+		return err
+	}
+
+	apiKeyId, err := apiKeyService.GetIdFromV1ApiKey(generatedKey)
+	if err != nil {
+		return err
+	}
+
+	services.NewAuditLogService(nil).RecordAuditLog(commands.UpsertAuditLogCommand{
+		Action:      models.AUDIT_API_KEY_CREATED,
+		EntityType:  models.AUDIT_ENTITY_API_KEY,
+		EntityId:    apiKeyId,
+		Description: fmt.Sprintf("Created API key '%s' for user '%s' from the command line", command.Name, user.Username),
+	})
+
+	fmt.Fprintf(out, "Created API key %s for %s, it is only shown once:\n%s\n", command.Name, user.Username, generatedKey)
+	return nil
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"receipt-wrangler/api/internal/constants"
+	config "receipt-wrangler/api/internal/env"
+	"receipt-wrangler/api/internal/migrations"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+
+	"github.com/hibiken/asynq"
+)
+
+type configCheck struct {
+	name  string
+	check func() error
+}
+
+// runConfigValidate checks the environment the server would start with, it runs before anything is connected
+func runConfigValidate(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error {
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	checks := []configCheck{
+		{name: "secret key", check: checkRequiredVariable(constants.SecretKey)},
+		{name: "encryption key", check: checkRequiredVariable(constants.EncryptionKey)},
+		{name: "database", check: checkDatabaseConfig},
+		{name: "storage", check: checkStorageConfig},
+		{name: "redis", check: checkRedisConfig},
+		{name: "public url", check: checkPublicUrl},
+		{name: "export directory", check: checkExportDirectory},
+	}
+
+	failedCount := 0
+	for _, check := range checks {
+		err := check.check()
+		if err != nil {
+			failedCount++
+			fmt.Fprintf(out, "FAIL %s: %s\n", check.name, err.Error())
+			continue
+		}
+
+		fmt.Fprintf(out, "ok   %s\n", check.name)
+	}
+
+	if failedCount > 0 {
+		return fmt.Errorf("%d configuration checks failed", failedCount)
+	}
+
+	return nil
+}
+
+func checkRequiredVariable(variable constants.EnvironmentVariable) func() error {
+	return func() error {
+		if len(os.Getenv(string(variable))) == 0 {
+			return fmt.Errorf("%s is not set", variable)
+		}
+		return nil
+	}
+}
+
+func checkDatabaseConfig() error {
+	dbConfig, err := config.GetDatabaseConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := repositories.OpenDatabase(dbConfig)
+	if err != nil {
+		return err
+	}
+
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDb.Close()
+
+	err = sqlDb.Ping()
+	if err != nil {
+		return err
+	}
+
+	// Pending migrations are fine, the server applies them when it starts
+	return migrations.CheckSchemaVersion(db)
+}
+
+func checkStorageConfig() error {
+	storageConfig, err := config.GetStorageConfig()
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.NewBackendStorage(storageConfig)
+	return err
+}
+
+func checkRedisConfig() error {
+	opts, err := config.GetAsynqRedisClientConnectionOptions()
+	if err != nil {
+		return err
+	}
+
+	client := asynq.NewClient(opts)
+	defer client.Close()
+
+	return client.Ping()
+}
+
+func checkPublicUrl() error {
+	publicUrl := config.GetPublicUrl()
+	if len(publicUrl) == 0 {
+		return nil
+	}
+
+	parsedUrl, err := url.Parse(publicUrl)
+	if err != nil {
+		return err
+	}
+
+	if (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || len(parsedUrl.Host) == 0 {
+		return fmt.Errorf("%s has to be an http or https url", constants.PublicUrl)
+	}
+
+	return nil
+}
+
+func checkExportDirectory() error {
+	exportDirectory := config.GetExportDirectory()
+	if len(exportDirectory) == 0 {
+		return nil
+	}
+
+	info, err := os.Stat(exportDirectory)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return errors.New(exportDirectory + " is not a directory")
+	}
+
+	return nil
+}
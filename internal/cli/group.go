@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"receipt-wrangler/api/internal/repositories"
+	"text/tabwriter"
+)
+
+func runGroupList(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error {
+	includeAllGroups := flags.Bool("include-all-groups", false, "also list the all group every user has")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	groups, err := repositories.NewGroupRepository(nil).GetAllGroups(*includeAllGroups)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tNAME\tSTATUS\tMEMBERS\tALL GROUP")
+	for _, group := range groups {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%d\t%t\n", group.ID, group.Name, group.Status, len(group.GroupMembers), group.IsAllGroup)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/utils"
+)
+
+func runReceiptsReprocess(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error {
+	groupId := flags.String("group", "", "id of the group to reprocess the receipts of")
+	status := flags.String("status", "", "only reprocess receipts with this status")
+	username := flags.String("username", "", "user the changes are made as, defaults to the first admin")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	err = requireFlags(map[string]string{"group": *groupId})
+	if err != nil {
+		return err
+	}
+
+	receiptStatus := models.ReceiptStatus(*status)
+	if len(receiptStatus) > 0 {
+		_, err = receiptStatus.Value()
+		if err != nil {
+			return fmt.Errorf("invalid status %q", *status)
+		}
+	}
+
+	group, err := repositories.NewGroupRepository(nil).GetGroupById(*groupId, false, false, false)
+	if err != nil {
+		return fmt.Errorf("group %s not found: %w", *groupId, err)
+	}
+
+	if group.IsAllGroup {
+		return errors.New("receipts are not kept in the all group, pick one of the user's groups")
+	}
+
+	var userId uint
+	if len(*username) > 0 {
+		user, err := getUserByUsername(*username)
+		if err != nil {
+			return err
+		}
+		userId = user.ID
+	} else {
+		userId, err = repositories.NewUserRepository(nil).GetFirstAdminUserId()
+		if err != nil {
+			return err
+		}
+	}
+
+	receiptIds, err := repositories.NewReceiptRepository(nil).GetReceiptIdsWithImagesByGroupId(group.ID, receiptStatus)
+	if err != nil {
+		return err
+	}
+
+	receiptService := services.NewReceiptService(nil)
+	failedCount := 0
+	for _, receiptId := range receiptIds {
+		_, err = receiptService.ReprocessReceipt(utils.UintToString(receiptId), userId)
+		if err != nil {
+			failedCount++
+			fmt.Fprintf(out, "Failed to reprocess receipt %d: %s\n", receiptId, err.Error())
+			continue
+		}
+
+		fmt.Fprintf(out, "Reprocessed receipt %d\n", receiptId)
+	}
+
+	fmt.Fprintf(out, "Reprocessed %d of %d receipts in %s\n", len(receiptIds)-failedCount, len(receiptIds), group.Name)
+	if failedCount > 0 {
+		return fmt.Errorf("%d receipts could not be reprocessed", failedCount)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"time"
+)
+
+func runTasksPrune(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error {
+	olderThanDays := flags.Int("older-than-days", 30, "delete tasks created more than this many days ago")
+	status := flags.String("status", "", "only delete tasks with this status, SUCCEEDED or FAILED")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *olderThanDays < 0 {
+		return fmt.Errorf("--older-than-days cannot be negative")
+	}
+
+	taskStatus := models.SystemTaskStatus(*status)
+	if len(taskStatus) > 0 && taskStatus != models.SYSTEM_TASK_SUCCEEDED && taskStatus != models.SYSTEM_TASK_FAILED {
+		return fmt.Errorf("invalid status %q, use SUCCEEDED or FAILED", *status)
+	}
+
+	cutOffDate := time.Now().AddDate(0, 0, -*olderThanDays)
+	deletedCount, err := repositories.NewSystemTaskRepository(nil).DeleteSystemTasksCreatedBefore(cutOffDate, taskStatus)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Deleted %d system tasks created before %s\n", deletedCount, cutOffDate.Format(time.RFC3339))
+	return nil
+}
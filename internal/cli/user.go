@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+func runUserCreate(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error {
+	username := flags.String("username", "", "username to log in with")
+	displayName := flags.String("display-name", "", "name shown to other users")
+	password := flags.String("password", "", "password, read from stdin when not set")
+	role := flags.String("role", "", "ADMIN or USER, the first user is an admin and others users by default")
+	isDummyUser := flags.Bool("dummy", false, "create a user that cannot log in")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	command := commands.SignUpCommand{
+		Username:    *username,
+		DisplayName: *displayName,
+		IsDummyUser: *isDummyUser,
+		UserRole:    models.UserRole(*role),
+	}
+
+	if !command.IsDummyUser {
+		command.Password, err = readPassword(*password, in)
+		if err != nil {
+			return err
+		}
+	}
+
+	vErr := command.Validate(false)
+	if len(command.Username) > 0 {
+		_, err = repositories.NewUserRepository(nil).GetUserByUsername(command.Username)
+		if err == nil {
+			vErr.Errors["username"] = "Username already exists"
+		}
+	}
+
+	err = validatorErrorToError(vErr)
+	if err != nil {
+		return err
+	}
+
+	var createdUser models.User
+	err = repositories.GetDB().Transaction(func(tx *gorm.DB) error {
+		userRepository := repositories.NewUserRepository(tx)
+		createdUser, err = userRepository.CreateUser(command)
+		if err != nil {
+			return err
+		}
+
+		if len(command.UserRole) > 0 && createdUser.UserRole != command.UserRole {
+			err = userRepository.UpdateUserRole(createdUser.ID, command.UserRole)
+			if err != nil {
+				return err
+			}
+			createdUser.UserRole = command.UserRole
+		}
+
+		// The view leaves out the password hash
+		createdUserView, err := userRepository.GetUserById(createdUser.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = services.NewAuditLogService(tx).CreateAuditLog(commands.UpsertAuditLogCommand{
+			Action:      models.AUDIT_USER_CREATED,
+			EntityType:  models.AUDIT_ENTITY_USER,
+			EntityId:    utils.UintToString(createdUser.ID),
+			After:       createdUserView,
+			Description: fmt.Sprintf("Created %s user '%s' from the command line", createdUser.UserRole, createdUser.Username),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Created %s user %s with id %d\n", createdUser.UserRole, createdUser.Username, createdUser.ID)
+	return nil
+}
+
+func runUserResetPassword(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error {
+	username := flags.String("username", "", "user to reset the password of")
+	password := flags.String("password", "", "new password, read from stdin when not set")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	err = requireFlags(map[string]string{"username": *username})
+	if err != nil {
+		return err
+	}
+
+	user, err := getUserByUsername(*username)
+	if err != nil {
+		return err
+	}
+
+	if user.IsDummyUser {
+		return fmt.Errorf("%s is a dummy user that cannot log in, convert it to a normal user in the app instead", user.Username)
+	}
+
+	newPassword, err := readPassword(*password, in)
+	if err != nil {
+		return err
+	}
+
+	if len(newPassword) == 0 {
+		return errors.New("password is required")
+	}
+
+	err = repositories.NewUserRepository(nil).UpdateUserPassword(user.ID, newPassword)
+	if err != nil {
+		return err
+	}
+
+	services.NewAuditLogService(nil).RecordAuditLog(commands.UpsertAuditLogCommand{
+		Action:      models.AUDIT_PASSWORD_RESET,
+		EntityType:  models.AUDIT_ENTITY_USER,
+		EntityId:    utils.UintToString(user.ID),
+		Description: "Reset user password from the command line",
+	})
+
+	fmt.Fprintf(out, "Reset password of %s\n", user.Username)
+	return nil
+}
+
+func runUserSetRole(flags *flag.FlagSet, args []string, in io.Reader, out io.Writer) error {
+	username := flags.String("username", "", "user to change the role of")
+	role := flags.String("role", "", "ADMIN or USER")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	err = requireFlags(map[string]string{"username": *username, "role": *role})
+	if err != nil {
+		return err
+	}
+
+	userRole := models.UserRole(*role)
+	if userRole != models.ADMIN && userRole != models.USER {
+		return fmt.Errorf("invalid role %q, use ADMIN or USER", *role)
+	}
+
+	user, err := getUserByUsername(*username)
+	if err != nil {
+		return err
+	}
+
+	if user.UserRole == userRole {
+		fmt.Fprintf(out, "%s is already %s\n", user.Username, userRole)
+		return nil
+	}
+
+	userRepository := repositories.NewUserRepository(nil)
+	err = userRepository.UpdateUserRole(user.ID, userRole)
+	if err != nil {
+		return err
+	}
+
+	updatedUser, err := userRepository.GetUserById(user.ID)
+	if err != nil {
+		return err
+	}
+
+	services.NewAuditLogService(nil).RecordAuditLog(commands.UpsertAuditLogCommand{
+		Action:      models.AUDIT_USER_ROLE_CHANGED,
+		EntityType:  models.AUDIT_ENTITY_USER,
+		EntityId:    utils.UintToString(user.ID),
+		Before:      user,
+		After:       updatedUser,
+		Description: fmt.Sprintf("Changed role of user '%s' from %s to %s from the command line", user.Username, user.UserRole, userRole),
+	})
+
+	fmt.Fprintf(out, "Changed role of %s from %s to %s\n", user.Username, user.UserRole, userRole)
+	return nil
+}
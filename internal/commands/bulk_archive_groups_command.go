@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+)
+
+type BulkArchiveGroupsCommand struct {
+	InactiveMonths int `json:"inactiveMonths"`
+}
+
+func (command *BulkArchiveGroupsCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	bytes, err := utils.GetBodyData(w, r)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (command *BulkArchiveGroupsCommand) Validate() structs.ValidatorError {
+	errors := make(map[string]string)
+	vErr := structs.ValidatorError{}
+
+	if command.InactiveMonths < 1 {
+		errors["inactiveMonths"] = "Inactive months must be at least 1"
+	}
+
+	vErr.Errors = errors
+	return vErr
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+)
+
+const (
+	DefaultGroupInviteExpiresInHours = 168
+	MaxGroupInviteExpiresInHours     = 720
+	MaxGroupInviteUses               = 100
+)
+
+type CreateGroupInviteCommand struct {
+	GroupRole models.GroupRole `json:"groupRole"`
+	// Defaults to DefaultGroupInviteExpiresInHours
+	ExpiresInHours int `json:"expiresInHours"`
+	// Defaults to a single use
+	MaxUses int `json:"maxUses"`
+	// Optional, the invite link is emailed to this address
+	Email string `json:"email"`
+}
+
+func (command *CreateGroupInviteCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	bytes, err := utils.GetBodyData(w, r)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		return err
+	}
+
+	if command.ExpiresInHours == 0 {
+		command.ExpiresInHours = DefaultGroupInviteExpiresInHours
+	}
+
+	if command.MaxUses == 0 {
+		command.MaxUses = 1
+	}
+
+	return nil
+}
+
+func (command *CreateGroupInviteCommand) Validate() structs.ValidatorError {
+	errors := make(map[string]string)
+	vErr := structs.ValidatorError{}
+
+	if _, err := command.GroupRole.Value(); err != nil {
+		errors["groupRole"] = "Group role must be one of: OWNER, EDITOR, VIEWER"
+	}
+
+	if command.ExpiresInHours < 1 || command.ExpiresInHours > MaxGroupInviteExpiresInHours {
+		errors["expiresInHours"] = "Expiration must be between 1 and 720 hours"
+	}
+
+	if command.MaxUses < 1 || command.MaxUses > MaxGroupInviteUses {
+		errors["maxUses"] = "Max uses must be between 1 and 100"
+	}
+
+	if len(command.Email) > 0 {
+		if _, err := mail.ParseAddress(command.Email); err != nil {
+			errors["email"] = "Email is invalid"
+		}
+	}
+
+	vErr.Errors = errors
+	return vErr
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"strconv"
+	"strings"
+)
+
+// CreateResumableUploadCommand is read from the headers of a tus creation request.
+// The Upload-Metadata header carries the file name and either a receiptId to attach the file to, or the quick scan fields.
+type CreateResumableUploadCommand struct {
+	Length       int64                `json:"length"`
+	RawMetadata  string               `json:"metadata"`
+	FileName     string               `json:"fileName"`
+	ReceiptId    *uint                `json:"receiptId"`
+	GroupId      *uint                `json:"groupId"`
+	PaidByUserId *uint                `json:"paidByUserId"`
+	Status       models.ReceiptStatus `json:"status"`
+}
+
+func (command *CreateResumableUploadCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return errors.New("invalid Upload-Length header")
+	}
+
+	rawMetadata := r.Header.Get("Upload-Metadata")
+	metadata, err := ParseTusMetadata(rawMetadata)
+	if err != nil {
+		return err
+	}
+
+	command.Length = length
+	command.RawMetadata = rawMetadata
+	command.FileName = metadata["filename"]
+	command.Status = models.ReceiptStatus(metadata["status"])
+
+	command.ReceiptId, err = parseOptionalUint(metadata["receiptId"])
+	if err != nil {
+		return err
+	}
+
+	command.GroupId, err = parseOptionalUint(metadata["groupId"])
+	if err != nil {
+		return err
+	}
+
+	command.PaidByUserId, err = parseOptionalUint(metadata["paidByUserId"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (command CreateResumableUploadCommand) Validate() structs.ValidatorError {
+	vErr := structs.ValidatorError{
+		Errors: make(map[string]string),
+	}
+
+	if command.Length <= 0 {
+		vErr.Errors["length"] = "Upload length must be greater than 0."
+	}
+
+	if command.Length > constants.ResumableUploadMaxSize {
+		vErr.Errors["length"] = "Upload length exceeds the maximum size."
+	}
+
+	if len(command.FileName) == 0 {
+		vErr.Errors["filename"] = "File name is required."
+	}
+
+	if command.ReceiptId != nil {
+		return vErr
+	}
+
+	if command.GroupId == nil {
+		vErr.Errors["groupId"] = "Group Id is required when no receipt Id is given."
+	}
+
+	if command.PaidByUserId == nil {
+		vErr.Errors["paidByUserId"] = "Paid By User Id is required when no receipt Id is given."
+	}
+
+	_, err := command.Status.Value()
+	if len(command.Status) == 0 || err != nil {
+		vErr.Errors["status"] = "Status is required when no receipt Id is given."
+	}
+
+	return vErr
+}
+
+// ParseTusMetadata decodes an Upload-Metadata header, comma separated pairs of a key and an optional base64 value
+func ParseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(pair, " ", 2)
+		if len(parts) == 1 {
+			metadata[parts[0]] = ""
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, errors.New("invalid Upload-Metadata header")
+		}
+
+		metadata[parts[0]] = string(value)
+	}
+
+	return metadata, nil
+}
+
+func parseOptionalUint(value string) (*uint, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := utils.StringToUint(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func encodeTusMetadataValue(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+func TestShouldLoadResumableUploadCommandFromTusHeaders(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/upload", nil)
+	r.Header.Set("Upload-Length", "1024")
+	r.Header.Set("Upload-Metadata", "filename "+encodeTusMetadataValue("scan 1.pdf")+",groupId "+encodeTusMetadataValue("2")+
+		",paidByUserId "+encodeTusMetadataValue("3")+",status "+encodeTusMetadataValue("OPEN")+",is_confidential")
+
+	command := CreateResumableUploadCommand{}
+	err := command.LoadDataFromRequest(nil, r)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if command.Length != 1024 || command.FileName != "scan 1.pdf" || command.Status != models.OPEN {
+		utils.PrintTestError(t, command, "length, file name and status from the headers")
+	}
+
+	if command.GroupId == nil || *command.GroupId != 2 || command.PaidByUserId == nil || *command.PaidByUserId != 3 || command.ReceiptId != nil {
+		utils.PrintTestError(t, command, "group 2 paid by user 3")
+	}
+
+	vErr := command.Validate()
+	if len(vErr.Errors) != 0 {
+		utils.PrintTestError(t, vErr.Errors, "no errors")
+	}
+}
+
+func TestShouldRejectInvalidTusMetadata(t *testing.T) {
+	_, err := ParseTusMetadata("filename not-base64!")
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+func TestShouldValidateResumableUploadCommand(t *testing.T) {
+	receiptId := uint(1)
+	tests := map[string]struct {
+		command CreateResumableUploadCommand
+		expect  int
+	}{
+		"attach to receipt": {
+			command: CreateResumableUploadCommand{Length: 10, FileName: "scan.pdf", ReceiptId: &receiptId},
+			expect:  0,
+		},
+		"quick scan fields missing": {
+			command: CreateResumableUploadCommand{Length: 10, FileName: "scan.pdf"},
+			expect:  3,
+		},
+		"empty upload without name": {
+			command: CreateResumableUploadCommand{ReceiptId: &receiptId},
+			expect:  2,
+		},
+		"too large": {
+			command: CreateResumableUploadCommand{Length: 1 << 40, FileName: "scan.pdf", ReceiptId: &receiptId},
+			expect:  1,
+		},
+	}
+
+	for name, test := range tests {
+		vErr := test.command.Validate()
+		if len(vErr.Errors) != test.expect {
+			utils.PrintTestError(t, vErr.Errors, name)
+		}
+	}
+}
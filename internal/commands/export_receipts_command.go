@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"net/http"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+	"strconv"
+	"strings"
+)
+
+// ExportReceiptsCommand is read from the query, group exports send their filter as body
+type ExportReceiptsCommand struct {
+	Format models.ExportFormat `json:"format"`
+	structs.ReceiptCsvOptions
+	invalidOptions []string
+}
+
+func (command *ExportReceiptsCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+
+	command.Format = models.ExportFormat(strings.ToUpper(query.Get("format")))
+	if len(command.Format) == 0 {
+		command.Format = models.EXPORT_CSV
+	}
+
+	options := map[string]*bool{
+		"includeCustomFields": &command.IncludeCustomFields,
+		"includeComments":     &command.IncludeComments,
+		"includeImages":       &command.IncludeImages,
+	}
+	for name, option := range options {
+		value := query.Get(name)
+		if len(value) == 0 {
+			continue
+		}
+
+		parsedValue, err := strconv.ParseBool(value)
+		if err != nil {
+			command.invalidOptions = append(command.invalidOptions, name)
+			continue
+		}
+		*option = parsedValue
+	}
+
+	return nil
+}
+
+func (command ExportReceiptsCommand) Validate() structs.ValidatorError {
+	vErr := structs.ValidatorError{
+		Errors: make(map[string]string),
+	}
+
+	if !command.Format.IsValid() {
+		vErr.Errors["format"] = "Format must be CSV, OFX, QIF, BEANCOUNT, HLEDGER or PDF"
+	}
+
+	for _, name := range command.invalidOptions {
+		vErr.Errors[name] = name + " must be true or false"
+	}
+
+	return vErr
+}
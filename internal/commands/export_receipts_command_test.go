@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldLoadExportReceiptsCommandFromQuery(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/export?format=csv&includeImages=true&includeComments=1", nil)
+
+	command := ExportReceiptsCommand{}
+	err := command.LoadDataFromRequest(nil, r)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if command.Format != models.EXPORT_CSV || !command.IncludeImages || !command.IncludeComments || command.IncludeCustomFields {
+		utils.PrintTestError(t, command, "csv with images and comments")
+	}
+
+	vErr := command.Validate()
+	if len(vErr.Errors) != 0 {
+		utils.PrintTestError(t, vErr.Errors, "no errors")
+	}
+}
+
+func TestShouldDefaultExportReceiptsCommandToCsv(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/export", nil)
+
+	command := ExportReceiptsCommand{}
+	command.LoadDataFromRequest(nil, r)
+
+	if command.Format != models.EXPORT_CSV || command.IncludeImages || command.IncludeComments || command.IncludeCustomFields {
+		utils.PrintTestError(t, command, "csv without options")
+	}
+}
+
+func TestShouldRejectInvalidExportReceiptsOptions(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/export?format=xlsx&includeCustomFields=maybe", nil)
+
+	command := ExportReceiptsCommand{}
+	command.LoadDataFromRequest(nil, r)
+
+	vErr := command.Validate()
+	if len(vErr.Errors) != 2 || len(vErr.Errors["format"]) == 0 || len(vErr.Errors["includeCustomFields"]) == 0 {
+		utils.PrintTestError(t, vErr.Errors, "format and includeCustomFields errors")
+	}
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+)
+
+// ImportExpensesCommand is read from a multipart form, the export is sent as file and everything else as json in options
+type ImportExpensesCommand struct {
+	File   []byte                      `json:"-"`
+	Source models.ExternalImportSource `json:"source"`
+	// Name of the created group, csv exports have no group id so it also identifies the group when importing again
+	GroupName string `json:"groupName"`
+	// Participant name to the id of a user sharing a group with the importer, other participants are matched by username
+	// among those users, then created as dummy users by admins or left unassigned
+	UserMappings map[string]uint `json:"userMappings"`
+}
+
+func (command *ImportExpensesCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	err := r.ParseMultipartForm(constants.MultipartFormMaxSize)
+	if err != nil {
+		return err
+	}
+
+	options := r.FormValue("options")
+	if len(options) > 0 {
+		err = json.Unmarshal([]byte(options), command)
+		if err != nil {
+			return errors.New("invalid options")
+		}
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	fileBytes, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	command.File = fileBytes
+
+	return nil
+}
+
+func (command ImportExpensesCommand) Validate() structs.ValidatorError {
+	vErr := structs.ValidatorError{
+		Errors: make(map[string]string),
+	}
+
+	if len(command.File) == 0 {
+		vErr.Errors["file"] = "File cannot be empty"
+	}
+
+	if !command.Source.IsValid() {
+		vErr.Errors["source"] = "Source must be SPLITWISE or TRICOUNT"
+	}
+
+	if len(command.GroupName) == 0 {
+		vErr.Errors["groupName"] = "Group name is required"
+	}
+
+	for participant, userId := range command.UserMappings {
+		if len(participant) == 0 || userId == 0 {
+			vErr.Errors["userMappings"] = "User mappings must map a participant to a user id"
+		}
+	}
+
+	return vErr
+}
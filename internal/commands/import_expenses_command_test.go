@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldValidateImportExpensesCommand(t *testing.T) {
+	tests := map[string]struct {
+		command ImportExpensesCommand
+		expect  int
+	}{
+		"valid": {
+			command: ImportExpensesCommand{File: []byte("a"), Source: models.SPLITWISE, GroupName: "Trip", UserMappings: map[string]uint{"Alice": 1}},
+			expect:  0,
+		},
+		"empty": {
+			command: ImportExpensesCommand{},
+			expect:  3,
+		},
+		"unknown source": {
+			command: ImportExpensesCommand{File: []byte("a"), Source: "SETTLE_UP", GroupName: "Trip"},
+			expect:  1,
+		},
+		"mapping without user": {
+			command: ImportExpensesCommand{File: []byte("a"), Source: models.TRICOUNT, GroupName: "Trip", UserMappings: map[string]uint{"Alice": 0}},
+			expect:  1,
+		},
+	}
+
+	for name, test := range tests {
+		vErr := test.command.Validate()
+		if len(vErr.Errors) != test.expect {
+			utils.PrintTestError(t, vErr.Errors, name)
+		}
+	}
+}
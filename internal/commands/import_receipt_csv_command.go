@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+	"strings"
+)
+
+// ReceiptCsvColumnMapping maps receipt fields to csv column headers, empty fields aren't imported
+type ReceiptCsvColumnMapping struct {
+	Name       string `json:"name"`
+	Date       string `json:"date"`
+	Amount     string `json:"amount"`
+	PaidBy     string `json:"paidBy"`
+	Categories string `json:"categories"`
+	Tags       string `json:"tags"`
+	Status     string `json:"status"`
+	// Custom field id to column header
+	CustomFields map[uint]string `json:"customFields"`
+}
+
+// GetDefaultReceiptCsvColumnMapping maps the columns of the receipts.csv export
+func GetDefaultReceiptCsvColumnMapping() ReceiptCsvColumnMapping {
+	return ReceiptCsvColumnMapping{
+		Name:       constants.ReceiptCsvNameColumn,
+		Date:       constants.ReceiptCsvDateColumn,
+		Amount:     constants.ReceiptCsvAmountColumn,
+		PaidBy:     constants.ReceiptCsvPaidByColumn,
+		Categories: constants.ReceiptCsvCategoriesColumn,
+		Tags:       constants.ReceiptCsvTagsColumn,
+		Status:     constants.ReceiptCsvStatusColumn,
+	}
+}
+
+// ImportReceiptCsvCommand is read from a multipart form, the csv is sent as file and everything else as json in options
+type ImportReceiptCsvCommand struct {
+	Csv     []byte                   `json:"-"`
+	GroupId uint                     `json:"groupId"`
+	Mapping *ReceiptCsvColumnMapping `json:"mapping"`
+	// Layout using YYYY, YY, MM and DD, for example DD.MM.YYYY
+	DateFormat          string                   `json:"dateFormat"`
+	DecimalSeparator    models.CurrencySeparator `json:"decimalSeparator"`
+	Delimiter           string                   `json:"delimiter"`
+	DefaultPaidByUserId *uint                    `json:"defaultPaidByUserId"`
+	DefaultStatus       models.ReceiptStatus     `json:"defaultStatus"`
+	// Only a preview is returned unless set
+	Commit bool `json:"commit"`
+}
+
+func (command *ImportReceiptCsvCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	err := r.ParseMultipartForm(constants.MultipartFormMaxSize)
+	if err != nil {
+		return err
+	}
+
+	options := r.FormValue("options")
+	if len(options) > 0 {
+		err = json.Unmarshal([]byte(options), command)
+		if err != nil {
+			return errors.New("invalid options")
+		}
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	csvBytes, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	command.Csv = csvBytes
+
+	return nil
+}
+
+// SetDefaults fills in the options that weren't sent
+func (command *ImportReceiptCsvCommand) SetDefaults() {
+	if command.Mapping == nil {
+		mapping := GetDefaultReceiptCsvColumnMapping()
+		command.Mapping = &mapping
+	}
+
+	if len(command.DateFormat) == 0 {
+		command.DateFormat = "YYYY-MM-DD"
+	}
+
+	if len(command.DecimalSeparator) == 0 {
+		command.DecimalSeparator = models.DOT
+	}
+
+	if len(command.Delimiter) == 0 {
+		command.Delimiter = ","
+	}
+}
+
+func (command ImportReceiptCsvCommand) Validate() structs.ValidatorError {
+	vErr := structs.ValidatorError{
+		Errors: make(map[string]string),
+	}
+
+	if len(command.Csv) == 0 {
+		vErr.Errors["file"] = "File cannot be empty"
+	}
+
+	if command.GroupId == 0 {
+		vErr.Errors["groupId"] = "Group Id is required"
+	}
+
+	if command.Mapping != nil {
+		if len(command.Mapping.Name) == 0 {
+			vErr.Errors["mapping.name"] = "Name column is required"
+		}
+
+		if len(command.Mapping.Date) == 0 {
+			vErr.Errors["mapping.date"] = "Date column is required"
+		}
+
+		if len(command.Mapping.Amount) == 0 {
+			vErr.Errors["mapping.amount"] = "Amount column is required"
+		}
+
+		if len(command.Mapping.PaidBy) == 0 && command.DefaultPaidByUserId == nil {
+			vErr.Errors["mapping.paidBy"] = "Paid By column or default paid by user is required"
+		}
+
+		if len(command.Mapping.Status) == 0 && len(command.DefaultStatus) == 0 {
+			vErr.Errors["mapping.status"] = "Status column or default status is required"
+		}
+	}
+
+	if len(command.DateFormat) > 0 && !strings.Contains(command.DateFormat, "YY") {
+		vErr.Errors["dateFormat"] = "Date format must contain a year"
+	}
+
+	_, err := command.DecimalSeparator.Value()
+	if err != nil {
+		vErr.Errors["decimalSeparator"] = "Decimal separator must be . or ,"
+	}
+
+	if len([]rune(command.Delimiter)) > 1 {
+		vErr.Errors["delimiter"] = "Delimiter must be a single character"
+	}
+
+	if len(command.DefaultStatus) > 0 {
+		_, err = command.DefaultStatus.Value()
+		if err != nil {
+			vErr.Errors["defaultStatus"] = "Default status is invalid"
+		}
+	}
+
+	return vErr
+}
+
+// GetDateLayout converts DateFormat to a time layout
+func (command ImportReceiptCsvCommand) GetDateLayout() string {
+	replacer := strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02")
+	return replacer.Replace(command.DateFormat)
+}
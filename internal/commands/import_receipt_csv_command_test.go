@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldLoadImportReceiptCsvCommandFromMultipartForm(t *testing.T) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("options", `{"groupId":1,"dateFormat":"DD.MM.YYYY","mapping":{"name":"Title","customFields":{"2":"Store"}}}`)
+	part, _ := writer.CreateFormFile("file", "receipts.csv")
+	part.Write([]byte("Title\ntest\n"))
+	writer.Close()
+
+	r := httptest.NewRequest("POST", "/api/import/receiptsCsv", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	command := ImportReceiptCsvCommand{}
+	err := command.LoadDataFromRequest(nil, r)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if command.GroupId != 1 || string(command.Csv) != "Title\ntest\n" || command.Mapping.CustomFields[2] != "Store" {
+		utils.PrintTestError(t, command, "options and csv from the form")
+	}
+
+	command.SetDefaults()
+	if command.GetDateLayout() != "02.01.2006" || command.DecimalSeparator != models.DOT || command.Delimiter != "," {
+		utils.PrintTestError(t, command, "defaults and date layout")
+	}
+}
+
+func TestShouldValidateImportReceiptCsvCommand(t *testing.T) {
+	paidByUserId := uint(1)
+	defaultMapping := GetDefaultReceiptCsvColumnMapping()
+	tests := map[string]struct {
+		command ImportReceiptCsvCommand
+		expect  int
+	}{
+		"default mapping": {
+			command: ImportReceiptCsvCommand{Csv: []byte("a"), GroupId: 1, Mapping: &defaultMapping, DecimalSeparator: models.DOT},
+			expect:  0,
+		},
+		"no file or group": {
+			command: ImportReceiptCsvCommand{Mapping: &defaultMapping},
+			expect:  2,
+		},
+		"defaults instead of columns": {
+			command: ImportReceiptCsvCommand{
+				Csv:                 []byte("a"),
+				GroupId:             1,
+				Mapping:             &ReceiptCsvColumnMapping{Name: "a", Date: "b", Amount: "c"},
+				DefaultPaidByUserId: &paidByUserId,
+				DefaultStatus:       models.OPEN,
+			},
+			expect: 0,
+		},
+		"missing required columns": {
+			command: ImportReceiptCsvCommand{Csv: []byte("a"), GroupId: 1, Mapping: &ReceiptCsvColumnMapping{}},
+			expect:  5,
+		},
+		"bad formats": {
+			command: ImportReceiptCsvCommand{
+				Csv:              []byte("a"),
+				GroupId:          1,
+				Mapping:          &defaultMapping,
+				DateFormat:       "MM-DD",
+				DecimalSeparator: "'",
+				Delimiter:        ";;",
+				DefaultStatus:    "LOST",
+			},
+			expect: 4,
+		},
+	}
+
+	for name, test := range tests {
+		vErr := test.command.Validate()
+		if len(vErr.Errors) != test.expect {
+			utils.PrintTestError(t, vErr.Errors, name)
+		}
+	}
+}
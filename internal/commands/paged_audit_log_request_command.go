@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"time"
+)
+
+type PagedAuditLogRequestCommand struct {
+	PagedRequestCommand
+	Filter AuditLogFilter `json:"filter"`
+}
+
+type AuditLogFilter struct {
+	Actions     []models.AuditAction   `json:"actions"`
+	ActorUserId *uint                  `json:"actorUserId"`
+	EntityType  models.AuditEntityType `json:"entityType"`
+	EntityId    string                 `json:"entityId"`
+	GroupId     *uint                  `json:"groupId"`
+	IpAddress   string                 `json:"ipAddress"`
+	StartDate   *time.Time             `json:"startDate"`
+	EndDate     *time.Time             `json:"endDate"`
+}
+
+func (command *PagedAuditLogRequestCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	bytes, err := utils.GetBodyData(w, r)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (command *PagedAuditLogRequestCommand) Validate() structs.ValidatorError {
+	vErrs := command.PagedRequestCommand.Validate()
+
+	for _, action := range command.Filter.Actions {
+		if !action.IsValid() {
+			vErrs.Errors["filter.actions"] = "Invalid audit action: " + string(action)
+			break
+		}
+	}
+
+	if command.Filter.StartDate != nil &&
+		command.Filter.EndDate != nil &&
+		command.Filter.StartDate.After(*command.Filter.EndDate) {
+		vErrs.Errors["filter.startDate"] = "Start date must be before end date"
+	}
+
+	return vErrs
+}
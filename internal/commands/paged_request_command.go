@@ -98,39 +98,44 @@ func (command *ReceiptPagedRequestCommand) LoadDataFromRequest(w http.ResponseWr
 		return err
 	}
 
-	if command.Filter.Amount.Value == nil || command.Filter.Amount.Value == "" {
-		command.Filter.Amount.Value = float64(0)
-	}
+	command.Filter.SetDefaults()
+
+	return nil
+}
 
-	if command.Filter.PaidBy.Value == nil || command.Filter.PaidBy.Value == "" {
-		command.Filter.PaidBy.Value = make([]interface{}, 0)
+// SetDefaults fills in the values of unset fields, so filters can be applied
+func (filter *ReceiptPagedRequestFilter) SetDefaults() {
+	if filter.Amount.Value == nil || filter.Amount.Value == "" {
+		filter.Amount.Value = float64(0)
 	}
 
-	if command.Filter.Categories.Value == nil || command.Filter.Categories.Value == "" {
-		command.Filter.Categories.Value = make([]interface{}, 0)
+	if filter.PaidBy.Value == nil || filter.PaidBy.Value == "" {
+		filter.PaidBy.Value = make([]interface{}, 0)
 	}
 
-	if command.Filter.Tags.Value == nil || command.Filter.Tags.Value == "" {
-		command.Filter.Tags.Value = make([]interface{}, 0)
+	if filter.Categories.Value == nil || filter.Categories.Value == "" {
+		filter.Categories.Value = make([]interface{}, 0)
 	}
 
-	if command.Filter.Status.Value == nil || command.Filter.Status.Value == "" {
-		command.Filter.Status.Value = make([]interface{}, 0)
+	if filter.Tags.Value == nil || filter.Tags.Value == "" {
+		filter.Tags.Value = make([]interface{}, 0)
 	}
 
-	if command.Filter.CreatedAt.Value == nil {
-		command.Filter.CreatedAt.Value = ""
+	if filter.Status.Value == nil || filter.Status.Value == "" {
+		filter.Status.Value = make([]interface{}, 0)
 	}
 
-	if command.Filter.Date.Value == nil {
-		command.Filter.Date.Value = ""
+	if filter.CreatedAt.Value == nil {
+		filter.CreatedAt.Value = ""
 	}
 
-	if command.Filter.ResolvedDate.Value == nil {
-		command.Filter.ResolvedDate.Value = ""
+	if filter.Date.Value == nil {
+		filter.Date.Value = ""
 	}
 
-	return nil
+	if filter.ResolvedDate.Value == nil {
+		filter.ResolvedDate.Value = ""
+	}
 }
 
 type ReceiptPagedRequestFilter struct {
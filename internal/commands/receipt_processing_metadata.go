@@ -1,5 +1,7 @@
 package commands
 
+import "receipt-wrangler/api/internal/models"
+
 type ReceiptProcessingMetadata struct {
 	ReceiptProcessingSettingsIdRan              uint
 	DidReceiptProcessingSettingsSucceed         bool
@@ -13,4 +15,5 @@ type ReceiptProcessingMetadata struct {
 	ChatCompletionSystemTaskCommand             UpsertSystemTaskCommand
 	FallbackOcrSystemTaskCommand                UpsertSystemTaskCommand
 	FallbackChatCompletionSystemTaskCommand     UpsertSystemTaskCommand
+	Barcodes                                    []models.Barcode
 }
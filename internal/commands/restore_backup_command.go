@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/structs"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+type RestoreBackupCommand struct {
+	Archive []byte `json:"archive"`
+}
+
+func (command *RestoreBackupCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	err := r.ParseMultipartForm(constants.MultipartFormMaxSize)
+	if err != nil {
+		return err
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	if len(archive) > 0 && mimetype.Detect(archive).String() != constants.ApplicationZip {
+		return errors.New("invalid file type")
+	}
+
+	command.Archive = archive
+
+	return nil
+}
+
+func (command *RestoreBackupCommand) Validate() structs.ValidatorError {
+	vErr := structs.ValidatorError{
+		Errors: make(map[string]string),
+	}
+
+	if len(command.Archive) == 0 {
+		vErr.Errors["file"] = "File cannot be empty"
+	}
+
+	return vErr
+}
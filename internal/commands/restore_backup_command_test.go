@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldReturnErrorForRestoreWithoutFile(t *testing.T) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("other", "value")
+	writer.Close()
+
+	r := httptest.NewRequest("POST", "/api/backup/restore", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	command := RestoreBackupCommand{}
+	err := command.LoadDataFromRequest(nil, r)
+	if !errors.Is(err, http.ErrMissingFile) {
+		utils.PrintTestError(t, err, http.ErrMissingFile)
+	}
+}
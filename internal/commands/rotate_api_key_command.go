@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+)
+
+const (
+	DefaultApiKeyRotationGracePeriodHours = 24
+	MaxApiKeyRotationGracePeriodHours     = 720
+)
+
+type RotateApiKeyCommand struct {
+	// How long the previous secret keeps working, defaults to DefaultApiKeyRotationGracePeriodHours
+	GracePeriodHours *int `json:"gracePeriodHours"`
+}
+
+func (command *RotateApiKeyCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	bytes, err := utils.GetBodyData(w, r)
+	if err != nil {
+		return err
+	}
+
+	// An empty body rotates with the default grace period
+	if len(bytes) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (command *RotateApiKeyCommand) Validate() structs.ValidatorError {
+	errors := make(map[string]string)
+	vErr := structs.ValidatorError{}
+
+	if command.GracePeriodHours != nil &&
+		(*command.GracePeriodHours < 0 || *command.GracePeriodHours > MaxApiKeyRotationGracePeriodHours) {
+		errors["gracePeriodHours"] = "Grace period must be between 0 and 720 hours"
+	}
+
+	vErr.Errors = errors
+	return vErr
+}
+
+func (command *RotateApiKeyCommand) GetGracePeriodHours() int {
+	if command.GracePeriodHours == nil {
+		return DefaultApiKeyRotationGracePeriodHours
+	}
+
+	return *command.GracePeriodHours
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+)
+
+// Without any action set, the check only reports
+type RunStorageIntegrityCheckCommand struct {
+	// Moves files that don't belong to any FileData to quarantine/
+	QuarantineOrphanedFiles bool `json:"quarantineOrphanedFiles"`
+	// Deletes FileData and page rows whose file is missing
+	RemoveDanglingRecords bool `json:"removeDanglingRecords"`
+	// Fills in sizes and checksums that were never recorded, mismatches are never overwritten
+	UpdateFileMetadata bool `json:"updateFileMetadata"`
+}
+
+func (command *RunStorageIntegrityCheckCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	bytes, err := utils.GetBodyData(w, r)
+	if err != nil {
+		return err
+	}
+
+	// An empty body runs a report only check
+	if len(bytes) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (command *RunStorageIntegrityCheckCommand) Validate() structs.ValidatorError {
+	return structs.ValidatorError{Errors: make(map[string]string)}
+}
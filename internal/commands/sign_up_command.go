@@ -1,6 +1,9 @@
 package commands
 
-import "receipt-wrangler/api/internal/models"
+import (
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+)
 
 type SignUpCommand struct {
 	Username    string          `json:"username"`
@@ -9,3 +12,32 @@ type SignUpCommand struct {
 	IsDummyUser bool            `json:"isDummyUser"`
 	UserRole    models.UserRole `json:"userRole"`
 }
+
+// Validate checks the fields, whether the username is taken is left to the caller
+func (command SignUpCommand) Validate(roleRequired bool) structs.ValidatorError {
+	errors := make(map[string]string)
+	vErr := structs.ValidatorError{}
+
+	if len(command.Username) == 0 {
+		errors["username"] = "Username is required"
+	}
+
+	if len(command.Password) == 0 && !command.IsDummyUser {
+		errors["password"] = "Password is required"
+	}
+
+	if len(command.DisplayName) == 0 {
+		errors["displayName"] = "Displayname is required"
+	}
+
+	if roleRequired && len(command.UserRole) == 0 {
+		errors["userRole"] = "User Role is required"
+	}
+
+	if len(command.UserRole) > 0 && command.UserRole != models.ADMIN && command.UserRole != models.USER {
+		errors["userRole"] = "User Role must be ADMIN or USER"
+	}
+
+	vErr.Errors = errors
+	return vErr
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"regexp"
+)
+
+// Accounts follow the Beancount rules, which hledger also accepts
+var ledgerAccountRegex = regexp.MustCompile(`^(Assets|Liabilities|Equity|Income|Expenses)(:[A-Z0-9][A-Za-z0-9-]*)+$`)
+var ledgerCurrencyRegex = regexp.MustCompile(`^[A-Z][A-Z0-9'._-]{0,22}[A-Z0-9]$`)
+
+type UpsertLedgerCategoryAccountCommand struct {
+	CategoryId uint   `json:"categoryId"`
+	Account    string `json:"account"`
+}
+
+type UpdateGroupLedgerSettingsCommand struct {
+	Currency              string                               `json:"currency"`
+	PaidByAccount         string                               `json:"paidByAccount"`
+	DefaultExpenseAccount string                               `json:"defaultExpenseAccount"`
+	CategoryAccounts      []UpsertLedgerCategoryAccountCommand `json:"categoryAccounts"`
+}
+
+func (command *UpdateGroupLedgerSettingsCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	bytes, err := utils.GetBodyData(w, r)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (command UpdateGroupLedgerSettingsCommand) Validate() structs.ValidatorError {
+	vErr := structs.ValidatorError{
+		Errors: make(map[string]string),
+	}
+
+	if !ledgerCurrencyRegex.MatchString(command.Currency) {
+		vErr.Errors["currency"] = "Currency must be an uppercase commodity like USD"
+	}
+
+	if !ledgerAccountRegex.MatchString(command.PaidByAccount) {
+		vErr.Errors["paidByAccount"] = "Paid by account must be an account like Liabilities:Payable"
+	}
+
+	if !ledgerAccountRegex.MatchString(command.DefaultExpenseAccount) {
+		vErr.Errors["defaultExpenseAccount"] = "Default expense account must be an account like Expenses:Uncategorized"
+	}
+
+	categoryIds := make(map[uint]bool)
+	for i, categoryAccount := range command.CategoryAccounts {
+		if categoryAccount.CategoryId == 0 {
+			vErr.Errors[fmt.Sprintf("categoryAccounts.%d.categoryId", i)] = "Category Id is required"
+		}
+
+		if categoryIds[categoryAccount.CategoryId] {
+			vErr.Errors[fmt.Sprintf("categoryAccounts.%d.categoryId", i)] = "Category is mapped more than once"
+		}
+		categoryIds[categoryAccount.CategoryId] = true
+
+		if !ledgerAccountRegex.MatchString(categoryAccount.Account) {
+			vErr.Errors[fmt.Sprintf("categoryAccounts.%d.account", i)] = "Account must be an account like Expenses:Food"
+		}
+	}
+
+	return vErr
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestUpdateGroupLedgerSettingsCommand_Validate(t *testing.T) {
+	tests := map[string]struct {
+		command     UpdateGroupLedgerSettingsCommand
+		invalidKeys []string
+	}{
+		"valid settings": {
+			command: UpdateGroupLedgerSettingsCommand{
+				Currency:              "EUR",
+				PaidByAccount:         "Liabilities:Payable",
+				DefaultExpenseAccount: "Expenses:Uncategorized",
+				CategoryAccounts:      []UpsertLedgerCategoryAccountCommand{{CategoryId: 1, Account: "Expenses:Food:Groceries"}},
+			},
+		},
+		"invalid currency and accounts": {
+			command: UpdateGroupLedgerSettingsCommand{
+				Currency:              "eur",
+				PaidByAccount:         "Payable",
+				DefaultExpenseAccount: "Expenses:uncategorized",
+			},
+			invalidKeys: []string{"currency", "paidByAccount", "defaultExpenseAccount"},
+		},
+		"duplicate and missing categories": {
+			command: UpdateGroupLedgerSettingsCommand{
+				Currency:              "USD",
+				PaidByAccount:         "Liabilities:Payable",
+				DefaultExpenseAccount: "Expenses:Uncategorized",
+				CategoryAccounts: []UpsertLedgerCategoryAccountCommand{
+					{CategoryId: 1, Account: "Expenses:Food"},
+					{CategoryId: 1, Account: "Expenses:Food"},
+					{Account: "Food"},
+				},
+			},
+			invalidKeys: []string{"categoryAccounts.1.categoryId", "categoryAccounts.2.categoryId", "categoryAccounts.2.account"},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			vErr := test.command.Validate()
+			if len(vErr.Errors) != len(test.invalidKeys) {
+				utils.PrintTestError(t, vErr.Errors, test.invalidKeys)
+				return
+			}
+
+			for _, key := range test.invalidKeys {
+				_, ok := vErr.Errors[key]
+				if !ok {
+					utils.PrintTestError(t, vErr.Errors, key)
+				}
+			}
+		})
+	}
+}
@@ -6,12 +6,21 @@ import (
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
+	"time"
 )
 
 type UpsertApiKeyCommand struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Scope       string `json:"scope"`
+	// Optional, keys without an expiry never expire
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// Optional, restricts the key to these groups
+	AllowedGroupIds []uint `json:"allowedGroupIds"`
+	// Optional, restricts the key to these resource families
+	ResourceScopes []models.ApiKeyResourceScope `json:"resourceScopes"`
+	// Set when the request contained expiresAt, so an explicit null can be told apart from leaving it out
+	expiresAtPresent bool
 }
 
 func (command *UpsertApiKeyCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
@@ -25,9 +34,28 @@ func (command *UpsertApiKeyCommand) LoadDataFromRequest(w http.ResponseWriter, r
 		return err
 	}
 
+	// On update, restrictions left out of the request are kept and null or empty ones are removed
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(bytes, &fields)
+	if err != nil {
+		return err
+	}
+
+	_, command.expiresAtPresent = fields["expiresAt"]
+	if _, ok := fields["allowedGroupIds"]; ok && command.AllowedGroupIds == nil {
+		command.AllowedGroupIds = []uint{}
+	}
+	if _, ok := fields["resourceScopes"]; ok && command.ResourceScopes == nil {
+		command.ResourceScopes = []models.ApiKeyResourceScope{}
+	}
+
 	return nil
 }
 
+func (command UpsertApiKeyCommand) HasExpiresAt() bool {
+	return command.ExpiresAt != nil || command.expiresAtPresent
+}
+
 func (command *UpsertApiKeyCommand) Validate() structs.ValidatorError {
 	errors := make(map[string]string)
 	vErr := structs.ValidatorError{}
@@ -42,6 +70,17 @@ func (command *UpsertApiKeyCommand) Validate() structs.ValidatorError {
 		errors["scope"] = "Scope must be one of: r, w, rw"
 	}
 
+	if command.ExpiresAt != nil && !command.ExpiresAt.After(time.Now()) {
+		errors["expiresAt"] = "Expiration date must be in the future"
+	}
+
+	for _, resourceScope := range command.ResourceScopes {
+		if !resourceScope.IsValid() {
+			errors["resourceScopes"] = "Invalid resource scope: " + string(resourceScope)
+			break
+		}
+	}
+
 	vErr.Errors = errors
 	return vErr
 }
@@ -1,8 +1,12 @@
 package commands
 
 import (
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/utils"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestUpsertApiKeyCommand_Validate_ValidInputs(t *testing.T) {
@@ -59,6 +63,7 @@ func TestUpsertApiKeyCommand_Validate_ValidInputs(t *testing.T) {
 }
 
 func TestUpsertApiKeyCommand_Validate_InvalidInputs(t *testing.T) {
+	pastDate := time.Now().Add(-time.Hour)
 	tests := map[string]struct {
 		command       UpsertApiKeyCommand
 		expectedError string
@@ -133,6 +138,22 @@ func TestUpsertApiKeyCommand_Validate_InvalidInputs(t *testing.T) {
 			},
 			expectedError: "scope",
 		},
+		"expiration in the past": {
+			command: UpsertApiKeyCommand{
+				Name:      "Test Key",
+				Scope:     "r",
+				ExpiresAt: &pastDate,
+			},
+			expectedError: "expiresAt",
+		},
+		"invalid resource scope": {
+			command: UpsertApiKeyCommand{
+				Name:           "Test Key",
+				Scope:          "r",
+				ResourceScopes: []models.ApiKeyResourceScope{models.API_KEY_RESOURCE_EXPORT, "receipts:delete"},
+			},
+			expectedError: "resourceScopes",
+		},
 	}
 
 	for testName, test := range tests {
@@ -196,3 +217,32 @@ func TestUpsertApiKeyCommand_Validate_EmptyCommand(t *testing.T) {
 		utils.PrintTestError(t, "error should exist for field", "scope")
 	}
 }
+
+func TestUpsertApiKeyCommand_LoadDataFromRequest_TracksRestrictionFields(t *testing.T) {
+	tests := map[string]struct {
+		body              string
+		hasExpiresAt      bool
+		hasAllowedGroups  bool
+		hasResourceScopes bool
+	}{
+		"omitted":       {body: `{"name":"Key","scope":"r"}`},
+		"explicit null": {body: `{"name":"Key","scope":"r","expiresAt":null,"allowedGroupIds":null,"resourceScopes":null}`, hasExpiresAt: true, hasAllowedGroups: true, hasResourceScopes: true},
+		"empty lists":   {body: `{"name":"Key","scope":"r","allowedGroupIds":[],"resourceScopes":[]}`, hasAllowedGroups: true, hasResourceScopes: true},
+	}
+
+	for name, test := range tests {
+		command := UpsertApiKeyCommand{}
+		r := httptest.NewRequest("PUT", "/api", strings.NewReader(test.body))
+		err := command.LoadDataFromRequest(httptest.NewRecorder(), r)
+		if err != nil {
+			utils.PrintTestError(t, err, nil)
+			continue
+		}
+
+		if command.HasExpiresAt() != test.hasExpiresAt ||
+			(command.AllowedGroupIds != nil) != test.hasAllowedGroups ||
+			(command.ResourceScopes != nil) != test.hasResourceScopes {
+			utils.PrintTestError(t, command, name)
+		}
+	}
+}
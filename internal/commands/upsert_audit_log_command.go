@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"net/http"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+)
+
+type UpsertAuditLogCommand struct {
+	Action        models.AuditAction     `json:"action"`
+	ActorUserId   *uint                  `json:"actorUserId"`
+	ActorApiKeyId *string                `json:"actorApiKeyId"`
+	IpAddress     string                 `json:"ipAddress"`
+	EntityType    models.AuditEntityType `json:"entityType"`
+	EntityId      string                 `json:"entityId"`
+	GroupId       *uint                  `json:"groupId"`
+	Before        any                    `json:"before"`
+	After         any                    `json:"after"`
+	Description   string                 `json:"description"`
+}
+
+func NewAuditLogCommandFromRequest(
+	r *http.Request,
+	action models.AuditAction,
+	entityType models.AuditEntityType,
+	entityId string,
+) UpsertAuditLogCommand {
+	command := UpsertAuditLogCommand{
+		Action:     action,
+		EntityType: entityType,
+		EntityId:   entityId,
+		IpAddress:  utils.GetRequestIpAddress(r),
+	}
+
+	claims := structs.GetClaimsIfPresent(r)
+	if claims != nil {
+		userId := claims.UserId
+		command.ActorUserId = &userId
+
+		if len(claims.ApiKeyId) > 0 {
+			apiKeyId := claims.ApiKeyId
+			command.ActorApiKeyId = &apiKeyId
+		}
+	}
+
+	return command
+}
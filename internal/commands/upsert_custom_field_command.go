@@ -13,6 +13,7 @@ type UpsertCustomFieldCommand struct {
 	Type        models.CustomFieldType           `json:"type"`
 	Description string                           `json:"description"`
 	Options     []UpsertCustomFieldOptionCommand `json:"options"`
+	GroupId     *uint                            `json:"groupId"`
 }
 
 func (command *UpsertCustomFieldCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
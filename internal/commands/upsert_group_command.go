@@ -40,6 +40,8 @@ func (command *UpsertGroupCommand) Validate(isCreate bool) structs.ValidatorErro
 
 	if len(command.Status) == 0 {
 		errorMap["status"] = "Status is required"
+	} else if !command.Status.IsValid() {
+		errorMap["status"] = "Status is invalid"
 	}
 
 	if !isCreate {
@@ -2,12 +2,16 @@ package commands
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
+	"regexp"
 )
 
+var ocrLanguageRegex = regexp.MustCompile(`^[a-zA-Z_]+$`)
+
 type UpsertReceiptProcessingSettingsCommand struct {
 	Name          string              `json:"name"`
 	Description   string              `json:"description"`
@@ -18,6 +22,13 @@ type UpsertReceiptProcessingSettingsCommand struct {
 	IsVisionModel bool                `json:"isVisionModel"`
 	OcrEngine     models.OcrEngine    `json:"ocrEngine"`
 	PromptId      uint                `json:"promptId"`
+	// Empty means the default pipeline is used
+	PreprocessingSteps     []models.ImagePreprocessingStep `json:"preprocessingSteps"`
+	OcrLanguages           []string                        `json:"ocrLanguages"`
+	OcrPageSegMode         *int                            `json:"ocrPageSegMode"`
+	OcrCharWhitelist       string                          `json:"ocrCharWhitelist"`
+	OcrCharBlacklist       *string                         `json:"ocrCharBlacklist"`
+	OcrConfidenceThreshold *float64                        `json:"ocrConfidenceThreshold"`
 }
 
 func (command *UpsertReceiptProcessingSettingsCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
@@ -53,6 +64,23 @@ func (command *UpsertReceiptProcessingSettingsCommand) Validate(updateKey bool)
 		errors["promptId"] = "promptId must be greater than 0"
 	}
 
+	ValidatePreprocessingSteps(command.PreprocessingSteps, errors)
+
+	for i, language := range command.OcrLanguages {
+		if !ocrLanguageRegex.MatchString(language) {
+			errors[fmt.Sprintf("ocrLanguages.%d", i)] = "language code is invalid"
+		}
+	}
+
+	// 0 only detects orientation and script, it returns no text
+	if command.OcrPageSegMode != nil && (*command.OcrPageSegMode < 1 || *command.OcrPageSegMode > 13) {
+		errors["ocrPageSegMode"] = "ocrPageSegMode must be between 1 and 13"
+	}
+
+	if command.OcrConfidenceThreshold != nil && (*command.OcrConfidenceThreshold < 0 || *command.OcrConfidenceThreshold > 100) {
+		errors["ocrConfidenceThreshold"] = "ocrConfidenceThreshold must be between 0 and 100"
+	}
+
 	if len(command.AiType) == 0 {
 		errors["type"] = "type is required"
 		return vErrs
@@ -86,5 +114,73 @@ func (command *UpsertReceiptProcessingSettingsCommand) IsEmpty() bool {
 		command.Model == "" &&
 		command.IsVisionModel == false &&
 		command.OcrEngine == "" &&
-		command.PromptId == 0
+		command.PromptId == 0 &&
+		len(command.PreprocessingSteps) == 0 &&
+		len(command.OcrLanguages) == 0 &&
+		command.OcrPageSegMode == nil &&
+		command.OcrCharWhitelist == "" &&
+		command.OcrCharBlacklist == nil &&
+		command.OcrConfidenceThreshold == nil
+}
+
+// Bounds keep a single settings row from making ImageMagick allocate huge buffers for every receipt
+func ValidatePreprocessingSteps(steps []models.ImagePreprocessingStep, errors map[string]string) {
+	for i, step := range steps {
+		key := fmt.Sprintf("preprocessingSteps.%d", i)
+
+		if !step.Type.IsValid() {
+			errors[key] = "type is invalid"
+			continue
+		}
+
+		if step.Amount != nil && *step.Amount < 0 {
+			errors[key] = "amount cannot be negative"
+		}
+
+		switch step.Type {
+		case models.PREPROCESS_TRIM:
+			if step.Amount != nil && *step.Amount > 65535 {
+				errors[key] = "trim fuzz must be between 0 and 65535"
+			}
+		case models.PREPROCESS_ADAPTIVE_THRESHOLD:
+			if (step.Width != nil && (*step.Width < 1 || *step.Width > 100)) ||
+				(step.Height != nil && (*step.Height < 1 || *step.Height > 100)) {
+				errors[key] = "adaptive threshold width and height must be between 1 and 100"
+			} else if step.Offset != nil && (*step.Offset < -65535 || *step.Offset > 65535) {
+				errors[key] = "adaptive threshold offset must be between -65535 and 65535"
+			}
+		case models.PREPROCESS_BLUR, models.PREPROCESS_SHARPEN:
+			if step.Amount != nil && (*step.Amount <= 0 || *step.Amount > 10) {
+				errors[key] = "sigma must be between 0 and 10"
+			}
+		case models.PREPROCESS_DESKEW:
+			if step.Amount != nil && *step.Amount > 1 {
+				errors[key] = "deskew threshold must be between 0 and 1"
+			}
+		case models.PREPROCESS_DENOISE:
+			if step.Amount != nil && (*step.Amount < 1 || *step.Amount > 15) {
+				errors[key] = "denoise window must be between 1 and 15"
+			}
+		case models.PREPROCESS_RESIZE_TO_DPI:
+			if step.Amount != nil && (*step.Amount < 72 || *step.Amount > 600) {
+				errors[key] = "dpi must be between 72 and 600"
+			}
+		case models.PREPROCESS_SCALE:
+			if step.Amount == nil || *step.Amount <= 0 || *step.Amount > 400 {
+				errors[key] = "scale percent must be between 0 and 400"
+			}
+		case models.PREPROCESS_PERSPECTIVE_CROP:
+			if len(step.Points) != 8 {
+				errors[key] = "perspective crop requires 4 corner points"
+				break
+			}
+
+			for _, point := range step.Points {
+				if point < 0 || point > models.MaxPreprocessedImageSide {
+					errors[key] = fmt.Sprintf("perspective crop points must be between 0 and %d", models.MaxPreprocessedImageSide)
+					break
+				}
+			}
+		}
+	}
 }
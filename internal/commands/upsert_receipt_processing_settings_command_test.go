@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestValidatePreprocessingSteps(t *testing.T) {
+	deskewThreshold := 0.4
+	tooHighThreshold := 1.5
+	dpi := 300.0
+	tooLowDpi := 10.0
+	tooHighDpi := 1200.0
+	zero := 0.0
+	largeWindow := uint(5000)
+
+	tests := map[string]struct {
+		steps []models.ImagePreprocessingStep
+		valid bool
+	}{
+		"no steps": {
+			steps: []models.ImagePreprocessingStep{},
+			valid: true,
+		},
+		"valid pipeline": {
+			steps: []models.ImagePreprocessingStep{
+				{Type: models.PREPROCESS_GRAYSCALE},
+				{Type: models.PREPROCESS_ADAPTIVE_THRESHOLD},
+				{Type: models.PREPROCESS_DESKEW, Amount: &deskewThreshold},
+				{Type: models.PREPROCESS_RESIZE_TO_DPI, Amount: &dpi},
+			},
+			valid: true,
+		},
+		"invalid type": {
+			steps: []models.ImagePreprocessingStep{{Type: "ROTATE"}},
+			valid: false,
+		},
+		"deskew threshold out of range": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_DESKEW, Amount: &tooHighThreshold}},
+			valid: false,
+		},
+		"dpi out of range": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_RESIZE_TO_DPI, Amount: &tooLowDpi}},
+			valid: false,
+		},
+		"denoise without window": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_DENOISE, Amount: &zero}},
+			valid: false,
+		},
+		"adaptive threshold window too large": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_ADAPTIVE_THRESHOLD, Width: &largeWindow}},
+			valid: false,
+		},
+		"dpi above maximum": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_RESIZE_TO_DPI, Amount: &tooHighDpi}},
+			valid: false,
+		},
+		"blur sigma too large": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_BLUR, Amount: &tooHighDpi}},
+			valid: false,
+		},
+		"perspective crop outside the image limit": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_PERSPECTIVE_CROP, Points: []float64{0, 0, 20000, 0, 20000, 100, 0, 100}}},
+			valid: false,
+		},
+		"scale without percent": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_SCALE}},
+			valid: false,
+		},
+		"perspective crop without corners": {
+			steps: []models.ImagePreprocessingStep{{Type: models.PREPROCESS_PERSPECTIVE_CROP, Points: []float64{0, 0, 10, 0}}},
+			valid: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			errors := make(map[string]string)
+			ValidatePreprocessingSteps(test.steps, errors)
+
+			if test.valid && len(errors) > 0 {
+				utils.PrintTestError(t, errors, "no errors")
+			}
+
+			if !test.valid && len(errors) == 0 {
+				utils.PrintTestError(t, len(errors), "greater than 0")
+			}
+		})
+	}
+}
+
+func TestShouldValidateOcrSettings(t *testing.T) {
+	validPsm := 6
+	invalidPsm := 0
+	validThreshold := 75.0
+	invalidThreshold := 101.0
+
+	tests := map[string]struct {
+		command UpsertReceiptProcessingSettingsCommand
+		errKey  string
+	}{
+		"valid ocr settings": {
+			command: UpsertReceiptProcessingSettingsCommand{
+				OcrLanguages:           []string{"deu", "fra", "chi_sim"},
+				OcrPageSegMode:         &validPsm,
+				OcrConfidenceThreshold: &validThreshold,
+			},
+		},
+		"invalid language": {
+			command: UpsertReceiptProcessingSettingsCommand{OcrLanguages: []string{"deu", "fra; rm"}},
+			errKey:  "ocrLanguages.1",
+		},
+		"invalid page segmentation mode": {
+			command: UpsertReceiptProcessingSettingsCommand{OcrPageSegMode: &invalidPsm},
+			errKey:  "ocrPageSegMode",
+		},
+		"invalid confidence threshold": {
+			command: UpsertReceiptProcessingSettingsCommand{OcrConfidenceThreshold: &invalidThreshold},
+			errKey:  "ocrConfidenceThreshold",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			test.command.Name = "name"
+			test.command.OcrEngine = models.TESSERACT_NEW
+			test.command.AiType = models.OLLAMA
+			test.command.Url = "http://localhost:11434"
+			test.command.PromptId = 1
+
+			vErr := test.command.Validate(false)
+
+			if len(test.errKey) == 0 && len(vErr.Errors) > 0 {
+				utils.PrintTestError(t, vErr.Errors, "no errors")
+			}
+
+			if len(test.errKey) > 0 && len(vErr.Errors[test.errKey]) == 0 {
+				utils.PrintTestError(t, vErr.Errors, test.errKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	config "receipt-wrangler/api/internal/env"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+)
+
+type UpsertScheduledExportCommand struct {
+	Name   string                    `json:"name"`
+	Filter ReceiptPagedRequestFilter `json:"filter"`
+	Format models.ExportFormat       `json:"format"`
+	structs.ReceiptCsvOptions
+	// Cron expression or descriptor like @monthly, evaluated in UTC
+	Schedule        string                       `json:"schedule"`
+	DestinationType models.ExportDestinationType `json:"destinationType"`
+	Destination     string                       `json:"destination"`
+	WebDavUsername  string                       `json:"webDavUsername"`
+	WebDavPassword  string                       `json:"webDavPassword"`
+	Enabled         bool                         `json:"enabled"`
+}
+
+func (command *UpsertScheduledExportCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
+	bytes, err := utils.GetBodyData(w, r)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(bytes, &command)
+	if err != nil {
+		return err
+	}
+
+	command.Filter.SetDefaults()
+
+	return nil
+}
+
+func (command UpsertScheduledExportCommand) Validate() structs.ValidatorError {
+	vErr := structs.ValidatorError{
+		Errors: make(map[string]string),
+	}
+
+	if len(strings.TrimSpace(command.Name)) == 0 {
+		vErr.Errors["name"] = "Name is required"
+	}
+
+	if !command.Format.IsValid() {
+		vErr.Errors["format"] = "Format must be CSV, OFX, QIF, BEANCOUNT, HLEDGER or PDF"
+	}
+
+	_, err := cron.ParseStandard(command.Schedule)
+	if err != nil {
+		vErr.Errors["schedule"] = "Schedule must be a cron expression like 0 6 1 * * or a descriptor like @monthly"
+	}
+
+	switch command.DestinationType {
+	case models.LOCAL_DIRECTORY:
+		if len(config.GetExportDirectory()) == 0 {
+			vErr.Errors["destinationType"] = "Local directory exports require EXPORT_DIRECTORY to be set"
+		}
+
+		// Local directories can't leave the group's directory in the export directory
+		cleanPath := filepath.Clean(command.Destination)
+		if len(command.Destination) == 0 || filepath.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+			vErr.Errors["destination"] = "Destination must be a directory relative to the group's export directory"
+		}
+	case models.WEBDAV:
+		destinationUrl, err := url.Parse(command.Destination)
+		if err != nil || (destinationUrl.Scheme != "http" && destinationUrl.Scheme != "https") || len(destinationUrl.Host) == 0 {
+			vErr.Errors["destination"] = "Destination must be an http or https url"
+		}
+	default:
+		vErr.Errors["destinationType"] = "Destination type must be LOCAL_DIRECTORY or WEBDAV"
+	}
+
+	return vErr
+}
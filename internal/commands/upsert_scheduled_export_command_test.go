@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldValidateUpsertScheduledExportCommand(t *testing.T) {
+	t.Setenv("EXPORT_DIRECTORY", t.TempDir())
+
+	validCommand := UpsertScheduledExportCommand{
+		Name:            "Monthly",
+		Format:          models.EXPORT_CSV,
+		Schedule:        "@monthly",
+		DestinationType: models.LOCAL_DIRECTORY,
+		Destination:     "accountant/2025",
+	}
+
+	tests := map[string]struct {
+		update func(command *UpsertScheduledExportCommand)
+		expect []string
+	}{
+		"valid local directory": {
+			update: func(command *UpsertScheduledExportCommand) {},
+			expect: []string{},
+		},
+		"valid webdav": {
+			update: func(command *UpsertScheduledExportCommand) {
+				command.DestinationType = models.WEBDAV
+				command.Destination = "https://cloud.example.com/remote.php/dav/files/accountant"
+				command.Schedule = "0 6 1 * *"
+			},
+			expect: []string{},
+		},
+		"missing fields": {
+			update: func(command *UpsertScheduledExportCommand) {
+				command.Name = " "
+				command.Format = "XLSX"
+				command.Schedule = "every month"
+			},
+			expect: []string{"name", "format", "schedule"},
+		},
+		"directory outside of export directory": {
+			update: func(command *UpsertScheduledExportCommand) {
+				command.Destination = "../etc"
+			},
+			expect: []string{"destination"},
+		},
+		"absolute directory": {
+			update: func(command *UpsertScheduledExportCommand) {
+				command.Destination = "/etc"
+			},
+			expect: []string{"destination"},
+		},
+		"webdav without url": {
+			update: func(command *UpsertScheduledExportCommand) {
+				command.DestinationType = models.WEBDAV
+				command.Destination = "ftp://example.com"
+			},
+			expect: []string{"destination"},
+		},
+		"unknown destination type": {
+			update: func(command *UpsertScheduledExportCommand) {
+				command.DestinationType = "S3"
+			},
+			expect: []string{"destinationType"},
+		},
+	}
+
+	for name, test := range tests {
+		command := validCommand
+		test.update(&command)
+
+		vErr := command.Validate()
+		if len(vErr.Errors) != len(test.expect) {
+			utils.PrintTestError(t, vErr.Errors, name)
+			continue
+		}
+
+		for _, key := range test.expect {
+			if len(vErr.Errors[key]) == 0 {
+				utils.PrintTestError(t, vErr.Errors, name+" "+key)
+			}
+		}
+	}
+}
+
+func TestShouldRequireExportDirectoryForLocalDirectories(t *testing.T) {
+	t.Setenv("EXPORT_DIRECTORY", "")
+
+	command := UpsertScheduledExportCommand{
+		Name:            "Monthly",
+		Format:          models.EXPORT_CSV,
+		Schedule:        "@monthly",
+		DestinationType: models.LOCAL_DIRECTORY,
+		Destination:     "accountant",
+	}
+
+	vErr := command.Validate()
+	if len(vErr.Errors["destinationType"]) == 0 {
+		utils.PrintTestError(t, vErr.Errors, "destinationType error")
+	}
+}
@@ -22,6 +22,8 @@ type UpsertSystemSettingsCommand struct {
 	FallbackReceiptProcessingSettingsId *uint                                 `json:"fallbackReceiptProcessingSettingsId"`
 	TaskConcurrency                     int                                   `json:"taskConcurrency"`
 	TaskQueueConfigurations             []UpsertTaskQueueConfigurationCommand `json:"taskQueueConfigurations"`
+	AuditLogRetentionDays               *int                                  `json:"auditLogRetentionDays"`
+	PdfRenderDpi                        int                                   `json:"pdfRenderDpi"`
 }
 
 func (command *UpsertSystemSettingsCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
@@ -82,6 +84,14 @@ func (command *UpsertSystemSettingsCommand) Validate() structs.ValidatorError {
 		errorMap["taskConcurrency"] = "Task concurrency must be greater than or equal to 0"
 	}
 
+	if command.AuditLogRetentionDays != nil && *command.AuditLogRetentionDays < 0 {
+		errorMap["auditLogRetentionDays"] = "Audit log retention days must be greater than or equal to 0"
+	}
+
+	if command.PdfRenderDpi != 0 && (command.PdfRenderDpi < 72 || command.PdfRenderDpi > 600) {
+		errorMap["pdfRenderDpi"] = "Pdf render dpi must be between 72 and 600"
+	}
+
 	queueNames := models.GetQueueNames()
 	if len(command.TaskQueueConfigurations) != len(queueNames) {
 		errorMap["taskQueueConfigurations"] = "Task queue configurations must be provided for all queues"
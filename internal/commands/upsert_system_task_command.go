@@ -19,4 +19,5 @@ type UpsertSystemTaskCommand struct {
 	AssociatedSystemTaskId *uint                       `json:"associatedSystemTaskId"`
 	AsynqTaskId            string                      `json:"asynqTaskId"`
 	ApiKeyId               *string                     `json:"apiKeyId"`
+	OcrWords               []models.OcrWord            `json:"ocrWords"`
 }
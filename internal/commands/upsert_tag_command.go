@@ -11,6 +11,7 @@ type UpsertTagCommand struct {
 	Id          *uint  `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	GroupId     *uint  `json:"groupId"`
 }
 
 func (tag *UpsertTagCommand) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
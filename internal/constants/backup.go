@@ -0,0 +1,8 @@
+package constants
+
+// Bumped when the backup archive layout changes, restores refuse archives newer than this
+const BackupVersion = 1
+const BackupDataFileName = "backup.json"
+
+// Original receipt files are stored in the archive under files/{fileDataId}
+const BackupFilesDirectory = "files"
@@ -3,19 +3,36 @@ package constants
 type EnvironmentVariable string
 
 const (
-	EncryptionKey EnvironmentVariable = "ENCRYPTION_KEY"
-	SecretKey     EnvironmentVariable = "SECRET_KEY"
-	DbUser        EnvironmentVariable = "DB_USER"
-	DbPassword    EnvironmentVariable = "DB_PASSWORD"
-	DbHost        EnvironmentVariable = "DB_HOST"
-	DbPort        EnvironmentVariable = "DB_PORT"
-	DbName        EnvironmentVariable = "DB_NAME"
-	DbFileName    EnvironmentVariable = "DB_FILENAME"
-	DbEngine      EnvironmentVariable = "DB_ENGINE"
-	RedisHost     EnvironmentVariable = "REDIS_HOST"
-	RedisPort     EnvironmentVariable = "REDIS_PORT"
-	RedisUser     EnvironmentVariable = "REDIS_USER"
-	RedisPassword EnvironmentVariable = "REDIS_PASSWORD"
-	BasePath      EnvironmentVariable = "BASE_PATH"
-	Env           EnvironmentVariable = "ENV"
+	EncryptionKey     EnvironmentVariable = "ENCRYPTION_KEY"
+	SecretKey         EnvironmentVariable = "SECRET_KEY"
+	DbUser            EnvironmentVariable = "DB_USER"
+	DbPassword        EnvironmentVariable = "DB_PASSWORD"
+	DbHost            EnvironmentVariable = "DB_HOST"
+	DbPort            EnvironmentVariable = "DB_PORT"
+	DbName            EnvironmentVariable = "DB_NAME"
+	DbFileName        EnvironmentVariable = "DB_FILENAME"
+	DbEngine          EnvironmentVariable = "DB_ENGINE"
+	RedisHost         EnvironmentVariable = "REDIS_HOST"
+	RedisPort         EnvironmentVariable = "REDIS_PORT"
+	RedisUser         EnvironmentVariable = "REDIS_USER"
+	RedisPassword     EnvironmentVariable = "REDIS_PASSWORD"
+	BasePath          EnvironmentVariable = "BASE_PATH"
+	Env               EnvironmentVariable = "ENV"
+	PublicUrl         EnvironmentVariable = "PUBLIC_URL"
+	SmtpHost          EnvironmentVariable = "SMTP_HOST"
+	SmtpPort          EnvironmentVariable = "SMTP_PORT"
+	SmtpUser          EnvironmentVariable = "SMTP_USER"
+	SmtpPassword      EnvironmentVariable = "SMTP_PASSWORD"
+	SmtpFrom          EnvironmentVariable = "SMTP_FROM"
+	StorageBackend    EnvironmentVariable = "STORAGE_BACKEND"
+	StoragePath       EnvironmentVariable = "STORAGE_PATH"
+	S3Endpoint        EnvironmentVariable = "S3_ENDPOINT"
+	S3Bucket          EnvironmentVariable = "S3_BUCKET"
+	S3Region          EnvironmentVariable = "S3_REGION"
+	S3AccessKeyId     EnvironmentVariable = "S3_ACCESS_KEY_ID"
+	S3SecretAccessKey EnvironmentVariable = "S3_SECRET_ACCESS_KEY"
+	S3UsePathStyle    EnvironmentVariable = "S3_USE_PATH_STYLE"
+	StorageEncryption EnvironmentVariable = "STORAGE_ENCRYPTION"
+	ExportDirectory   EnvironmentVariable = "EXPORT_DIRECTORY"
+	TrustedProxies    EnvironmentVariable = "TRUSTED_PROXIES"
 )
@@ -0,0 +1,10 @@
+package constants
+
+import "time"
+
+const (
+	// Reports with more receipts are generated in the background and delivered through a notification
+	ExpenseReportSyncReceiptLimit = 25
+	ExpenseReportRetention        = 7 * 24 * time.Hour
+	ExpenseReportFileName         = "expense-report.pdf"
+)
@@ -5,7 +5,11 @@ import "time"
 const ApplicationJson = "application/json"
 const ApplicationZip = "application/zip"
 const ApplicationPdf = "application/pdf"
+const ApplicationOfx = "application/x-ofx"
+const ApplicationQif = "application/qif"
 const ImageHeic = "image/heic"
+const ImagePng = "image/png"
+const ImageJpeg = "image/jpeg"
 const AnyImage = "image/*"
 const TextPlain = "text/plain"
 const TextCsv = "text/csv"
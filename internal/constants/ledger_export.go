@@ -0,0 +1,9 @@
+package constants
+
+const (
+	LedgerDefaultCurrency       = "USD"
+	LedgerDefaultPaidByAccount  = "Liabilities:Payable"
+	LedgerDefaultExpenseAccount = "Expenses:Uncategorized"
+	// Categories without a mapped account are posted to this account followed by the category name
+	LedgerExpenseAccountPrefix = "Expenses"
+)
@@ -0,0 +1,22 @@
+package constants
+
+// Column headers of the receipts.csv export, also the default column mapping of csv imports
+const (
+	ReceiptCsvIdColumn           = "Id"
+	ReceiptCsvAddedAtColumn      = "Added At"
+	ReceiptCsvDateColumn         = "Receipt Date"
+	ReceiptCsvNameColumn         = "Name"
+	ReceiptCsvPaidByColumn       = "Paid By"
+	ReceiptCsvAmountColumn       = "Amount"
+	ReceiptCsvStatusColumn       = "Status"
+	ReceiptCsvCategoriesColumn   = "Categories"
+	ReceiptCsvTagsColumn         = "Tags"
+	ReceiptCsvResolvedDateColumn = "Resolved Date"
+	// Only exported when images are included, lists the paths of the receipt's files in the zip
+	ReceiptCsvImagesColumn = "Images"
+)
+
+const ReceiptCsvDateFormat = "2006-01-02"
+
+// Categories and tags are joined with this in a single column
+const ReceiptCsvListSeparator = ","
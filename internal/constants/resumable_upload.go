@@ -0,0 +1,13 @@
+package constants
+
+import "time"
+
+const TusVersion = "1.0.0"
+const TusExtensions = "creation,termination,expiration"
+const TusOffsetOctetStream = "application/offset+octet-stream"
+
+// Completed uploads are read into memory for processing, like multipart uploads
+const ResumableUploadMaxSize int64 = 200 << 20
+
+// Partial uploads not touched for this long are removed by the clean up task
+const ResumableUploadExpiration = 24 * time.Hour
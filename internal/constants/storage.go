@@ -0,0 +1,5 @@
+package constants
+
+const LocalStorageBackend = "local"
+const S3StorageBackend = "s3"
+const DefaultS3Region = "us-east-1"
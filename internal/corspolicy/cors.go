@@ -13,8 +13,9 @@ func GetCorsPolicy() *cors.Cors {
 			AllowedOrigins:      []string{"http://localhost:4200", "http://localhost:8100"},
 			AllowCredentials:    true,
 			AllowPrivateNetwork: true,
-			AllowedMethods:      []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:      []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+			AllowedMethods:      []string{"GET", "POST", "PUT", "PATCH", "HEAD", "DELETE", "OPTIONS"},
+			AllowedHeaders:      []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset"},
+			ExposedHeaders:      []string{"Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Metadata", "Upload-Expires"},
 		})
 	}
 
@@ -23,8 +24,9 @@ func GetCorsPolicy() *cors.Cors {
 			AllowedOrigins:      []string{"http://localhost:4200", "http://localhost:8100"},
 			AllowCredentials:    true,
 			AllowPrivateNetwork: true,
-			AllowedMethods:      []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:      []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+			AllowedMethods:      []string{"GET", "POST", "PUT", "PATCH", "HEAD", "DELETE", "OPTIONS"},
+			AllowedHeaders:      []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset"},
+			ExposedHeaders:      []string{"Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Metadata", "Upload-Expires"},
 		})
 	}
 
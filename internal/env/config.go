@@ -13,6 +13,8 @@ import (
 var basePath string
 var env string
 
+const targetDatabasePrefix = "TARGET_"
+
 func GetSecretKey() string {
 	if len(os.Getenv(string(constants.SecretKey))) == 0 && env != "test" {
 		logging.LogStd(logging.LOG_LEVEL_FATAL, constants.EmptySecretKeyError)
@@ -22,8 +24,21 @@ func GetSecretKey() string {
 }
 
 func GetDatabaseConfig() (structs.DatabaseConfig, error) {
-	dbEngine := os.Getenv(string(constants.DbEngine))
-	port := os.Getenv(string(constants.DbPort))
+	return getDatabaseConfig("")
+}
+
+// Database transfer-database copies into, read from the DB_* variables prefixed with TARGET_
+func GetTargetDatabaseConfig() (structs.DatabaseConfig, error) {
+	return getDatabaseConfig(targetDatabasePrefix)
+}
+
+func getDatabaseConfig(prefix string) (structs.DatabaseConfig, error) {
+	getEnv := func(variable constants.EnvironmentVariable) string {
+		return os.Getenv(prefix + string(variable))
+	}
+
+	dbEngine := getEnv(constants.DbEngine)
+	port := getEnv(constants.DbPort)
 	portToUse := 0
 
 	if dbEngine == "postgresql" || dbEngine == "mariadb" || dbEngine == "mysql" {
@@ -36,13 +51,13 @@ func GetDatabaseConfig() (structs.DatabaseConfig, error) {
 	}
 
 	return structs.DatabaseConfig{
-		User:     os.Getenv(string(constants.DbUser)),
-		Password: os.Getenv(string(constants.DbPassword)),
-		Name:     os.Getenv(string(constants.DbName)),
-		Host:     os.Getenv(string(constants.DbHost)),
+		User:     getEnv(constants.DbUser),
+		Password: getEnv(constants.DbPassword),
+		Name:     getEnv(constants.DbName),
+		Host:     getEnv(constants.DbHost),
 		Port:     portToUse,
-		Engine:   os.Getenv(string(constants.DbEngine)),
-		Filename: os.Getenv(string(constants.DbFileName)),
+		Engine:   dbEngine,
+		Filename: getEnv(constants.DbFileName),
 	}, nil
 }
 
@@ -55,6 +70,16 @@ func GetBasePath() string {
 	return envBase
 }
 
+// Url the frontend is served from, used to build links sent to users
+func GetPublicUrl() string {
+	return strings.TrimSuffix(os.Getenv(string(constants.PublicUrl)), "/")
+}
+
+// Directory scheduled exports to local directories are written in, they are disabled when it is not set
+func GetExportDirectory() string {
+	return os.Getenv(string(constants.ExportDirectory))
+}
+
 func GetEncryptionKey() string {
 	if len(os.Getenv(string(constants.EncryptionKey))) == 0 && env != "test" {
 		logging.LogStd(logging.LOG_LEVEL_FATAL, constants.EmptyEncryptionKeyError)
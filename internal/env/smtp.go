@@ -0,0 +1,35 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+)
+
+// Outgoing email is optional, an empty SMTP_HOST means it is not configured
+func GetSmtpConfig() (structs.SmtpConfig, error) {
+	host := os.Getenv(string(constants.SmtpHost))
+	if len(host) == 0 {
+		return structs.SmtpConfig{}, nil
+	}
+
+	port, err := utils.StringToInt(os.Getenv(string(constants.SmtpPort)))
+	if err != nil {
+		return structs.SmtpConfig{}, fmt.Errorf("invalid SMTP_PORT environment variable: %w", err)
+	}
+
+	return structs.SmtpConfig{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv(string(constants.SmtpUser)),
+		Password: os.Getenv(string(constants.SmtpPassword)),
+		From:     os.Getenv(string(constants.SmtpFrom)),
+	}, nil
+}
+
+func IsSmtpConfigured() bool {
+	smtpConfig, err := GetSmtpConfig()
+	return err == nil && len(smtpConfig.Host) > 0 && len(smtpConfig.From) > 0
+}
@@ -0,0 +1,72 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/structs"
+	"strconv"
+)
+
+// Receipt files are stored on local disk unless STORAGE_BACKEND is set to s3, STORAGE_ENCRYPTION turns on encryption at rest
+func GetStorageConfig() (structs.StorageConfig, error) {
+	backend := os.Getenv(string(constants.StorageBackend))
+	if len(backend) == 0 {
+		backend = constants.LocalStorageBackend
+	}
+
+	storageConfig := structs.StorageConfig{
+		Backend: backend,
+		Path:    os.Getenv(string(constants.StoragePath)),
+	}
+
+	encrypt := os.Getenv(string(constants.StorageEncryption))
+	if len(encrypt) > 0 {
+		parsed, err := strconv.ParseBool(encrypt)
+		if err != nil {
+			return structs.StorageConfig{}, fmt.Errorf("invalid STORAGE_ENCRYPTION environment variable: %w", err)
+		}
+		storageConfig.Encrypt = parsed
+	}
+
+	// The key is also needed to read files encrypted before encryption was turned off
+	storageConfig.EncryptionKey = os.Getenv(string(constants.EncryptionKey))
+	if storageConfig.Encrypt && len(storageConfig.EncryptionKey) == 0 {
+		return structs.StorageConfig{}, fmt.Errorf("ENCRYPTION_KEY is required when STORAGE_ENCRYPTION is enabled")
+	}
+
+	switch backend {
+	case constants.LocalStorageBackend:
+		return storageConfig, nil
+	case constants.S3StorageBackend:
+		s3Config := structs.S3Config{
+			Endpoint:        os.Getenv(string(constants.S3Endpoint)),
+			Bucket:          os.Getenv(string(constants.S3Bucket)),
+			Region:          os.Getenv(string(constants.S3Region)),
+			AccessKeyId:     os.Getenv(string(constants.S3AccessKeyId)),
+			SecretAccessKey: os.Getenv(string(constants.S3SecretAccessKey)),
+		}
+
+		if len(s3Config.Endpoint) == 0 || len(s3Config.Bucket) == 0 {
+			return structs.StorageConfig{}, fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required when STORAGE_BACKEND is s3")
+		}
+
+		if len(s3Config.Region) == 0 {
+			s3Config.Region = constants.DefaultS3Region
+		}
+
+		usePathStyle := os.Getenv(string(constants.S3UsePathStyle))
+		if len(usePathStyle) > 0 {
+			parsed, err := strconv.ParseBool(usePathStyle)
+			if err != nil {
+				return structs.StorageConfig{}, fmt.Errorf("invalid S3_USE_PATH_STYLE environment variable: %w", err)
+			}
+			s3Config.UsePathStyle = parsed
+		}
+
+		storageConfig.S3 = s3Config
+		return storageConfig, nil
+	}
+
+	return structs.StorageConfig{}, fmt.Errorf("invalid STORAGE_BACKEND environment variable: %s", backend)
+}
@@ -0,0 +1,131 @@
+package env
+
+import (
+	"os"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func tearDownStorageConfigTests() {
+	os.Unsetenv("STORAGE_BACKEND")
+	os.Unsetenv("STORAGE_PATH")
+	os.Unsetenv("S3_ENDPOINT")
+	os.Unsetenv("S3_BUCKET")
+	os.Unsetenv("S3_REGION")
+	os.Unsetenv("S3_ACCESS_KEY_ID")
+	os.Unsetenv("S3_SECRET_ACCESS_KEY")
+	os.Unsetenv("S3_USE_PATH_STYLE")
+	os.Unsetenv("STORAGE_ENCRYPTION")
+}
+
+func TestShouldDefaultToLocalStorage(t *testing.T) {
+	tearDownStorageConfigTests()
+
+	storageConfig, err := GetStorageConfig()
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if storageConfig.Backend != constants.LocalStorageBackend {
+		utils.PrintTestError(t, storageConfig.Backend, constants.LocalStorageBackend)
+	}
+}
+
+func TestShouldGetS3StorageConfig(t *testing.T) {
+	defer tearDownStorageConfigTests()
+
+	os.Setenv("STORAGE_BACKEND", "s3")
+	os.Setenv("S3_ENDPOINT", "http://localhost:9000")
+	os.Setenv("S3_BUCKET", "receipts")
+	os.Setenv("S3_ACCESS_KEY_ID", "minio")
+	os.Setenv("S3_SECRET_ACCESS_KEY", "minio123")
+	os.Setenv("S3_USE_PATH_STYLE", "true")
+
+	storageConfig, err := GetStorageConfig()
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if storageConfig.S3.Endpoint != "http://localhost:9000" {
+		utils.PrintTestError(t, storageConfig.S3.Endpoint, "http://localhost:9000")
+	}
+
+	if storageConfig.S3.Bucket != "receipts" {
+		utils.PrintTestError(t, storageConfig.S3.Bucket, "receipts")
+	}
+
+	if storageConfig.S3.Region != constants.DefaultS3Region {
+		utils.PrintTestError(t, storageConfig.S3.Region, constants.DefaultS3Region)
+	}
+
+	if !storageConfig.S3.UsePathStyle {
+		utils.PrintTestError(t, storageConfig.S3.UsePathStyle, true)
+	}
+}
+
+func TestShouldRequireS3BucketAndEndpoint(t *testing.T) {
+	defer tearDownStorageConfigTests()
+
+	os.Setenv("STORAGE_BACKEND", "s3")
+	os.Setenv("S3_ENDPOINT", "http://localhost:9000")
+
+	_, err := GetStorageConfig()
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+func TestShouldRejectUnknownStorageBackend(t *testing.T) {
+	defer tearDownStorageConfigTests()
+
+	os.Setenv("STORAGE_BACKEND", "ftp")
+
+	_, err := GetStorageConfig()
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+func TestShouldGetStorageEncryptionConfig(t *testing.T) {
+	defer tearDownStorageConfigTests()
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	os.Setenv("STORAGE_ENCRYPTION", "true")
+
+	storageConfig, err := GetStorageConfig()
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if !storageConfig.Encrypt {
+		utils.PrintTestError(t, storageConfig.Encrypt, true)
+	}
+
+	if storageConfig.EncryptionKey != "test-key" {
+		utils.PrintTestError(t, storageConfig.EncryptionKey, "test-key")
+	}
+}
+
+func TestShouldRequireEncryptionKeyForStorageEncryption(t *testing.T) {
+	defer tearDownStorageConfigTests()
+	t.Setenv("ENCRYPTION_KEY", "")
+	os.Setenv("STORAGE_ENCRYPTION", "true")
+
+	_, err := GetStorageConfig()
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+func TestShouldRejectInvalidStorageEncryption(t *testing.T) {
+	defer tearDownStorageConfigTests()
+	os.Setenv("STORAGE_ENCRYPTION", "maybe")
+
+	_, err := GetStorageConfig()
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/services"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
@@ -19,8 +22,13 @@ func CreateApiKey(w http.ResponseWriter, r *http.Request) {
 		Request:      r,
 		ResponseType: constants.ApplicationJson,
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			err := validateApiKeyManagementCaller(structs.GetClaims(r))
+			if err != nil {
+				return http.StatusForbidden, err
+			}
+
 			command := commands.UpsertApiKeyCommand{}
-			err := command.LoadDataFromRequest(w, r)
+			err = command.LoadDataFromRequest(w, r)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
@@ -34,11 +42,24 @@ func CreateApiKey(w http.ResponseWriter, r *http.Request) {
 			token := structs.GetClaims(r)
 			apiKeyService := services.NewApiKeyService(nil)
 
+			err = apiKeyService.ValidateAllowedGroupIds(token.UserId, command.AllowedGroupIds)
+			if err != nil {
+				vErrs.Errors["allowedGroupIds"] = err.Error()
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
 			generatedKey, err := apiKeyService.CreateApiKey(token.UserId, command)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
+			apiKeyId, err := apiKeyService.GetIdFromV1ApiKey(generatedKey)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+			recordApiKeyAuditLog(r, models.AUDIT_API_KEY_CREATED, apiKeyId, nil)
+
 			response := structs.ApiKeyResult{
 				Key: generatedKey,
 			}
@@ -115,13 +136,18 @@ func UpdateApiKey(w http.ResponseWriter, r *http.Request) {
 		Writer:       w,
 		Request:      r,
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			err := validateApiKeyManagementCaller(structs.GetClaims(r))
+			if err != nil {
+				return http.StatusForbidden, err
+			}
+
 			id := chi.URLParam(r, "id")
 			// URL decode the ID parameter in case it was encoded by the frontend
 			if decodedId, err := url.QueryUnescape(id); err == nil {
 				id = decodedId
 			}
 			command := commands.UpsertApiKeyCommand{}
-			err := command.LoadDataFromRequest(w, r)
+			err = command.LoadDataFromRequest(w, r)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
@@ -134,13 +160,81 @@ func UpdateApiKey(w http.ResponseWriter, r *http.Request) {
 
 			token := structs.GetClaims(r)
 			apiKeyService := services.NewApiKeyService(nil)
+			previousApiKey := getApiKeyViewForAuditLog(id)
+
+			err = apiKeyService.ValidateAllowedGroupIds(token.UserId, command.AllowedGroupIds)
+			if err != nil {
+				vErrs.Errors["allowedGroupIds"] = err.Error()
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
 
 			err = apiKeyService.UpdateApiKey(id, token.UserId, command)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
+			recordApiKeyAuditLog(r, models.AUDIT_API_KEY_UPDATED, id, previousApiKey)
+
+			w.WriteHeader(http.StatusOK)
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func RotateApiKey(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error rotating API key",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			err := validateApiKeyManagementCaller(structs.GetClaims(r))
+			if err != nil {
+				return http.StatusForbidden, err
+			}
+
+			id := chi.URLParam(r, "id")
+			// URL decode the ID parameter in case it was encoded by the frontend
+			if decodedId, err := url.QueryUnescape(id); err == nil {
+				id = decodedId
+			}
+
+			command := commands.RotateApiKeyCommand{}
+			err = command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErrs := command.Validate()
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			token := structs.GetClaims(r)
+			apiKeyService := services.NewApiKeyService(nil)
+			previousApiKey := getApiKeyViewForAuditLog(id)
+
+			generatedKey, err := apiKeyService.RotateApiKey(id, token.UserId, command)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			recordApiKeyAuditLog(r, models.AUDIT_API_KEY_ROTATED, id, previousApiKey)
+
+			bytes, err := utils.MarshalResponseData(structs.ApiKeyResult{
+				Key: generatedKey,
+			})
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
 			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
 			return 0, nil
 		},
 	}
@@ -164,11 +258,15 @@ func DeleteApiKey(w http.ResponseWriter, r *http.Request) {
 			apiKeyService := services.NewApiKeyService(nil)
 
 			isAdmin := token.UserRole == "ADMIN"
+			previousApiKey := getApiKeyViewForAuditLog(id)
+
 			err := apiKeyService.DeleteApiKey(id, token.UserId, isAdmin)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
+			recordApiKeyAuditLog(r, models.AUDIT_API_KEY_DELETED, id, previousApiKey)
+
 			w.WriteHeader(http.StatusOK)
 			return 0, nil
 		},
@@ -176,3 +274,44 @@ func DeleteApiKey(w http.ResponseWriter, r *http.Request) {
 
 	HandleRequest(handler)
 }
+
+func getApiKeyViewForAuditLog(id string) *models.ApiKeyView {
+	apiKeyRepository := repositories.NewApiKeyRepository(nil)
+	apiKey, err := apiKeyRepository.GetApiKeyById(id)
+	if err != nil {
+		return nil
+	}
+
+	apiKeyView := apiKey.ToView()
+	return &apiKeyView
+}
+
+func recordApiKeyAuditLog(
+	r *http.Request,
+	action models.AuditAction,
+	apiKeyId string,
+	previousApiKey *models.ApiKeyView,
+) {
+	auditLogService := services.NewAuditLogService(nil)
+	auditLogCommand := commands.NewAuditLogCommandFromRequest(r, action, models.AUDIT_ENTITY_API_KEY, apiKeyId)
+
+	if previousApiKey != nil {
+		auditLogCommand.Before = previousApiKey
+	}
+
+	if action != models.AUDIT_API_KEY_DELETED {
+		auditLogCommand.After = getApiKeyViewForAuditLog(apiKeyId)
+	}
+
+	auditLogService.RecordAuditLog(auditLogCommand)
+}
+
+// validateApiKeyManagementCaller rejects api keys on routes creating or changing keys, otherwise a restricted key
+// could lift its own restrictions or mint a key without them
+func validateApiKeyManagementCaller(token *structs.Claims) error {
+	if len(token.ApiKeyId) > 0 {
+		return errors.New("api keys cannot manage api keys, log in to manage them")
+	}
+
+	return nil
+}
@@ -1184,6 +1184,54 @@ func TestUpdateApiKey_URLEncodedId(t *testing.T) {
 	}
 }
 
+func TestShouldNotLetApiKeysManageApiKeys(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer tearDownApiKeyHandlerTests()
+	createTestPepper()
+	createTestApiKeysForHandlerTests()
+	repositories.GetDB().Create(&models.ApiKeyGroup{ApiKeyId: "handler-key-2", GroupId: 1})
+
+	// A read write key restricted to group 1 trying to lift its own restriction or mint an unrestricted key
+	tests := map[string]struct {
+		method  string
+		body    string
+		handler http.HandlerFunc
+	}{
+		"update own key": {method: "PUT", body: `{"name": "Beta Handler Key", "scope": "rw", "allowedGroupIds": []}`, handler: UpdateApiKey},
+		"create key":     {method: "POST", body: `{"name": "Unrestricted", "scope": "rw"}`, handler: CreateApiKey},
+		"rotate key":     {method: "POST", body: "", handler: RotateApiKey},
+	}
+
+	for name, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(test.method, "/api/apiKey/handler-key-2", strings.NewReader(test.body))
+		r = r.WithContext(context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{
+			CustomClaims: &structs.Claims{
+				UserId:         1,
+				UserRole:       models.USER,
+				ApiKeyScope:    models.API_KEY_SCOPE_READ_WRITE,
+				ApiKeyId:       "handler-key-2",
+				ApiKeyGroupIds: []uint{1},
+			},
+		}))
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		chi.RouteContext(r.Context()).URLParams.Add("id", "handler-key-2")
+
+		test.handler(w, r)
+
+		if w.Result().StatusCode != http.StatusForbidden {
+			utils.PrintTestError(t, w.Result().StatusCode, name)
+		}
+	}
+
+	var allowedGroupCount, apiKeyCount int64
+	repositories.GetDB().Model(&models.ApiKeyGroup{}).Where("api_key_id = ?", "handler-key-2").Count(&allowedGroupCount)
+	repositories.GetDB().Model(&models.ApiKey{}).Count(&apiKeyCount)
+	if allowedGroupCount != 1 || apiKeyCount != 3 {
+		utils.PrintTestError(t, []int64{allowedGroupCount, apiKeyCount}, "restriction kept and no key created")
+	}
+}
+
 func TestDeleteApiKey_Success(t *testing.T) {
 	t.Setenv("ENCRYPTION_KEY", "test-key")
 	defer tearDownApiKeyHandlerTests()
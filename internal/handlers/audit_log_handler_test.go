@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+)
+
+func tearDownAuditLogHandlerTests() {
+	repositories.TruncateTestDb()
+}
+
+func createTestAuditLogs() {
+	db := repositories.GetDB()
+	userId := uint(1)
+
+	auditLogs := []models.AuditLog{
+		{Action: models.AUDIT_LOGIN, ActorUserId: &userId, EntityType: models.AUDIT_ENTITY_USER, EntityId: "1"},
+		{Action: models.AUDIT_LOGIN_FAILED, EntityType: models.AUDIT_ENTITY_USER},
+		{Action: models.AUDIT_API_KEY_CREATED, ActorUserId: &userId, EntityType: models.AUDIT_ENTITY_API_KEY, EntityId: "key"},
+	}
+
+	for _, auditLog := range auditLogs {
+		db.Create(&auditLog)
+	}
+}
+
+func TestGetPagedAuditLogs_ForbiddenForNonAdmin(t *testing.T) {
+	defer tearDownAuditLogHandlerTests()
+
+	command := commands.PagedAuditLogRequestCommand{
+		PagedRequestCommand: commands.PagedRequestCommand{Page: 1, PageSize: 10},
+	}
+	bytes, _ := json.Marshal(command)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/auditLog/getPagedAuditLogs", strings.NewReader(string(bytes)))
+	r = createJWTContext(r, 1, models.USER)
+
+	GetPagedAuditLogs(w, r)
+
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestGetPagedAuditLogs_FiltersByAction(t *testing.T) {
+	defer tearDownAuditLogHandlerTests()
+	createTestAuditLogs()
+
+	command := commands.PagedAuditLogRequestCommand{
+		PagedRequestCommand: commands.PagedRequestCommand{Page: 1, PageSize: 10},
+		Filter: commands.AuditLogFilter{
+			Actions: []models.AuditAction{models.AUDIT_LOGIN, models.AUDIT_LOGIN_FAILED},
+		},
+	}
+	bytes, _ := json.Marshal(command)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/auditLog/getPagedAuditLogs", strings.NewReader(string(bytes)))
+	r = createJWTContext(r, 1, models.ADMIN)
+
+	GetPagedAuditLogs(w, r)
+
+	if w.Result().StatusCode != http.StatusOK {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+	}
+
+	var pagedData structs.PagedData
+	json.Unmarshal(w.Body.Bytes(), &pagedData)
+
+	if pagedData.TotalCount != 2 {
+		utils.PrintTestError(t, pagedData.TotalCount, 2)
+	}
+}
+
+func TestGetPagedAuditLogs_RejectsInvalidAction(t *testing.T) {
+	defer tearDownAuditLogHandlerTests()
+
+	command := commands.PagedAuditLogRequestCommand{
+		PagedRequestCommand: commands.PagedRequestCommand{Page: 1, PageSize: 10},
+		Filter: commands.AuditLogFilter{
+			Actions: []models.AuditAction{"NOT_AN_ACTION"},
+		},
+	}
+	bytes, _ := json.Marshal(command)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/auditLog/getPagedAuditLogs", strings.NewReader(string(bytes)))
+	r = createJWTContext(r, 1, models.ADMIN)
+
+	GetPagedAuditLogs(w, r)
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestLogin_RecordsFailedLoginAuditLog(t *testing.T) {
+	defer tearDownAuditLogHandlerTests()
+
+	loginCommand := commands.LoginCommand{Username: "nobody", Password: "wrong"}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/login", strings.NewReader(""))
+	r.RemoteAddr = "192.0.2.10:1234"
+	r = r.WithContext(context.WithValue(r.Context(), "user", loginCommand))
+
+	Login(w, r)
+
+	var auditLog models.AuditLog
+	repositories.GetDB().Model(&models.AuditLog{}).Where("action = ?", models.AUDIT_LOGIN_FAILED).First(&auditLog)
+
+	if auditLog.IpAddress != "192.0.2.10" {
+		utils.PrintTestError(t, auditLog.IpAddress, "192.0.2.10")
+	}
+
+	if auditLog.ActorUserId != nil {
+		utils.PrintTestError(t, auditLog.ActorUserId, nil)
+	}
+}
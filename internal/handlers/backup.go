@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func GetGroupBackup(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+	handler := structs.Handler{
+		ErrorMessage: "Error creating backup",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationZip,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			uintGroupId, err := utils.StringToUint(groupId)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
+			backupService := services.NewBackupService(nil)
+			archive, err := backupService.CreateGroupBackup([]uint{uintGroupId})
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_EXPORT, models.AUDIT_ENTITY_GROUP, groupId)
+			auditLogCommand.GroupId = &uintGroupId
+			auditLogCommand.Description = "Created group backup"
+			auditLogService.RecordAuditLog(auditLogCommand)
+
+			writeBackupArchive(w, archive, "group-"+groupId)
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func GetInstanceBackup(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error creating backup",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationZip,
+		UserRole:     models.ADMIN,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			backupService := services.NewBackupService(nil)
+			archive, err := backupService.CreateInstanceBackup()
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_EXPORT, models.AUDIT_ENTITY_INSTANCE, "")
+			auditLogCommand.Description = "Created instance backup"
+			auditLogService.RecordAuditLog(auditLogCommand)
+
+			writeBackupArchive(w, archive, "instance")
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error restoring backup",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		UserRole:     models.ADMIN,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			command := commands.RestoreBackupCommand{}
+			err := command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
+			vErr := command.Validate()
+			if len(vErr.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErr, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			token := structs.GetClaims(r)
+			backupService := services.NewBackupService(nil)
+			result, err := backupService.RestoreBackup(command.Archive, token.UserId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			groupIds := make([]string, 0, len(result.Groups))
+			for _, group := range result.Groups {
+				groupIds = append(groupIds, utils.UintToString(group.ID))
+			}
+
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_RESTORE, models.AUDIT_ENTITY_GROUP, strings.Join(groupIds, ","))
+			auditLogCommand.Description = fmt.Sprintf("Restored %d groups with %d receipts from backup", len(result.Groups), result.Receipts)
+			auditLogService.RecordAuditLog(auditLogCommand)
+
+			bytes, err := json.Marshal(result)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func writeBackupArchive(w http.ResponseWriter, archive []byte, name string) {
+	fileName := fmt.Sprintf("backup-%s-%s.zip", name, time.Now().Format("20060102"))
+	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func buildGetGroupBackupRequest(groupId string, userId uint) *http.Request {
+	r := httptest.NewRequest("GET", "/api/backup/group/"+groupId, nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("groupId", groupId)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	return createJWTContext(r, userId, models.USER)
+}
+
+func TestShouldGetGroupBackupAsOwner(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+	defer storage.SetStorage(nil)
+	repositories.GetDB().Model(&models.GroupMember{}).
+		Where("group_id = ? AND user_id = ?", 1, 1).
+		Update("group_role", models.OWNER)
+
+	w := httptest.NewRecorder()
+	GetGroupBackup(w, buildGetGroupBackupRequest("1", 1))
+
+	if w.Result().StatusCode != http.StatusOK {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+		return
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil || len(archive.File) != 1 {
+		utils.PrintTestError(t, err, "archive with backup.json")
+	}
+}
+
+func TestShouldNotGetGroupBackupWithoutOwnerRole(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	repositories.GetDB().Model(&models.GroupMember{}).
+		Where("group_id = ? AND user_id = ?", 1, 2).
+		Update("group_role", models.EDITOR)
+
+	w := httptest.NewRecorder()
+	GetGroupBackup(w, buildGetGroupBackupRequest("1", 2))
+
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestShouldNotRestoreBackupAsUser(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	r := createJWTContext(httptest.NewRequest("POST", "/api/backup/restore", nil), 1, models.USER)
+	w := httptest.NewRecorder()
+	RestoreBackup(w, r)
+
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
@@ -21,8 +21,13 @@ func GetAllCategories(w http.ResponseWriter, r *http.Request) {
 		UserRole:     models.USER,
 		ResponseType: constants.ApplicationJson,
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			groupIds, status, err := getScopeGroupIds(r, false)
+			if err != nil {
+				return status, err
+			}
+
 			categoriesRepository := repositories.NewCategoryRepository(nil)
-			categories, err := categoriesRepository.GetAllCategories("*")
+			categories, err := categoriesRepository.GetAllCategories("*", groupIds)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
@@ -56,6 +61,11 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			status, err := validateScopeGroupId(r, category.GroupId)
+			if err != nil {
+				return status, err
+			}
+
 			categoriesRepository := repositories.NewCategoryRepository(nil)
 			createdCategory, err := categoriesRepository.CreateCategory(category)
 			if err != nil {
@@ -92,8 +102,13 @@ func GetPagedCategories(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			groupIds, status, err := getScopeGroupIds(r, true)
+			if err != nil {
+				return status, err
+			}
+
 			categoriesRepository := repositories.NewCategoryRepository(nil)
-			categories, err := categoriesRepository.GetAllPagedCategories(pagedRequestCommand)
+			categories, err := categoriesRepository.GetAllPagedCategories(pagedRequestCommand, groupIds)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
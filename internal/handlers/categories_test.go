@@ -304,3 +304,100 @@ func TestShouldNotGetCategoryNameDueToRole(t *testing.T) {
 		utils.PrintTestError(t, w.Result().StatusCode, expectedStatus)
 	}
 }
+
+func TestShouldOnlyGetGlobalAndOwnGroupCategories(t *testing.T) {
+	defer tearDownCategoriesTest()
+	categories := make([]models.Category, 0)
+	setupCategoriesTest()
+	repositories.CreateTestGroupWithUsers()
+
+	groupOneId := uint(1)
+	groupTwoId := uint(2)
+	db := repositories.GetDB()
+	db.Create(&models.Category{Name: "group one", GroupId: &groupOneId})
+	db.Create(&models.Category{Name: "group two", GroupId: &groupTwoId})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api", strings.NewReader(""))
+	r = createJWTContext(r, 2, models.USER)
+
+	GetAllCategories(w, r)
+
+	if w.Result().StatusCode != 200 {
+		utils.PrintTestError(t, w.Result().StatusCode, 200)
+		return
+	}
+
+	err := json.Unmarshal(w.Body.Bytes(), &categories)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(categories) != 4 {
+		utils.PrintTestError(t, len(categories), 4)
+	}
+
+	for _, category := range categories {
+		if category.GroupId != nil && *category.GroupId != groupOneId {
+			utils.PrintTestError(t, *category.GroupId, groupOneId)
+		}
+	}
+}
+
+func TestShouldNotCreateCategoryInGroupUserIsNotIn(t *testing.T) {
+	defer tearDownCategoriesTest()
+	setupCategoriesTest()
+	repositories.CreateTestGroupWithUsers()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api", strings.NewReader(`{"name": "test", "groupId": 2}`))
+	r = createJWTContext(r, 2, models.USER)
+
+	CreateCategory(w, r)
+
+	if w.Result().StatusCode != 403 {
+		utils.PrintTestError(t, w.Result().StatusCode, 403)
+	}
+}
+
+func TestShouldCreateGroupCategoryWithGlobalName(t *testing.T) {
+	defer tearDownCategoriesTest()
+	category := models.Category{}
+	setupCategoriesTest()
+	repositories.CreateTestGroupWithUsers()
+	repositories.GetDB().Model(&models.GroupMember{}).
+		Where("group_id = ? AND user_id = ?", 1, 1).
+		Update("group_role", models.EDITOR)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api", strings.NewReader(`{"name": "test", "groupId": 1}`))
+	r = createJWTContext(r, 1, models.USER)
+
+	CreateCategory(w, r)
+
+	if w.Result().StatusCode != 200 {
+		utils.PrintTestError(t, w.Result().StatusCode, 200)
+		return
+	}
+
+	err := json.Unmarshal(w.Body.Bytes(), &category)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if category.GroupId == nil || *category.GroupId != 1 {
+		utils.PrintTestError(t, category.GroupId, 1)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/api", strings.NewReader(`{"name": "test", "groupId": 1}`))
+	r = createJWTContext(r, 1, models.USER)
+
+	CreateCategory(w, r)
+
+	if w.Result().StatusCode != 500 {
+		utils.PrintTestError(t, w.Result().StatusCode, 500)
+	}
+}
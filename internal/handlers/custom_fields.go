@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
@@ -9,6 +10,7 @@ import (
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
+	"slices"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -34,8 +36,13 @@ func GetPagedCustomFields(w http.ResponseWriter, r *http.Request) {
 				return 0, nil
 			}
 
+			groupIds, status, err := getScopeGroupIds(r, true)
+			if err != nil {
+				return status, err
+			}
+
 			customFieldsRepository := repositories.NewCustomFieldRepository(nil)
-			customFields, count, err := customFieldsRepository.GetPagedCustomFields(pagedRequestCommand)
+			customFields, count, err := customFieldsRepository.GetPagedCustomFields(pagedRequestCommand, groupIds)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
@@ -83,6 +90,11 @@ func CreateCustomField(w http.ResponseWriter, r *http.Request) {
 				return 0, nil
 			}
 
+			status, err := validateScopeGroupId(r, command.GroupId)
+			if err != nil {
+				return status, err
+			}
+
 			token := structs.GetClaims(r)
 			customFieldsRepository := repositories.NewCustomFieldRepository(nil)
 			customField, err := customFieldsRepository.CreateCustomField(command, &token.UserId)
@@ -124,6 +136,15 @@ func GetCustomFieldById(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			groupIds, status, err := getScopeGroupIds(r, true)
+			if err != nil {
+				return status, err
+			}
+
+			if customField.GroupId != nil && groupIds != nil && !slices.Contains(groupIds, *customField.GroupId) {
+				return http.StatusForbidden, errors.New("custom field belongs to another group")
+			}
+
 			bytes, err := json.Marshal(customField)
 			if err != nil {
 				return http.StatusInternalServerError, err
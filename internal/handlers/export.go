@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
@@ -8,8 +11,12 @@ import (
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/services"
 	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"receipt-wrangler/api/internal/wranglerasynq"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/hibiken/asynq"
 )
 
 func ExportAllReceiptsFromGroup(w http.ResponseWriter, r *http.Request) {
@@ -22,13 +29,25 @@ func ExportAllReceiptsFromGroup(w http.ResponseWriter, r *http.Request) {
 		GroupId:      groupId,
 		GroupRole:    models.VIEWER,
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			exportCommand := commands.ExportReceiptsCommand{}
+			err := exportCommand.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErrs := exportCommand.Validate()
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
 			pagedRequest := commands.ReceiptPagedRequestCommand{}
-			err := pagedRequest.LoadDataFromRequest(w, r)
+			err = pagedRequest.LoadDataFromRequest(w, r)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
-			vErrs := pagedRequest.Validate()
+			vErrs = pagedRequest.Validate()
 			if len(vErrs.Errors) > 0 {
 				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
 				return 0, nil
@@ -42,21 +61,30 @@ func ExportAllReceiptsFromGroup(w http.ResponseWriter, r *http.Request) {
 					token.UserId,
 					groupId,
 					pagedRequest,
-					getExportReceiptAssociations(),
+					services.GetExportReceiptAssociations(),
+					token.ApiKeyGroupIds,
 				)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
-			receiptCsvService := services.NewReceiptCsvService()
-			zip, err := receiptCsvService.GetZippedCsvFiles(receipts)
+			uintGroupId, err := utils.StringToUint(groupId)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
-			w.Header().Set("Content-Disposition", "attachment; filename=data.zip")
-			w.WriteHeader(http.StatusOK)
-			w.Write(zip)
+			export, err := buildReceiptExport(exportCommand, receipts, &uintGroupId, token.UserId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_EXPORT, models.AUDIT_ENTITY_GROUP, groupId)
+			auditLogCommand.GroupId = &uintGroupId
+			auditLogCommand.Description = fmt.Sprintf("Exported %d receipts from group", len(receipts))
+			auditLogService.RecordAuditLog(auditLogCommand)
+
+			writeReceiptExport(w, export)
 
 			return 0, nil
 		},
@@ -81,21 +109,36 @@ func ExportReceiptsById(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			exportCommand := commands.ExportReceiptsCommand{}
+			err = exportCommand.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErrs := exportCommand.Validate()
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
 			receiptRepository := repositories.NewReceiptRepository(nil)
-			receipts, err := receiptRepository.GetReceiptsByIds(receiptIds, getExportReceiptAssociations())
+			receipts, err := receiptRepository.GetReceiptsByIds(receiptIds, services.GetExportReceiptAssociations())
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
-			receiptCsvService := services.NewReceiptCsvService()
-			zip, err := receiptCsvService.GetZippedCsvFiles(receipts)
+			token := structs.GetClaims(r)
+			export, err := buildReceiptExport(exportCommand, receipts, nil, token.UserId)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
-			w.Header().Set("Content-Disposition", "attachment; filename=data.zip")
-			w.WriteHeader(http.StatusOK)
-			w.Write(zip)
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_EXPORT, models.AUDIT_ENTITY_RECEIPT, strings.Join(receiptIds, ","))
+			auditLogCommand.Description = fmt.Sprintf("Exported %d receipts by id", len(receipts))
+			auditLogService.RecordAuditLog(auditLogCommand)
+
+			writeReceiptExport(w, export)
 
 			return 0, nil
 		},
@@ -104,13 +147,89 @@ func ExportReceiptsById(w http.ResponseWriter, r *http.Request) {
 	HandleRequest(handler)
 }
 
-func getExportReceiptAssociations() []string {
-	return []string{
-		"PaidByUser",
-		"ReceiptItems",
-		"ReceiptItems.Categories",
-		"ReceiptItems.Tags",
-		"ReceiptItems.ChargedToUser",
-		"ReceiptItems.Receipt",
+// buildReceiptExport exports receipts, pdf reports of many receipts are generated in the background and nil is returned
+func buildReceiptExport(command commands.ExportReceiptsCommand, receipts []models.Receipt, groupId *uint, userId uint) (*structs.ReceiptExport, error) {
+	if command.Format == models.EXPORT_PDF && len(receipts) > constants.ExpenseReportSyncReceiptLimit {
+		receiptIds := make([]string, 0, len(receipts))
+		for _, receipt := range receipts {
+			receiptIds = append(receiptIds, utils.UintToString(receipt.ID))
+		}
+
+		payload := wranglerasynq.ExpenseReportTaskPayload{
+			ReceiptIds:  receiptIds,
+			GroupId:     groupId,
+			RanByUserId: userId,
+		}
+
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		task := asynq.NewTask(wranglerasynq.ExpenseReport, payloadBytes)
+		_, err = wranglerasynq.EnqueueTask(task, models.QuickScanQueue)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, nil
 	}
+
+	export, err := services.NewExportService(nil).ExportReceipts(command, receipts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+func writeReceiptExport(w http.ResponseWriter, export *structs.ReceiptExport) {
+	if export == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+export.FileName)
+	w.WriteHeader(http.StatusOK)
+	w.Write(export.Bytes)
+}
+
+func GetExpenseReport(w http.ResponseWriter, r *http.Request) {
+	expenseReportId := chi.URLParam(r, "expenseReportId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error getting expense report",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationPdf,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			token := structs.GetClaims(r)
+
+			expenseReportRepository := repositories.NewExpenseReportRepository(nil)
+			expenseReport, err := expenseReportRepository.GetExpenseReportById(expenseReportId)
+			if err != nil {
+				return http.StatusNotFound, err
+			}
+
+			if expenseReport.UserId != token.UserId {
+				return http.StatusForbidden, errors.New("expense report belongs to another user")
+			}
+
+			pdfBytes, err := services.NewExpenseReportService(nil).GetExpenseReportPdf(expenseReport)
+			if err != nil {
+				return http.StatusNotFound, err
+			}
+
+			writeReceiptExport(w, &structs.ReceiptExport{
+				Bytes:       pdfBytes,
+				FileName:    expenseReport.FileName,
+				ContentType: constants.ApplicationPdf,
+			})
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
 }
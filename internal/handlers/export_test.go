@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+)
+
+func buildExportReceiptsByIdRequest(format string) *http.Request {
+	r := httptest.NewRequest("POST", "/api/export?format="+format, strings.NewReader("receiptIds=1"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return createJWTContext(r, 1, models.USER)
+}
+
+func TestShouldExportReceiptsByIdInLedgerFormat(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	db := repositories.GetDB()
+	db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", 1, 1).Update("group_role", models.VIEWER)
+	db.Create(&models.Receipt{
+		Name:         "Groceries",
+		Amount:       decimal.NewFromInt(12),
+		Date:         time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		GroupId:      1,
+		PaidByUserID: 1,
+		Status:       models.OPEN,
+	})
+
+	w := httptest.NewRecorder()
+	ExportReceiptsById(w, buildExportReceiptsByIdRequest("beancount"))
+
+	if w.Result().StatusCode != http.StatusOK {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+		return
+	}
+
+	if w.Result().Header.Get("Content-Type") != constants.TextPlain ||
+		w.Result().Header.Get("Content-Disposition") != "attachment; filename=receipts.beancount" {
+		utils.PrintTestError(t, w.Result().Header, "beancount attachment")
+	}
+
+	if !strings.Contains(w.Body.String(), `2025-01-01 ! "asdf" "Groceries"`) {
+		utils.PrintTestError(t, w.Body.String(), "beancount transaction")
+	}
+
+	w = httptest.NewRecorder()
+	ExportReceiptsById(w, buildExportReceiptsByIdRequest("xlsx"))
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestShouldExportSmallPdfReportDirectly(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	db := repositories.GetDB()
+	db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", 1, 1).Update("group_role", models.VIEWER)
+	db.Create(&models.Receipt{Name: "Hotel", Amount: decimal.NewFromInt(80), Date: time.Now(), GroupId: 1, PaidByUserID: 1, Status: models.OPEN})
+
+	w := httptest.NewRecorder()
+	ExportReceiptsById(w, buildExportReceiptsByIdRequest("PDF"))
+
+	if w.Result().StatusCode != http.StatusOK || w.Result().Header.Get("Content-Type") != constants.ApplicationPdf {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+		return
+	}
+
+	if !strings.HasPrefix(w.Body.String(), "%PDF") {
+		utils.PrintTestError(t, w.Body.String()[:10], "%PDF")
+	}
+}
+
+func TestShouldOnlyReturnExpenseReportToItsUser(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	defer storage.SetStorage(nil)
+	repositories.CreateTestGroupWithUsers()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+	repositories.GetDB().Create(&models.Receipt{Name: "Hotel", Amount: decimal.NewFromInt(80), Date: time.Now(), GroupId: 1, PaidByUserID: 1, Status: models.OPEN})
+
+	systemTask, err := services.NewExpenseReportService(nil).CreateExpenseReport([]string{"1"}, nil, 1, "")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	getExpenseReport := func(userId uint) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/export/reports/1", nil)
+		r = createJWTContext(r, userId, models.USER)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		chi.RouteContext(r.Context()).URLParams.Add("expenseReportId", utils.UintToString(systemTask.AssociatedEntityId))
+
+		w := httptest.NewRecorder()
+		GetExpenseReport(w, r)
+		return w
+	}
+
+	w := getExpenseReport(1)
+	if w.Result().StatusCode != http.StatusOK || !strings.HasPrefix(w.Body.String(), "%PDF") {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+	}
+
+	w = getExpenseReport(2)
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
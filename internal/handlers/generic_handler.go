@@ -48,6 +48,13 @@ func HandleRequest(handler structs.Handler) {
 		return
 	}
 
+	// Restricted keys are checked whether or not the handler validates a group role
+	if !apiKeyCanAccessGroups(handler) {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, "API key is not allowed to access this group")
+		utils.WriteCustomErrorResponse(handler.Writer, "User is unauthorized to access entity", http.StatusForbidden)
+		return
+	}
+
 	if len(handler.GroupRole) > 0 && len(handler.GroupId) > 0 {
 		groupService := services.NewGroupService(nil)
 		token := structs.GetClaims(handler.Request)
@@ -102,3 +109,29 @@ func HandleRequest(handler structs.Handler) {
 		return
 	}
 }
+
+// API keys restricted to groups may only be used against those groups
+func apiKeyCanAccessGroups(handler structs.Handler) bool {
+	token := structs.GetClaimsIfPresent(handler.Request)
+	if token == nil || len(token.ApiKeyGroupIds) == 0 {
+		return true
+	}
+
+	groupIds := handler.GroupIds
+	if len(handler.GroupId) > 0 {
+		groupIds = append([]string{handler.GroupId}, groupIds...)
+	}
+
+	for _, groupId := range groupIds {
+		uintGroupId, err := utils.StringToUint(groupId)
+		if err != nil {
+			return false
+		}
+
+		if !token.CanAccessGroup(uintGroupId) {
+			return false
+		}
+	}
+
+	return true
+}
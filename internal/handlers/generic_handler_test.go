@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"receipt-wrangler/api/internal/constants"
@@ -11,9 +12,11 @@ import (
 	"receipt-wrangler/api/internal/utils"
 	"strings"
 	"testing"
+	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/shopspring/decimal"
 )
 
 func tearDownGenericHandlerTest() {
@@ -499,3 +502,105 @@ func TestShouldRejectBasedOnUserRole(t *testing.T) {
 		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
 	}
 }
+
+func TestShouldRejectApiKeyRestrictedToOtherGroups(t *testing.T) {
+	defer tearDownGenericHandlerTest()
+	repositories.CreateTestGroupWithUsers()
+
+	tests := map[string]struct {
+		allowedGroupIds []uint
+		expect          int
+	}{
+		"restricted to other group": {allowedGroupIds: []uint{2}, expect: http.StatusForbidden},
+		"restricted to same group":  {allowedGroupIds: []uint{1}, expect: http.StatusOK},
+		"unrestricted":              {allowedGroupIds: nil, expect: http.StatusOK},
+	}
+
+	for name, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/api", strings.NewReader(""))
+		claims := &structs.Claims{UserId: 1, ApiKeyId: "key-id", ApiKeyGroupIds: test.allowedGroupIds}
+		r = r.WithContext(context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{CustomClaims: claims}))
+
+		handler := structs.Handler{
+			Writer:    w,
+			Request:   r,
+			GroupRole: models.VIEWER,
+			GroupId:   "1",
+			HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+				w.WriteHeader(http.StatusOK)
+				return 0, nil
+			},
+		}
+
+		HandleRequest(handler)
+
+		if w.Result().StatusCode != test.expect {
+			utils.PrintTestError(t, w.Result().StatusCode, test.expect)
+			t.Log(name)
+		}
+	}
+}
+
+func createRestrictedApiKeyRequest(target string, allowedGroupIds []uint) *http.Request {
+	r := httptest.NewRequest("GET", target, strings.NewReader(""))
+	claims := &structs.Claims{UserId: 1, UserRole: models.USER, ApiKeyId: "key-id", ApiKeyGroupIds: allowedGroupIds}
+	return r.WithContext(context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{CustomClaims: claims}))
+}
+
+func TestShouldRejectRestrictedApiKeyWithoutGroupRole(t *testing.T) {
+	defer tearDownGenericHandlerTest()
+	repositories.CreateTestGroupWithUsers()
+
+	w := httptest.NewRecorder()
+	handler := structs.Handler{
+		Writer:  w,
+		Request: createRestrictedApiKeyRequest("/api", []uint{1}),
+		GroupId: "2",
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			w.WriteHeader(http.StatusOK)
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestShouldLimitGroupSpanningEndpointsToApiKeyGroups(t *testing.T) {
+	defer tearDownGenericHandlerTest()
+	repositories.CreateTestGroupWithUsers()
+	db := repositories.GetDB()
+	db.Create(&models.GroupMember{UserID: 1, GroupID: 2, GroupRole: models.VIEWER})
+	db.Create(&models.Receipt{Name: "Coffee one", Amount: decimal.NewFromInt(3), Date: time.Now(), GroupId: 1, PaidByUserID: 1, Status: models.OPEN})
+	db.Create(&models.Receipt{Name: "Coffee two", Amount: decimal.NewFromInt(4), Date: time.Now(), GroupId: 2, PaidByUserID: 1, Status: models.OPEN})
+
+	w := httptest.NewRecorder()
+	Search(w, createRestrictedApiKeyRequest("/api/search?searchTerm=Coffee", []uint{1}))
+
+	var results []structs.SearchResult
+	json.Unmarshal(w.Body.Bytes(), &results)
+	if w.Result().StatusCode != http.StatusOK || len(results) != 1 || results[0].GroupID != 1 {
+		utils.PrintTestError(t, results, "only the receipt of group 1")
+	}
+
+	w = httptest.NewRecorder()
+	GetGroupsForUser(w, createRestrictedApiKeyRequest("/api/group", []uint{1}))
+
+	var groups []models.Group
+	json.Unmarshal(w.Body.Bytes(), &groups)
+	if w.Result().StatusCode != http.StatusOK || len(groups) != 1 || groups[0].ID != 1 {
+		utils.PrintTestError(t, groups, "only group 1")
+	}
+
+	w = httptest.NewRecorder()
+	Search(w, createRestrictedApiKeyRequest("/api/search?searchTerm=Coffee", nil))
+
+	json.Unmarshal(w.Body.Bytes(), &results)
+	if len(results) != 2 {
+		utils.PrintTestError(t, len(results), 2)
+	}
+}
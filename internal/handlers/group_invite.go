@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/env"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func CreateGroupInvite(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error creating group invite.",
+		Writer:       w,
+		Request:      r,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			command := commands.CreateGroupInviteCommand{}
+			err := command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErrs := command.Validate()
+			if len(command.Email) > 0 && !env.IsSmtpConfigured() {
+				vErrs.Errors["email"] = "Outgoing email is not configured"
+			}
+
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			token := structs.GetClaims(r)
+			groupInviteService := services.NewGroupInviteService(nil)
+			result, err := groupInviteService.CreateGroupInvite(groupId, token.UserId, command)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(result)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func GetPendingGroupInvites(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error retrieving group invites.",
+		Writer:       w,
+		Request:      r,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			groupInviteService := services.NewGroupInviteService(nil)
+			invites, err := groupInviteService.GetPendingGroupInvites(groupId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(invites)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func RevokeGroupInvite(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error revoking group invite.",
+		Writer:       w,
+		Request:      r,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			inviteId := chi.URLParam(r, "inviteId")
+
+			groupInviteService := services.NewGroupInviteService(nil)
+			err := groupInviteService.RevokeGroupInvite(groupId, inviteId)
+			if err != nil {
+				return http.StatusNotFound, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func GetGroupInviteInfo(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Invite not found or expired.",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			inviteToken := chi.URLParam(r, "token")
+
+			groupInviteService := services.NewGroupInviteService(nil)
+			inviteInfo, err := groupInviteService.GetGroupInviteInfo(inviteToken)
+			if err != nil {
+				return http.StatusNotFound, err
+			}
+
+			bytes, err := utils.MarshalResponseData(inviteInfo)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func AcceptGroupInvite(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error accepting invite.",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			inviteToken := chi.URLParam(r, "token")
+			token := structs.GetClaims(r)
+			groupInviteService := services.NewGroupInviteService(nil)
+
+			invite, err := groupInviteService.GetPendingInviteByToken(inviteToken)
+			if err != nil {
+				return http.StatusNotFound, err
+			}
+
+			groupMemberRepository := repositories.NewGroupMemberRepository(nil)
+			groupIdString := utils.UintToString(invite.GroupId)
+			previousGroupMembers, err := groupMemberRepository.GetsGroupMembersByGroupId(groupIdString)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			groupMember, err := groupInviteService.AcceptGroupInvite(inviteToken, token.UserId)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
+			updatedGroupMembers, err := groupMemberRepository.GetsGroupMembersByGroupId(groupIdString)
+			if err == nil {
+				recordGroupMembershipChange(r, invite.GroupId, previousGroupMembers, updatedGroupMembers)
+			}
+
+			bytes, err := utils.MarshalResponseData(groupMember)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func SignUpWithGroupInvite(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error signing up.",
+		Writer:       w,
+		Request:      r,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			systemSettingsService := services.NewSystemSettingsService(nil)
+			featureConfig, err := systemSettingsService.GetFeatureConfig()
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			if !featureConfig.EnableLocalSignUp {
+				return http.StatusNotFound, errors.New("Local sign up is disabled")
+			}
+
+			inviteToken := chi.URLParam(r, "token")
+			groupInviteService := services.NewGroupInviteService(nil)
+
+			invite, err := groupInviteService.GetPendingInviteByToken(inviteToken)
+			if err != nil {
+				return http.StatusNotFound, err
+			}
+
+			command := r.Context().Value("signUpCommand").(commands.SignUpCommand)
+			vErrs := validateSignUpData(models.User{
+				Username:    command.Username,
+				Password:    command.Password,
+				DisplayName: command.DisplayName,
+			})
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			user, err := groupInviteService.SignUpWithGroupInvite(inviteToken, command)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(
+				r,
+				models.AUDIT_GROUP_MEMBERSHIP_CHANGED,
+				models.AUDIT_ENTITY_GROUP,
+				utils.UintToString(invite.GroupId),
+			)
+			auditLogCommand.ActorUserId = &user.ID
+			auditLogCommand.GroupId = &invite.GroupId
+			auditLogCommand.Description = "Signed up with group invite"
+			auditLogService.RecordAuditLog(auditLogCommand)
+
+			w.WriteHeader(http.StatusOK)
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
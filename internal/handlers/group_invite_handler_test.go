@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCreateGroupInvite_ForbiddenForNonOwner(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	repositories.GetDB().Model(&models.GroupMember{}).Where("user_id = ?", 2).Update("group_role", models.EDITOR)
+
+	command := commands.CreateGroupInviteCommand{GroupRole: models.VIEWER}
+	bytes, _ := json.Marshal(command)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/group/1/invites", strings.NewReader(string(bytes)))
+	chiContext := chi.NewRouteContext()
+	chiContext.URLParams.Add("groupId", "1")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chiContext))
+	r = createJWTContext(r, 2, models.USER)
+
+	CreateGroupInvite(w, r)
+
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestSignUpWithGroupInvite_HonoursLocalSignUpSetting(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.GetDB().Create(&models.SystemSettings{EnableLocalSignUp: false})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/groupInvite/token/signUp", strings.NewReader(""))
+	chiContext := chi.NewRouteContext()
+	chiContext.URLParams.Add("token", "token")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chiContext))
+	r = r.WithContext(context.WithValue(r.Context(), "signUpCommand", commands.SignUpCommand{Username: "invited"}))
+
+	SignUpWithGroupInvite(w, r)
+
+	if w.Result().StatusCode != http.StatusNotFound {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"slices"
+)
+
+// getScopeGroupIds resolves whose categories, tags and custom fields a request may see, besides the global ones.
+// The optional groupId query param narrows it down to a single group. Admins are not restricted when allowAdmin is set.
+func getScopeGroupIds(r *http.Request, allowAdmin bool) ([]uint, int, error) {
+	token := structs.GetClaims(r)
+	groupService := services.NewGroupService(nil)
+	stringUserId := utils.UintToString(token.UserId)
+
+	groupId := r.URL.Query().Get("groupId")
+	if len(groupId) > 0 {
+		uintGroupId, err := utils.StringToUint(groupId)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+
+		err = groupService.ValidateGroupRole(models.VIEWER, groupId, stringUserId)
+		if err != nil {
+			return nil, http.StatusForbidden, err
+		}
+
+		if len(token.ApiKeyGroupIds) > 0 && !slices.Contains(token.ApiKeyGroupIds, uintGroupId) {
+			return nil, http.StatusForbidden, errors.New("api key is not allowed to access this group")
+		}
+
+		return []uint{uintGroupId}, 0, nil
+	}
+
+	if len(token.ApiKeyGroupIds) > 0 {
+		return token.ApiKeyGroupIds, 0, nil
+	}
+
+	if allowAdmin && token.UserRole == models.ADMIN {
+		return nil, 0, nil
+	}
+
+	groupIds, err := groupService.GetGroupIdsForUser(stringUserId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return groupIds, 0, nil
+}
+
+// validateScopeGroupId checks that the user may create group scoped data in groupId. Nil means global.
+func validateScopeGroupId(r *http.Request, groupId *uint) (int, error) {
+	if groupId == nil {
+		return 0, nil
+	}
+
+	token := structs.GetClaims(r)
+	if len(token.ApiKeyGroupIds) > 0 && !slices.Contains(token.ApiKeyGroupIds, *groupId) {
+		return http.StatusForbidden, errors.New("api key is not allowed to access this group")
+	}
+
+	groupService := services.NewGroupService(nil)
+	err := groupService.ValidateGroupRole(models.EDITOR, utils.UintToString(*groupId), utils.UintToString(token.UserId))
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+
+	return 0, nil
+}
+
+func filterAccessibleGroups(token *structs.Claims, groups []models.Group) []models.Group {
+	return slices.DeleteFunc(groups, func(group models.Group) bool {
+		return !token.CanAccessGroup(group.ID)
+	})
+}
+
+// getUserGroupIds returns the groups of the requesting user, narrowed down to the groups an api key is restricted to
+func getUserGroupIds(r *http.Request) ([]uint, error) {
+	token := structs.GetClaims(r)
+	groupMemberRepository := repositories.NewGroupMemberRepository(nil)
+
+	groupIds, err := groupMemberRepository.GetGroupIdsByUserId(utils.UintToString(token.UserId))
+	if err != nil {
+		return nil, err
+	}
+
+	return token.FilterAccessibleGroupIds(groupIds), nil
+}
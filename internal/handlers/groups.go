@@ -112,6 +112,8 @@ func GetGroupsForUser(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
+			groups = filterAccessibleGroups(token, groups)
+
 			bytes, err := utils.MarshalResponseData(groups)
 			if err != nil {
 				return http.StatusInternalServerError, err
@@ -189,18 +191,9 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
-			bytes, err := utils.MarshalResponseData(group)
-			if err != nil {
-				return http.StatusInternalServerError, err
-			}
+			recordGroupMembershipChange(r, group.ID, nil, group.GroupMembers)
 
-			fileRepository := repositories.NewFileRepository(nil)
-			groupPath, err := fileRepository.BuildGroupPath(group.ID, "")
-			if err != nil {
-				return http.StatusInternalServerError, err
-			}
-
-			err = utils.MakeDirectory(groupPath)
+			bytes, err := utils.MarshalResponseData(group)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
@@ -252,12 +245,20 @@ func UpdateGroup(w http.ResponseWriter, r *http.Request) {
 				return http.StatusBadRequest, errors.New("cannot update all group")
 			}
 
+			groupMemberRepository := repositories.NewGroupMemberRepository(nil)
+			previousGroupMembers, err := groupMemberRepository.GetsGroupMembersByGroupId(groupId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
 			updatedGroup, err := groupRepository.UpdateGroup(command, groupId)
 
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
+			recordGroupMembershipChange(r, updatedGroup.ID, previousGroupMembers, updatedGroup.GroupMembers)
+
 			bytes, err := utils.MarshalResponseData(updatedGroup)
 			if err != nil {
 				return http.StatusInternalServerError, err
@@ -351,6 +352,92 @@ func UpdateGroupReceiptSettings(w http.ResponseWriter, r *http.Request) {
 	HandleRequest(handler)
 }
 
+func GetGroupLedgerSettings(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error getting group ledger settings",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		GroupId:      groupId,
+		GroupRole:    models.VIEWER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			uintGroupId, err := utils.StringToUint(groupId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			groupLedgerSettingsRepository := repositories.NewGroupLedgerSettingsRepository(nil)
+			groupLedgerSettings, err := groupLedgerSettingsRepository.GetGroupLedgerSettings(uintGroupId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(groupLedgerSettings)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func UpdateGroupLedgerSettings(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error updating group ledger settings",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			command := commands.UpdateGroupLedgerSettingsCommand{}
+			err := command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErrs := command.Validate()
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			uintGroupId, err := utils.StringToUint(groupId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			groupLedgerSettingsRepository := repositories.NewGroupLedgerSettingsRepository(nil)
+			updatedGroupLedgerSettings, err := groupLedgerSettingsRepository.UpdateGroupLedgerSettings(uintGroupId, command)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(updatedGroupLedgerSettings)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
 func PollGroupEmail(w http.ResponseWriter, r *http.Request) {
 	groupId := chi.URLParam(r, "groupId")
 	errMessage := "Error polling email(s), please review your email integration settings"
@@ -385,6 +472,7 @@ func PollGroupEmail(w http.ResponseWriter, r *http.Request) {
 				if err != nil {
 					return http.StatusInternalServerError, err
 				}
+				groups = filterAccessibleGroups(token, groups)
 
 				for _, group := range groups {
 					if group.GroupSettings.EmailIntegrationEnabled {
@@ -476,6 +564,85 @@ func DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	HandleRequest(handler)
 }
 
+func ArchiveGroup(w http.ResponseWriter, r *http.Request) {
+	setGroupStatus(w, r, models.GROUP_ARCHIVED, "Error archiving group.")
+}
+
+func UnarchiveGroup(w http.ResponseWriter, r *http.Request) {
+	setGroupStatus(w, r, models.GROUP_ACTIVE, "Error unarchiving group.")
+}
+
+func setGroupStatus(w http.ResponseWriter, r *http.Request, status models.GroupStatus, errMessage string) {
+	handler := structs.Handler{
+		ErrorMessage: errMessage,
+		Writer:       w,
+		Request:      r,
+		GroupId:      chi.URLParam(r, "groupId"),
+		GroupRole:    models.OWNER,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			groupService := services.NewGroupService(nil)
+			group, err := groupService.SetGroupStatus(chi.URLParam(r, "groupId"), status)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(group)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func BulkArchiveGroups(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error archiving groups.",
+		Writer:       w,
+		Request:      r,
+		UserRole:     models.ADMIN,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			command := commands.BulkArchiveGroupsCommand{}
+			err := command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErrs := command.Validate()
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			groupService := services.NewGroupService(nil)
+			archivedGroupIds, err := groupService.ArchiveInactiveGroups(command.InactiveMonths)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(archivedGroupIds)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
 func GetOcrTextForGroup(w http.ResponseWriter, r *http.Request) {
 	groupId := chi.URLParam(r, "groupId")
 	handler := structs.Handler{
@@ -532,3 +699,42 @@ func GetOcrTextForGroup(w http.ResponseWriter, r *http.Request) {
 
 	HandleRequest(handler)
 }
+
+func recordGroupMembershipChange(
+	r *http.Request,
+	groupId uint,
+	previousGroupMembers []models.GroupMember,
+	updatedGroupMembers []models.GroupMember,
+) {
+	previousRoles := make(map[uint]models.GroupRole)
+	for _, groupMember := range previousGroupMembers {
+		previousRoles[groupMember.UserID] = groupMember.GroupRole
+	}
+
+	hasChanged := len(previousGroupMembers) != len(updatedGroupMembers)
+	for _, groupMember := range updatedGroupMembers {
+		previousRole, ok := previousRoles[groupMember.UserID]
+		if !ok || previousRole != groupMember.GroupRole {
+			hasChanged = true
+			break
+		}
+	}
+
+	if !hasChanged {
+		return
+	}
+
+	auditLogService := services.NewAuditLogService(nil)
+	auditLogCommand := commands.NewAuditLogCommandFromRequest(
+		r,
+		models.AUDIT_GROUP_MEMBERSHIP_CHANGED,
+		models.AUDIT_ENTITY_GROUP,
+		utils.UintToString(groupId),
+	)
+	auditLogCommand.GroupId = &groupId
+	if previousGroupMembers != nil {
+		auditLogCommand.Before = previousGroupMembers
+	}
+	auditLogCommand.After = updatedGroupMembers
+	auditLogService.RecordAuditLog(auditLogCommand)
+}
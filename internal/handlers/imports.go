@@ -1,12 +1,22 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/logging"
 	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/services"
 	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"receipt-wrangler/api/internal/wranglerasynq"
+	"strings"
+
+	"github.com/hibiken/asynq"
 )
 
 func ImportConfigJson(w http.ResponseWriter, r *http.Request) {
@@ -41,3 +51,168 @@ func ImportConfigJson(w http.ResponseWriter, r *http.Request) {
 
 	HandleRequest(handler)
 }
+
+func ImportReceiptsCsv(w http.ResponseWriter, r *http.Request) {
+	errMessage := "Error importing receipts."
+	command := commands.ImportReceiptCsvCommand{}
+	err := command.LoadDataFromRequest(w, r)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+		utils.WriteCustomErrorResponse(w, errMessage, http.StatusBadRequest)
+		return
+	}
+
+	command.SetDefaults()
+	vErr := command.Validate()
+	if len(vErr.Errors) > 0 {
+		structs.WriteValidatorErrorResponse(w, vErr, http.StatusBadRequest)
+		return
+	}
+
+	handler := structs.Handler{
+		ErrorMessage: errMessage,
+		Writer:       w,
+		Request:      r,
+		GroupRole:    models.EDITOR,
+		GroupId:      utils.UintToString(command.GroupId),
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			token := structs.GetClaims(r)
+			receiptCsvImportService := services.NewReceiptCsvImportService(nil)
+
+			preview, vErr, err := receiptCsvImportService.PreviewReceiptCsvImport(command, token.UserId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			if len(vErr.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErr, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			status := http.StatusOK
+			if command.Commit && preview.InvalidRows > 0 {
+				status = http.StatusBadRequest
+			}
+
+			if command.Commit && status == http.StatusOK {
+				err = enqueueReceiptCsvImport(command, token.UserId)
+				if err != nil {
+					return http.StatusInternalServerError, err
+				}
+				status = http.StatusAccepted
+			}
+
+			bytes, err := json.Marshal(preview)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(status)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func ImportExpenses(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error importing expenses.",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			command := commands.ImportExpensesCommand{}
+			err := command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
+			vErr := command.Validate()
+			if len(vErr.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErr, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			token := structs.GetClaims(r)
+			expenseImportService := services.NewExpenseImportService(nil)
+			vErr, err = expenseImportService.ValidateUserMappings(command, token.UserId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+			if len(vErr.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErr, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			result, err := expenseImportService.ImportExpenses(command, token.UserId, token.UserRole)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			if len(result.AddedMembers) > 0 {
+				auditLogService := services.NewAuditLogService(nil)
+				auditLogCommand := commands.NewAuditLogCommandFromRequest(
+					r,
+					models.AUDIT_GROUP_MEMBERSHIP_CHANGED,
+					models.AUDIT_ENTITY_GROUP,
+					utils.UintToString(result.Group.ID),
+				)
+				auditLogCommand.GroupId = &result.Group.ID
+				auditLogCommand.After = result.Group.GroupMembers
+				auditLogCommand.Description = fmt.Sprintf("Added %s from expense import", strings.Join(result.AddedMembers, ", "))
+				auditLogService.RecordAuditLog(auditLogCommand)
+			}
+
+			bytes, err := json.Marshal(result)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func enqueueReceiptCsvImport(command commands.ImportReceiptCsvCommand, userId uint) error {
+	fileRepository := repositories.NewFileRepository(nil)
+	tempPath, err := fileRepository.BuildTempFilePath("csv")
+	if err != nil {
+		return err
+	}
+
+	utils.MakeDirectory(fileRepository.GetTempDirectoryPath())
+	err = utils.WriteFile(tempPath, command.Csv)
+	if err != nil {
+		return err
+	}
+
+	payload := wranglerasynq.ReceiptCsvImportTaskPayload{
+		Command:     command,
+		TempPath:    tempPath,
+		RanByUserId: userId,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	task := asynq.NewTask(wranglerasynq.ReceiptCsvImport, payloadBytes)
+	_, err = wranglerasynq.EnqueueTask(task, models.QuickScanQueue)
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	return nil
+}
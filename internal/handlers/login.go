@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
@@ -21,9 +22,14 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
 			userData := r.Context().Value("user").(commands.LoginCommand)
 			var dbUser models.User
+			auditLogService := services.NewAuditLogService(nil)
 
 			dbUser, firstAdminToLogin, err := services.LoginUser(userData)
 			if err != nil {
+				auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_LOGIN_FAILED, models.AUDIT_ENTITY_USER, "")
+				auditLogCommand.Description = fmt.Sprintf("Failed login attempt for username '%s'", userData.Username)
+				auditLogService.RecordAuditLog(auditLogCommand)
+
 				return http.StatusInternalServerError, err
 			}
 
@@ -35,7 +41,12 @@ func Login(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
+			userIdString := utils.UintToString(dbUser.ID)
 			if dbUser.IsDummyUser {
+				auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_LOGIN_FAILED, models.AUDIT_ENTITY_USER, userIdString)
+				auditLogCommand.Description = fmt.Sprintf("Dummy user '%s' attempted to log in", dbUser.Username)
+				auditLogService.RecordAuditLog(auditLogCommand)
+
 				return http.StatusInternalServerError, errors.New("dummy users cannot log in")
 			}
 
@@ -66,6 +77,10 @@ func Login(w http.ResponseWriter, r *http.Request) {
 
 			appData.Claims = accessTokenClaims
 
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_LOGIN, models.AUDIT_ENTITY_USER, userIdString)
+			auditLogCommand.ActorUserId = &dbUser.ID
+			auditLogService.RecordAuditLog(auditLogCommand)
+
 			bytes, err := utils.MarshalResponseData(appData)
 			if err != nil {
 				return http.StatusInternalServerError, err
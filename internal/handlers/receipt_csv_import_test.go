@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func buildImportReceiptsCsvRequest(options string, csv string, userId uint) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("options", options)
+	part, _ := writer.CreateFormFile("file", "receipts.csv")
+	part.Write([]byte(csv))
+	writer.Close()
+
+	r := httptest.NewRequest("POST", "/api/import/receiptsCsv", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return createJWTContext(r, userId, models.USER)
+}
+
+func TestShouldPreviewReceiptsCsvImport(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	repositories.GetDB().Model(&models.GroupMember{}).
+		Where("group_id = ? AND user_id = ?", 1, 1).
+		Update("group_role", models.EDITOR)
+
+	csv := "Name,Receipt Date,Amount,Paid By,Categories,Tags,Status\n" +
+		"valid,2025-01-01,10,test,,,OPEN\n" +
+		"invalid,2025-01-01,10,nobody,,,OPEN\n"
+
+	w := httptest.NewRecorder()
+	ImportReceiptsCsv(w, buildImportReceiptsCsvRequest(`{"groupId":1}`, csv, 1))
+
+	if w.Result().StatusCode != http.StatusOK {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+		return
+	}
+
+	var preview structs.ReceiptCsvImportPreview
+	json.Unmarshal(w.Body.Bytes(), &preview)
+	if preview.ValidRows != 1 || preview.InvalidRows != 1 {
+		utils.PrintTestError(t, preview, "1 valid and 1 invalid row")
+	}
+
+	// Invalid rows block the commit
+	w = httptest.NewRecorder()
+	ImportReceiptsCsv(w, buildImportReceiptsCsvRequest(`{"groupId":1,"commit":true}`, csv, 1))
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestShouldNotImportReceiptsCsvToOtherGroup(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	w := httptest.NewRecorder()
+	ImportReceiptsCsv(w, buildImportReceiptsCsvRequest(`{"groupId":2}`, "Name\ntest\n", 1))
+
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
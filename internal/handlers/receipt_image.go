@@ -1,19 +1,24 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
-	"os"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
 	"receipt-wrangler/api/internal/logging"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/storage"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 func UploadReceiptImage(w http.ResponseWriter, r *http.Request) {
@@ -32,12 +37,19 @@ func UploadReceiptImage(w http.ResponseWriter, r *http.Request) {
 		utils.WriteCustomErrorResponse(w, errMessage, http.StatusInternalServerError)
 	}
 
-	file, fileHeader, err := r.FormFile("file")
-	if err != nil {
-		logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
-		utils.WriteCustomErrorResponse(w, errMessage, http.StatusInternalServerError)
+	// Clients that already know the hash of a file the server has can send it instead of the bytes
+	hash := r.Form.Get("hash")
+	var file multipart.File
+	var fileHeader *multipart.FileHeader
+	if len(hash) == 0 {
+		file, fileHeader, err = r.FormFile("file")
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+			utils.WriteCustomErrorResponse(w, errMessage, http.StatusInternalServerError)
+			return
+		}
+		defer file.Close()
 	}
-	defer file.Close()
 
 	// TODO: Validate size
 	handler := structs.Handler{
@@ -48,11 +60,40 @@ func UploadReceiptImage(w http.ResponseWriter, r *http.Request) {
 		ReceiptId:    r.Form.Get("receiptId"),
 		GroupRole:    models.EDITOR,
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
-			fileBytes := make([]byte, fileHeader.Size)
+			var fileBytes []byte
+			var fileName string
 
-			_, err = file.Read(fileBytes)
-			if err != nil {
-				return http.StatusInternalServerError, err
+			if len(hash) > 0 {
+				fileName = r.Form.Get("name")
+				if !utils.IsSha256Hash(hash) || len(fileName) == 0 {
+					return http.StatusBadRequest, errors.New("a valid hash and name are required")
+				}
+
+				groupIds, status, err := getScopeGroupIds(r, false)
+				if err != nil {
+					return status, err
+				}
+
+				_, err = services.GetReceiptImageBlob(hash, groupIds)
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return http.StatusNotFound, err
+				}
+				if err != nil {
+					return http.StatusInternalServerError, err
+				}
+
+				fileBytes, err = fileRepository.ReadFile(fileRepository.BuildBlobKey(hash))
+				if err != nil {
+					return http.StatusInternalServerError, err
+				}
+			} else {
+				fileName = fileHeader.Filename
+				fileBytes = make([]byte, fileHeader.Size)
+
+				_, err = file.Read(fileBytes)
+				if err != nil {
+					return http.StatusInternalServerError, err
+				}
 			}
 
 			_, err = fileRepository.ValidateFileType(fileBytes)
@@ -62,8 +103,8 @@ func UploadReceiptImage(w http.ResponseWriter, r *http.Request) {
 
 			fileImageRepository := repositories.NewReceiptImageRepository(nil)
 			fileData := models.FileData{
-				Name:      fileHeader.Filename,
-				Size:      uint(fileHeader.Size),
+				Name:      fileName,
+				Size:      uint(len(fileBytes)),
 				ReceiptId: receiptId,
 			}
 
@@ -99,6 +140,47 @@ func UploadReceiptImage(w http.ResponseWriter, r *http.Request) {
 	HandleRequest(handler)
 }
 
+// GetReceiptImageBlob tells clients whether the server already has a file, so they can upload its hash instead of the bytes
+func GetReceiptImageBlob(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error retrieving file.",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			hash := chi.URLParam(r, "hash")
+			if !utils.IsSha256Hash(hash) {
+				return http.StatusBadRequest, errors.New("invalid hash")
+			}
+
+			groupIds, status, err := getScopeGroupIds(r, false)
+			if err != nil {
+				return status, err
+			}
+
+			fileBlob, err := services.GetReceiptImageBlob(hash, groupIds)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return http.StatusNotFound, err
+			}
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(fileBlob)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
 func GetReceiptImage(w http.ResponseWriter, r *http.Request) {
 	db := repositories.GetDB()
 	errorMessage := "Error retrieving image."
@@ -123,13 +205,35 @@ func GetReceiptImage(w http.ResponseWriter, r *http.Request) {
 			var bytes []byte
 			result := models.FileDataView{}
 
+			size := models.IMAGE_SIZE_ORIGINAL
+			if len(r.URL.Query().Get("size")) > 0 {
+				size = models.ImageSize(r.URL.Query().Get("size"))
+			}
+
+			if !size.IsValid() {
+				return http.StatusBadRequest, errors.New("invalid image size")
+			}
+
+			// Stored files never change, so clients can keep them until the FileData itself changes
+			etag := buildReceiptImageEtag(fileData, size)
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "private, max-age=86400")
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return 0, nil
+			}
+
 			err = db.Model(models.Receipt{}).Where("id = ?", fileData.ReceiptId).Select("id").Find(&receipt).Error
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
 			fileRepository := repositories.NewFileRepository(nil)
-			bytes, err = fileRepository.GetBytesForFileData(fileData)
+			if size == models.IMAGE_SIZE_ORIGINAL {
+				bytes, err = fileRepository.GetBytesForFileData(fileData)
+			} else {
+				bytes, err = repositories.NewReceiptImageRepository(nil).GetReceiptImagePreview(fileData, size)
+			}
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
@@ -157,6 +261,10 @@ func GetReceiptImage(w http.ResponseWriter, r *http.Request) {
 	HandleRequest(handler)
 }
 
+func buildReceiptImageEtag(fileData models.FileData, size models.ImageSize) string {
+	return fmt.Sprintf("\"%d-%s-%d\"", fileData.ID, size, fileData.UpdatedAt.UnixNano())
+}
+
 func DownloadReceiptImage(w http.ResponseWriter, r *http.Request) {
 	db := repositories.GetDB()
 	errorMessage := "Error retrieving image."
@@ -180,14 +288,17 @@ func DownloadReceiptImage(w http.ResponseWriter, r *http.Request) {
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
 			fileRepository := repositories.NewFileRepository(nil)
 
-			path, err := fileRepository.BuildFilePath(utils.UintToString(fileData.ReceiptId), utils.UintToString(fileData.ID), fileData.Name)
+			fileBytes, err := fileRepository.GetRawBytesForFileData(fileData)
+			if errors.Is(err, storage.ErrNotFound) {
+				return http.StatusNotFound, err
+			}
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
 			w.Header().Set("Content-Disposition", "attachment; filename="+fileData.Name)
 
-			http.ServeFile(w, r, path)
+			http.ServeContent(w, r, fileData.Name, fileData.UpdatedAt, bytes.NewReader(fileBytes))
 
 			return 0, nil
 		},
@@ -196,41 +307,89 @@ func DownloadReceiptImage(w http.ResponseWriter, r *http.Request) {
 	HandleRequest(handler)
 }
 
-func RemoveReceiptImage(w http.ResponseWriter, r *http.Request) {
+func GetReceiptImagePage(w http.ResponseWriter, r *http.Request) {
 	db := repositories.GetDB()
-	errorMessage := "Error deleting image."
-
-	id := chi.URLParam(r, "id")
+	errorMessage := "Error retrieving page."
 	var fileData models.FileData
+	id := chi.URLParam(r, "id")
+	pageNumber := chi.URLParam(r, "pageNumber")
 
 	err := db.Model(models.FileData{}).Where("id = ?", id).First(&fileData).Error
 	if err != nil {
-		utils.WriteCustomErrorResponse(w, errorMessage, http.StatusInternalServerError)
+		utils.WriteCustomErrorResponse(w, errorMessage, http.StatusNotFound)
+		return
 	}
 	stringReceiptId := utils.UintToString(fileData.ReceiptId)
 
 	handler := structs.Handler{
-		ErrorMessage: "Error deleting image.",
+		ErrorMessage: errorMessage,
 		ReceiptId:    stringReceiptId,
+		GroupRole:    models.VIEWER,
 		Writer:       w,
 		Request:      r,
+		ResponseType: "",
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
-			err = db.Delete(fileData).Error
+			receiptImageRepository := repositories.NewReceiptImageRepository(nil)
+			page, err := receiptImageRepository.GetPdfPage(id, pageNumber)
 			if err != nil {
-				return http.StatusInternalServerError, err
+				return http.StatusNotFound, err
 			}
 
 			fileRepository := repositories.NewFileRepository(nil)
-			path, err := fileRepository.BuildFilePath(utils.UintToString(fileData.ReceiptId), id, fileData.Name)
+			pageBytes, err := fileRepository.ReadFileDataPage(fileData, page)
+			if errors.Is(err, storage.ErrNotFound) {
+				return http.StatusNotFound, err
+			}
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
-			err = os.Remove(path)
+			w.Header().Set("Content-Type", page.FileType)
+			http.ServeContent(w, r, page.Name, page.UpdatedAt, bytes.NewReader(pageBytes))
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func RemoveReceiptImage(w http.ResponseWriter, r *http.Request) {
+	db := repositories.GetDB()
+	errorMessage := "Error deleting image."
+
+	id := chi.URLParam(r, "id")
+	var fileData models.FileData
+
+	err := db.Model(models.FileData{}).Where("id = ?", id).First(&fileData).Error
+	if err != nil {
+		utils.WriteCustomErrorResponse(w, errorMessage, http.StatusInternalServerError)
+	}
+	stringReceiptId := utils.UintToString(fileData.ReceiptId)
+
+	handler := structs.Handler{
+		ErrorMessage: "Error deleting image.",
+		ReceiptId:    stringReceiptId,
+		Writer:       w,
+		Request:      r,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			var unusedKeys []string
+			err := db.Transaction(func(tx *gorm.DB) error {
+				receiptImageRepository := repositories.NewReceiptImageRepository(tx)
+				keys, err := receiptImageRepository.ReleaseFileData(fileData)
+				if err != nil {
+					return err
+				}
+				unusedKeys = keys
+
+				return tx.Delete(fileData).Error
+			})
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
+			repositories.NewReceiptImageRepository(nil).DeleteReleasedFiles([]models.FileData{fileData}, unusedKeys)
+
 			w.WriteHeader(http.StatusOK)
 			return 0, nil
 		},
@@ -418,6 +577,10 @@ func validateReceiptImageAccess(r *http.Request, groupRole models.GroupRole, rec
 		return http.StatusInternalServerError, err
 	}
 
+	if !token.CanAccessGroup(receiptImage.Receipt.GroupId) {
+		return http.StatusForbidden, errors.New("api key is not allowed to access this group")
+	}
+
 	err = groupService.ValidateGroupRole(groupRole, utils.UintToString(receiptImage.Receipt.GroupId), utils.UintToString(token.UserId))
 	if err != nil {
 		return http.StatusForbidden, err
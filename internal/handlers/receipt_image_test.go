@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+)
+
+var testPngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func setupReceiptImageTest(t *testing.T) models.FileData {
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+
+	receipt := models.Receipt{
+		Name:         "Test Receipt",
+		Amount:       decimal.NewFromFloat(10.00),
+		Date:         time.Now(),
+		PaidByUserID: 1,
+		Status:       models.OPEN,
+		GroupId:      1,
+	}
+	db.Create(&receipt)
+
+	fileData := models.FileData{Name: "receipt.png", FileType: "image/png", ReceiptId: receipt.ID}
+	db.Create(&fileData)
+
+	fileRepository := repositories.NewFileRepository(nil)
+	fileRepository.WriteFile(fileRepository.BuildFileDataKey(fileData), testPngBytes)
+
+	return fileData
+}
+
+func tearDownReceiptImageTest() {
+	repositories.TruncateTestDb()
+	storage.SetStorage(nil)
+}
+
+func buildGetReceiptImageRequest(fileData models.FileData, query string) *http.Request {
+	r := httptest.NewRequest("GET", "/api/receiptImage/"+utils.UintToString(fileData.ID)+query, nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", utils.UintToString(fileData.ID))
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	newContext := context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{CustomClaims: &structs.Claims{UserId: 1, UserRole: models.USER}})
+	return r.WithContext(newContext)
+}
+
+func TestShouldGetOriginalReceiptImageWithCachingHeaders(t *testing.T) {
+	defer tearDownReceiptImageTest()
+	fileData := setupReceiptImageTest(t)
+
+	w := httptest.NewRecorder()
+	GetReceiptImage(w, buildGetReceiptImageRequest(fileData, "?size=original"))
+
+	if w.Result().StatusCode != http.StatusOK {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+		return
+	}
+
+	var result models.FileDataView
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.EncodedImage != utils.BuildDataURI("image/png", testPngBytes) {
+		utils.PrintTestError(t, result.EncodedImage, "the original png")
+	}
+
+	if w.Header().Get("ETag") == "" || w.Header().Get("Cache-Control") == "" {
+		utils.PrintTestError(t, w.Header(), "ETag and Cache-Control headers")
+	}
+}
+
+func TestShouldNotReturnUnchangedReceiptImage(t *testing.T) {
+	defer tearDownReceiptImageTest()
+	fileData := setupReceiptImageTest(t)
+
+	r := buildGetReceiptImageRequest(fileData, "?size=thumb")
+	r.Header.Set("If-None-Match", buildReceiptImageEtag(fileData, models.IMAGE_SIZE_THUMB))
+
+	w := httptest.NewRecorder()
+	GetReceiptImage(w, r)
+
+	if w.Result().StatusCode != http.StatusNotModified {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusNotModified)
+	}
+
+	if w.Body.Len() != 0 {
+		utils.PrintTestError(t, w.Body.String(), "empty body")
+	}
+}
+
+func TestShouldRejectInvalidReceiptImageSize(t *testing.T) {
+	defer tearDownReceiptImageTest()
+	fileData := setupReceiptImageTest(t)
+
+	w := httptest.NewRecorder()
+	GetReceiptImage(w, buildGetReceiptImageRequest(fileData, "?size=huge"))
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusBadRequest)
+	}
+}
+
+func buildGetReceiptImageBlobRequest(hash string, userId uint) *http.Request {
+	r := httptest.NewRequest("GET", "/api/receiptImage/hash/"+hash, nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("hash", hash)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	newContext := context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{CustomClaims: &structs.Claims{UserId: userId, UserRole: models.USER}})
+	return r.WithContext(newContext)
+}
+
+func TestShouldOnlyFindReceiptImageBlobsInOwnGroups(t *testing.T) {
+	defer tearDownReceiptImageTest()
+	fileData := setupReceiptImageTest(t)
+
+	fileBlob, _, err := repositories.NewFileBlobRepository(nil).StoreBlob(testPngBytes)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+	repositories.GetDB().Model(&fileData).Update("blob_hash", fileBlob.Hash)
+
+	w := httptest.NewRecorder()
+	GetReceiptImageBlob(w, buildGetReceiptImageBlobRequest(fileBlob.Hash, 1))
+
+	if w.Result().StatusCode != http.StatusOK {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+		return
+	}
+
+	var result models.FileBlob
+	json.Unmarshal(w.Body.Bytes(), &result)
+	if result.Hash != fileBlob.Hash || result.Size != uint(len(testPngBytes)) {
+		utils.PrintTestError(t, result, fileBlob)
+	}
+
+	w = httptest.NewRecorder()
+	GetReceiptImageBlob(w, buildGetReceiptImageBlobRequest(fileBlob.Hash, 4))
+
+	if w.Result().StatusCode != http.StatusNotFound {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusNotFound)
+	}
+
+	w = httptest.NewRecorder()
+	GetReceiptImageBlob(w, buildGetReceiptImageBlobRequest("not-a-hash", 1))
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusBadRequest)
+	}
+}
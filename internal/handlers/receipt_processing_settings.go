@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/json"
+	"io"
 	"net/http"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
@@ -276,3 +278,75 @@ func CheckReceiptProcessingSettingsConnectivity(w http.ResponseWriter, r *http.R
 
 	HandleRequest(handler)
 }
+
+func PreviewReceiptProcessingSettingsPreprocessing(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error previewing image preprocessing",
+		Writer:       w,
+		Request:      r,
+		UserRole:     models.ADMIN,
+		ResponseType: constants.ImagePng,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			id := chi.URLParam(r, "id")
+
+			err := r.ParseMultipartForm(constants.MultipartFormMaxSize)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+			defer file.Close()
+
+			fileBytes, err := io.ReadAll(file)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			// Unsaved steps can be previewed before they are stored on the settings
+			steps := make([]models.ImagePreprocessingStep, 0)
+			stepsJson := r.FormValue("preprocessingSteps")
+			if len(stepsJson) > 0 {
+				err = json.Unmarshal([]byte(stepsJson), &steps)
+				if err != nil {
+					return http.StatusBadRequest, err
+				}
+
+				errors := make(map[string]string)
+				commands.ValidatePreprocessingSteps(steps, errors)
+				if len(errors) > 0 {
+					w.Header().Set("Content-Type", constants.ApplicationJson)
+					structs.WriteValidatorErrorResponse(w, structs.ValidatorError{Errors: errors}, http.StatusBadRequest)
+					return 0, nil
+				}
+			}
+
+			receiptProcessingSettingsRepository := repositories.NewReceiptProcessingSettings(nil)
+			settings, err := receiptProcessingSettingsRepository.GetReceiptProcessingSettingsById(id)
+			if err != nil {
+				return http.StatusNotFound, err
+			}
+
+			fileRepository := repositories.NewFileRepository(nil)
+			imageBytes, err := fileRepository.GetBytesFromImageBytes(fileBytes)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
+			ocrService := services.NewOcrService(nil, settings)
+			previewBytes, err := ocrService.PreviewPreprocessing(imageBytes, steps)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(previewBytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/go-chi/chi/v5"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -655,3 +657,54 @@ func TestShouldNotCheckReceiptProcessingSettingsConnectivityWithBadRequest(t *te
 		}
 	}
 }
+
+func TestShouldNotAllowUserToPreviewPreprocessing(t *testing.T) {
+	defer tearDownReceiptProcessingSettings()
+	reader := strings.NewReader("")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api", reader)
+
+	newContext := context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{CustomClaims: &structs.Claims{UserId: 1, UserRole: models.USER}})
+	r = r.WithContext(newContext)
+
+	PreviewReceiptProcessingSettingsPreprocessing(w, r)
+
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestShouldRejectInvalidPreprocessingStepsInPreview(t *testing.T) {
+	defer tearDownReceiptProcessingSettings()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "receipt.jpg")
+	part.Write([]byte("image"))
+	writer.WriteField("preprocessingSteps", `[{"type":"SCALE"},{"type":"NOT_A_STEP"}]`)
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	chiContext := chi.NewRouteContext()
+	chiContext.URLParams.Add("id", "1")
+	routeContext := context.WithValue(r.Context(), chi.RouteCtxKey, chiContext)
+	r = r.WithContext(routeContext)
+
+	newContext := context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{CustomClaims: &structs.Claims{UserId: 1, UserRole: models.ADMIN}})
+	r = r.WithContext(newContext)
+
+	PreviewReceiptProcessingSettingsPreprocessing(w, r)
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusBadRequest)
+	}
+
+	errors := make(map[string]string)
+	json.Unmarshal(w.Body.Bytes(), &errors)
+	if len(errors["preprocessingSteps.0"]) == 0 || len(errors["preprocessingSteps.1"]) == 0 {
+		utils.PrintTestError(t, errors, "errors for both steps")
+	}
+}
@@ -51,6 +51,7 @@ func GetPagedReceiptsForGroup(w http.ResponseWriter, r *http.Request) {
 				groupId,
 				pagedRequest,
 				associations,
+				token.ApiKeyGroupIds,
 			)
 			if err != nil {
 				return http.StatusInternalServerError, err
@@ -170,6 +171,12 @@ func CreateReceipt(w http.ResponseWriter, r *http.Request) {
 		GroupRole:    models.EDITOR,
 		ResponseType: constants.ApplicationJson,
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			receiptService := services.NewReceiptService(nil)
+			err := receiptService.ValidateGroupScope(command)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
 			receiptRepository := repositories.NewReceiptRepository(nil)
 			createdReceipt, err := receiptRepository.CreateReceipt(command, token.UserId, true)
 			if err != nil {
@@ -327,6 +334,12 @@ func UpdateReceipt(w http.ResponseWriter, r *http.Request) {
 				return 0, nil
 			}
 
+			receiptService := services.NewReceiptService(nil)
+			err = receiptService.ValidateGroupScope(command)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+
 			updatedReceipt, err := receiptRepository.UpdateReceipt(receiptId, command, token.UserId)
 			if err != nil {
 				return http.StatusInternalServerError, err
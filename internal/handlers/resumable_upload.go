@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"receipt-wrangler/api/internal/wranglerasynq"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+func GetResumableUploadOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", constants.TusVersion)
+	w.Header().Set("Tus-Version", constants.TusVersion)
+	w.Header().Set("Tus-Extension", constants.TusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(constants.ResumableUploadMaxSize, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func CreateResumableUpload(w http.ResponseWriter, r *http.Request) {
+	errMessage := "Error creating upload."
+	w.Header().Set("Tus-Resumable", constants.TusVersion)
+	if !hasSupportedTusVersion(w, r) {
+		return
+	}
+
+	command := commands.CreateResumableUploadCommand{}
+	err := command.LoadDataFromRequest(w, r)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+		utils.WriteCustomErrorResponse(w, errMessage, http.StatusBadRequest)
+		return
+	}
+
+	vErr := command.Validate()
+	if len(vErr.Errors) > 0 {
+		status := http.StatusBadRequest
+		if command.Length > constants.ResumableUploadMaxSize {
+			status = http.StatusRequestEntityTooLarge
+		}
+		structs.WriteValidatorErrorResponse(w, vErr, status)
+		return
+	}
+
+	handler := structs.Handler{
+		ErrorMessage: errMessage,
+		Writer:       w,
+		Request:      r,
+		GroupRole:    models.EDITOR,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			token := structs.GetClaims(r)
+			resumableUploadService := services.NewResumableUploadService(nil)
+
+			upload, err := resumableUploadService.CreateResumableUpload(command, token.UserId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+upload.UploadId)
+			w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusCreated)
+
+			return 0, nil
+		},
+	}
+	setResumableUploadTarget(&handler, command.ReceiptId, command.GroupId)
+
+	HandleRequest(handler)
+}
+
+func GetResumableUploadOffset(w http.ResponseWriter, r *http.Request) {
+	errMessage := "Error retrieving upload."
+	w.Header().Set("Tus-Resumable", constants.TusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+	if !hasSupportedTusVersion(w, r) {
+		return
+	}
+
+	upload, status, err := getResumableUploadForRequest(r)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+		utils.WriteCustomErrorResponse(w, errMessage, status)
+		return
+	}
+
+	handler := structs.Handler{
+		ErrorMessage: errMessage,
+		Writer:       w,
+		Request:      r,
+		GroupRole:    models.EDITOR,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			w.Header().Set("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+			w.Header().Set("Upload-Length", strconv.FormatInt(upload.UploadLength, 10))
+			w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+			if len(upload.Metadata) > 0 {
+				w.Header().Set("Upload-Metadata", upload.Metadata)
+			}
+			w.WriteHeader(http.StatusOK)
+
+			return 0, nil
+		},
+	}
+	setResumableUploadTarget(&handler, upload.ReceiptId, upload.GroupId)
+
+	HandleRequest(handler)
+}
+
+func PatchResumableUpload(w http.ResponseWriter, r *http.Request) {
+	errMessage := "Error uploading chunk."
+	w.Header().Set("Tus-Resumable", constants.TusVersion)
+	if !hasSupportedTusVersion(w, r) {
+		return
+	}
+
+	upload, status, err := getResumableUploadForRequest(r)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+		utils.WriteCustomErrorResponse(w, errMessage, status)
+		return
+	}
+
+	handler := structs.Handler{
+		ErrorMessage: errMessage,
+		Writer:       w,
+		Request:      r,
+		GroupRole:    models.EDITOR,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			if r.Header.Get("Content-Type") != constants.TusOffsetOctetStream {
+				return http.StatusUnsupportedMediaType, errors.New("content type must be " + constants.TusOffsetOctetStream)
+			}
+
+			offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+			if err != nil {
+				return http.StatusBadRequest, errors.New("invalid Upload-Offset header")
+			}
+
+			resumableUploadService := services.NewResumableUploadService(nil)
+			upload, err = resumableUploadService.WriteResumableUploadChunk(upload, offset, r.Body)
+			if errors.Is(err, services.ErrResumableUploadOffsetMismatch) {
+				return http.StatusConflict, err
+			}
+			if errors.Is(err, services.ErrResumableUploadTooLarge) {
+				return http.StatusRequestEntityTooLarge, err
+			}
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			if upload.IsComplete() {
+				status, err := completeResumableUpload(r, upload)
+				if err != nil {
+					return status, err
+				}
+			}
+
+			w.Header().Set("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+			w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusNoContent)
+
+			return 0, nil
+		},
+	}
+	setResumableUploadTarget(&handler, upload.ReceiptId, upload.GroupId)
+
+	HandleRequest(handler)
+}
+
+func DeleteResumableUpload(w http.ResponseWriter, r *http.Request) {
+	errMessage := "Error deleting upload."
+	w.Header().Set("Tus-Resumable", constants.TusVersion)
+	if !hasSupportedTusVersion(w, r) {
+		return
+	}
+
+	upload, status, err := getResumableUploadForRequest(r)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+		utils.WriteCustomErrorResponse(w, errMessage, status)
+		return
+	}
+
+	handler := structs.Handler{
+		ErrorMessage: errMessage,
+		Writer:       w,
+		Request:      r,
+		GroupRole:    models.EDITOR,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			resumableUploadService := services.NewResumableUploadService(nil)
+			err := resumableUploadService.DeleteResumableUpload(upload)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return 0, nil
+		},
+	}
+	setResumableUploadTarget(&handler, upload.ReceiptId, upload.GroupId)
+
+	HandleRequest(handler)
+}
+
+// completeResumableUpload hands a fully received file to the same code multipart uploads go through.
+// Files that can't be processed are removed. Other failures leave the upload incomplete, so a PATCH without a body
+// at the final offset retries the hand-off.
+func completeResumableUpload(r *http.Request, upload models.ResumableUpload) (int, error) {
+	resumableUploadService := services.NewResumableUploadService(nil)
+	fileRepository := repositories.NewFileRepository(nil)
+
+	fileBytes, err := resumableUploadService.ReadResumableUpload(upload)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	_, err = fileRepository.ValidateFileType(fileBytes)
+	if err != nil {
+		resumableUploadService.DeleteResumableUpload(upload)
+		return http.StatusBadRequest, err
+	}
+
+	_, err = resumableUploadService.FinishResumableUpload(upload, func(tx *gorm.DB) error {
+		if upload.ReceiptId != nil {
+			_, err := repositories.NewReceiptImageRepository(tx).CreateReceiptImage(models.FileData{
+				Name:      upload.FileName,
+				ReceiptId: *upload.ReceiptId,
+			}, fileBytes)
+			return err
+		}
+
+		tempPath, err := fileRepository.WriteTempFile(fileBytes)
+		if err != nil {
+			return err
+		}
+
+		payload := wranglerasynq.QuickScanTaskPayload{
+			Token:            structs.GetClaims(r),
+			PaidByUserId:     *upload.PaidByUserId,
+			GroupId:          *upload.GroupId,
+			Status:           upload.Status,
+			TempPath:         tempPath,
+			OriginalFileName: upload.FileName,
+		}
+
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			os.Remove(tempPath)
+			return err
+		}
+
+		// Enqueued last, so the upload is only marked completed once the scan is queued
+		_, err = wranglerasynq.EnqueueTask(asynq.NewTask(wranglerasynq.QuickScan, payloadBytes), models.QuickScanQueue)
+		if err != nil {
+			os.Remove(tempPath)
+			return err
+		}
+
+		return nil
+	})
+	if errors.Is(err, services.ErrResumableUploadCompleted) {
+		return http.StatusConflict, err
+	}
+	if err != nil {
+		if upload.ReceiptId != nil {
+			// The rollback undid the blob's reference, the file written for it is removed here
+			repositories.NewFileBlobRepository(nil).DeleteBlobIfUnreferenced(utils.Sha256Hash(fileBytes))
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	return 0, nil
+}
+
+func getResumableUploadForRequest(r *http.Request) (models.ResumableUpload, int, error) {
+	token := structs.GetClaims(r)
+	resumableUploadService := services.NewResumableUploadService(nil)
+
+	upload, err := resumableUploadService.GetResumableUpload(chi.URLParam(r, "uploadId"), token.UserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ResumableUpload{}, http.StatusNotFound, err
+	}
+	if err != nil {
+		return models.ResumableUpload{}, http.StatusInternalServerError, err
+	}
+
+	if upload.ExpiresAt.Before(time.Now()) {
+		return models.ResumableUpload{}, http.StatusGone, errors.New("upload has expired")
+	}
+
+	return upload, 0, nil
+}
+
+// Uploads are checked against the receipt they are attached to, or the group they are quick scanned into
+func setResumableUploadTarget(handler *structs.Handler, receiptId *uint, groupId *uint) {
+	if receiptId != nil {
+		handler.ReceiptId = utils.UintToString(*receiptId)
+		return
+	}
+
+	if groupId != nil {
+		handler.GroupIds = []string{utils.UintToString(*groupId)}
+	}
+}
+
+func hasSupportedTusVersion(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Tus-Resumable") == constants.TusVersion {
+		return true
+	}
+
+	w.Header().Set("Tus-Version", constants.TusVersion)
+	utils.WriteCustomErrorResponse(w, "Unsupported tus version.", http.StatusPreconditionFailed)
+	return false
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setUpResumableUploadTest(t *testing.T) {
+	t.Setenv("BASE_PATH", t.TempDir())
+	repositories.CreateTestGroupWithUsers()
+	repositories.GetDB().Model(&models.GroupMember{}).
+		Where("user_id = ? AND group_id = ?", 1, 1).
+		Update("group_role", models.EDITOR)
+}
+
+func buildResumableUploadRequest(method string, uploadId string, userId uint, body io.Reader) *http.Request {
+	r := httptest.NewRequest(method, "/api/upload/"+uploadId, body)
+	r.Header.Set("Tus-Resumable", constants.TusVersion)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("uploadId", uploadId)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	return createJWTContext(r, userId, models.USER)
+}
+
+func createTestResumableUploadRequest(t *testing.T, groupId string) string {
+	encode := func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	r := buildResumableUploadRequest("POST", "", 1, nil)
+	r.Header.Set("Upload-Length", "10")
+	r.Header.Set("Upload-Metadata", "filename "+encode("scan.pdf")+",groupId "+encode(groupId)+",paidByUserId "+encode("1")+",status "+encode("OPEN"))
+
+	w := httptest.NewRecorder()
+	CreateResumableUpload(w, r)
+
+	if w.Result().StatusCode != http.StatusCreated {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusCreated)
+		return ""
+	}
+
+	location := w.Result().Header.Get("Location")
+	return location[strings.LastIndex(location, "/")+1:]
+}
+
+func TestShouldRejectResumableUploadWithoutTusVersion(t *testing.T) {
+	r := buildResumableUploadRequest("POST", "", 1, nil)
+	r.Header.Del("Tus-Resumable")
+
+	w := httptest.NewRecorder()
+	CreateResumableUpload(w, r)
+
+	if w.Result().StatusCode != http.StatusPreconditionFailed {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusPreconditionFailed)
+	}
+
+	if w.Result().Header.Get("Tus-Version") != constants.TusVersion {
+		utils.PrintTestError(t, w.Result().Header.Get("Tus-Version"), constants.TusVersion)
+	}
+}
+
+func TestShouldNotCreateResumableUploadInOtherGroup(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpResumableUploadTest(t)
+
+	r := buildResumableUploadRequest("POST", "", 4, nil)
+	r.Header.Set("Upload-Length", "10")
+	r.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte("scan.pdf"))+
+		",groupId "+base64.StdEncoding.EncodeToString([]byte("1"))+
+		",paidByUserId "+base64.StdEncoding.EncodeToString([]byte("4"))+
+		",status "+base64.StdEncoding.EncodeToString([]byte("OPEN")))
+
+	w := httptest.NewRecorder()
+	CreateResumableUpload(w, r)
+
+	if w.Result().StatusCode != http.StatusForbidden {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestShouldResumeUploadFromReportedOffset(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpResumableUploadTest(t)
+
+	uploadId := createTestResumableUploadRequest(t, "1")
+	if len(uploadId) == 0 {
+		return
+	}
+
+	r := buildResumableUploadRequest("PATCH", uploadId, 1, strings.NewReader("hello"))
+	r.Header.Set("Content-Type", constants.TusOffsetOctetStream)
+	r.Header.Set("Upload-Offset", "0")
+	w := httptest.NewRecorder()
+	PatchResumableUpload(w, r)
+
+	if w.Result().StatusCode != http.StatusNoContent || w.Result().Header.Get("Upload-Offset") != "5" {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusNoContent)
+	}
+
+	w = httptest.NewRecorder()
+	GetResumableUploadOffset(w, buildResumableUploadRequest("HEAD", uploadId, 1, nil))
+
+	if w.Result().StatusCode != http.StatusOK {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusOK)
+	}
+
+	if w.Result().Header.Get("Upload-Offset") != "5" || w.Result().Header.Get("Upload-Length") != "10" {
+		utils.PrintTestError(t, w.Result().Header, "offset 5 of 10")
+	}
+
+	r = buildResumableUploadRequest("PATCH", uploadId, 1, strings.NewReader("world"))
+	r.Header.Set("Content-Type", constants.TusOffsetOctetStream)
+	r.Header.Set("Upload-Offset", "0")
+	w = httptest.NewRecorder()
+	PatchResumableUpload(w, r)
+
+	if w.Result().StatusCode != http.StatusConflict {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusConflict)
+	}
+}
+
+func TestShouldNotFindResumableUploadOfOtherUser(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpResumableUploadTest(t)
+
+	uploadId := createTestResumableUploadRequest(t, "1")
+	if len(uploadId) == 0 {
+		return
+	}
+
+	w := httptest.NewRecorder()
+	GetResumableUploadOffset(w, buildResumableUploadRequest("HEAD", uploadId, 4, nil))
+
+	if w.Result().StatusCode != http.StatusNotFound {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusNotFound)
+	}
+
+	w = httptest.NewRecorder()
+	DeleteResumableUpload(w, buildResumableUploadRequest("DELETE", uploadId, 1, nil))
+
+	if w.Result().StatusCode != http.StatusNoContent {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusNoContent)
+	}
+}
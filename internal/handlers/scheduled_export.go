@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"receipt-wrangler/api/internal/wranglerasynq"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hibiken/asynq"
+)
+
+func GetScheduledExportsForGroup(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error getting scheduled exports",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			scheduledExportRepository := repositories.NewScheduledExportRepository(nil)
+			scheduledExports, err := scheduledExportRepository.GetScheduledExportsByGroupId(groupId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(scheduledExports)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func CreateScheduledExport(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error creating scheduled export",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			command := commands.UpsertScheduledExportCommand{}
+			err := command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErrs := command.Validate()
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			uintGroupId, err := utils.StringToUint(groupId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			token := structs.GetClaims(r)
+			err = validateScheduledExportDestinationRole(command, token)
+			if err != nil {
+				return http.StatusForbidden, err
+			}
+
+			scheduledExportRepository := repositories.NewScheduledExportRepository(nil)
+			scheduledExport, err := scheduledExportRepository.CreateScheduledExport(uintGroupId, token.UserId, command)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			err = wranglerasynq.ScheduleExport(scheduledExport)
+			if err != nil {
+				scheduledExportRepository.DeleteScheduledExport(scheduledExport.ID)
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(scheduledExport)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func UpdateScheduledExport(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+	scheduledExportId := chi.URLParam(r, "scheduledExportId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error updating scheduled export",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			command := commands.UpsertScheduledExportCommand{}
+			err := command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErrs := command.Validate()
+			if len(vErrs.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErrs, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			scheduledExport, status, err := getGroupScheduledExport(groupId, scheduledExportId)
+			if err != nil {
+				return status, err
+			}
+
+			token := structs.GetClaims(r)
+			err = validateScheduledExportDestinationRole(command, token)
+			if err != nil {
+				return http.StatusForbidden, err
+			}
+
+			updatePassword := r.URL.Query().Get("updatePassword") == "true"
+
+			scheduledExportRepository := repositories.NewScheduledExportRepository(nil)
+			scheduledExport, err = scheduledExportRepository.UpdateScheduledExport(scheduledExport, token.UserId, command, updatePassword)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			err = wranglerasynq.ScheduleExport(scheduledExport)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			bytes, err := utils.MarshalResponseData(scheduledExport)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func DeleteScheduledExport(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+	scheduledExportId := chi.URLParam(r, "scheduledExportId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error deleting scheduled export",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			scheduledExport, status, err := getGroupScheduledExport(groupId, scheduledExportId)
+			if err != nil {
+				return status, err
+			}
+
+			err = wranglerasynq.UnscheduleExport(scheduledExport.ID)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			err = repositories.NewScheduledExportRepository(nil).DeleteScheduledExport(scheduledExport.ID)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusOK)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+// RunScheduledExport runs an export now, the run is recorded like scheduled ones
+func RunScheduledExport(w http.ResponseWriter, r *http.Request) {
+	groupId := chi.URLParam(r, "groupId")
+	scheduledExportId := chi.URLParam(r, "scheduledExportId")
+
+	handler := structs.Handler{
+		ErrorMessage: "Error running scheduled export",
+		Writer:       w,
+		Request:      r,
+		ResponseType: constants.ApplicationJson,
+		GroupId:      groupId,
+		GroupRole:    models.OWNER,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			scheduledExport, status, err := getGroupScheduledExport(groupId, scheduledExportId)
+			if err != nil {
+				return status, err
+			}
+
+			payloadBytes, err := json.Marshal(wranglerasynq.ScheduledExportTaskPayload{ScheduledExportId: scheduledExport.ID})
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			task := asynq.NewTask(wranglerasynq.ScheduledExport, payloadBytes)
+			_, err = wranglerasynq.EnqueueTask(task, models.QuickScanQueue)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
+
+func getGroupScheduledExport(groupId string, scheduledExportId string) (models.ScheduledExport, int, error) {
+	scheduledExportRepository := repositories.NewScheduledExportRepository(nil)
+	scheduledExport, err := scheduledExportRepository.GetScheduledExportById(scheduledExportId)
+	if err != nil {
+		return models.ScheduledExport{}, http.StatusNotFound, err
+	}
+
+	if utils.UintToString(scheduledExport.GroupId) != groupId {
+		return models.ScheduledExport{}, http.StatusNotFound, errors.New("scheduled export belongs to another group")
+	}
+
+	return scheduledExport, 0, nil
+}
+
+// validateScheduledExportDestinationRole only lets admins export to WebDAV, uploads to arbitrary urls would reach internal services
+func validateScheduledExportDestinationRole(command commands.UpsertScheduledExportCommand, token *structs.Claims) error {
+	if command.DestinationType == models.WEBDAV && token.UserRole != models.ADMIN {
+		return errors.New("only admins can export to webdav")
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setUpScheduledExportHandlerTest(t *testing.T) models.ScheduledExport {
+	repositories.CreateTestGroupWithUsers()
+	repositories.GetDB().Model(&models.GroupMember{}).Where("user_id IN ?", []uint{1, 4}).Update("group_role", models.OWNER)
+
+	command := commands.UpsertScheduledExportCommand{
+		Name:            "Monthly",
+		Format:          models.EXPORT_CSV,
+		Schedule:        "@monthly",
+		DestinationType: models.WEBDAV,
+		Destination:     "https://cloud.example.com/dav",
+		Enabled:         true,
+	}
+	command.Filter.SetDefaults()
+
+	scheduledExport, err := repositories.NewScheduledExportRepository(nil).CreateScheduledExport(1, 1, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return scheduledExport
+}
+
+func TestShouldGetScheduledExportsForGroup(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpScheduledExportHandlerTest(t)
+
+	r := httptest.NewRequest("GET", "/api/group/1/scheduledExports", nil)
+	r = createJWTContext(r, 1, models.USER)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+	chi.RouteContext(r.Context()).URLParams.Add("groupId", "1")
+
+	w := httptest.NewRecorder()
+	GetScheduledExportsForGroup(w, r)
+
+	var scheduledExports []models.ScheduledExport
+	json.Unmarshal(w.Body.Bytes(), &scheduledExports)
+	if w.Result().StatusCode != http.StatusOK || len(scheduledExports) != 1 || scheduledExports[0].Name != "Monthly" {
+		utils.PrintTestError(t, w.Body.String(), "the monthly export")
+	}
+}
+
+func TestShouldNotUpdateScheduledExportOfOtherGroup(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	scheduledExport := setUpScheduledExportHandlerTest(t)
+
+	body := `{"name": "Taken", "format": "CSV", "schedule": "@daily", "destinationType": "WEBDAV", "destination": "https://example.com", "enabled": true}`
+	r := httptest.NewRequest("PUT", "/api/group/2/scheduledExports/1", strings.NewReader(body))
+	r = createJWTContext(r, 4, models.USER)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+	chi.RouteContext(r.Context()).URLParams.Add("groupId", "2")
+	chi.RouteContext(r.Context()).URLParams.Add("scheduledExportId", utils.UintToString(scheduledExport.ID))
+
+	w := httptest.NewRecorder()
+	UpdateScheduledExport(w, r)
+
+	if w.Result().StatusCode != http.StatusNotFound {
+		utils.PrintTestError(t, w.Result().StatusCode, http.StatusNotFound)
+	}
+
+	unchangedScheduledExport, _ := repositories.NewScheduledExportRepository(nil).GetScheduledExportById(utils.UintToString(scheduledExport.ID))
+	if unchangedScheduledExport.Name != "Monthly" {
+		utils.PrintTestError(t, unchangedScheduledExport.Name, "Monthly")
+	}
+}
+
+func TestShouldOnlyLetAdminsCreateWebDavScheduledExports(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpScheduledExportHandlerTest(t)
+
+	body := `{"name": "Internal", "format": "CSV", "schedule": "@daily", "destinationType": "WEBDAV", "destination": "http://169.254.169.254/latest", "enabled": true}`
+	for _, userRole := range []models.UserRole{models.USER, models.ADMIN} {
+		r := httptest.NewRequest("POST", "/api/group/1/scheduledExports", strings.NewReader(body))
+		r = createJWTContext(r, 1, userRole)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+		chi.RouteContext(r.Context()).URLParams.Add("groupId", "1")
+
+		w := httptest.NewRecorder()
+		CreateScheduledExport(w, r)
+
+		// Admins get past the role check, scheduling itself needs the queue
+		forbidden := w.Result().StatusCode == http.StatusForbidden
+		if forbidden != (userRole == models.USER) {
+			utils.PrintTestError(t, w.Result().StatusCode, userRole)
+		}
+	}
+}
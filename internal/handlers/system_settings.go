@@ -7,6 +7,7 @@ import (
 	config "receipt-wrangler/api/internal/env"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
 	"receipt-wrangler/api/internal/wranglerasynq"
@@ -72,6 +73,17 @@ func UpdateSystemSettings(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(
+				r,
+				models.AUDIT_SYSTEM_SETTINGS_UPDATED,
+				models.AUDIT_ENTITY_SYSTEM_SETTINGS,
+				utils.UintToString(previousSystemSettings.ID),
+			)
+			auditLogCommand.Before = previousSystemSettings
+			auditLogCommand.After = updatedSystemSettings
+			auditLogService.RecordAuditLog(auditLogCommand)
+
 			if previousSystemSettings.EmailPollingInterval != updatedSystemSettings.EmailPollingInterval &&
 				updatedSystemSettings.EmailPollingInterval > 0 && config.GetDeployEnv() != "test" {
 				err = wranglerasynq.StartEmailPolling()
@@ -200,6 +200,21 @@ func TestShouldValidateUpsertSystemSettingsCommand(t *testing.T) {
 			},
 			expect: http.StatusBadRequest,
 		},
+		"invalid pdf render dpi": {
+			input: commands.UpsertSystemSettingsCommand{
+				EmailPollingInterval:                1,
+				ReceiptProcessingSettingsId:         &id,
+				FallbackReceiptProcessingSettingsId: &id2,
+				NumWorkers:                          1,
+				CurrencyThousandthsSeparator:        models.COMMA,
+				CurrencyDecimalSeparator:            models.DOT,
+				CurrencySymbolPosition:              models.START,
+				TaskConcurrency:                     10,
+				TaskQueueConfigurations:             defaultAsynqConfigCommands,
+				PdfRenderDpi:                        10,
+			},
+			expect: http.StatusBadRequest,
+		},
 		"valid command": {
 			input: commands.UpsertSystemSettingsCommand{
 				EmailPollingInterval:                1,
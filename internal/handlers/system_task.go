@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"github.com/go-chi/chi/v5"
+	"github.com/hibiken/asynq"
 	"net/http"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
@@ -217,3 +218,48 @@ func RerunActivity(w http.ResponseWriter, r *http.Request) {
 
 	HandleRequest(handler)
 }
+
+func RunStorageIntegrityCheck(w http.ResponseWriter, r *http.Request) {
+	handler := structs.Handler{
+		ErrorMessage: "Error starting storage integrity check",
+		Writer:       w,
+		Request:      r,
+		UserRole:     models.ADMIN,
+		ResponseType: constants.ApplicationJson,
+		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			command := commands.RunStorageIntegrityCheckCommand{}
+			err := command.LoadDataFromRequest(w, r)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			vErr := command.Validate()
+			if len(vErr.Errors) > 0 {
+				structs.WriteValidatorErrorResponse(w, vErr, http.StatusBadRequest)
+				return 0, nil
+			}
+
+			token := structs.GetClaims(r)
+			payloadBytes, err := json.Marshal(wranglerasynq.StorageIntegrityCheckTaskPayload{
+				Command:     command,
+				RanByUserId: &token.UserId,
+			})
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			// Every stored file is read, so the check runs in the background and records a system task
+			task := asynq.NewTask(wranglerasynq.StorageIntegrityCheck, payloadBytes)
+			_, err = wranglerasynq.EnqueueTask(task, models.SystemCleanUpQueue)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+
+			return 0, nil
+		},
+	}
+
+	HandleRequest(handler)
+}
@@ -20,8 +20,13 @@ func GetAllTags(w http.ResponseWriter, r *http.Request) {
 		Request:      r,
 		ResponseType: constants.ApplicationJson,
 		HandlerFunction: func(w http.ResponseWriter, r *http.Request) (int, error) {
+			groupIds, status, err := getScopeGroupIds(r, false)
+			if err != nil {
+				return status, err
+			}
+
 			tagsRepository := repositories.NewTagsRepository(nil)
-			tags, err := tagsRepository.GetAllTags("*")
+			tags, err := tagsRepository.GetAllTags("*", groupIds)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
@@ -59,6 +64,11 @@ func CreateTag(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			status, err := validateScopeGroupId(r, tag.GroupId)
+			if err != nil {
+				return status, err
+			}
+
 			tagRepository := repositories.NewTagsRepository(nil)
 			createdTag, err := tagRepository.CreateTag(tag)
 			if err != nil {
@@ -94,8 +104,13 @@ func GetPagedTags(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			groupIds, status, err := getScopeGroupIds(r, true)
+			if err != nil {
+				return status, err
+			}
+
 			tagsRepository := repositories.NewTagsRepository(nil)
-			tags, err := tagsRepository.GetAllPagedTags(pagedRequestCommand)
+			tags, err := tagsRepository.GetAllPagedTags(pagedRequestCommand, groupIds)
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
@@ -101,13 +102,43 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 			db := repositories.GetDB()
 			id := chi.URLParam(r, "id")
 			bodyData := r.Context().Value("user").(commands.SignUpCommand)
+			userRepository := repositories.NewUserRepository(nil)
+
+			uintId, err := utils.StringToUint(id)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			previousUser, err := userRepository.GetUserById(uintId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
 
 			//TODO: Move to repo
-			err := db.Table("users").Select("username", "display_name", "user_role").Where("id = ?", id).Updates(&bodyData).Error
+			err = db.Table("users").Select("username", "display_name", "user_role").Where("id = ?", id).Updates(&bodyData).Error
 			if err != nil {
 				return http.StatusInternalServerError, err
 			}
 
+			updatedUser, err := userRepository.GetUserById(uintId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			if previousUser.UserRole != updatedUser.UserRole {
+				auditLogService := services.NewAuditLogService(nil)
+				auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_USER_ROLE_CHANGED, models.AUDIT_ENTITY_USER, id)
+				auditLogCommand.Before = previousUser
+				auditLogCommand.After = updatedUser
+				auditLogCommand.Description = fmt.Sprintf(
+					"Changed role of user '%s' from %s to %s",
+					updatedUser.Username,
+					previousUser.UserRole,
+					updatedUser.UserRole,
+				)
+				auditLogService.RecordAuditLog(auditLogCommand)
+			}
+
 			w.WriteHeader(http.StatusOK)
 			return 0, nil
 		},
@@ -159,8 +190,7 @@ func GetAmountOwedForUser(w http.ResponseWriter, r *http.Request) {
 				}
 
 				if isAllGroup {
-					groupMemberRepository := repositories.NewGroupMemberRepository(nil)
-					userGroupIds, err := groupMemberRepository.GetGroupIdsByUserId(utils.UintToString(token.UserId))
+					userGroupIds, err := getUserGroupIds(r)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
@@ -298,6 +328,11 @@ func ResetPassword(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_PASSWORD_RESET, models.AUDIT_ENTITY_USER, id)
+			auditLogCommand.Description = "Reset user password"
+			auditLogService.RecordAuditLog(auditLogCommand)
+
 			w.WriteHeader(http.StatusOK)
 			return 0, nil
 		},
@@ -342,6 +377,11 @@ func ConvertDummyUserToNormalUser(w http.ResponseWriter, r *http.Request) {
 				return http.StatusInternalServerError, err
 			}
 
+			auditLogService := services.NewAuditLogService(nil)
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_PASSWORD_RESET, models.AUDIT_ENTITY_USER, id)
+			auditLogCommand.Description = fmt.Sprintf("Set password while converting dummy user '%s' to a normal user", dbUser.Username)
+			auditLogService.RecordAuditLog(auditLogCommand)
+
 			w.WriteHeader(200)
 			return 0, nil
 		},
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"receipt-wrangler/api/internal/models"
+	"slices"
+	"strings"
+)
+
+type apiKeyResourceFamily struct {
+	ReadScope  models.ApiKeyResourceScope
+	WriteScope models.ApiKeyResourceScope
+}
+
+// Maps the first path segment after /api/ to the resource family guarding it
+var apiKeyResourceFamilies = map[string]apiKeyResourceFamily{
+	"receipt":                   {models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_RECEIPTS_WRITE},
+	"receiptImage":              {models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_RECEIPTS_WRITE},
+	"upload":                    {models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_RECEIPTS_WRITE},
+	"comment":                   {models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_RECEIPTS_WRITE},
+	"search":                    {models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_RECEIPTS_WRITE},
+	"category":                  {models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_RECEIPTS_WRITE},
+	"tag":                       {models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_RECEIPTS_WRITE},
+	"customField":               {models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_RECEIPTS_WRITE},
+	"group":                     {models.API_KEY_RESOURCE_GROUPS_READ, models.API_KEY_RESOURCE_GROUPS_WRITE},
+	"dashboard":                 {models.API_KEY_RESOURCE_GROUPS_READ, models.API_KEY_RESOURCE_GROUPS_WRITE},
+	"groupInvite":               {models.API_KEY_RESOURCE_GROUPS_READ, models.API_KEY_RESOURCE_GROUPS_WRITE},
+	"export":                    {models.API_KEY_RESOURCE_EXPORT, models.API_KEY_RESOURCE_EXPORT},
+	"import":                    {models.API_KEY_RESOURCE_IMPORT, models.API_KEY_RESOURCE_IMPORT},
+	"backup":                    {models.API_KEY_RESOURCE_EXPORT, models.API_KEY_RESOURCE_IMPORT},
+	"user":                      {models.API_KEY_RESOURCE_ADMIN, models.API_KEY_RESOURCE_ADMIN},
+	"systemSettings":            {models.API_KEY_RESOURCE_ADMIN, models.API_KEY_RESOURCE_ADMIN},
+	"systemTask":                {models.API_KEY_RESOURCE_ADMIN, models.API_KEY_RESOURCE_ADMIN},
+	"systemEmail":               {models.API_KEY_RESOURCE_ADMIN, models.API_KEY_RESOURCE_ADMIN},
+	"receiptProcessingSettings": {models.API_KEY_RESOURCE_ADMIN, models.API_KEY_RESOURCE_ADMIN},
+	"prompt":                    {models.API_KEY_RESOURCE_ADMIN, models.API_KEY_RESOURCE_ADMIN},
+	"auditLog":                  {models.API_KEY_RESOURCE_ADMIN, models.API_KEY_RESOURCE_ADMIN},
+}
+
+func validateApiKeyRequestScope(apiKey models.ApiKey, r *http.Request) error {
+	isRead := isReadRequest(r)
+
+	if models.ApiKeyScope(apiKey.Scope) == models.API_KEY_SCOPE_READ && !isRead {
+		return errors.New("read only API key cannot perform write requests")
+	}
+
+	resourceScopes := apiKey.GetResourceScopes()
+	if len(resourceScopes) == 0 {
+		return nil
+	}
+
+	family, ok := apiKeyResourceFamilies[getApiPathSegment(r)]
+	if !ok {
+		return errors.New("API key resource scopes do not cover " + r.URL.Path)
+	}
+
+	if isRead && (slices.Contains(resourceScopes, family.ReadScope) || slices.Contains(resourceScopes, family.WriteScope)) {
+		return nil
+	}
+
+	if !isRead && slices.Contains(resourceScopes, family.WriteScope) {
+		return nil
+	}
+
+	return errors.New("API key resource scopes do not allow " + r.Method + " " + r.URL.Path)
+}
+
+func getApiPathSegment(r *http.Request) string {
+	path := strings.TrimPrefix(r.URL.Path, "/api/")
+	return strings.Split(path, "/")[0]
+}
+
+// Several query endpoints use POST to carry their filters, those are treated as reads
+func isReadRequest(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return true
+	}
+
+	if r.Method != http.MethodPost {
+		return false
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	lastSegment := segments[len(segments)-1]
+	if strings.HasPrefix(lastSegment, "getPaged") ||
+		strings.HasPrefix(lastSegment, "paged") ||
+		lastSegment == "getSystemEmails" {
+		return true
+	}
+
+	// POST /api/receipt/group/{groupId} returns paged receipts
+	return len(segments) == 4 && segments[1] == "receipt" && segments[2] == "group"
+}
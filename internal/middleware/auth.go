@@ -4,16 +4,20 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
 	"receipt-wrangler/api/internal/logging"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/services"
 	"receipt-wrangler/api/internal/utils"
 	"strings"
+	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 )
 
+const apiKeyUsageInterval = time.Minute
+
 func UnifiedAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		const unauthorized = "Unauthorized"
@@ -29,6 +33,13 @@ func UnifiedAuthMiddleware(next http.Handler) http.Handler {
 				return
 			}
 
+			err = validateApiKeyRequestScope(dbApiKey, r)
+			if err != nil {
+				logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+				utils.WriteCustomErrorResponse(w, unauthorized, http.StatusForbidden)
+				return
+			}
+
 			apiKeyService := services.NewApiKeyService(nil)
 			claims, err := apiKeyService.GetClaimsFromApiKey(dbApiKey)
 			if err != nil {
@@ -37,14 +48,29 @@ func UnifiedAuthMiddleware(next http.Handler) http.Handler {
 				return
 			}
 
+			r = r.Clone(context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &claims))
+			auditLogCommand := commands.NewAuditLogCommandFromRequest(r, models.AUDIT_API_KEY_USED, models.AUDIT_ENTITY_API_KEY, dbApiKey.ID)
+			auditLogCommand.Description = r.Method + " " + r.URL.Path
+
 			go func() {
 				err := apiKeyService.UpdateApiKeyLastUsedDate(dbApiKey.ID)
 				if err != nil {
 					logging.LogStd(logging.LOG_LEVEL_ERROR, "Failed to update API key last used date: "+err.Error())
 				}
+
+				// Usage is audited at most once per interval, so busy keys don't add an audit log for every request
+				auditLogService := services.NewAuditLogService(nil)
+				recentlyAudited, err := auditLogService.HasRecentAuditLog(models.AUDIT_API_KEY_USED, dbApiKey.ID, apiKeyUsageInterval)
+				if err != nil {
+					logging.LogStd(logging.LOG_LEVEL_ERROR, "Failed to check API key usage audit log: "+err.Error())
+					return
+				}
+
+				if !recentlyAudited {
+					auditLogService.RecordAuditLog(auditLogCommand)
+				}
 			}()
 
-			r = r.Clone(context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, &claims))
 			next.ServeHTTP(w, r)
 			return
 		} else if len(jwt) != 0 {
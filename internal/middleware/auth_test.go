@@ -698,6 +698,49 @@ func TestUnifiedAuthMiddleware_UpdatesApiKeyLastUsedDate_MultipleRequests(t *tes
 	}
 }
 
+func TestUnifiedAuthMiddleware_ThrottlesApiKeyUsageAuditLogs(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer teardownAuthTest()
+	setupAuthTest()
+
+	user := createTestUser()
+	dbApiKey, generatedKey, err := createTestApiKey(user.ID, "r")
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	handler := UnifiedAuthMiddleware(createFakeHandler())
+	countUsedAuditLogs := func() int64 {
+		var usedCount int64
+		repositories.GetDB().Model(&models.AuditLog{}).Where("action = ? AND entity_id = ?", models.AUDIT_API_KEY_USED, dbApiKey.ID).Count(&usedCount)
+		return usedCount
+	}
+
+	// Requests within the interval are audited once
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+		r.Header.Set("Authorization", generatedKey)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if countUsedAuditLogs() != 1 {
+		utils.PrintTestError(t, countUsedAuditLogs(), 1)
+	}
+
+	// Once the interval passed the next request is audited again
+	repositories.GetDB().Model(&models.AuditLog{}).Where("entity_id = ?", dbApiKey.ID).Update("created_at", time.Now().Add(-2*apiKeyUsageInterval))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	r.Header.Set("Authorization", generatedKey)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	time.Sleep(100 * time.Millisecond)
+
+	if countUsedAuditLogs() != 2 {
+		utils.PrintTestError(t, countUsedAuditLogs(), 2)
+	}
+}
+
 func TestUnifiedAuthMiddleware_DoesNotUpdateLastUsedDate_OnJWT(t *testing.T) {
 	t.Setenv("ENCRYPTION_KEY", "test-key")
 	defer teardownAuthTest()
@@ -991,3 +1034,91 @@ func TestGetJwt_BearerPrefixHandling(t *testing.T) {
 		utils.PrintTestError(t, jwt3, expected3)
 	}
 }
+
+func TestUnifiedAuthMiddleware_ReadOnlyApiKeyRejectsWrites(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer teardownAuthTest()
+	setupAuthTest()
+
+	user := createTestUser()
+	_, generatedKey, err := createTestApiKey(user.ID, "r")
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	tests := map[string]struct {
+		method string
+		path   string
+		expect int
+	}{
+		"get receipt":         {method: http.MethodGet, path: "/api/receipt/1", expect: http.StatusOK},
+		"paged receipt query": {method: http.MethodPost, path: "/api/receipt/group/1", expect: http.StatusOK},
+		"create receipt":      {method: http.MethodPost, path: "/api/receipt/", expect: http.StatusForbidden},
+		"delete receipt":      {method: http.MethodDelete, path: "/api/receipt/1", expect: http.StatusForbidden},
+	}
+
+	for name, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		r.Header.Set("Authorization", generatedKey)
+		w := httptest.NewRecorder()
+
+		handler := UnifiedAuthMiddleware(createFakeHandler())
+		handler.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != test.expect {
+			utils.PrintTestError(t, w.Result().StatusCode, test.expect)
+			t.Log(name)
+		}
+	}
+}
+
+func TestUnifiedAuthMiddleware_ApiKeyResourceScopes(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer teardownAuthTest()
+	setupAuthTest()
+
+	user := createTestUser()
+	dbApiKey, generatedKey, err := createTestApiKey(user.ID, "rw")
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	apiKeyRepository := repositories.NewApiKeyRepository(nil)
+	err = apiKeyRepository.UpdateApiKeyRestrictions(
+		dbApiKey.ID,
+		nil,
+		nil,
+		[]models.ApiKeyResourceScope{models.API_KEY_RESOURCE_RECEIPTS_READ, models.API_KEY_RESOURCE_EXPORT},
+	)
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	tests := map[string]struct {
+		method string
+		path   string
+		expect int
+	}{
+		"read receipts":         {method: http.MethodGet, path: "/api/receipt/1", expect: http.StatusOK},
+		"write receipts":        {method: http.MethodPut, path: "/api/receipt/1", expect: http.StatusForbidden},
+		"export":                {method: http.MethodPost, path: "/api/export/1", expect: http.StatusOK},
+		"admin endpoint":        {method: http.MethodGet, path: "/api/systemSettings", expect: http.StatusForbidden},
+		"unmapped endpoint":     {method: http.MethodGet, path: "/api/notifications", expect: http.StatusForbidden},
+		"receipt image lookup":  {method: http.MethodGet, path: "/api/receiptImage/1", expect: http.StatusOK},
+		"group read not scoped": {method: http.MethodGet, path: "/api/group/1", expect: http.StatusForbidden},
+	}
+
+	for name, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		r.Header.Set("Authorization", generatedKey)
+		w := httptest.NewRecorder()
+
+		handler := UnifiedAuthMiddleware(createFakeHandler())
+		handler.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != test.expect {
+			utils.PrintTestError(t, w.Result().StatusCode, test.expect)
+			t.Log(name)
+		}
+	}
+}
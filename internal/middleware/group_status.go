@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GroupIdResolver finds the groups a request writes to
+type GroupIdResolver func(r *http.Request) ([]string, error)
+
+// ValidateGroupIsActive rejects the request when any group it writes to is archived.
+// It is meant for mutating routes only, reads of archived groups stay allowed.
+func ValidateGroupIsActive(resolveGroupIds GroupIdResolver) (mw func(http.Handler) http.Handler) {
+	mw = func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			groupIds, err := resolveGroupIds(r)
+			if err != nil {
+				logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+				utils.WriteCustomErrorResponse(w, "Error validating group status", http.StatusInternalServerError)
+				return
+			}
+
+			groupService := services.NewGroupService(nil)
+			err = groupService.ValidateGroupsAreActive(groupIds)
+			if err != nil {
+				logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error(), r)
+				utils.WriteCustomErrorResponse(w, "Group is archived and read-only.", http.StatusForbidden)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+	return
+}
+
+func GroupIdFromUrl(r *http.Request) ([]string, error) {
+	return []string{chi.URLParam(r, "groupId")}, nil
+}
+
+func GroupIdFromReceiptUrl(r *http.Request) ([]string, error) {
+	return groupIdsFromReceiptIds([]string{chi.URLParam(r, "id")})
+}
+
+// GroupIdFromReceiptUrlAndBody covers receipt updates, which can move the receipt into the group of the body
+func GroupIdFromReceiptUrlAndBody(r *http.Request) ([]string, error) {
+	groupIds, err := GroupIdFromReceiptUrl(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyGroupIds, err := GroupIdFromBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(groupIds, bodyGroupIds...), nil
+}
+
+func GroupIdFromCommentUrl(r *http.Request) ([]string, error) {
+	var comment models.Comment
+	db := repositories.GetDB()
+
+	err := db.Model(&models.Comment{}).Where("id = ?", chi.URLParam(r, "commentId")).Select("receipt_id").Find(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return groupIdsFromReceiptIds([]string{utils.UintToString(comment.ReceiptId)})
+}
+
+func GroupIdFromReceiptImageUrl(r *http.Request) ([]string, error) {
+	var fileData models.FileData
+	db := repositories.GetDB()
+
+	err := db.Model(&models.FileData{}).Where("id = ?", chi.URLParam(r, "id")).Select("receipt_id").Find(&fileData).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return groupIdsFromReceiptIds([]string{utils.UintToString(fileData.ReceiptId)})
+}
+
+// GroupIdFromUploadMetadata reads groupId and receiptId from the Upload-Metadata header of a tus creation request
+func GroupIdFromUploadMetadata(r *http.Request) ([]string, error) {
+	// Malformed metadata is left for the handler to reject
+	metadata, err := commands.ParseTusMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		return []string{}, nil
+	}
+
+	groupIds := make([]string, 0)
+	if len(metadata["groupId"]) > 0 {
+		groupIds = append(groupIds, metadata["groupId"])
+	}
+
+	receiptIds := make([]string, 0)
+	if len(metadata["receiptId"]) > 0 {
+		receiptIds = append(receiptIds, metadata["receiptId"])
+	}
+
+	receiptGroupIds, err := groupIdsFromReceiptIds(receiptIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(groupIds, receiptGroupIds...), nil
+}
+
+func GroupIdFromResumableUploadUrl(r *http.Request) ([]string, error) {
+	var upload models.ResumableUpload
+	db := repositories.GetDB()
+
+	err := db.Model(&models.ResumableUpload{}).Where("upload_id = ?", chi.URLParam(r, "uploadId")).Select("receipt_id", "group_id").Find(&upload).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.ReceiptId != nil {
+		return groupIdsFromReceiptIds([]string{utils.UintToString(*upload.ReceiptId)})
+	}
+
+	if upload.GroupId != nil {
+		return []string{utils.UintToString(*upload.GroupId)}, nil
+	}
+
+	return []string{}, nil
+}
+
+// GroupIdFromBody reads groupId, groupIds, receiptId and receiptIds from a json or multipart body.
+// A json body is restored afterwards so the handler can still read it.
+func GroupIdFromBody(r *http.Request) ([]string, error) {
+	groupIds := make([]string, 0)
+	receiptIds := make([]string, 0)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		err := r.ParseMultipartForm(constants.MultipartFormMaxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		groupIds = append(groupIds, r.Form["groupId"]...)
+		groupIds = append(groupIds, r.Form["groupIds"]...)
+		receiptIds = append(receiptIds, r.Form["receiptId"]...)
+		receiptIds = append(receiptIds, r.Form["receiptIds"]...)
+	} else {
+		bodyData, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(bodyData))
+
+		body := struct {
+			GroupId    *uint  `json:"groupId"`
+			GroupIds   []uint `json:"groupIds"`
+			ReceiptId  *uint  `json:"receiptId"`
+			ReceiptIds []uint `json:"receiptIds"`
+		}{}
+
+		// Malformed bodies are left for the handler to reject
+		if json.Unmarshal(bodyData, &body) == nil {
+			if body.GroupId != nil {
+				groupIds = append(groupIds, utils.UintToString(*body.GroupId))
+			}
+			if body.ReceiptId != nil {
+				receiptIds = append(receiptIds, utils.UintToString(*body.ReceiptId))
+			}
+			for _, groupId := range body.GroupIds {
+				groupIds = append(groupIds, utils.UintToString(groupId))
+			}
+			for _, receiptId := range body.ReceiptIds {
+				receiptIds = append(receiptIds, utils.UintToString(receiptId))
+			}
+		}
+	}
+
+	receiptGroupIds, err := groupIdsFromReceiptIds(receiptIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(groupIds, receiptGroupIds...), nil
+}
+
+// GroupIdFromImportOptions reads groupId from the json options field of a multipart import request
+func GroupIdFromImportOptions(r *http.Request) ([]string, error) {
+	err := r.ParseMultipartForm(constants.MultipartFormMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	options := struct {
+		GroupId *uint `json:"groupId"`
+	}{}
+
+	// Malformed options are left for the handler to reject
+	if json.Unmarshal([]byte(r.FormValue("options")), &options) != nil || options.GroupId == nil {
+		return []string{}, nil
+	}
+
+	return []string{utils.UintToString(*options.GroupId)}, nil
+}
+
+func groupIdsFromReceiptIds(receiptIds []string) ([]string, error) {
+	receiptRepository := repositories.NewReceiptRepository(nil)
+	groupIds, err := receiptRepository.GetGroupIdsByReceiptIds(receiptIds)
+	if err != nil {
+		return nil, err
+	}
+
+	stringGroupIds := make([]string, len(groupIds))
+	for i, groupId := range groupIds {
+		stringGroupIds[i] = utils.UintToString(groupId)
+	}
+
+	return stringGroupIds, nil
+}
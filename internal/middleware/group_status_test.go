@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupGroupStatusTest(status models.GroupStatus) {
+	repositories.CreateTestUser()
+	db := repositories.GetDB()
+	db.Create(&models.Group{Name: "Test", Status: status})
+	db.Create(&models.Receipt{Name: "Test", GroupId: 1, PaidByUserID: 1, Status: models.OPEN})
+}
+
+func teardownGroupStatusTest() {
+	repositories.TruncateTestDb()
+}
+
+func serveGroupStatusRequest(resolver GroupIdResolver, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler := ValidateGroupIsActive(resolver)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestValidateGroupIsActiveShouldAllowActiveGroup(t *testing.T) {
+	defer teardownGroupStatusTest()
+	setupGroupStatusTest(models.GROUP_ACTIVE)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/receipt", strings.NewReader(`{"groupId": 1}`))
+	w := serveGroupStatusRequest(GroupIdFromBody, r)
+
+	if w.Result().StatusCode != 200 {
+		utils.PrintTestError(t, w.Result().StatusCode, 200)
+	}
+}
+
+func TestValidateGroupIsActiveShouldRejectArchivedGroupFromBody(t *testing.T) {
+	defer teardownGroupStatusTest()
+	setupGroupStatusTest(models.GROUP_ARCHIVED)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/comment", strings.NewReader(`{"receiptId": 1, "comment": "test"}`))
+	w := serveGroupStatusRequest(GroupIdFromBody, r)
+
+	if w.Result().StatusCode != 403 {
+		utils.PrintTestError(t, w.Result().StatusCode, 403)
+	}
+}
+
+func TestValidateGroupIsActiveShouldRejectArchivedGroupFromReceiptUrl(t *testing.T) {
+	defer teardownGroupStatusTest()
+	setupGroupStatusTest(models.GROUP_ARCHIVED)
+
+	r := httptest.NewRequest(http.MethodPut, "/api/receipt/1", strings.NewReader(""))
+	ctx := chi.NewRouteContext()
+	ctx.URLParams.Add("id", "1")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, ctx))
+
+	w := serveGroupStatusRequest(GroupIdFromReceiptUrl, r)
+
+	if w.Result().StatusCode != 403 {
+		utils.PrintTestError(t, w.Result().StatusCode, 403)
+	}
+}
+
+func TestValidateGroupIsActiveShouldRejectMovingReceiptToArchivedGroup(t *testing.T) {
+	defer teardownGroupStatusTest()
+	setupGroupStatusTest(models.GROUP_ACTIVE)
+	repositories.GetDB().Create(&models.Group{Name: "Archived", Status: models.GROUP_ARCHIVED})
+
+	tests := map[string]struct {
+		body   string
+		expect int
+	}{
+		"same group":     {body: `{"groupId": 1, "name": "Test"}`, expect: 200},
+		"archived group": {body: `{"groupId": 2, "name": "Test"}`, expect: 403},
+	}
+
+	for name, test := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/api/receipt/1", strings.NewReader(test.body))
+		ctx := chi.NewRouteContext()
+		ctx.URLParams.Add("id", "1")
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, ctx))
+
+		w := serveGroupStatusRequest(GroupIdFromReceiptUrlAndBody, r)
+
+		if w.Result().StatusCode != test.expect {
+			utils.PrintTestError(t, w.Result().StatusCode, test.expect)
+			t.Log(name)
+		}
+	}
+}
+
+func TestGroupIdFromBodyShouldRestoreBody(t *testing.T) {
+	defer teardownGroupStatusTest()
+	setupGroupStatusTest(models.GROUP_ACTIVE)
+	body := `{"groupId": 1, "name": "test"}`
+
+	r := httptest.NewRequest(http.MethodPost, "/api/receipt", strings.NewReader(body))
+	groupIds, err := GroupIdFromBody(r)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(groupIds) != 1 || groupIds[0] != "1" {
+		utils.PrintTestError(t, groupIds, []string{"1"})
+	}
+
+	restoredBody, _ := io.ReadAll(r.Body)
+	if string(restoredBody) != body {
+		utils.PrintTestError(t, string(restoredBody), body)
+	}
+}
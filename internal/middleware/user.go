@@ -72,13 +72,9 @@ func ValidateUserData(roleRequired bool) (mw func(http.Handler) http.Handler) {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			db := repositories.GetDB()
 			userData := r.Context().Value("user").(commands.SignUpCommand)
-			err := structs.ValidatorError{
-				Errors: make(map[string]string),
-			}
+			err := userData.Validate(roleRequired)
 
-			if len(userData.Username) == 0 {
-				err.Errors["username"] = "Username is required"
-			} else {
+			if len(userData.Username) > 0 {
 				var count int64
 				db.Model(&models.User{}).Where("username = ?", userData.Username).Count(&count)
 
@@ -87,20 +83,6 @@ func ValidateUserData(roleRequired bool) (mw func(http.Handler) http.Handler) {
 				}
 			}
 
-			if len(userData.Password) == 0 && !userData.IsDummyUser {
-				err.Errors["password"] = "Password is required"
-			}
-
-			if len(userData.DisplayName) == 0 {
-				err.Errors["displayName"] = "Displayname is required"
-			}
-
-			if roleRequired {
-				if len(userData.UserRole) == 0 {
-					err.Errors["userRole"] = "User Role is required"
-				}
-			}
-
 			if len(err.Errors) > 0 {
 				structs.WriteValidatorErrorResponse(w, err, http.StatusBadRequest)
 				return
@@ -0,0 +1,305 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"receipt-wrangler/api/internal/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration changes the schema or data from one version to the next.
+// Migrations run in a transaction unless NoTransaction is set, MySQL commits schema changes right away regardless.
+// A nil Down means the migration can't be rolled back.
+type Migration struct {
+	Version       uint
+	Name          string
+	Up            func(tx *gorm.DB) error
+	Down          func(tx *gorm.DB) error
+	NoTransaction bool
+}
+
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+	// Applied by a newer version of the api
+	Unknown bool
+}
+
+// dialectSql holds statements per gorm dialect name, statements under "" are used for the other dialects
+type dialectSql map[string][]string
+
+func execDialectSql(tx *gorm.DB, statements dialectSql) error {
+	dialectStatements, ok := statements[tx.Dialector.Name()]
+	if !ok {
+		dialectStatements = statements[""]
+	}
+
+	for _, statement := range dialectStatements {
+		err := tx.Exec(statement).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GetMigrations() []Migration {
+	sortedMigrations := make([]Migration, len(migrations))
+	copy(sortedMigrations, migrations)
+	sort.Slice(sortedMigrations, func(i, j int) bool {
+		return sortedMigrations[i].Version < sortedMigrations[j].Version
+	})
+
+	return sortedMigrations
+}
+
+func GetLatestVersion() uint {
+	allMigrations := GetMigrations()
+	if len(allMigrations) == 0 {
+		return 0
+	}
+
+	return allMigrations[len(allMigrations)-1].Version
+}
+
+func getAppliedMigrations(db *gorm.DB) ([]models.SchemaMigration, error) {
+	err := db.AutoMigrate(&models.SchemaMigration{})
+	if err != nil {
+		return nil, err
+	}
+
+	var appliedMigrations []models.SchemaMigration
+	err = db.Model(&models.SchemaMigration{}).Order("version").Find(&appliedMigrations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return appliedMigrations, nil
+}
+
+// CheckSchemaVersion fails when the database has migrations applied that this binary doesn't know
+func CheckSchemaVersion(db *gorm.DB) error {
+	appliedMigrations, err := getAppliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	knownVersions := make(map[uint]bool)
+	for _, migration := range GetMigrations() {
+		knownVersions[migration.Version] = true
+	}
+
+	for _, appliedMigration := range appliedMigrations {
+		if !knownVersions[appliedMigration.Version] {
+			return fmt.Errorf(
+				"database schema version %d (%s) is newer than the latest version %d this binary knows, upgrade the api or migrate down with a newer binary",
+				appliedMigration.Version,
+				appliedMigration.Name,
+				GetLatestVersion(),
+			)
+		}
+	}
+
+	return nil
+}
+
+// migrationLockName names the advisory lock every replica takes before migrating
+const migrationLockName = "receipt_wrangler_migrations"
+
+// withMigrationLock runs migrate while holding the migration lock, so replicas starting together migrate one after another.
+// Advisory locks belong to a session, so everything runs on the connection holding it. SQLite locks the whole file on writes already.
+func withMigrationLock(db *gorm.DB, migrate func(db *gorm.DB) error) error {
+	var lockSql, unlockSql string
+	switch db.Dialector.Name() {
+	case "postgres":
+		lockSql = "SELECT pg_advisory_lock(hashtext(?))"
+		unlockSql = "SELECT pg_advisory_unlock(hashtext(?))"
+	case "mysql":
+		lockSql = "SELECT GET_LOCK(?, -1)"
+		unlockSql = "SELECT RELEASE_LOCK(?)"
+	default:
+		return migrate(db)
+	}
+
+	return db.Connection(func(conn *gorm.DB) error {
+		err := conn.Exec(lockSql, migrationLockName).Error
+		if err != nil {
+			return err
+		}
+		defer conn.Exec(unlockSql, migrationLockName)
+
+		return migrate(conn)
+	})
+}
+
+// Up applies the pending migrations in order, brings the tables up to the current models and returns the applied migrations
+func Up(db *gorm.DB) ([]Migration, error) {
+	var ranMigrations []Migration
+	err := withMigrationLock(db, func(db *gorm.DB) error {
+		var err error
+		ranMigrations, err = up(db)
+		if err != nil {
+			return err
+		}
+
+		// New tables, columns and indexes of the models reach existing databases here, migrations handle what auto migrating can't
+		return db.AutoMigrate(GetModels()...)
+	})
+
+	return ranMigrations, err
+}
+
+func up(db *gorm.DB) ([]Migration, error) {
+	err := CheckSchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedMigrations, err := getAppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedVersions := make(map[uint]bool)
+	for _, appliedMigration := range appliedMigrations {
+		appliedVersions[appliedMigration.Version] = true
+	}
+
+	ranMigrations := make([]Migration, 0)
+	for _, migration := range GetMigrations() {
+		if appliedVersions[migration.Version] {
+			continue
+		}
+
+		err = runMigration(db, migration, migration.Up, func(tx *gorm.DB) error {
+			return tx.Create(&models.SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return ranMigrations, fmt.Errorf("migration %d %s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		ranMigrations = append(ranMigrations, migration)
+	}
+
+	return ranMigrations, nil
+}
+
+// Down rolls back the given number of applied migrations, latest first, and returns them
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	var rolledBackMigrations []Migration
+	err := withMigrationLock(db, func(db *gorm.DB) error {
+		var err error
+		rolledBackMigrations, err = down(db, steps)
+		return err
+	})
+
+	return rolledBackMigrations, err
+}
+
+func down(db *gorm.DB, steps int) ([]Migration, error) {
+	appliedMigrations, err := getAppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationsByVersion := make(map[uint]Migration)
+	for _, migration := range GetMigrations() {
+		migrationsByVersion[migration.Version] = migration
+	}
+
+	rolledBackMigrations := make([]Migration, 0)
+	for i := len(appliedMigrations) - 1; i >= 0 && len(rolledBackMigrations) < steps; i-- {
+		appliedMigration := appliedMigrations[i]
+		migration, ok := migrationsByVersion[appliedMigration.Version]
+		if !ok {
+			return rolledBackMigrations, fmt.Errorf("migration %d %s is unknown to this binary", appliedMigration.Version, appliedMigration.Name)
+		}
+
+		if migration.Down == nil {
+			return rolledBackMigrations, fmt.Errorf("migration %d %s can't be rolled back", migration.Version, migration.Name)
+		}
+
+		err = runMigration(db, migration, migration.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&models.SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return rolledBackMigrations, fmt.Errorf("rolling back migration %d %s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		rolledBackMigrations = append(rolledBackMigrations, migration)
+	}
+
+	return rolledBackMigrations, nil
+}
+
+// GetStatus lists the known migrations with when they were applied, followed by applied migrations this binary doesn't know
+func GetStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	appliedMigrations, err := getAppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedMigrationsByVersion := make(map[uint]models.SchemaMigration)
+	for _, appliedMigration := range appliedMigrations {
+		appliedMigrationsByVersion[appliedMigration.Version] = appliedMigration
+	}
+
+	statuses := make([]MigrationStatus, 0)
+	for _, migration := range GetMigrations() {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		appliedMigration, ok := appliedMigrationsByVersion[migration.Version]
+		if ok {
+			status.AppliedAt = &appliedMigration.AppliedAt
+			delete(appliedMigrationsByVersion, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, appliedMigration := range appliedMigrations {
+		_, unknown := appliedMigrationsByVersion[appliedMigration.Version]
+		if unknown {
+			appliedAt := appliedMigration.AppliedAt
+			statuses = append(statuses, MigrationStatus{
+				Version:   appliedMigration.Version,
+				Name:      appliedMigration.Name,
+				AppliedAt: &appliedAt,
+				Unknown:   true,
+			})
+		}
+	}
+
+	return statuses, nil
+}
+
+func runMigration(db *gorm.DB, migration Migration, migrate func(tx *gorm.DB) error, record func(tx *gorm.DB) error) error {
+	if migrate == nil {
+		return errors.New("migration has no function to run")
+	}
+
+	if migration.NoTransaction {
+		err := migrate(db)
+		if err != nil {
+			return err
+		}
+
+		return record(db)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := migrate(tx)
+		if err != nil {
+			return err
+		}
+
+		return record(tx)
+	})
+}
@@ -0,0 +1,295 @@
+package migrations
+
+import (
+	"path/filepath"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDb(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrations.db")+"?_pragma=synchronous(OFF)&_pragma=journal_mode(MEMORY)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestShouldApplyPendingMigrationsOnce(t *testing.T) {
+	db := openTestDb(t)
+
+	ranMigrations, err := Up(db)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(ranMigrations) != len(GetMigrations()) {
+		utils.PrintTestError(t, len(ranMigrations), len(GetMigrations()))
+	}
+
+	if !db.Migrator().HasTable(&models.Receipt{}) {
+		utils.PrintTestError(t, "no receipts table", "receipts table")
+	}
+
+	ranMigrations, err = Up(db)
+	if err != nil || len(ranMigrations) != 0 {
+		utils.PrintTestError(t, ranMigrations, "no migrations")
+	}
+
+	statuses, err := GetStatus(db)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	for _, status := range statuses {
+		if status.AppliedAt == nil || status.Unknown {
+			utils.PrintTestError(t, status, "applied migration")
+		}
+	}
+}
+
+func TestShouldNormalizeLegacyAiAndOcrTypes(t *testing.T) {
+	db := openTestDb(t)
+
+	_, err := Up(db)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	// Settings saved before the values were renamed
+	db.Exec("INSERT INTO receipt_processing_settings (name, ai_type, ocr_engine) VALUES ('legacy', 'openAiCustom', 'easyOcr')")
+	db.Delete(&models.SchemaMigration{}, 2)
+
+	ranMigrations, err := Up(db)
+	if err != nil || len(ranMigrations) != 1 || ranMigrations[0].Version != 2 {
+		utils.PrintTestError(t, ranMigrations, "migration 2")
+		return
+	}
+
+	var settings models.ReceiptProcessingSettings
+	db.Model(&models.ReceiptProcessingSettings{}).Where("name = ?", "legacy").First(&settings)
+	if settings.AiType != models.OPEN_AI_CUSTOM_NEW || settings.OcrEngine == nil || *settings.OcrEngine != models.EASY_OCR_NEW {
+		utils.PrintTestError(t, settings, "OPEN_AI_CUSTOM and EASY_OCR")
+	}
+}
+
+func TestShouldBringMigratedDatabasesUpToTheModels(t *testing.T) {
+	db := openTestDb(t)
+
+	_, err := Up(db)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	// A database migrated before the column was added to the model
+	db.Migrator().DropColumn(&models.ScheduledExport{}, "web_dav_username")
+	db.Migrator().DropTable(&models.ExternalImportRecord{})
+
+	ranMigrations, err := Up(db)
+	if err != nil || len(ranMigrations) != 0 {
+		utils.PrintTestError(t, ranMigrations, "no migrations")
+		return
+	}
+
+	if !db.Migrator().HasColumn(&models.ScheduledExport{}, "web_dav_username") || !db.Migrator().HasTable(&models.ExternalImportRecord{}) {
+		utils.PrintTestError(t, "missing column or table", "column and table of the models")
+	}
+}
+
+func TestShouldRollBackMigrationsUntilIrreversible(t *testing.T) {
+	db := openTestDb(t)
+
+	_, err := Up(db)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	rolledBackMigrations, err := Down(db, 5)
+	if err == nil || !strings.Contains(err.Error(), "can't be rolled back") {
+		utils.PrintTestError(t, err, "initial schema can't be rolled back")
+	}
+
+	if len(rolledBackMigrations) != 3 || rolledBackMigrations[0].Version != 4 || rolledBackMigrations[1].Version != 3 || rolledBackMigrations[2].Version != 2 {
+		utils.PrintTestError(t, rolledBackMigrations, "migrations 4, 3 and 2 rolled back")
+	}
+
+	var appliedCount int64
+	db.Model(&models.SchemaMigration{}).Count(&appliedCount)
+	if appliedCount != 1 {
+		utils.PrintTestError(t, appliedCount, 1)
+	}
+}
+
+func TestShouldRefuseSchemaNewerThanBinary(t *testing.T) {
+	db := openTestDb(t)
+
+	_, err := Up(db)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	db.Create(&models.SchemaMigration{Version: GetLatestVersion() + 1, Name: "from_the_future", AppliedAt: time.Now()})
+
+	err = CheckSchemaVersion(db)
+	if err == nil || !strings.Contains(err.Error(), "from_the_future") {
+		utils.PrintTestError(t, err, "newer schema error")
+	}
+
+	_, err = Up(db)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	_, err = Down(db, 1)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	statuses, _ := GetStatus(db)
+	lastStatus := statuses[len(statuses)-1]
+	if !lastStatus.Unknown || lastStatus.Name != "from_the_future" {
+		utils.PrintTestError(t, lastStatus, "unknown migration")
+	}
+}
+
+func TestShouldExecSqlOfDialect(t *testing.T) {
+	db := openTestDb(t)
+
+	err := execDialectSql(db, dialectSql{
+		"":       {"CREATE TABLE dialect_test (name TEXT)", "INSERT INTO dialect_test VALUES ('default')"},
+		"sqlite": {"CREATE TABLE dialect_test (name TEXT)", "INSERT INTO dialect_test VALUES ('sqlite')"},
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	var name string
+	db.Raw("SELECT name FROM dialect_test").Scan(&name)
+	if name != "sqlite" {
+		utils.PrintTestError(t, name, "sqlite")
+	}
+}
+
+func TestShouldKeepMigrationVersionsUnique(t *testing.T) {
+	versions := make(map[uint]bool)
+	for _, migration := range GetMigrations() {
+		if versions[migration.Version] || migration.Version == 0 || len(migration.Name) == 0 || migration.Up == nil {
+			utils.PrintTestError(t, migration.Version, "unique version with a name and up")
+		}
+		versions[migration.Version] = true
+	}
+}
+
+func TestShouldKeepGlobalCategoryAndTagNamesUnique(t *testing.T) {
+	db := openTestDb(t)
+
+	_, err := Up(db)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	// Duplicates saved before the index existed
+	_, err = Down(db, 2)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	groupId := uint(1)
+	db.Create(&models.Category{Name: "Food"})
+	db.Create(&models.Category{Name: "Food"})
+	db.Create(&models.Category{Name: "Food", GroupId: &groupId})
+
+	ranMigrations, err := Up(db)
+	if err != nil || len(ranMigrations) != 2 || ranMigrations[0].Version != 3 {
+		utils.PrintTestError(t, err, "migrations 3 and 4")
+		return
+	}
+
+	var names []string
+	db.Model(&models.Category{}).Order("id").Pluck("name", &names)
+	if strings.Join(names, ",") != "Food,Food (2),Food" {
+		utils.PrintTestError(t, names, "Food,Food (2),Food")
+	}
+
+	err = db.Create(&models.Category{Name: "Food"}).Error
+	if err == nil {
+		utils.PrintTestError(t, err, "duplicate global category rejected")
+	}
+
+	otherGroupId := uint(2)
+	err = db.Create(&models.Category{Name: "Food", GroupId: &otherGroupId}).Error
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+	}
+
+	db.Create(&models.Tag{Name: "Work"})
+	err = db.Create(&models.Tag{Name: "Work"}).Error
+	if err == nil {
+		utils.PrintTestError(t, err, "duplicate global tag rejected")
+	}
+}
+
+func TestShouldKeepExternalImportRecordsPerImporter(t *testing.T) {
+	db := openTestDb(t)
+
+	_, err := Up(db)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	// Records imported while they were unique across all users
+	_, err = Down(db, 1)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	db.Exec("CREATE UNIQUE INDEX idx_external_import_record ON external_import_records (source, external_group_id, external_id)")
+	db.Create(&models.GroupMember{GroupID: 1, UserID: 3, GroupRole: models.OWNER})
+	db.Exec("INSERT INTO external_import_records (source, external_group_id, external_id, group_id) VALUES ('SPLITWISE', 'Roommates', '', 1)")
+
+	ranMigrations, err := Up(db)
+	if err != nil || len(ranMigrations) != 1 || ranMigrations[0].Version != 4 {
+		utils.PrintTestError(t, err, "migration 4")
+		return
+	}
+
+	var record models.ExternalImportRecord
+	db.Model(&models.ExternalImportRecord{}).First(&record)
+	if record.ImportedByUserId != 3 {
+		utils.PrintTestError(t, record.ImportedByUserId, 3)
+	}
+
+	if db.Migrator().HasIndex(&models.ExternalImportRecord{}, "idx_external_import_record") {
+		utils.PrintTestError(t, "old index", "no old index")
+	}
+
+	err = db.Create(&models.ExternalImportRecord{Source: models.SPLITWISE, ImportedByUserId: 4, ExternalGroupId: "Roommates", GroupId: 2}).Error
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+	}
+
+	err = db.Create(&models.ExternalImportRecord{Source: models.SPLITWISE, ImportedByUserId: 4, ExternalGroupId: "Roommates", GroupId: 2}).Error
+	if err == nil {
+		utils.PrintTestError(t, err, "duplicate record of the same importer rejected")
+	}
+}
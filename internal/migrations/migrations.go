@@ -0,0 +1,230 @@
+package migrations
+
+import (
+	"fmt"
+	"receipt-wrangler/api/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// migrations are applied in order of their version, versions are never reused or changed once released.
+// Up auto migrates the current models after the migrations, so new tables and columns need no migration.
+// Migrations are for what auto migrating can't do, like renames, drops, data changes and raw indexes.
+// The initial schema is created from the current models, so migrations changing the schema have to check before they change it.
+var migrations = []Migration{
+	{
+		Version:       1,
+		Name:          "initial_schema",
+		Up:            migrateInitialSchema,
+		NoTransaction: true,
+	},
+	{
+		Version: 2,
+		Name:    "normalize_legacy_ai_and_ocr_types",
+		Up: func(tx *gorm.DB) error {
+			return execDialectSql(tx, dialectSql{
+				"": {
+					"UPDATE receipt_processing_settings SET ocr_engine = 'TESSERACT' WHERE ocr_engine = 'tesseract'",
+					"UPDATE receipt_processing_settings SET ocr_engine = 'EASY_OCR' WHERE ocr_engine = 'easyOcr'",
+					"UPDATE receipt_processing_settings SET ai_type = 'OPEN_AI' WHERE ai_type = 'openAi'",
+					"UPDATE receipt_processing_settings SET ai_type = 'OPEN_AI_CUSTOM' WHERE ai_type = 'openAiCustom'",
+					"UPDATE receipt_processing_settings SET ai_type = 'GEMINI' WHERE ai_type = 'gemini'",
+				},
+			})
+		},
+		// Older versions read both values, so there is nothing to undo
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	},
+	{
+		Version: 3,
+		Name:    "unique_global_category_and_tag_names",
+		Up:      migrateUniqueGlobalNames,
+		Down:    rollBackUniqueGlobalNames,
+	},
+	{
+		Version: 4,
+		Name:    "external_import_records_per_importer",
+		Up:      migrateExternalImportRecordsPerImporter,
+		Down:    rollBackExternalImportRecordsPerImporter,
+	},
+}
+
+var globalNameTables = []string{"categories", "tags"}
+
+// Nulls never collide in the name and group id index, so global names need an index of their own.
+// MySQL has no partial indexes, there a generated column only holds a hash of global names.
+func migrateUniqueGlobalNames(tx *gorm.DB) error {
+	for _, table := range globalNameTables {
+		keptIds := fmt.Sprintf("SELECT MIN(id) FROM %s WHERE group_id IS NULL GROUP BY name", table)
+		indexName := fmt.Sprintf("idx_%s_global_name", table)
+
+		// Duplicates created before the index existed are renamed, the oldest row keeps its name
+		err := execDialectSql(tx, dialectSql{
+			"": {
+				fmt.Sprintf("UPDATE %s SET name = name || ' (' || id || ')' WHERE group_id IS NULL AND id NOT IN (%s)", table, keptIds),
+				fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (name) WHERE group_id IS NULL", indexName, table),
+			},
+			"mysql": {
+				fmt.Sprintf("UPDATE %s SET name = CONCAT(name, ' (', id, ')') WHERE group_id IS NULL AND id NOT IN (SELECT id FROM (%s) AS kept)", table, strings.Replace(keptIds, "MIN(id)", "MIN(id) AS id", 1)),
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN global_name_hash VARCHAR(64) AS (IF(group_id IS NULL, SHA2(name, 256), NULL)) STORED", table),
+				fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (global_name_hash)", indexName, table),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func rollBackUniqueGlobalNames(tx *gorm.DB) error {
+	for _, table := range globalNameTables {
+		indexName := fmt.Sprintf("idx_%s_global_name", table)
+
+		err := execDialectSql(tx, dialectSql{
+			"": {
+				fmt.Sprintf("DROP INDEX %s", indexName),
+			},
+			"mysql": {
+				fmt.Sprintf("DROP INDEX %s ON %s", indexName, table),
+				fmt.Sprintf("ALTER TABLE %s DROP COLUMN global_name_hash", table),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Import records used to be unique across all users, so an export imported by one user blocked everyone else's of the same name.
+// Existing records are assigned to an owner of the group they were imported into, the new index is created by auto migrating.
+func migrateExternalImportRecordsPerImporter(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if !migrator.HasTable(&models.ExternalImportRecord{}) {
+		return nil
+	}
+
+	if !migrator.HasColumn(&models.ExternalImportRecord{}, "ImportedByUserId") {
+		err := migrator.AddColumn(&models.ExternalImportRecord{}, "ImportedByUserId")
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tx.Exec(
+		"UPDATE external_import_records SET imported_by_user_id = COALESCE((SELECT MIN(user_id) FROM group_members WHERE group_members.group_id = external_import_records.group_id AND group_members.group_role = ?), 0) WHERE imported_by_user_id = 0",
+		models.OWNER,
+	).Error
+	if err != nil {
+		return err
+	}
+
+	if migrator.HasIndex(&models.ExternalImportRecord{}, "idx_external_import_record") {
+		return migrator.DropIndex(&models.ExternalImportRecord{}, "idx_external_import_record")
+	}
+
+	return nil
+}
+
+// Older versions recreate their index, which fails while several users have records of the same export
+func rollBackExternalImportRecordsPerImporter(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if migrator.HasIndex(&models.ExternalImportRecord{}, "idx_external_import_record_importer") {
+		err := migrator.DropIndex(&models.ExternalImportRecord{}, "idx_external_import_record_importer")
+		if err != nil {
+			return err
+		}
+	}
+
+	if migrator.HasColumn(&models.ExternalImportRecord{}, "ImportedByUserId") {
+		return migrator.DropColumn(&models.ExternalImportRecord{}, "ImportedByUserId")
+	}
+
+	return nil
+}
+
+// migrateInitialSchema is the schema databases were auto migrated to before versioned migrations
+func migrateInitialSchema(db *gorm.DB) error {
+	err := dropGlobalNameIndexes(db)
+	if err != nil {
+		return err
+	}
+
+	return db.AutoMigrate(GetModels()...)
+}
+
+// GetModels returns every model in the order MakeMigrations migrates them
+func GetModels() []any {
+	return []any{
+		&models.RefreshToken{},
+		&models.User{},
+		&models.CustomField{},
+		&models.CustomFieldValue{},
+		&models.CustomFieldOption{},
+		&models.Receipt{},
+		&models.Item{},
+		&models.FileData{},
+		&models.FileDataPage{},
+		&models.FileBlob{},
+		&models.Tag{},
+		&models.Category{},
+		&models.Group{},
+		&models.GroupMember{},
+		&models.Comment{},
+		&models.Notification{},
+		&models.UserShortcut{},
+		&models.UserPrefernces{},
+		&models.SubjectLineRegex{},
+		&models.GroupSettingsWhiteListEmail{},
+		&models.GroupSettings{},
+		&models.Dashboard{},
+		&models.Widget{},
+		&models.TaskQueueConfiguration{},
+		&models.SystemSettings{},
+		&models.SystemEmail{},
+		&models.SystemTask{},
+		&models.ReceiptProcessingSettings{},
+		&models.Prompt{},
+		&models.GroupReceiptSettings{},
+		&models.Pepper{},
+		&models.ApiKey{},
+		&models.ApiKeyGroup{},
+		&models.ApiKeyResourceGrant{},
+		&models.AuditLog{},
+		&models.GroupInvite{},
+		&models.ResumableUpload{},
+		&models.ExternalImportRecord{},
+		&models.GroupLedgerSettings{},
+		&models.LedgerCategoryAccount{},
+		&models.ExpenseReport{},
+		&models.ScheduledExport{},
+	}
+}
+
+// Category and tag names used to be unique across all groups, they are now unique per group.
+// Existing rows keep a null group id, so they stay global.
+func dropGlobalNameIndexes(db *gorm.DB) error {
+	indexes := map[any]string{
+		&models.Category{}: "idx_categories_name",
+		&models.Tag{}:      "idx_tags_name",
+	}
+
+	for model, indexName := range indexes {
+		if !db.Migrator().HasIndex(model, indexName) {
+			continue
+		}
+
+		err := db.Migrator().DropIndex(model, indexName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -17,4 +17,53 @@ type ApiKey struct {
 	UserID          *uint      `json:"userId"`
 	Scope           string     `json:"scope"`
 	LastUsedAt      *time.Time `json:"lastUsedAt"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	RotatedAt       *time.Time `json:"rotatedAt"`
+	// Hmac of the secret before the last rotation, accepted until PreviousHmacExpiresAt
+	PreviousHmac          string                `json:"-"`
+	PreviousHmacExpiresAt *time.Time            `json:"-"`
+	ExpiryNotifiedAt      *time.Time            `json:"-"`
+	AllowedGroups         []ApiKeyGroup         `gorm:"foreignKey:ApiKeyId" json:"-"`
+	ResourceScopes        []ApiKeyResourceGrant `gorm:"foreignKey:ApiKeyId" json:"-"`
+}
+
+func (apiKey ApiKey) IsExpired() bool {
+	return apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now())
+}
+
+func (apiKey ApiKey) GetAllowedGroupIds() []uint {
+	groupIds := make([]uint, len(apiKey.AllowedGroups))
+	for i, allowedGroup := range apiKey.AllowedGroups {
+		groupIds[i] = allowedGroup.GroupId
+	}
+
+	return groupIds
+}
+
+func (apiKey ApiKey) GetResourceScopes() []ApiKeyResourceScope {
+	resourceScopes := make([]ApiKeyResourceScope, len(apiKey.ResourceScopes))
+	for i, resourceGrant := range apiKey.ResourceScopes {
+		resourceScopes[i] = resourceGrant.ResourceScope
+	}
+
+	return resourceScopes
+}
+
+func (apiKey ApiKey) ToView() ApiKeyView {
+	return ApiKeyView{
+		ID:              apiKey.ID,
+		CreatedAt:       apiKey.CreatedAt,
+		UpdatedAt:       apiKey.UpdatedAt,
+		CreatedBy:       apiKey.CreatedBy,
+		CreatedByString: apiKey.CreatedByString,
+		Name:            apiKey.Name,
+		Description:     apiKey.Description,
+		UserID:          apiKey.UserID,
+		Scope:           apiKey.Scope,
+		LastUsedAt:      apiKey.LastUsedAt,
+		ExpiresAt:       apiKey.ExpiresAt,
+		RotatedAt:       apiKey.RotatedAt,
+		AllowedGroupIds: apiKey.GetAllowedGroupIds(),
+		ResourceScopes:  apiKey.GetResourceScopes(),
+	}
 }
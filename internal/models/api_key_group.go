@@ -0,0 +1,7 @@
+package models
+
+// Restricts an api key to a group. Keys without any rows may access every group of their user.
+type ApiKeyGroup struct {
+	ApiKeyId string `gorm:"primaryKey" json:"apiKeyId"`
+	GroupId  uint   `gorm:"primaryKey" json:"groupId"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "database/sql/driver"
+
+type ApiKeyResourceScope string
+
+const (
+	API_KEY_RESOURCE_RECEIPTS_READ  ApiKeyResourceScope = "receipts:read"
+	API_KEY_RESOURCE_RECEIPTS_WRITE ApiKeyResourceScope = "receipts:write"
+	API_KEY_RESOURCE_GROUPS_READ    ApiKeyResourceScope = "groups:read"
+	API_KEY_RESOURCE_GROUPS_WRITE   ApiKeyResourceScope = "groups:write"
+	API_KEY_RESOURCE_EXPORT         ApiKeyResourceScope = "export"
+	API_KEY_RESOURCE_IMPORT         ApiKeyResourceScope = "import"
+	API_KEY_RESOURCE_ADMIN          ApiKeyResourceScope = "admin"
+)
+
+func GetApiKeyResourceScopes() []ApiKeyResourceScope {
+	return []ApiKeyResourceScope{
+		API_KEY_RESOURCE_RECEIPTS_READ,
+		API_KEY_RESOURCE_RECEIPTS_WRITE,
+		API_KEY_RESOURCE_GROUPS_READ,
+		API_KEY_RESOURCE_GROUPS_WRITE,
+		API_KEY_RESOURCE_EXPORT,
+		API_KEY_RESOURCE_IMPORT,
+		API_KEY_RESOURCE_ADMIN,
+	}
+}
+
+func (self ApiKeyResourceScope) IsValid() bool {
+	for _, resourceScope := range GetApiKeyResourceScopes() {
+		if self == resourceScope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (self *ApiKeyResourceScope) Scan(value string) error {
+	*self = ApiKeyResourceScope(value)
+	return nil
+}
+
+func (self ApiKeyResourceScope) Value() (driver.Value, error) {
+	return string(self), nil
+}
+
+// Resource scope granted to an api key. Keys without any rows are not restricted by resource.
+type ApiKeyResourceGrant struct {
+	ApiKeyId      string              `gorm:"primaryKey" json:"apiKeyId"`
+	ResourceScope ApiKeyResourceScope `gorm:"primaryKey" json:"resourceScope"`
+}
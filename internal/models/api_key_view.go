@@ -3,14 +3,18 @@ package models
 import "time"
 
 type ApiKeyView struct {
-	ID              string     `json:"id"`
-	CreatedAt       time.Time  `json:"createdAt"`
-	UpdatedAt       time.Time  `json:"updatedAt"`
-	CreatedBy       *uint      `json:"createdBy"`
-	CreatedByString string     `json:"createdByString"`
-	Name            string     `json:"name"`
-	Description     string     `json:"description"`
-	UserID          *uint      `json:"userId"`
-	Scope           string     `json:"scope"`
-	LastUsedAt      *time.Time `json:"lastUsedAt"`
+	ID              string                `json:"id"`
+	CreatedAt       time.Time             `json:"createdAt"`
+	UpdatedAt       time.Time             `json:"updatedAt"`
+	CreatedBy       *uint                 `json:"createdBy"`
+	CreatedByString string                `json:"createdByString"`
+	Name            string                `json:"name"`
+	Description     string                `json:"description"`
+	UserID          *uint                 `json:"userId"`
+	Scope           string                `json:"scope"`
+	LastUsedAt      *time.Time            `json:"lastUsedAt"`
+	ExpiresAt       *time.Time            `json:"expiresAt"`
+	RotatedAt       *time.Time            `json:"rotatedAt"`
+	AllowedGroupIds []uint                `json:"allowedGroupIds"`
+	ResourceScopes  []ApiKeyResourceScope `json:"resourceScopes"`
 }
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type AuditLog struct {
+	BaseModel
+	Action        AuditAction     `json:"action" gorm:"index"`
+	ActorUserId   *uint           `json:"actorUserId" gorm:"index"`
+	ActorApiKeyId *string         `json:"actorApiKeyId"`
+	IpAddress     string          `json:"ipAddress"`
+	EntityType    AuditEntityType `json:"entityType"`
+	EntityId      string          `json:"entityId"`
+	GroupId       *uint           `json:"groupId"`
+	Before        string          `json:"before"`
+	After         string          `json:"after"`
+	Description   string          `json:"description"`
+}
+
+type AuditAction string
+
+const (
+	AUDIT_LOGIN                    AuditAction = "LOGIN"
+	AUDIT_LOGIN_FAILED             AuditAction = "LOGIN_FAILED"
+	AUDIT_PASSWORD_RESET           AuditAction = "PASSWORD_RESET"
+	AUDIT_USER_ROLE_CHANGED        AuditAction = "USER_ROLE_CHANGED"
+	AUDIT_USER_CREATED             AuditAction = "USER_CREATED"
+	AUDIT_GROUP_MEMBERSHIP_CHANGED AuditAction = "GROUP_MEMBERSHIP_CHANGED"
+	AUDIT_API_KEY_CREATED          AuditAction = "API_KEY_CREATED"
+	AUDIT_API_KEY_UPDATED          AuditAction = "API_KEY_UPDATED"
+	AUDIT_API_KEY_USED             AuditAction = "API_KEY_USED"
+	AUDIT_API_KEY_DELETED          AuditAction = "API_KEY_DELETED"
+	AUDIT_API_KEY_ROTATED          AuditAction = "API_KEY_ROTATED"
+	AUDIT_SYSTEM_SETTINGS_UPDATED  AuditAction = "SYSTEM_SETTINGS_UPDATED"
+	AUDIT_EXPORT                   AuditAction = "EXPORT"
+	AUDIT_RESTORE                  AuditAction = "RESTORE"
+)
+
+func GetAuditActions() []AuditAction {
+	return []AuditAction{
+		AUDIT_LOGIN,
+		AUDIT_LOGIN_FAILED,
+		AUDIT_PASSWORD_RESET,
+		AUDIT_USER_ROLE_CHANGED,
+		AUDIT_USER_CREATED,
+		AUDIT_GROUP_MEMBERSHIP_CHANGED,
+		AUDIT_API_KEY_CREATED,
+		AUDIT_API_KEY_UPDATED,
+		AUDIT_API_KEY_USED,
+		AUDIT_API_KEY_DELETED,
+		AUDIT_API_KEY_ROTATED,
+		AUDIT_SYSTEM_SETTINGS_UPDATED,
+		AUDIT_EXPORT,
+		AUDIT_RESTORE,
+	}
+}
+
+func (self AuditAction) IsValid() bool {
+	for _, action := range GetAuditActions() {
+		if self == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (self *AuditAction) Scan(value string) error {
+	*self = AuditAction(value)
+	return nil
+}
+
+func (self AuditAction) Value() (driver.Value, error) {
+	if !self.IsValid() {
+		return nil, errors.New("invalid AuditAction")
+	}
+	return string(self), nil
+}
+
+type AuditEntityType string
+
+const (
+	AUDIT_ENTITY_USER            AuditEntityType = "USER"
+	AUDIT_ENTITY_GROUP           AuditEntityType = "GROUP"
+	AUDIT_ENTITY_API_KEY         AuditEntityType = "API_KEY"
+	AUDIT_ENTITY_SYSTEM_SETTINGS AuditEntityType = "SYSTEM_SETTINGS"
+	AUDIT_ENTITY_RECEIPT         AuditEntityType = "RECEIPT"
+	AUDIT_ENTITY_INSTANCE        AuditEntityType = "INSTANCE"
+)
+
+func (self *AuditEntityType) Scan(value string) error {
+	*self = AuditEntityType(value)
+	return nil
+}
+
+func (self AuditEntityType) Value() (driver.Value, error) {
+	if self != AUDIT_ENTITY_USER &&
+		self != AUDIT_ENTITY_GROUP &&
+		self != AUDIT_ENTITY_API_KEY &&
+		self != AUDIT_ENTITY_SYSTEM_SETTINGS &&
+		self != AUDIT_ENTITY_RECEIPT &&
+		self != AUDIT_ENTITY_INSTANCE {
+		return nil, errors.New("invalid AuditEntityType")
+	}
+	return string(self), nil
+}
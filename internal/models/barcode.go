@@ -0,0 +1,7 @@
+package models
+
+// Barcode is a barcode or QR code decoded from a receipt image
+type Barcode struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
@@ -8,8 +8,10 @@ import (
 
 type Category struct {
 	BaseModel
-	Name        string `gorm:"not null; uniqueIndex" json:"name"`
+	Name        string `gorm:"not null; uniqueIndex:idx_category_name_group_id" json:"name"`
 	Description string `json:"description"`
+	// Nil means the category is global and shared by every group
+	GroupId *uint `gorm:"uniqueIndex:idx_category_name_group_id" json:"groupId"`
 }
 
 func (category *Category) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
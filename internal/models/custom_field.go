@@ -6,4 +6,6 @@ type CustomField struct {
 	Type        CustomFieldType     `gorm:"not null" json:"type"`
 	Description string              `json:"description"`
 	Options     []CustomFieldOption `json:"options"`
+	// Nil means the custom field is global and shared by every group
+	GroupId *uint `gorm:"index" json:"groupId"`
 }
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ExpenseReport is a generated pdf report kept in storage until it expires, so it can be downloaded from its notification
+type ExpenseReport struct {
+	BaseModel
+	UserId       uint      `gorm:"not null; index" json:"userId"`
+	GroupId      *uint     `json:"groupId"`
+	FileName     string    `gorm:"not null" json:"fileName"`
+	ReceiptCount int       `json:"receiptCount"`
+	ExpiresAt    time.Time `gorm:"index" json:"expiresAt"`
+}
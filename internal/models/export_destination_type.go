@@ -0,0 +1,30 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type ExportDestinationType string
+
+const (
+	// Relative to the EXPORT_DIRECTORY of the server
+	LOCAL_DIRECTORY ExportDestinationType = "LOCAL_DIRECTORY"
+	WEBDAV          ExportDestinationType = "WEBDAV"
+)
+
+func (self *ExportDestinationType) Scan(value string) error {
+	*self = ExportDestinationType(value)
+	return nil
+}
+
+func (self ExportDestinationType) Value() (driver.Value, error) {
+	if !self.IsValid() {
+		return nil, errors.New("invalid ExportDestinationType")
+	}
+	return string(self), nil
+}
+
+func (self ExportDestinationType) IsValid() bool {
+	return self == LOCAL_DIRECTORY || self == WEBDAV
+}
@@ -0,0 +1,21 @@
+package models
+
+type ExportFormat string
+
+const (
+	EXPORT_CSV       ExportFormat = "CSV"
+	EXPORT_OFX       ExportFormat = "OFX"
+	EXPORT_QIF       ExportFormat = "QIF"
+	EXPORT_BEANCOUNT ExportFormat = "BEANCOUNT"
+	EXPORT_HLEDGER   ExportFormat = "HLEDGER"
+	EXPORT_PDF       ExportFormat = "PDF"
+)
+
+func (self ExportFormat) IsValid() bool {
+	return self == EXPORT_CSV ||
+		self == EXPORT_OFX ||
+		self == EXPORT_QIF ||
+		self == EXPORT_BEANCOUNT ||
+		self == EXPORT_HLEDGER ||
+		self == EXPORT_PDF
+}
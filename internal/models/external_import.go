@@ -0,0 +1,42 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type ExternalImportSource string
+
+const (
+	SPLITWISE ExternalImportSource = "SPLITWISE"
+	TRICOUNT  ExternalImportSource = "TRICOUNT"
+)
+
+func (self *ExternalImportSource) Scan(value string) error {
+	*self = ExternalImportSource(value)
+	return nil
+}
+
+func (self ExternalImportSource) Value() (driver.Value, error) {
+	if !self.IsValid() {
+		return nil, errors.New("invalid ExternalImportSource")
+	}
+	return string(self), nil
+}
+
+func (self ExternalImportSource) IsValid() bool {
+	return self == SPLITWISE || self == TRICOUNT
+}
+
+// ExternalImportRecord remembers what a user imported from another app, so running an import again skips it.
+// Records are kept per importer, exports without a group id of their own are told apart by the group name the user chose.
+// The record of the group itself has no ExternalId.
+type ExternalImportRecord struct {
+	BaseModel
+	Source           ExternalImportSource `gorm:"not null; uniqueIndex:idx_external_import_record_importer" json:"source"`
+	ImportedByUserId uint                 `gorm:"not null; default:0; uniqueIndex:idx_external_import_record_importer" json:"importedByUserId"`
+	ExternalGroupId  string               `gorm:"not null; uniqueIndex:idx_external_import_record_importer" json:"externalGroupId"`
+	ExternalId       string               `gorm:"not null; uniqueIndex:idx_external_import_record_importer" json:"externalId"`
+	GroupId          uint                 `gorm:"not null" json:"groupId"`
+	Group            Group                `json:"-"`
+}
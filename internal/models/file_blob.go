@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// FileBlob is a stored file shared by every FileData with the same content, it is removed once RefCount drops to zero
+type FileBlob struct {
+	Hash      string    `gorm:"primaryKey; size:64" json:"hash"`
+	Size      uint      `json:"size"`
+	RefCount  uint      `gorm:"not null; default:0" json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
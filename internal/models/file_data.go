@@ -2,9 +2,16 @@ package models
 
 type FileData struct {
 	BaseModel
-	Name      string  `json:"name"`
-	FileType  string  `json:"fileType"`
-	Size      uint    `json:"size"`
-	ReceiptId uint    `json:"receiptId"`
-	Receipt   Receipt `json:"-"`
+	Name      string `json:"name"`
+	FileType  string `json:"fileType"`
+	Size      uint   `json:"size"`
+	ReceiptId uint   `json:"receiptId"`
+	// Hex encoded sha256 of the stored file
+	Checksum string `json:"checksum"`
+	// Set when the file is stored content addressed, empty for files stored under their own key
+	BlobHash string  `gorm:"index; size:64" json:"-"`
+	Receipt  Receipt `json:"-"`
+	// Only set for pdfs
+	Pages    []FileDataPage `gorm:"constraint:OnDelete:CASCADE" json:"pages"`
+	Barcodes []Barcode      `gorm:"type:text; serializer:json" json:"barcodes"`
 }
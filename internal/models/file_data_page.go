@@ -0,0 +1,11 @@
+package models
+
+// FileDataPage is a rendered preview of one page of a pdf FileData
+type FileDataPage struct {
+	BaseModel
+	FileDataId uint   `gorm:"not null; uniqueIndex:idx_file_data_page" json:"fileDataId"`
+	PageNumber uint   `gorm:"not null; uniqueIndex:idx_file_data_page" json:"pageNumber"`
+	Name       string `json:"name"`
+	FileType   string `json:"fileType"`
+	Size       uint   `json:"size"`
+}
@@ -4,6 +4,7 @@ type FileDataView struct {
 	BaseModel
 	EncodedImage string `json:"encodedImage"`
 	Name         string `json:"name"`
+	Checksum     string `json:"checksum"`
 }
 
 func (view FileDataView) FromFileData(fileData FileData) FileDataView {
@@ -17,5 +18,6 @@ func (view FileDataView) FromFileData(fileData FileData) FileDataView {
 		},
 		EncodedImage: "",
 		Name:         fileData.Name,
+		Checksum:     fileData.Checksum,
 	}
 }
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type GroupInvite struct {
+	BaseModel
+	GroupId          uint       `gorm:"not null;index" json:"groupId"`
+	Group            Group      `json:"-"`
+	GroupRole        GroupRole  `gorm:"not null" json:"groupRole"`
+	TokenHash        string     `gorm:"not null;uniqueIndex" json:"-"`
+	Email            string     `json:"email"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expiresAt"`
+	MaxUses          int        `gorm:"not null;default:1" json:"maxUses"`
+	UseCount         int        `gorm:"not null;default:0" json:"useCount"`
+	RevokedAt        *time.Time `json:"revokedAt"`
+	LastAcceptedAt   *time.Time `json:"lastAcceptedAt"`
+	LastAcceptedById *uint      `json:"lastAcceptedById"`
+}
+
+func (invite GroupInvite) IsPending() bool {
+	return invite.RevokedAt == nil &&
+		invite.ExpiresAt.After(time.Now()) &&
+		invite.UseCount < invite.MaxUses
+}
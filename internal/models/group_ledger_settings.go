@@ -0,0 +1,21 @@
+package models
+
+// GroupLedgerSettings configures the accounts of the OFX, QIF, Beancount and hledger exports of a group.
+// Groups without settings export with the defaults in constants.
+type GroupLedgerSettings struct {
+	BaseModel
+	GroupId               uint                    `gorm:"not null;unique" json:"groupId"`
+	Currency              string                  `gorm:"not null" json:"currency"`
+	PaidByAccount         string                  `gorm:"not null" json:"paidByAccount"`
+	DefaultExpenseAccount string                  `gorm:"not null" json:"defaultExpenseAccount"`
+	CategoryAccounts      []LedgerCategoryAccount `json:"categoryAccounts"`
+}
+
+// LedgerCategoryAccount is the account receipts and items of a category are posted to
+type LedgerCategoryAccount struct {
+	BaseModel
+	GroupLedgerSettingsId uint     `gorm:"not null;uniqueIndex:idx_ledger_category_account" json:"groupLedgerSettingsId"`
+	CategoryId            uint     `gorm:"not null;uniqueIndex:idx_ledger_category_account" json:"categoryId"`
+	Category              Category `json:"-"`
+	Account               string   `gorm:"not null" json:"account"`
+}
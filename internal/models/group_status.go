@@ -19,3 +19,7 @@ func (self *GroupStatus) Scan(value string) error {
 func (self GroupStatus) Value() (driver.Value, error) {
 	return string(self), nil
 }
+
+func (self GroupStatus) IsValid() bool {
+	return self == GROUP_ACTIVE || self == GROUP_ARCHIVED
+}
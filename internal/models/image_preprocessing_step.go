@@ -0,0 +1,116 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type ImagePreprocessingStepType string
+
+const (
+	PREPROCESS_TRIM               ImagePreprocessingStepType = "TRIM"
+	PREPROCESS_GRAYSCALE          ImagePreprocessingStepType = "GRAYSCALE"
+	PREPROCESS_BILEVEL            ImagePreprocessingStepType = "BILEVEL"
+	PREPROCESS_ADAPTIVE_THRESHOLD ImagePreprocessingStepType = "ADAPTIVE_THRESHOLD"
+	PREPROCESS_BLUR               ImagePreprocessingStepType = "BLUR"
+	PREPROCESS_SHARPEN            ImagePreprocessingStepType = "SHARPEN"
+	PREPROCESS_ENHANCE            ImagePreprocessingStepType = "ENHANCE"
+	PREPROCESS_CONTRAST           ImagePreprocessingStepType = "CONTRAST"
+	PREPROCESS_NORMALIZE          ImagePreprocessingStepType = "NORMALIZE"
+	PREPROCESS_DESKEW             ImagePreprocessingStepType = "DESKEW"
+	PREPROCESS_DENOISE            ImagePreprocessingStepType = "DENOISE"
+	PREPROCESS_RESIZE_TO_DPI      ImagePreprocessingStepType = "RESIZE_TO_DPI"
+	PREPROCESS_SCALE              ImagePreprocessingStepType = "SCALE"
+	PREPROCESS_PERSPECTIVE_CROP   ImagePreprocessingStepType = "PERSPECTIVE_CROP"
+)
+
+func GetImagePreprocessingStepTypes() []ImagePreprocessingStepType {
+	return []ImagePreprocessingStepType{
+		PREPROCESS_TRIM,
+		PREPROCESS_GRAYSCALE,
+		PREPROCESS_BILEVEL,
+		PREPROCESS_ADAPTIVE_THRESHOLD,
+		PREPROCESS_BLUR,
+		PREPROCESS_SHARPEN,
+		PREPROCESS_ENHANCE,
+		PREPROCESS_CONTRAST,
+		PREPROCESS_NORMALIZE,
+		PREPROCESS_DESKEW,
+		PREPROCESS_DENOISE,
+		PREPROCESS_RESIZE_TO_DPI,
+		PREPROCESS_SCALE,
+		PREPROCESS_PERSPECTIVE_CROP,
+	}
+}
+
+func (self ImagePreprocessingStepType) IsValid() bool {
+	for _, stepType := range GetImagePreprocessingStepTypes() {
+		if self == stepType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (self *ImagePreprocessingStepType) Scan(value string) error {
+	*self = ImagePreprocessingStepType(value)
+	return nil
+}
+
+func (self ImagePreprocessingStepType) Value() (driver.Value, error) {
+	if !self.IsValid() {
+		return nil, errors.New("invalid image preprocessing step type")
+	}
+
+	return string(self), nil
+}
+
+// MaxPreprocessedImageSide is the largest width or height steps may resize an image to
+const MaxPreprocessedImageSide = 10000
+
+// ImagePreprocessingStep is one operation of the image pipeline run before OCR.
+// Amount is the main parameter of a step: fuzz for TRIM, sigma for BLUR and SHARPEN, threshold for DESKEW,
+// window size for DENOISE, target dpi for RESIZE_TO_DPI and percent for SCALE.
+type ImagePreprocessingStep struct {
+	Type   ImagePreprocessingStepType `json:"type"`
+	Amount *float64                   `json:"amount,omitempty"`
+	// Window and offset of ADAPTIVE_THRESHOLD
+	Width  *uint    `json:"width,omitempty"`
+	Height *uint    `json:"height,omitempty"`
+	Offset *float64 `json:"offset,omitempty"`
+	// Corners of PERSPECTIVE_CROP as x,y pairs: top left, top right, bottom right, bottom left
+	Points []float64 `json:"points,omitempty"`
+}
+
+func (step ImagePreprocessingStep) GetAmount(defaultAmount float64) float64 {
+	if step.Amount == nil {
+		return defaultAmount
+	}
+
+	return *step.Amount
+}
+
+// DefaultImagePreprocessingSteps is the pipeline used by settings that don't define their own
+func DefaultImagePreprocessingSteps(ocrEngine *OcrEngine) []ImagePreprocessingStep {
+	blurSigma := 1.5
+	sharpenSigma := 1.0
+	deskewThreshold := 0.40
+
+	steps := []ImagePreprocessingStep{
+		{Type: PREPROCESS_TRIM},
+		{Type: PREPROCESS_BILEVEL},
+		{Type: PREPROCESS_BLUR, Amount: &blurSigma},
+		{Type: PREPROCESS_SHARPEN, Amount: &sharpenSigma},
+		{Type: PREPROCESS_ENHANCE},
+		{Type: PREPROCESS_CONTRAST},
+		{Type: PREPROCESS_DESKEW, Amount: &deskewThreshold},
+	}
+
+	if ocrEngine != nil && *ocrEngine == EASY_OCR_NEW {
+		scalePercent := 50.0
+		steps = append(steps, ImagePreprocessingStep{Type: PREPROCESS_SCALE, Amount: &scalePercent})
+	}
+
+	return steps
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldUseDefaultPreprocessingStepsWhenNoneAreSet(t *testing.T) {
+	tesseract := TESSERACT_NEW
+	easyOcr := EASY_OCR_NEW
+
+	tesseractSteps := ReceiptProcessingSettings{OcrEngine: &tesseract}.GetPreprocessingSteps()
+	if len(tesseractSteps) != 7 {
+		utils.PrintTestError(t, len(tesseractSteps), 7)
+	}
+
+	easyOcrSteps := ReceiptProcessingSettings{OcrEngine: &easyOcr}.GetPreprocessingSteps()
+	lastStep := easyOcrSteps[len(easyOcrSteps)-1]
+	if lastStep.Type != PREPROCESS_SCALE || lastStep.GetAmount(0) != 50 {
+		utils.PrintTestError(t, lastStep, "scale to 50 percent")
+	}
+}
+
+func TestShouldUseConfiguredPreprocessingSteps(t *testing.T) {
+	settings := ReceiptProcessingSettings{
+		PreprocessingSteps: []ImagePreprocessingStep{{Type: PREPROCESS_GRAYSCALE}},
+	}
+
+	steps := settings.GetPreprocessingSteps()
+	if len(steps) != 1 || steps[0].Type != PREPROCESS_GRAYSCALE {
+		utils.PrintTestError(t, steps, "grayscale only")
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+type ImageSize string
+
+const (
+	IMAGE_SIZE_THUMB    ImageSize = "thumb"
+	IMAGE_SIZE_MEDIUM   ImageSize = "medium"
+	IMAGE_SIZE_ORIGINAL ImageSize = "original"
+)
+
+func (self ImageSize) IsValid() bool {
+	return self == IMAGE_SIZE_THUMB || self == IMAGE_SIZE_MEDIUM || self == IMAGE_SIZE_ORIGINAL
+}
+
+// GetMaxDimension returns the longest edge of a preview in pixels, originals aren't resized
+func (self ImageSize) GetMaxDimension() uint {
+	switch self {
+	case IMAGE_SIZE_THUMB:
+		return 256
+	case IMAGE_SIZE_MEDIUM:
+		return 1024
+	}
+
+	return 0
+}
+
+func GetPreviewImageSizes() []ImageSize {
+	return []ImageSize{IMAGE_SIZE_THUMB, IMAGE_SIZE_MEDIUM}
+}
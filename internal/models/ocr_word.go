@@ -0,0 +1,7 @@
+package models
+
+// OcrWord is a single word recognized by the OCR engine with its confidence from 0 to 100
+type OcrWord struct {
+	Word       string  `json:"word"`
+	Confidence float64 `json:"confidence"`
+}
@@ -18,6 +18,56 @@ type ReceiptProcessingSettings struct {
 	Prompt        Prompt       `json:"prompt"`
 	PromptId      uint         `json:"promptId"`
 	IsVisionModel bool         `json:"isVisionModel"`
+	// Empty means the default pipeline is used
+	PreprocessingSteps []ImagePreprocessingStep `gorm:"type:text; serializer:json" json:"preprocessingSteps"`
+	// Language codes of the ocr engine, e.g. deu and fra for tesseract or de and fr for easyocr
+	OcrLanguages []string `gorm:"type:text; serializer:json" json:"ocrLanguages"`
+	// Tesseract page segmentation mode, nil uses tesseract's default
+	OcrPageSegMode   *int   `json:"ocrPageSegMode"`
+	OcrCharWhitelist string `json:"ocrCharWhitelist"`
+	// Nil uses the default blacklist, an empty string disables it
+	OcrCharBlacklist *string `json:"ocrCharBlacklist"`
+	// Words below this confidence are flagged in the prompt, nil uses the default
+	OcrConfidenceThreshold *float64 `json:"ocrConfidenceThreshold"`
+}
+
+const DefaultOcrCharBlacklist = "!@#$%^&*()_+=-[]}{;:'\"\\|~`<>/?"
+const DefaultOcrConfidenceThreshold = 60.0
+
+func (settings ReceiptProcessingSettings) GetOcrLanguages() []string {
+	if len(settings.OcrLanguages) > 0 {
+		return settings.OcrLanguages
+	}
+
+	if settings.OcrEngine != nil && *settings.OcrEngine == EASY_OCR_NEW {
+		return []string{"en"}
+	}
+
+	return []string{"eng"}
+}
+
+func (settings ReceiptProcessingSettings) GetOcrCharBlacklist() string {
+	if settings.OcrCharBlacklist == nil {
+		return DefaultOcrCharBlacklist
+	}
+
+	return *settings.OcrCharBlacklist
+}
+
+func (settings ReceiptProcessingSettings) GetOcrConfidenceThreshold() float64 {
+	if settings.OcrConfidenceThreshold == nil {
+		return DefaultOcrConfidenceThreshold
+	}
+
+	return *settings.OcrConfidenceThreshold
+}
+
+func (settings ReceiptProcessingSettings) GetPreprocessingSteps() []ImagePreprocessingStep {
+	if len(settings.PreprocessingSteps) == 0 {
+		return DefaultImagePreprocessingSteps(settings.OcrEngine)
+	}
+
+	return settings.PreprocessingSteps
 }
 
 func (ReceiptProcessingSettings *ReceiptProcessingSettings) LoadDataFromRequest(w http.ResponseWriter, r *http.Request) error {
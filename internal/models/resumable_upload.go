@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ResumableUpload tracks a tus upload while its chunks are assembled in the temp directory.
+// Uploads with a receipt are attached to it when complete, the others are quick scanned.
+type ResumableUpload struct {
+	BaseModel
+	UploadId     string        `gorm:"uniqueIndex; not null" json:"uploadId"`
+	UserId       uint          `gorm:"not null" json:"userId"`
+	UploadLength int64         `gorm:"not null" json:"uploadLength"`
+	UploadOffset int64         `gorm:"not null; default:0" json:"uploadOffset"`
+	FileName     string        `json:"fileName"`
+	Metadata     string        `json:"metadata"`
+	ReceiptId    *uint         `json:"receiptId"`
+	GroupId      *uint         `json:"groupId"`
+	PaidByUserId *uint         `json:"paidByUserId"`
+	Status       ReceiptStatus `json:"status"`
+	ExpiresAt    time.Time     `gorm:"index" json:"expiresAt"`
+	CompletedAt  *time.Time    `json:"completedAt"`
+}
+
+func (upload ResumableUpload) IsComplete() bool {
+	return upload.UploadOffset == upload.UploadLength
+}
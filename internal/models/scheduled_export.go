@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ScheduledExport exports the receipts of a group matching a filter on a schedule, runs are recorded as system tasks
+type ScheduledExport struct {
+	BaseModel
+	UserId              uint                  `gorm:"not null" json:"userId"`
+	GroupId             uint                  `gorm:"not null; index" json:"groupId"`
+	Name                string                `gorm:"not null" json:"name"`
+	Filter              json.RawMessage       `gorm:"type:text; serializer:json" json:"filter"`
+	Format              ExportFormat          `gorm:"not null" json:"format"`
+	IncludeCustomFields bool                  `json:"includeCustomFields"`
+	IncludeComments     bool                  `json:"includeComments"`
+	IncludeImages       bool                  `json:"includeImages"`
+	Schedule            string                `gorm:"not null" json:"schedule"`
+	DestinationType     ExportDestinationType `gorm:"not null" json:"destinationType"`
+	Destination         string                `gorm:"not null" json:"destination"`
+	WebDavUsername      string                `json:"webDavUsername"`
+	WebDavPassword      string                `json:"-"`
+	Enabled             bool                  `json:"enabled"`
+	LastRunAt           *time.Time            `json:"lastRunAt"`
+}
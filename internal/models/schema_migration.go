@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SchemaMigration records an applied database migration
+type SchemaMigration struct {
+	Version   uint      `gorm:"primarykey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"not null" json:"name"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
@@ -17,4 +17,16 @@ type SystemSettings struct {
 	FallbackReceiptProcessingSettingsId *uint                     `json:"fallbackReceiptProcessingSettingsId"`
 	TaskConcurrency                     int                       `json:"taskConcurrency" gorm:"default:10"`
 	TaskQueueConfigurations             []TaskQueueConfiguration  `json:"taskQueueConfigurations"`
+	AuditLogRetentionDays               int                       `json:"auditLogRetentionDays" gorm:"default:365"`
+	PdfRenderDpi                        int                       `json:"pdfRenderDpi" gorm:"default:200"`
+}
+
+const DefaultPdfRenderDpi = 200
+
+func (settings SystemSettings) GetPdfRenderDpi() int {
+	if settings.PdfRenderDpi <= 0 {
+		return DefaultPdfRenderDpi
+	}
+
+	return settings.PdfRenderDpi
 }
@@ -23,6 +23,8 @@ type SystemTask struct {
 	ChildSystemTasks       []*SystemTask        `gorm:"foreignKey:AssociatedSystemTaskId" json:"childSystemTasks"`
 	AsynqTaskId            string               `json:"asynqTaskId"`
 	ApiKeyId               *string              `json:"apiKeyId"`
+	// Word confidences of OCR_PROCESSING tasks
+	OcrWords []OcrWord `gorm:"type:text; serializer:json" json:"ocrWords,omitempty"`
 }
 
 type SystemTaskStatus string
@@ -59,6 +61,10 @@ const (
 	PROMPT_GENERATED                               SystemTaskType = "PROMPT_GENERATED"
 	RECEIPT_UPDATED                                SystemTaskType = "RECEIPT_UPDATED"
 	API_KEY_DELETED                                SystemTaskType = "API_KEY_DELETED"
+	STORAGE_INTEGRITY_CHECK                        SystemTaskType = "STORAGE_INTEGRITY_CHECK"
+	RECEIPT_CSV_IMPORT                             SystemTaskType = "RECEIPT_CSV_IMPORT"
+	EXPENSE_REPORT                                 SystemTaskType = "EXPENSE_REPORT"
+	SCHEDULED_EXPORT                               SystemTaskType = "SCHEDULED_EXPORT"
 )
 
 func (self *SystemTaskType) Scan(value string) error {
@@ -78,7 +84,11 @@ func (self SystemTaskType) Value() (driver.Value, error) {
 		self != RECEIPT_UPLOADED &&
 		self != PROMPT_GENERATED &&
 		self != RECEIPT_UPDATED &&
-		self != API_KEY_DELETED {
+		self != API_KEY_DELETED &&
+		self != STORAGE_INTEGRITY_CHECK &&
+		self != RECEIPT_CSV_IMPORT &&
+		self != EXPENSE_REPORT &&
+		self != SCHEDULED_EXPORT {
 		return nil, errors.New("invalid SystemTaskType")
 	}
 	return string(self), nil
@@ -2,6 +2,8 @@ package models
 
 type Tag struct {
 	BaseModel
-	Name        string `gorm:"not null; uniqueIndex" json:"name"`
+	Name        string `gorm:"not null; uniqueIndex:idx_tag_name_group_id" json:"name"`
 	Description string `json:"description"`
+	// Nil means the tag is global and shared by every group
+	GroupId *uint `gorm:"uniqueIndex:idx_tag_name_group_id" json:"groupId"`
 }
@@ -28,7 +28,11 @@ func (repository ApiKeyRepository) CreateApiKey(apiKey models.ApiKey) (models.Ap
 
 func (repository ApiKeyRepository) GetApiKeyById(id string) (models.ApiKey, error) {
 	var apiKey models.ApiKey
-	err := repository.GetDB().Where("id = ?", id).First(&apiKey).Error
+	err := repository.GetDB().
+		Preload("AllowedGroups").
+		Preload("ResourceScopes").
+		Where("id = ?", id).
+		First(&apiKey).Error
 	return apiKey, err
 }
 
@@ -51,6 +55,7 @@ func (repository ApiKeyRepository) GetPagedApiKeys(command commands.PagedApiKeyR
 
 	query = repository.Sort(query, command.OrderBy, command.SortDirection)
 	query = query.Scopes(repository.Paginate(command.Page, command.PageSize))
+	query = query.Preload("AllowedGroups").Preload("ResourceScopes")
 
 	err := query.Find(&results).Error
 	if err != nil {
@@ -77,9 +82,144 @@ func (repository ApiKeyRepository) UpdateApiKey(id string, userId uint, name, de
 	return err
 }
 
+func (repository ApiKeyRepository) UpdateApiKeyRestrictions(
+	id string,
+	expiresAt *time.Time,
+	allowedGroupIds []uint,
+	resourceScopes []models.ApiKeyResourceScope,
+) error {
+	db := repository.GetDB()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		txApiKeyRepository := NewApiKeyRepository(tx)
+
+		err := txApiKeyRepository.UpdateApiKeyExpiresAt(id, expiresAt)
+		if err != nil {
+			return err
+		}
+
+		err = txApiKeyRepository.ReplaceApiKeyAllowedGroups(id, allowedGroupIds)
+		if err != nil {
+			return err
+		}
+
+		return txApiKeyRepository.ReplaceApiKeyResourceScopes(id, resourceScopes)
+	})
+}
+
+func (repository ApiKeyRepository) UpdateApiKeyExpiresAt(id string, expiresAt *time.Time) error {
+	db := repository.GetDB()
+
+	var existingApiKey models.ApiKey
+	err := db.Model(&models.ApiKey{}).Where("id = ?", id).Select("expires_at").First(&existingApiKey).Error
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"expires_at": expiresAt,
+	}
+
+	// A new expiry date deserves a new expiry notification
+	if !timesEqual(existingApiKey.ExpiresAt, expiresAt) {
+		updates["expiry_notified_at"] = nil
+	}
+
+	return db.Model(&models.ApiKey{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (repository ApiKeyRepository) ReplaceApiKeyAllowedGroups(id string, allowedGroupIds []uint) error {
+	db := repository.GetDB()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("api_key_id = ?", id).Delete(&models.ApiKeyGroup{}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, groupId := range allowedGroupIds {
+			err = tx.Create(&models.ApiKeyGroup{ApiKeyId: id, GroupId: groupId}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repository ApiKeyRepository) ReplaceApiKeyResourceScopes(id string, resourceScopes []models.ApiKeyResourceScope) error {
+	db := repository.GetDB()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("api_key_id = ?", id).Delete(&models.ApiKeyResourceGrant{}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, resourceScope := range resourceScopes {
+			err = tx.Create(&models.ApiKeyResourceGrant{ApiKeyId: id, ResourceScope: resourceScope}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repository ApiKeyRepository) RotateApiKeyHmac(id string, hmac string, previousHmacExpiresAt time.Time) error {
+	db := repository.GetDB()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var existingApiKey models.ApiKey
+		err := tx.Model(&models.ApiKey{}).Where("id = ?", id).Select("hmac").First(&existingApiKey).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&models.ApiKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"hmac":                     hmac,
+			"previous_hmac":            existingApiKey.Hmac,
+			"previous_hmac_expires_at": previousHmacExpiresAt,
+			"rotated_at":               now,
+		}).Error
+	})
+}
+
+func (repository ApiKeyRepository) GetApiKeysExpiringBefore(date time.Time) ([]models.ApiKey, error) {
+	var apiKeys []models.ApiKey
+
+	err := repository.GetDB().
+		Model(&models.ApiKey{}).
+		Where("expires_at IS NOT NULL AND expires_at > ? AND expires_at <= ?", time.Now(), date).
+		Where("expiry_notified_at IS NULL AND user_id IS NOT NULL").
+		Find(&apiKeys).Error
+
+	return apiKeys, err
+}
+
+func (repository ApiKeyRepository) UpdateApiKeyExpiryNotifiedAt(id string, notifiedAt time.Time) error {
+	return repository.GetDB().Model(&models.ApiKey{}).Where("id = ?", id).Update("expiry_notified_at", notifiedAt).Error
+}
+
 func (repository ApiKeyRepository) DeleteApiKey(id string) error {
-	err := repository.GetDB().Where("id = ?", id).Delete(&models.ApiKey{}).Error
-	return err
+	db := repository.GetDB()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("api_key_id = ?", id).Delete(&models.ApiKeyGroup{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("api_key_id = ?", id).Delete(&models.ApiKeyResourceGrant{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", id).Delete(&models.ApiKey{}).Error
+	})
 }
 
 func (repository ApiKeyRepository) isValidColumn(orderBy string) bool {
@@ -88,5 +228,14 @@ func (repository ApiKeyRepository) isValidColumn(orderBy string) bool {
 		orderBy == "description" ||
 		orderBy == "created_at" ||
 		orderBy == "updated_at" ||
-		orderBy == "last_used_at"
+		orderBy == "last_used_at" ||
+		orderBy == "expires_at"
+}
+
+func timesEqual(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
 }
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"errors"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository struct {
+	BaseRepository
+}
+
+func NewAuditLogRepository(tx *gorm.DB) AuditLogRepository {
+	repository := AuditLogRepository{BaseRepository: BaseRepository{
+		DB: GetDB(),
+		TX: tx,
+	}}
+	return repository
+}
+
+func (repository AuditLogRepository) CreateAuditLog(auditLog models.AuditLog) (models.AuditLog, error) {
+	db := repository.GetDB()
+
+	err := db.Create(&auditLog).Error
+	if err != nil {
+		return models.AuditLog{}, err
+	}
+
+	return auditLog, nil
+}
+
+// HasAuditLogSince returns whether the action was recorded for the entity since the given time
+func (repository AuditLogRepository) HasAuditLogSince(action models.AuditAction, entityId string, since time.Time) (bool, error) {
+	var count int64
+	err := repository.GetDB().Model(&models.AuditLog{}).
+		Where("action = ? AND entity_id = ? AND created_at >= ?", action, entityId, since).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+func (repository AuditLogRepository) GetPagedAuditLogs(command commands.PagedAuditLogRequestCommand) ([]models.AuditLog, int64, error) {
+	db := repository.GetDB()
+	var results []models.AuditLog
+	var count int64
+
+	if !repository.isValidColumn(command.OrderBy) {
+		return nil, 0, errors.New("invalid column")
+	}
+
+	query := db.Model(&models.AuditLog{})
+	filter := command.Filter
+
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+
+	if filter.ActorUserId != nil {
+		query = query.Where("actor_user_id = ?", *filter.ActorUserId)
+	}
+
+	if len(filter.EntityType) > 0 {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+
+	if len(filter.EntityId) > 0 {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+
+	if filter.GroupId != nil {
+		query = query.Where("group_id = ?", *filter.GroupId)
+	}
+
+	if len(filter.IpAddress) > 0 {
+		query = query.Where("ip_address = ?", filter.IpAddress)
+	}
+
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", *filter.StartDate)
+	}
+
+	if filter.EndDate != nil {
+		query = query.Where("created_at <= ?", *filter.EndDate)
+	}
+
+	err := query.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(command.OrderBy) == 0 {
+		query = query.Order("created_at desc")
+	} else {
+		query = repository.Sort(query, command.OrderBy, command.SortDirection)
+	}
+	query = query.Scopes(repository.Paginate(command.Page, command.PageSize))
+
+	err = query.Find(&results).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, count, nil
+}
+
+func (repository AuditLogRepository) DeleteAuditLogsCreatedBefore(date time.Time) (int64, error) {
+	db := repository.GetDB()
+
+	result := db.Where("created_at < ?", date).Delete(&models.AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
+func (repository AuditLogRepository) isValidColumn(orderBy string) bool {
+	return orderBy == "" ||
+		orderBy == "created_at" ||
+		orderBy == "action" ||
+		orderBy == "actor_user_id" ||
+		orderBy == "ip_address" ||
+		orderBy == "entity_type" ||
+		orderBy == "entity_id" ||
+		orderBy == "group_id"
+}
@@ -69,3 +69,34 @@ func (repository BaseRepository) GetCount(table string, queryWhere string) (int6
 
 	return result, err
 }
+
+// GroupScope limits a query to global rows and rows belonging to one of groupIds. A nil slice applies no filter.
+func (repository BaseRepository) GroupScope(table string, groupIds []uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if groupIds == nil {
+			return db
+		}
+
+		if len(groupIds) == 0 {
+			return db.Where(table + ".group_id IS NULL")
+		}
+
+		return db.Where("("+table+".group_id IS NULL OR "+table+".group_id IN ?)", groupIds)
+	}
+}
+
+// CountOutsideGroup counts the rows in ids that are neither global nor belong to groupId.
+func (repository BaseRepository) CountOutsideGroup(table string, ids []uint, groupId uint) (int64, error) {
+	db := repository.GetDB()
+	var result int64
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := db.Table(table).
+		Where("id IN ? AND group_id IS NOT NULL AND group_id <> ?", ids, groupId).
+		Count(&result).Error
+
+	return result, err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
 	"gorm.io/gorm"
 	"receipt-wrangler/api/internal/commands"
@@ -19,11 +20,15 @@ func NewCategoryRepository(tx *gorm.DB) CategoryRepository {
 	return repository
 }
 
-func (repository CategoryRepository) GetAllCategories(querySelect string) ([]models.Category, error) {
+// GetAllCategories returns the global categories and those of groupIds, or every category when groupIds is nil
+func (repository CategoryRepository) GetAllCategories(querySelect string, groupIds []uint) ([]models.Category, error) {
 	db := repository.GetDB()
 	var categories []models.Category
 
-	err := db.Table("categories").Select(querySelect).Find(&categories).Error
+	err := db.Table("categories").
+		Select(querySelect).
+		Scopes(repository.GroupScope("categories", groupIds)).
+		Find(&categories).Error
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +39,12 @@ func (repository CategoryRepository) GetAllCategories(querySelect string) ([]mod
 func (repository CategoryRepository) CreateCategory(category models.Category) (models.Category, error) {
 	db := repository.GetDB()
 
-	err := db.Model(&category).Create(&category).Error
+	err := repository.validateNameIsAvailable(category.Name, category.GroupId, 0)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	err = db.Model(&category).Create(&category).Error
 	if err != nil {
 		return models.Category{}, err
 	}
@@ -42,7 +52,7 @@ func (repository CategoryRepository) CreateCategory(category models.Category) (m
 	return category, nil
 }
 
-func (repository CategoryRepository) GetAllPagedCategories(pagedRequestCommand commands.PagedRequestCommand) ([]models.CategoryView, error) {
+func (repository CategoryRepository) GetAllPagedCategories(pagedRequestCommand commands.PagedRequestCommand, groupIds []uint) ([]models.CategoryView, error) {
 	db := repository.GetDB()
 	var categories []models.CategoryView
 	quotedAlias := "\"NumberOfReceipts\""
@@ -53,11 +63,12 @@ func (repository CategoryRepository) GetAllPagedCategories(pagedRequestCommand c
 
 	query := repository.Sort(db, pagedRequestCommand.OrderBy, pagedRequestCommand.SortDirection)
 	query = query.Scopes(repository.Paginate(pagedRequestCommand.Page, pagedRequestCommand.PageSize))
-	selectString := fmt.Sprintf("categories.id, categories.name, categories.description,  COUNT(DISTINCT receipt_categories.receipt_id) as %s", quotedAlias)
+	selectString := fmt.Sprintf("categories.id, categories.name, categories.description, categories.group_id, COUNT(DISTINCT receipt_categories.receipt_id) as %s", quotedAlias)
 	query = query.Table("categories").
 		Select(selectString).
 		Joins("LEFT JOIN receipt_categories ON categories.id = receipt_categories.category_id").
-		Group("categories.id, categories.name")
+		Scopes(repository.GroupScope("categories", groupIds)).
+		Group("categories.id, categories.name, categories.description, categories.group_id")
 
 	err := query.Scan(&categories).Error
 	if err != nil {
@@ -69,8 +80,22 @@ func (repository CategoryRepository) GetAllPagedCategories(pagedRequestCommand c
 
 func (repository CategoryRepository) UpdateCategory(categoryToUpdate models.Category, querySelect string) (models.Category, error) {
 	db := repository.GetDB()
+	var existingCategory models.Category
 
-	err := db.Model(models.Category{}).Where("id = ?", categoryToUpdate.ID).Updates(map[string]interface{}{"name": categoryToUpdate.Name, "description": categoryToUpdate.Description}).Error
+	err := db.Model(models.Category{}).Where("id = ?", categoryToUpdate.ID).First(&existingCategory).Error
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	// The group a category belongs to is fixed at creation
+	categoryToUpdate.GroupId = existingCategory.GroupId
+
+	err = repository.validateNameIsAvailable(categoryToUpdate.Name, existingCategory.GroupId, existingCategory.ID)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	err = db.Model(models.Category{}).Where("id = ?", categoryToUpdate.ID).Updates(map[string]interface{}{"name": categoryToUpdate.Name, "description": categoryToUpdate.Description}).Error
 	if err != nil {
 		return models.Category{}, err
 	}
@@ -87,6 +112,11 @@ func (repository CategoryRepository) DeleteCategory(categoryId uint) error {
 			return err
 		}
 
+		err = tx.Where("category_id = ?", categoryId).Delete(&models.LedgerCategoryAccount{}).Error
+		if err != nil {
+			return err
+		}
+
 		err = tx.Where("id = ?", categoryId).Delete(&models.Category{}).Error
 		if err != nil {
 			return err
@@ -100,3 +130,64 @@ func (repository CategoryRepository) DeleteCategory(categoryId uint) error {
 
 	return nil
 }
+
+func (repository CategoryRepository) DeleteCategoriesByGroupId(groupId uint) error {
+	db := repository.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var categoryIds []uint
+		err := tx.Model(&models.Category{}).Where("group_id = ?", groupId).Pluck("id", &categoryIds).Error
+		if err != nil || len(categoryIds) == 0 {
+			return err
+		}
+
+		err = tx.Where("category_id IN ?", categoryIds).Delete(&models.ReceiptCategory{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("DELETE FROM item_categories WHERE category_id IN ?", categoryIds).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("category_id IN ?", categoryIds).Delete(&models.LedgerCategoryAccount{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("group_id = ?", groupId).Delete(&models.Category{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository CategoryRepository) CountCategoriesOutsideGroup(categoryIds []uint, groupId uint) (int64, error) {
+	return repository.CountOutsideGroup("categories", categoryIds, groupId)
+}
+
+func (repository CategoryRepository) validateNameIsAvailable(name string, groupId *uint, excludeId uint) error {
+	db := repository.GetDB()
+	var count int64
+
+	query := db.Model(&models.Category{}).Where("name = ? AND id <> ?", name, excludeId)
+	if groupId == nil {
+		query = query.Where("group_id IS NULL")
+	} else {
+		query = query.Where("group_id = ?", *groupId)
+	}
+
+	err := query.Count(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return errors.New("category with this name already exists")
+	}
+
+	return nil
+}
@@ -21,6 +21,7 @@ func NewCustomFieldRepository(tx *gorm.DB) CustomFieldRepository {
 
 func (repository CustomFieldRepository) GetPagedCustomFields(
 	pagedRequestCommand commands.PagedRequestCommand,
+	groupIds []uint,
 ) ([]models.CustomField, int64, error) {
 	db := repository.GetDB()
 	var customFields []models.CustomField
@@ -33,13 +34,18 @@ func (repository CustomFieldRepository) GetPagedCustomFields(
 	query := repository.Sort(db, pagedRequestCommand.OrderBy, pagedRequestCommand.SortDirection)
 	query = query.Scopes(repository.Paginate(pagedRequestCommand.Page, pagedRequestCommand.PageSize))
 
-	err = query.Model(&models.CustomField{}).Preload("Options").Find(&customFields).Error
+	err = query.Model(&models.CustomField{}).
+		Scopes(repository.GroupScope("custom_fields", groupIds)).
+		Preload("Options").
+		Find(&customFields).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	var count int64
-	err = db.Model(&models.CustomField{}).Count(&count).Error
+	err = db.Model(&models.CustomField{}).
+		Scopes(repository.GroupScope("custom_fields", groupIds)).
+		Count(&count).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -70,6 +76,7 @@ func (repository CustomFieldRepository) CreateCustomField(
 		Type:        command.Type,
 		Description: command.Description,
 		Options:     options,
+		GroupId:     command.GroupId,
 	}
 
 	err := db.Create(&customFieldToCreate).Error
@@ -120,6 +127,29 @@ func (repository CustomFieldRepository) DeleteCustomField(id uint) error {
 	return nil
 }
 
+func (repository CustomFieldRepository) DeleteCustomFieldsByGroupId(groupId uint) error {
+	db := repository.GetDB()
+	var customFieldIds []uint
+
+	err := db.Model(&models.CustomField{}).Where("group_id = ?", groupId).Pluck("id", &customFieldIds).Error
+	if err != nil {
+		return err
+	}
+
+	for _, customFieldId := range customFieldIds {
+		err = repository.DeleteCustomField(customFieldId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repository CustomFieldRepository) CountCustomFieldsOutsideGroup(customFieldIds []uint, groupId uint) (int64, error) {
+	return repository.CountOutsideGroup("custom_fields", customFieldIds, groupId)
+}
+
 func (repository CustomFieldRepository) validateOrderBy(orderBy string) error {
 	if orderBy != "name" && orderBy != "type" && orderBy != "description" {
 		return errors.New("invalid orderBy")
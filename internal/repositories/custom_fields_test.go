@@ -169,7 +169,7 @@ func TestShouldGetPagedCustomFieldsWithDefaultSorting(t *testing.T) {
 		SortDirection: commands.ASCENDING,
 	}
 
-	customFields, count, err := repository.GetPagedCustomFields(pagedRequest)
+	customFields, count, err := repository.GetPagedCustomFields(pagedRequest, nil)
 	if err != nil {
 		utils.PrintTestError(t, err, nil)
 		return
@@ -208,7 +208,7 @@ func TestShouldGetPagedCustomFieldsWithLimit(t *testing.T) {
 		SortDirection: commands.ASCENDING,
 	}
 
-	customFields, count, err := repository.GetPagedCustomFields(pagedRequest)
+	customFields, count, err := repository.GetPagedCustomFields(pagedRequest, nil)
 	if err != nil {
 		utils.PrintTestError(t, err, nil)
 		return
@@ -239,7 +239,7 @@ func TestShouldGetPagedCustomFieldsWithSecondPage(t *testing.T) {
 		SortDirection: commands.ASCENDING,
 	}
 
-	customFields, count, err := repository.GetPagedCustomFields(pagedRequest)
+	customFields, count, err := repository.GetPagedCustomFields(pagedRequest, nil)
 	if err != nil {
 		utils.PrintTestError(t, err, nil)
 		return
@@ -279,7 +279,7 @@ func TestShouldGetPagedCustomFieldsWithDescendingOrder(t *testing.T) {
 		SortDirection: commands.DESCENDING,
 	}
 
-	customFields, count, err := repository.GetPagedCustomFields(pagedRequest)
+	customFields, count, err := repository.GetPagedCustomFields(pagedRequest, nil)
 	if err != nil {
 		utils.PrintTestError(t, err, nil)
 		return
@@ -313,7 +313,7 @@ func TestShouldReturnErrorWithInvalidOrderBy(t *testing.T) {
 		SortDirection: commands.ASCENDING,
 	}
 
-	_, _, err := repository.GetPagedCustomFields(pagedRequest)
+	_, _, err := repository.GetPagedCustomFields(pagedRequest, nil)
 
 	// Should return an error
 	if err == nil {
@@ -3,6 +3,7 @@ package repositories
 import (
 	"fmt"
 	config "receipt-wrangler/api/internal/env"
+	"receipt-wrangler/api/internal/migrations"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
@@ -43,79 +44,42 @@ func Connect() error {
 		return err
 	}
 
-	dbEngine := dbConfig.Engine
+	connectedDb, err := OpenDatabase(dbConfig)
+	if err != nil {
+		return err
+	}
 
-	var connectedDb *gorm.DB
+	db = connectedDb
+	return nil
+}
+
+// OpenDatabase opens a connection for the engine in the config without making it the app's database
+func OpenDatabase(dbConfig structs.DatabaseConfig) (*gorm.DB, error) {
+	dbEngine := dbConfig.Engine
 
 	if dbEngine == "mariadb" || dbEngine == "mysql" {
-		connectedDb, err = gorm.Open(mysql.Open(BuildMariaDbConnectionString(dbConfig)), &gorm.Config{})
-		if err != nil {
-			return err
-		}
+		return gorm.Open(mysql.Open(BuildMariaDbConnectionString(dbConfig)), &gorm.Config{})
 	}
 
 	if dbEngine == "postgresql" {
-		connectedDb, err = gorm.Open(postgres.Open(BuildPostgresqlConnectionString(dbConfig)), &gorm.Config{})
-		if err != nil {
-			return err
-		}
+		return gorm.Open(postgres.Open(BuildPostgresqlConnectionString(dbConfig)), &gorm.Config{})
 	}
 
 	if dbEngine == "sqlite" {
 		connectionString, err := BuildSqliteConnectionString(dbConfig)
 		if err != nil {
-			return err
-		}
-
-		connectedDb, err = gorm.Open(sqlite.Open(connectionString), &gorm.Config{})
-		if err != nil {
-			return err
-
+			return nil, err
 		}
-	}
 
-	if connectedDb == nil {
-		return fmt.Errorf("database engine of: %s! check your config to make sure it is correct", dbEngine)
+		return gorm.Open(sqlite.Open(connectionString), &gorm.Config{})
 	}
 
-	db = connectedDb
-	return nil
+	return nil, fmt.Errorf("database engine of: %s! check your config to make sure it is correct", dbEngine)
 }
 
+// MakeMigrations applies pending migrations, it refuses databases migrated by a newer version
 func MakeMigrations() error {
-	err := db.AutoMigrate(
-		&models.RefreshToken{},
-		&models.User{},
-		&models.CustomField{},
-		&models.CustomFieldValue{},
-		&models.CustomFieldOption{},
-		&models.Receipt{},
-		&models.Item{},
-		&models.FileData{},
-		&models.Tag{},
-		&models.Category{},
-		&models.Group{},
-		&models.GroupMember{},
-		&models.Comment{},
-		&models.Notification{},
-		&models.UserShortcut{},
-		&models.UserPrefernces{},
-		&models.SubjectLineRegex{},
-		&models.GroupSettingsWhiteListEmail{},
-		&models.GroupSettings{},
-		&models.Dashboard{},
-		&models.Widget{},
-		&models.TaskQueueConfiguration{},
-		&models.SystemSettings{},
-		&models.SystemEmail{},
-		&models.SystemTask{},
-		&models.ReceiptProcessingSettings{},
-		&models.Prompt{},
-		&models.GroupReceiptSettings{},
-		&models.Pepper{},
-		&models.ApiKey{},
-	)
-
+	_, err := migrations.Up(db)
 	return err
 }
 
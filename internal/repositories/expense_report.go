@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"path"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExpenseReportRepository struct {
+	BaseRepository
+}
+
+func NewExpenseReportRepository(tx *gorm.DB) ExpenseReportRepository {
+	repository := ExpenseReportRepository{BaseRepository: BaseRepository{
+		DB: GetDB(),
+		TX: tx,
+	}}
+	return repository
+}
+
+// BuildExpenseReportKey returns the storage key of a report's pdf
+func (repository ExpenseReportRepository) BuildExpenseReportKey(expenseReport models.ExpenseReport) string {
+	return path.Join("reports", utils.UintToString(expenseReport.ID)+".pdf")
+}
+
+func (repository ExpenseReportRepository) CreateExpenseReport(expenseReport models.ExpenseReport) (models.ExpenseReport, error) {
+	db := repository.GetDB()
+
+	err := db.Create(&expenseReport).Error
+	if err != nil {
+		return models.ExpenseReport{}, err
+	}
+
+	return expenseReport, nil
+}
+
+func (repository ExpenseReportRepository) GetExpenseReportById(id string) (models.ExpenseReport, error) {
+	db := repository.GetDB()
+	var expenseReport models.ExpenseReport
+
+	err := db.Model(&models.ExpenseReport{}).Where("id = ?", id).First(&expenseReport).Error
+	if err != nil {
+		return models.ExpenseReport{}, err
+	}
+
+	return expenseReport, nil
+}
+
+func (repository ExpenseReportRepository) GetExpenseReportsExpiredBefore(cutOff time.Time) ([]models.ExpenseReport, error) {
+	db := repository.GetDB()
+	var expenseReports []models.ExpenseReport
+
+	err := db.Model(&models.ExpenseReport{}).Where("expires_at < ?", cutOff).Find(&expenseReports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return expenseReports, nil
+}
+
+func (repository ExpenseReportRepository) DeleteExpenseReport(id uint) error {
+	db := repository.GetDB()
+
+	return db.Delete(&models.ExpenseReport{}, id).Error
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"errors"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FileBlobRepository struct {
+	BaseRepository
+}
+
+func NewFileBlobRepository(tx *gorm.DB) FileBlobRepository {
+	repository := FileBlobRepository{BaseRepository: BaseRepository{
+		DB: GetDB(),
+		TX: tx,
+	}}
+	return repository
+}
+
+// StoreBlob adds a reference to the blob holding fileBytes, the bytes are only written when storage doesn't have them yet.
+// The reference is counted before storage is checked, so the row stays locked until the file is written and a concurrent
+// DeleteBlobIfUnreferenced either finishes first or sees the new reference. The returned bool tells whether the blob was already stored.
+func (repository FileBlobRepository) StoreBlob(fileBytes []byte) (models.FileBlob, bool, error) {
+	hash := utils.Sha256Hash(fileBytes)
+	fileBlob := models.FileBlob{
+		Hash:     hash,
+		Size:     uint(len(fileBytes)),
+		RefCount: 1,
+	}
+	exists := false
+
+	err := repository.GetDB().Transaction(func(tx *gorm.DB) error {
+		fileRepository := NewFileRepository(tx)
+		key := fileRepository.BuildBlobKey(hash)
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("file_blobs.ref_count + 1")}),
+		}).Create(&fileBlob).Error
+		if err != nil {
+			return err
+		}
+
+		exists, err = fileRepository.FileExists(key)
+		if err != nil || exists {
+			return err
+		}
+
+		return fileRepository.WriteFile(key, fileBytes)
+	})
+	if err != nil {
+		return models.FileBlob{}, false, err
+	}
+
+	return fileBlob, exists, nil
+}
+
+func (repository FileBlobRepository) GetFileBlobByHash(hash string) (models.FileBlob, error) {
+	db := repository.GetDB()
+	var fileBlob models.FileBlob
+
+	err := db.Model(&models.FileBlob{}).Where("hash = ?", hash).First(&fileBlob).Error
+	if err != nil {
+		return models.FileBlob{}, err
+	}
+
+	return fileBlob, nil
+}
+
+func (repository FileBlobRepository) AddReference(hash string) error {
+	db := repository.GetDB()
+
+	result := db.Model(&models.FileBlob{}).Where("hash = ?", hash).Update("ref_count", gorm.Expr("ref_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("file blob not found")
+	}
+
+	return nil
+}
+
+// ReleaseReference drops one reference to a blob, the row is kept so files are only removed by DeleteBlobIfUnreferenced
+func (repository FileBlobRepository) ReleaseReference(hash string) error {
+	db := repository.GetDB()
+
+	return db.Model(&models.FileBlob{}).
+		Where("hash = ? AND ref_count > 0", hash).
+		Update("ref_count", gorm.Expr("ref_count - 1")).Error
+}
+
+// DeleteBlobIfUnreferenced removes a blob and its derived files when nothing references it anymore, the returned bool tells whether it did.
+// Call it after the transaction releasing the reference commits, the row stays locked until the files are gone.
+// A blob without a row, like one written by a rolled back transaction, is claimed with an empty row first so it is locked the same way.
+func (repository FileBlobRepository) DeleteBlobIfUnreferenced(hash string) (bool, error) {
+	removed := false
+
+	err := repository.GetDB().Transaction(func(tx *gorm.DB) error {
+		fileRepository := NewFileRepository(tx)
+
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.FileBlob{Hash: hash}).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("hash = ? AND ref_count = 0", hash).Delete(&models.FileBlob{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		derivedKeys, err := storage.GetStorage().List(fileRepository.BuildBlobDerivedKey(hash, "") + "/")
+		if err != nil {
+			return err
+		}
+
+		for _, key := range append(derivedKeys, fileRepository.BuildBlobKey(hash)) {
+			err = fileRepository.DeleteFile(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		removed = true
+		return nil
+	})
+
+	return removed, err
+}
+
+// RecountReferences sets every blob's ref count to the number of FileData using it, returning how many were off
+func (repository FileBlobRepository) RecountReferences() (int64, error) {
+	db := repository.GetDB()
+	referenceCount := db.Model(&models.FileData{}).Select("count(*)").Where("file_data.blob_hash = file_blobs.hash")
+
+	result := db.Model(&models.FileBlob{}).
+		Where("ref_count <> (?)", referenceCount).
+		Update("ref_count", referenceCount)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"errors"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func setUpFileBlobTest(t *testing.T) {
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+}
+
+func tearDownFileBlobTest() {
+	TruncateTestDb()
+	storage.SetStorage(nil)
+}
+
+func TestShouldStoreIdenticalFilesOnce(t *testing.T) {
+	defer tearDownFileBlobTest()
+	setUpFileBlobTest(t)
+	repository := NewFileBlobRepository(nil)
+	fileBytes := []byte("the same receipt")
+
+	fileBlob, alreadyStored, err := repository.StoreBlob(fileBytes)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if alreadyStored || fileBlob.Hash != utils.Sha256Hash(fileBytes) || fileBlob.Size != uint(len(fileBytes)) {
+		utils.PrintTestError(t, fileBlob, "a new blob")
+	}
+
+	_, alreadyStored, err = repository.StoreBlob(fileBytes)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if !alreadyStored {
+		utils.PrintTestError(t, alreadyStored, true)
+	}
+
+	storedBlob, err := repository.GetFileBlobByHash(fileBlob.Hash)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if storedBlob.RefCount != 2 {
+		utils.PrintTestError(t, storedBlob.RefCount, 2)
+	}
+
+	keys, _ := storage.GetStorage().List("blobs/")
+	if len(keys) != 1 || keys[0] != NewFileRepository(nil).BuildBlobKey(fileBlob.Hash) {
+		utils.PrintTestError(t, keys, "one blob")
+	}
+}
+
+func TestShouldRemoveBlobWhenLastReferenceIsReleased(t *testing.T) {
+	defer tearDownFileBlobTest()
+	setUpFileBlobTest(t)
+	repository := NewFileBlobRepository(nil)
+
+	fileBlob, _, _ := repository.StoreBlob([]byte("receipt"))
+	err := repository.AddReference(fileBlob.Hash)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	fileRepository := NewFileRepository(nil)
+	derivedKey := fileRepository.BuildBlobDerivedKey(fileBlob.Hash, "page-1.jpg")
+	fileRepository.WriteFile(derivedKey, []byte("page"))
+
+	err = repository.ReleaseReference(fileBlob.Hash)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	removed, err := repository.DeleteBlobIfUnreferenced(fileBlob.Hash)
+	if err != nil || removed {
+		utils.PrintTestError(t, removed, false)
+		return
+	}
+
+	err = repository.ReleaseReference(fileBlob.Hash)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	removed, err = repository.DeleteBlobIfUnreferenced(fileBlob.Hash)
+	if err != nil || !removed {
+		utils.PrintTestError(t, removed, true)
+		return
+	}
+
+	keys, _ := storage.GetStorage().List("blobs/")
+	if len(keys) != 0 {
+		utils.PrintTestError(t, keys, "blob and derived files to be deleted")
+	}
+
+	_, err = repository.GetFileBlobByHash(fileBlob.Hash)
+	if err == nil {
+		utils.PrintTestError(t, err, "record not found")
+	}
+
+	err = repository.AddReference(fileBlob.Hash)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+func TestShouldRecountBlobReferences(t *testing.T) {
+	defer tearDownFileBlobTest()
+	setUpFileBlobTest(t)
+	db := GetDB()
+	repository := NewFileBlobRepository(nil)
+	fileData := createTestPdfFileData()
+
+	fileBlob, _, _ := repository.StoreBlob([]byte("receipt"))
+	db.Model(&models.FileData{}).Where("id = ?", fileData.ID).Update("blob_hash", fileBlob.Hash)
+	db.Create(&models.FileData{Name: "copy.pdf", ReceiptId: fileData.ReceiptId, BlobHash: fileBlob.Hash})
+
+	recounted, err := repository.RecountReferences()
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if recounted != 1 {
+		utils.PrintTestError(t, recounted, 1)
+	}
+
+	storedBlob, _ := repository.GetFileBlobByHash(fileBlob.Hash)
+	if storedBlob.RefCount != 2 {
+		utils.PrintTestError(t, storedBlob.RefCount, 2)
+	}
+}
+
+func TestShouldKeepBlobStoredAgainBeforeItIsDeleted(t *testing.T) {
+	defer tearDownFileBlobTest()
+	setUpFileBlobTest(t)
+	repository := NewFileBlobRepository(nil)
+
+	fileBlob, _, _ := repository.StoreBlob([]byte("receipt"))
+	repository.ReleaseReference(fileBlob.Hash)
+
+	// A new upload of the same file wins over the pending delete of the released blob
+	_, alreadyStored, err := repository.StoreBlob([]byte("receipt"))
+	if err != nil || !alreadyStored {
+		utils.PrintTestError(t, alreadyStored, true)
+		return
+	}
+
+	removed, err := repository.DeleteBlobIfUnreferenced(fileBlob.Hash)
+	if err != nil || removed {
+		utils.PrintTestError(t, removed, false)
+	}
+
+	storedBlob, _ := repository.GetFileBlobByHash(fileBlob.Hash)
+	exists, _ := NewFileRepository(nil).FileExists(NewFileRepository(nil).BuildBlobKey(fileBlob.Hash))
+	if storedBlob.RefCount != 1 || !exists {
+		utils.PrintTestError(t, storedBlob, "one reference to a stored blob")
+	}
+}
+
+func TestShouldDeleteBlobWrittenByRolledBackTransaction(t *testing.T) {
+	defer tearDownFileBlobTest()
+	setUpFileBlobTest(t)
+	fileRepository := NewFileRepository(nil)
+	var fileBlob models.FileBlob
+
+	GetDB().Transaction(func(tx *gorm.DB) error {
+		fileBlob, _, _ = NewFileBlobRepository(tx).StoreBlob([]byte("receipt"))
+		return errors.New("restore failed")
+	})
+
+	exists, _ := fileRepository.FileExists(fileRepository.BuildBlobKey(fileBlob.Hash))
+	if !exists {
+		utils.PrintTestError(t, exists, "the file to outlive the rollback")
+		return
+	}
+
+	removed, err := NewFileBlobRepository(nil).DeleteBlobIfUnreferenced(fileBlob.Hash)
+	if err != nil || !removed {
+		utils.PrintTestError(t, removed, true)
+	}
+
+	exists, _ = fileRepository.FileExists(fileRepository.BuildBlobKey(fileBlob.Hash))
+	if exists {
+		utils.PrintTestError(t, exists, false)
+	}
+
+	_, err = NewFileBlobRepository(nil).GetFileBlobByHash(fileBlob.Hash)
+	if err == nil {
+		utils.PrintTestError(t, err, "record not found")
+	}
+}
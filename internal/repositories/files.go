@@ -6,12 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"receipt-wrangler/api/internal/constants"
 	config "receipt-wrangler/api/internal/env"
 	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/storage"
 	"receipt-wrangler/api/internal/utils"
 	"regexp"
 	"strings"
@@ -33,59 +34,105 @@ func NewFileRepository(tx *gorm.DB) FileRepository {
 	return repository
 }
 
-func (repository FileRepository) BuildFilePath(receiptId string, receiptImageId string, receiptImageFileName string) (string, error) {
-	db := repository.GetDB()
-	var receipt models.Receipt
+// BuildFileKey returns the storage key of a receipt's file, keys don't depend on the receipt's group so moving receipts or renaming groups never touches files
+func (repository FileRepository) BuildFileKey(receiptId string, fileDataId string, fileName string) string {
+	return path.Join("receipts", receiptId, fileDataId+"-"+strings.ReplaceAll(fileName, "/", "_"))
+}
 
-	err := db.Model(models.Receipt{}).Where("id = ?", receiptId).Select("group_id").Find(&receipt).Error
-	if err != nil {
-		return "", err
+// BuildBlobKey returns the key of a content addressed file, fanned out by the first two characters of the hash
+func (repository FileRepository) BuildBlobKey(hash string) string {
+	return path.Join("blobs", hash[:2], hash)
+}
+
+// BuildBlobDerivedKey returns the key of a file generated from a blob, like a pdf page or a preview
+func (repository FileRepository) BuildBlobDerivedKey(hash string, name string) string {
+	return path.Join("blobs", hash[:2], hash+"-derived", strings.ReplaceAll(name, "/", "_"))
+}
+
+func (repository FileRepository) BuildFileDataKey(fileData models.FileData) string {
+	if len(fileData.BlobHash) > 0 {
+		return repository.BuildBlobKey(fileData.BlobHash)
 	}
 
-	groupPath, err := repository.BuildGroupPath(receipt.GroupId, "")
-	if err != nil {
-		return "", err
+	return repository.BuildFileKey(utils.UintToString(fileData.ReceiptId), utils.UintToString(fileData.ID), fileData.Name)
+}
+
+func (repository FileRepository) BuildFileDataPageKey(fileData models.FileData, page models.FileDataPage) string {
+	if len(fileData.BlobHash) > 0 {
+		return repository.BuildBlobDerivedKey(fileData.BlobHash, page.Name)
 	}
 
-	fileName := utils.BuildFileName(receiptId, receiptImageId, receiptImageFileName)
-	path := filepath.Join(groupPath, fileName)
+	return repository.BuildFileKey(utils.UintToString(fileData.ReceiptId), utils.UintToString(fileData.ID), page.Name)
+}
 
-	return path, nil
+func (repository FileRepository) WriteFile(key string, data []byte) error {
+	return storage.GetStorage().Put(key, data)
 }
 
-func (repository FileRepository) BuildGroupPath(groupId uint, alternateGroupName string) (string, error) {
-	db := repository.GetDB()
-	var groupNameToUse string
+func (repository FileRepository) ReadFile(key string) ([]byte, error) {
+	return storage.GetStorage().Get(key)
+}
 
-	if len(alternateGroupName) > 0 {
-		groupNameToUse = alternateGroupName
-	} else {
-		var group models.Group
-		err := db.Model(models.Group{}).Where("id = ?", groupId).Select("name").Find(&group).Error
-		if err != nil {
-			return "", err
-		}
+func (repository FileRepository) DeleteFile(key string) error {
+	return storage.GetStorage().Delete(key)
+}
 
-		groupNameToUse = group.Name
-	}
+func (repository FileRepository) FileExists(key string) (bool, error) {
+	return storage.GetStorage().Exists(key)
+}
 
-	strGroupId := utils.UintToString(groupId)
-	groupPath, err := utils.BuildGroupPathString(strGroupId, groupNameToUse)
-	if err != nil {
-		return "", err
+// GetRawBytesForFileData returns the file as uploaded, without converting pdfs or heic images
+func (repository FileRepository) GetRawBytesForFileData(fileData models.FileData) ([]byte, error) {
+	fileBytes, err := repository.ReadFile(repository.BuildFileDataKey(fileData))
+	if errors.Is(err, storage.ErrNotFound) && len(fileData.BlobHash) == 0 {
+		return repository.readLegacyFile(fileData, fileData.Name)
 	}
 
-	return groupPath, nil
+	return fileBytes, err
 }
 
-func (repository FileRepository) GetBytesForFileData(fileData models.FileData) ([]byte, error) {
-	path, err := repository.BuildFilePath(utils.UintToString(fileData.ReceiptId), utils.UintToString(fileData.ID), fileData.Name)
+// readLegacyFile reads a file from the old data/{groupId}-{groupName} directory, for files the storage migration hasn't moved yet
+func (repository FileRepository) readLegacyFile(fileData models.FileData, fileName string) ([]byte, error) {
+	db := repository.GetDB()
 
+	var receipt models.Receipt
+	err := db.Model(&models.Receipt{}).Select("group_id").Where("id = ?", fileData.ReceiptId).First(&receipt).Error
+	if err != nil {
+		return nil, storage.ErrNotFound
+	}
+
+	var group models.Group
+	err = db.Model(&models.Group{}).Select("id", "name").First(&group, receipt.GroupId).Error
+	if err != nil {
+		return nil, storage.ErrNotFound
+	}
+
+	groupPath, err := utils.BuildGroupPathString(utils.UintToString(group.ID), group.Name)
 	if err != nil {
 		return nil, err
 	}
 
-	fileBytes, err := utils.ReadFile(path)
+	legacyPath := filepath.Join(groupPath, utils.BuildFileName(utils.UintToString(fileData.ReceiptId), utils.UintToString(fileData.ID), fileName))
+	fileBytes, err := os.ReadFile(legacyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, storage.ErrNotFound
+	}
+
+	return fileBytes, err
+}
+
+// ReadFileDataPage returns a page generated from a pdf, falling back to the legacy directory like GetRawBytesForFileData
+func (repository FileRepository) ReadFileDataPage(fileData models.FileData, page models.FileDataPage) ([]byte, error) {
+	pageBytes, err := repository.ReadFile(repository.BuildFileDataPageKey(fileData, page))
+	if errors.Is(err, storage.ErrNotFound) && len(fileData.BlobHash) == 0 {
+		return repository.readLegacyFile(fileData, page.Name)
+	}
+
+	return pageBytes, err
+}
+
+func (repository FileRepository) GetBytesForFileData(fileData models.FileData) ([]byte, error) {
+	fileBytes, err := repository.GetRawBytesForFileData(fileData)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +145,25 @@ func (repository FileRepository) GetBytesForFileData(fileData models.FileData) (
 	return resultBytes, nil
 }
 
+// BuildFileDataPreviewKey returns the key of a downscaled preview, kept in its own directory so it can't clash with uploaded file names
+func (repository FileRepository) BuildFileDataPreviewKey(fileData models.FileData, size models.ImageSize) string {
+	if len(fileData.BlobHash) > 0 {
+		return repository.BuildBlobDerivedKey(fileData.BlobHash, string(size)+".jpg")
+	}
+
+	return path.Join("receipts", utils.UintToString(fileData.ReceiptId), utils.UintToString(fileData.ID), string(size)+".jpg")
+}
+
+// WriteFileDataToTempFile copies a stored file to the temp directory, for tools that need a path on disk
+func (repository FileRepository) WriteFileDataToTempFile(fileData models.FileData) (string, error) {
+	fileBytes, err := repository.GetRawBytesForFileData(fileData)
+	if err != nil {
+		return "", err
+	}
+
+	return repository.WriteTempFile(fileBytes)
+}
+
 func (repository FileRepository) GetBytesFromImageBytes(imageData []byte) ([]byte, error) {
 	var bytes []byte
 	validatedType, err := repository.ValidateFileType(imageData)
@@ -196,69 +262,156 @@ func (repository FileRepository) ConvertHeicToJpg(bytes []byte) ([]byte, error)
 }
 
 func (repository FileRepository) ConvertPdfToJpg(bytes []byte) ([]byte, error) {
+	mw, err := repository.readPdf(bytes)
+	if err != nil {
+		return nil, err
+	}
+	defer mw.Destroy()
+
+	// Resetting the wand is necessary for AppendImages to work.
+	mw.ResetIterator()
+	combinedImage := mw.AppendImages(true)
+	defer combinedImage.Destroy()
+
+	if err := combinedImage.SetImageFormat("jpeg"); err != nil {
+		return nil, err
+	}
+
+	return combinedImage.GetImageBlob()
+}
+
+// ResizeImage scales an image down to fit within maxDimension and encodes it as a jpg, smaller images keep their size
+func (repository FileRepository) ResizeImage(imageBytes []byte, maxDimension uint) ([]byte, error) {
 	mw := imagick.NewMagickWand()
 	defer mw.Destroy()
 
-	if err := mw.ReadImageBlob(bytes); err != nil {
+	if err := mw.ReadImageBlob(imageBytes); err != nil {
+		return nil, err
+	}
+
+	// Orientation lives in the exif data, which is stripped below
+	if err := mw.AutoOrientImage(); err != nil {
+		return nil, err
+	}
+
+	width := mw.GetImageWidth()
+	height := mw.GetImageHeight()
+	if width > maxDimension || height > maxDimension {
+		newWidth := maxDimension
+		newHeight := maxDimension
+		if width > height {
+			newHeight = max(1, height*maxDimension/width)
+		} else {
+			newWidth = max(1, width*maxDimension/height)
+		}
+
+		if err := mw.ThumbnailImage(newWidth, newHeight); err != nil {
+			return nil, err
+		}
+	}
+
+	background := imagick.NewPixelWand()
+	defer background.Destroy()
+	background.SetColor("white")
+
+	if err := mw.SetImageBackgroundColor(background); err != nil {
+		return nil, err
+	}
+
+	if err := mw.SetImageAlphaChannel(imagick.ALPHA_CHANNEL_REMOVE); err != nil {
+		return nil, err
+	}
+
+	if err := mw.StripImage(); err != nil {
 		return nil, err
 	}
 
-	// Set the format to JPEG once, the setting is retained across frames.
 	if err := mw.SetImageFormat("jpeg"); err != nil {
 		return nil, err
 	}
 
-	// Must be *after* ReadImageFile
-	// Flatten image and remove alpha channel, to prevent alpha turning black in jpg
-	if err := mw.SetImageAlphaChannel(0); err != nil {
+	if err := mw.SetImageCompressionQuality(80); err != nil {
 		return nil, err
 	}
 
-	// Find out how many images/pages we've got in a pdf.
-	numPages := int(mw.GetNumberImages())
+	return mw.GetImageBlob()
+}
 
-	// Create a new wand to store the final long image.
-	finalImage := imagick.NewMagickWand()
-	defer finalImage.Destroy()
+// ConvertPdfToJpgPages renders every page of a pdf to its own jpg, in page order
+func (repository FileRepository) ConvertPdfToJpgPages(bytes []byte) ([][]byte, error) {
+	mw, err := repository.readPdf(bytes)
+	if err != nil {
+		return nil, err
+	}
+	defer mw.Destroy()
+
+	numPages := int(mw.GetNumberImages())
+	pages := make([][]byte, 0, numPages)
 
-	// Iterate over each page, processing it as needed.
 	for i := 0; i < numPages; i++ {
 		mw.SetIteratorIndex(i)
+		page := mw.GetImage()
 
-		// Get the current image as a MagickWand.
-		// This is done because AddImage() expects a MagickWand, not a blob.
-		currImage := mw.GetImage()
+		if err := page.SetImageFormat("jpeg"); err != nil {
+			page.Destroy()
+			return nil, err
+		}
 
-		// Add the current image to the finalImage wand.
-		if err := finalImage.AddImage(currImage); err != nil {
-			log.Fatal(err) // Handle the error appropriately
+		pageBytes, err := page.GetImageBlob()
+		page.Destroy()
+		if err != nil {
+			return nil, err
 		}
 
-		// Destroy the current image object as it's no longer needed.
-		currImage.Destroy()
+		pages = append(pages, pageBytes)
 	}
 
-	// Now, we will append all the images stored in finalImage vertically.
-	// Resetting the wand is necessary for AppendImages to work.
-	finalImage.ResetIterator()
-	combinedImage := finalImage.AppendImages(true)
+	return pages, nil
+}
 
-	tempFilePath, err := repository.BuildTempFilePath("jpg")
+// readPdf renders a pdf at the system's pdf dpi, with every page flattened onto a white background
+func (repository FileRepository) readPdf(bytes []byte) (*imagick.MagickWand, error) {
+	systemSettingsRepository := NewSystemSettingsRepository(repository.TX)
+	systemSettings, err := systemSettingsRepository.GetSystemSettings()
 	if err != nil {
 		return nil, err
 	}
 
-	if err := combinedImage.WriteImage(tempFilePath); err != nil {
+	mw := imagick.NewMagickWand()
+	dpi := float64(systemSettings.GetPdfRenderDpi())
+
+	// Must be set before reading, pdfs are rasterized while being read
+	if err := mw.SetResolution(dpi, dpi); err != nil {
+		mw.Destroy()
 		return nil, err
 	}
 
-	bytes, err = utils.ReadFile(tempFilePath)
-	if err != nil {
+	if err := mw.ReadImageBlob(bytes); err != nil {
+		mw.Destroy()
 		return nil, err
 	}
 
-	os.Remove(tempFilePath)
-	return bytes, nil
+	background := imagick.NewPixelWand()
+	defer background.Destroy()
+	background.SetColor("white")
+
+	numPages := int(mw.GetNumberImages())
+	for i := 0; i < numPages; i++ {
+		mw.SetIteratorIndex(i)
+
+		// Remove alpha to prevent transparent areas turning black in jpg
+		if err := mw.SetImageBackgroundColor(background); err != nil {
+			mw.Destroy()
+			return nil, err
+		}
+
+		if err := mw.SetImageAlphaChannel(imagick.ALPHA_CHANNEL_REMOVE); err != nil {
+			mw.Destroy()
+			return nil, err
+		}
+	}
+
+	return mw, nil
 }
 
 func (repository FileRepository) WriteTempFile(data []byte) (string, error) {
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"errors"
+	"receipt-wrangler/api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GroupInviteRepository struct {
+	BaseRepository
+}
+
+func NewGroupInviteRepository(tx *gorm.DB) GroupInviteRepository {
+	repository := GroupInviteRepository{BaseRepository: BaseRepository{
+		DB: GetDB(),
+		TX: tx,
+	}}
+	return repository
+}
+
+func (repository GroupInviteRepository) CreateGroupInvite(invite models.GroupInvite) (models.GroupInvite, error) {
+	db := repository.GetDB()
+
+	err := db.Create(&invite).Error
+	if err != nil {
+		return models.GroupInvite{}, err
+	}
+
+	return invite, nil
+}
+
+func (repository GroupInviteRepository) GetGroupInviteById(id string) (models.GroupInvite, error) {
+	db := repository.GetDB()
+	var invite models.GroupInvite
+
+	err := db.Model(&models.GroupInvite{}).Where("id = ?", id).First(&invite).Error
+	if err != nil {
+		return models.GroupInvite{}, err
+	}
+
+	return invite, nil
+}
+
+func (repository GroupInviteRepository) GetGroupInviteByTokenHash(tokenHash string) (models.GroupInvite, error) {
+	db := repository.GetDB()
+	var invite models.GroupInvite
+
+	err := db.Model(&models.GroupInvite{}).Where("token_hash = ?", tokenHash).First(&invite).Error
+	if err != nil {
+		return models.GroupInvite{}, err
+	}
+
+	return invite, nil
+}
+
+func (repository GroupInviteRepository) GetPendingGroupInvitesByGroupId(groupId string) ([]models.GroupInvite, error) {
+	db := repository.GetDB()
+	var invites []models.GroupInvite
+
+	err := db.Model(&models.GroupInvite{}).
+		Where("group_id = ? AND revoked_at IS NULL AND expires_at > ? AND use_count < max_uses", groupId, time.Now()).
+		Order("created_at desc").
+		Find(&invites).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return invites, nil
+}
+
+func (repository GroupInviteRepository) RevokeGroupInvite(id string) error {
+	db := repository.GetDB()
+	return db.Model(&models.GroupInvite{}).Where("id = ?", id).Update("revoked_at", time.Now()).Error
+}
+
+// Only counts the use if the invite is still pending, so concurrent accepts cannot exceed MaxUses
+func (repository GroupInviteRepository) UseGroupInvite(id uint, userId uint) error {
+	db := repository.GetDB()
+
+	result := db.Model(&models.GroupInvite{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ? AND use_count < max_uses", id, time.Now()).
+		Updates(map[string]interface{}{
+			"use_count":           gorm.Expr("use_count + 1"),
+			"last_accepted_at":    time.Now(),
+			"last_accepted_by_id": userId,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("invite is no longer valid")
+	}
+
+	return nil
+}
+
+func (repository GroupInviteRepository) DeleteGroupInvitesByGroupId(groupId string) error {
+	db := repository.GetDB()
+	return db.Where("group_id = ?", groupId).Delete(&models.GroupInvite{}).Error
+}
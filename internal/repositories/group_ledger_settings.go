@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"errors"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type GroupLedgerSettingsRepository struct {
+	BaseRepository
+}
+
+func NewGroupLedgerSettingsRepository(tx *gorm.DB) GroupLedgerSettingsRepository {
+	repository := GroupLedgerSettingsRepository{BaseRepository: BaseRepository{
+		DB: GetDB(),
+		TX: tx,
+	}}
+	return repository
+}
+
+// GetGroupLedgerSettings returns the settings of a group, or the defaults when the group has none
+func (repository GroupLedgerSettingsRepository) GetGroupLedgerSettings(groupId uint) (models.GroupLedgerSettings, error) {
+	settingsByGroupId, err := repository.GetGroupLedgerSettingsByGroupIds([]uint{groupId})
+	if err != nil {
+		return models.GroupLedgerSettings{}, err
+	}
+
+	return settingsByGroupId[groupId], nil
+}
+
+func (repository GroupLedgerSettingsRepository) GetGroupLedgerSettingsByGroupIds(groupIds []uint) (map[uint]models.GroupLedgerSettings, error) {
+	db := repository.GetDB()
+	var groupLedgerSettings []models.GroupLedgerSettings
+
+	err := db.Model(&models.GroupLedgerSettings{}).
+		Where("group_id IN ?", groupIds).
+		Preload("CategoryAccounts").
+		Find(&groupLedgerSettings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	settingsByGroupId := make(map[uint]models.GroupLedgerSettings)
+	for _, groupId := range groupIds {
+		settingsByGroupId[groupId] = models.GroupLedgerSettings{
+			GroupId:               groupId,
+			Currency:              constants.LedgerDefaultCurrency,
+			PaidByAccount:         constants.LedgerDefaultPaidByAccount,
+			DefaultExpenseAccount: constants.LedgerDefaultExpenseAccount,
+			CategoryAccounts:      make([]models.LedgerCategoryAccount, 0),
+		}
+	}
+
+	for _, settings := range groupLedgerSettings {
+		settingsByGroupId[settings.GroupId] = settings
+	}
+
+	return settingsByGroupId, nil
+}
+
+func (repository GroupLedgerSettingsRepository) UpdateGroupLedgerSettings(
+	groupId uint,
+	command commands.UpdateGroupLedgerSettingsCommand,
+) (models.GroupLedgerSettings, error) {
+	db := repository.GetDB()
+	var groupLedgerSettings models.GroupLedgerSettings
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		categoryIds := make([]uint, 0, len(command.CategoryAccounts))
+		for _, categoryAccount := range command.CategoryAccounts {
+			categoryIds = append(categoryIds, categoryAccount.CategoryId)
+		}
+
+		var categoryCount int64
+		err := tx.Model(&models.Category{}).
+			Where("id IN ? AND (group_id = ? OR group_id IS NULL)", categoryIds, groupId).
+			Count(&categoryCount).Error
+		if err != nil {
+			return err
+		}
+
+		if int(categoryCount) != len(categoryIds) {
+			return errors.New("categories must be global or belong to the group")
+		}
+
+		err = tx.Model(&models.GroupLedgerSettings{}).Where("group_id = ?", groupId).Limit(1).Find(&groupLedgerSettings).Error
+		if err != nil {
+			return err
+		}
+
+		groupLedgerSettings.GroupId = groupId
+		groupLedgerSettings.Currency = command.Currency
+		groupLedgerSettings.PaidByAccount = command.PaidByAccount
+		groupLedgerSettings.DefaultExpenseAccount = command.DefaultExpenseAccount
+		err = tx.Omit("CategoryAccounts").Save(&groupLedgerSettings).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("group_ledger_settings_id = ?", groupLedgerSettings.ID).Delete(&models.LedgerCategoryAccount{}).Error
+		if err != nil {
+			return err
+		}
+
+		groupLedgerSettings.CategoryAccounts = make([]models.LedgerCategoryAccount, 0, len(command.CategoryAccounts))
+		for _, categoryAccount := range command.CategoryAccounts {
+			groupLedgerSettings.CategoryAccounts = append(groupLedgerSettings.CategoryAccounts, models.LedgerCategoryAccount{
+				GroupLedgerSettingsId: groupLedgerSettings.ID,
+				CategoryId:            categoryAccount.CategoryId,
+				Account:               categoryAccount.Account,
+			})
+		}
+
+		if len(groupLedgerSettings.CategoryAccounts) > 0 {
+			return tx.Create(&groupLedgerSettings.CategoryAccounts).Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.GroupLedgerSettings{}, err
+	}
+
+	return groupLedgerSettings, nil
+}
+
+func (repository GroupLedgerSettingsRepository) DeleteGroupLedgerSettingsByGroupId(groupId uint) error {
+	db := repository.GetDB()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("group_ledger_settings_id IN (?)", tx.Model(&models.GroupLedgerSettings{}).Select("id").Where("group_id = ?", groupId)).
+			Delete(&models.LedgerCategoryAccount{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("group_id = ?", groupId).Delete(&models.GroupLedgerSettings{}).Error
+	})
+}
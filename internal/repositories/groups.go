@@ -6,6 +6,7 @@ import (
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/utils"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -143,7 +144,7 @@ func (repository GroupRepository) CreateGroup(command commands.UpsertGroupComman
 		return models.Group{}, err
 	}
 
-	err = repository.GetDB().Model(models.Group{}).Where("id = ?", groupToCreate.ID).Preload("GroupMembers").Find(&returnGroup).Error
+	err = db.Model(models.Group{}).Where("id = ?", groupToCreate.ID).Preload("GroupMembers").Find(&returnGroup).Error
 	if err != nil {
 		return models.Group{}, err
 	}
@@ -276,3 +277,67 @@ func (repository GroupRepository) IsAllGroup(groupId uint) (bool, error) {
 
 	return group.IsAllGroup, nil
 }
+
+func (repository GroupRepository) UpdateGroupStatus(groupId string, status models.GroupStatus) error {
+	db := repository.GetDB()
+
+	return db.Model(&models.Group{}).Where("id = ?", groupId).Update("status", status).Error
+}
+
+func (repository GroupRepository) CountArchivedGroups(groupIds []string) (int64, error) {
+	db := repository.GetDB()
+	var count int64
+
+	if len(groupIds) == 0 {
+		return 0, nil
+	}
+
+	err := db.Model(&models.Group{}).
+		Where("id IN ? AND status = ?", groupIds, models.GROUP_ARCHIVED).
+		Count(&count).Error
+
+	return count, err
+}
+
+// GetInactiveGroupIds returns the active groups that were created, and had no receipt changes, since cutoff
+func (repository GroupRepository) GetInactiveGroupIds(cutoff time.Time) ([]uint, error) {
+	db := repository.GetDB()
+	groupIds := make([]uint, 0)
+
+	err := db.Model(&models.Group{}).
+		Where("status = ? AND is_all_group = ? AND created_at < ?", models.GROUP_ACTIVE, false, cutoff).
+		Where("id NOT IN (?)", db.Model(&models.Receipt{}).Select("group_id").Where("updated_at >= ?", cutoff)).
+		Pluck("id", &groupIds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return groupIds, nil
+}
+
+func (repository GroupRepository) ArchiveGroups(groupIds []uint) error {
+	db := repository.GetDB()
+
+	if len(groupIds) == 0 {
+		return nil
+	}
+
+	return db.Model(&models.Group{}).Where("id IN ?", groupIds).Update("status", models.GROUP_ARCHIVED).Error
+}
+
+func (repository GroupRepository) GetAllGroups(includeAllGroups bool) ([]models.Group, error) {
+	db := repository.GetDB()
+	var groups []models.Group
+
+	query := db.Model(&models.Group{}).Preload("GroupMembers").Order("id")
+	if !includeAllGroups {
+		query = query.Where("is_all_group = ?", false)
+	}
+
+	err := query.Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
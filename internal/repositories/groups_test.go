@@ -5,6 +5,7 @@ import (
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/utils"
 	"testing"
+	"time"
 )
 
 func setUpGroupTest() {
@@ -112,3 +113,33 @@ func TestShouldUpdateGroup(t *testing.T) {
 		utils.PrintTestError(t, updatedGroup.Status, models.GROUP_ARCHIVED)
 	}
 }
+
+func TestShouldGetInactiveGroupIds(t *testing.T) {
+	defer teardownGroupTest()
+	setUpGroupTest()
+	db := GetDB()
+	longAgo := time.Now().AddDate(-1, 0, 0)
+
+	inactiveGroup := models.Group{Name: "inactive", BaseModel: models.BaseModel{CreatedAt: longAgo}}
+	activeGroup := models.Group{Name: "active", BaseModel: models.BaseModel{CreatedAt: longAgo}}
+	newGroup := models.Group{Name: "new"}
+	archivedGroup := models.Group{Name: "archived", Status: models.GROUP_ARCHIVED, BaseModel: models.BaseModel{CreatedAt: longAgo}}
+	db.Create(&inactiveGroup)
+	db.Create(&activeGroup)
+	db.Create(&newGroup)
+	db.Create(&archivedGroup)
+
+	db.Create(&models.Receipt{Name: "old", GroupId: inactiveGroup.ID, PaidByUserID: 1, Status: models.OPEN})
+	db.Model(&models.Receipt{}).Where("group_id = ?", inactiveGroup.ID).UpdateColumn("updated_at", longAgo)
+	db.Create(&models.Receipt{Name: "recent", GroupId: activeGroup.ID, PaidByUserID: 1, Status: models.OPEN})
+
+	groupIds, err := setupGroupRepository().GetInactiveGroupIds(time.Now().AddDate(0, -6, 0))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(groupIds) != 1 || groupIds[0] != inactiveGroup.ID {
+		utils.PrintTestError(t, groupIds, []uint{inactiveGroup.ID})
+	}
+}
@@ -1,9 +1,12 @@
 package repositories
 
 import (
-	"os"
-	"path/filepath"
+	"errors"
+	"fmt"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/logging"
 	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/storage"
 	"receipt-wrangler/api/internal/utils"
 
 	"gorm.io/gorm"
@@ -25,6 +28,7 @@ func NewReceiptImageRepository(tx *gorm.DB) ReceiptImageRepository {
 // TODO: Move to service
 func (repository ReceiptImageRepository) CreateReceiptImage(fileData models.FileData, fileBytes []byte) (models.FileData, error) {
 	fileRepository := NewFileRepository(repository.TX)
+	fileBlobRepository := NewFileBlobRepository(repository.TX)
 	db := repository.GetDB()
 
 	// TODO: refactor to use command
@@ -33,50 +37,319 @@ func (repository ReceiptImageRepository) CreateReceiptImage(fileData models.File
 		return models.FileData{}, err
 	}
 
+	// Identical files share one blob, so the same pdf arriving twice takes up space once
+	fileBlob, alreadyStored, err := fileBlobRepository.StoreBlob(fileBytes)
+	if err != nil {
+		return models.FileData{}, err
+	}
+
 	fileData.FileType = validatedFileType
+	fileData.Size = fileBlob.Size
+	fileData.Checksum = fileBlob.Hash
+	fileData.BlobHash = fileBlob.Hash
 
-	basePath, err := os.Getwd()
+	err = db.Model(models.FileData{}).Create(&fileData).Error
 	if err != nil {
+		// Inside a transaction the rollback undoes the reference, so the blob is only cleaned up when nothing else will
+		releaseErr := fileBlobRepository.ReleaseReference(fileBlob.Hash)
+		if releaseErr == nil && repository.TX == nil {
+			fileBlobRepository.DeleteBlobIfUnreferenced(fileBlob.Hash)
+		}
 		return models.FileData{}, err
 	}
 
-	// Check if data path exists
-	err = utils.DirectoryExists(basePath+"/data", true)
+	if fileData.FileType == constants.ApplicationPdf {
+		pages, err := repository.copyBlobPages(fileData)
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, "Error copying pdf pages: ", err.Error())
+		}
+
+		// Page previews can't be rendered for every pdf, the upload itself still succeeds
+		if len(pages) == 0 {
+			pages, err = repository.CreatePdfPages(fileData, fileBytes)
+			if err != nil {
+				logging.LogStd(logging.LOG_LEVEL_ERROR, "Error rendering pdf pages: ", err.Error())
+			}
+		}
+		fileData.Pages = pages
+	}
+
+	if alreadyStored {
+		return fileData, nil
+	}
+
+	// Previews are generated on first use instead when this fails
+	err = repository.CreateReceiptImagePreviews(fileData)
 	if err != nil {
-		return models.FileData{}, err
+		logging.LogStd(logging.LOG_LEVEL_ERROR, "Error creating image previews: ", err.Error())
+	}
+
+	return fileData, nil
+}
+
+// copyBlobPages gives a FileData the pages of another FileData sharing its blob, the rendered pages themselves are shared
+func (repository ReceiptImageRepository) copyBlobPages(fileData models.FileData) ([]models.FileDataPage, error) {
+	db := repository.GetDB()
+
+	var sourceFileData models.FileData
+	err := db.Model(&models.FileData{}).
+		Where("blob_hash = ? AND id <> ?", fileData.BlobHash, fileData.ID).
+		Where("id IN (?)", db.Model(&models.FileDataPage{}).Select("file_data_id")).
+		Limit(1).
+		Find(&sourceFileData).Error
+	if err != nil || sourceFileData.ID == 0 {
+		return make([]models.FileDataPage, 0), err
 	}
 
-	// Get initial group directory to see if it exists
-	filePath, err := fileRepository.BuildFilePath(utils.UintToString(fileData.ReceiptId), "", fileData.Name)
+	return repository.CopyFileDataPages(sourceFileData, fileData)
+}
+
+// CopyFileDataPages copies the page rows of source to target, page files are only copied when the two don't share them
+func (repository ReceiptImageRepository) CopyFileDataPages(source models.FileData, target models.FileData) ([]models.FileDataPage, error) {
+	fileRepository := NewFileRepository(repository.TX)
+	db := repository.GetDB()
+	sourcePages := make([]models.FileDataPage, 0)
+
+	err := db.Model(&models.FileDataPage{}).Where("file_data_id = ?", source.ID).Order("page_number").Find(&sourcePages).Error
 	if err != nil {
-		return models.FileData{}, err
+		return nil, err
 	}
-	groupDir, _ := filepath.Split(filePath)
 
-	err = db.Model(models.FileData{}).Create(&fileData).Error
+	pages := make([]models.FileDataPage, 0, len(sourcePages))
+	for _, sourcePage := range sourcePages {
+		page := models.FileDataPage{
+			FileDataId: target.ID,
+			PageNumber: sourcePage.PageNumber,
+			Name:       sourcePage.Name,
+			FileType:   sourcePage.FileType,
+			Size:       sourcePage.Size,
+		}
+
+		sourceKey := fileRepository.BuildFileDataPageKey(source, sourcePage)
+		targetKey := fileRepository.BuildFileDataPageKey(target, page)
+		if sourceKey != targetKey {
+			pageBytes, err := fileRepository.ReadFile(sourceKey)
+			if err != nil {
+				return pages, err
+			}
+
+			err = fileRepository.WriteFile(targetKey, pageBytes)
+			if err != nil {
+				return pages, err
+			}
+		}
+
+		err = db.Model(&models.FileDataPage{}).Create(&page).Error
+		if err != nil {
+			return pages, err
+		}
+
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+// ReleaseFileData drops a FileData's claim on its stored files before the FileData is deleted.
+// It returns the keys to delete once the transaction commits, a blob is left to DeleteReleasedFiles since other FileData may still use it.
+func (repository ReceiptImageRepository) ReleaseFileData(fileData models.FileData) ([]string, error) {
+	fileRepository := NewFileRepository(repository.TX)
+	fileBlobRepository := NewFileBlobRepository(repository.TX)
+
+	if len(fileData.BlobHash) > 0 {
+		return nil, fileBlobRepository.ReleaseReference(fileData.BlobHash)
+	}
+
+	derivedKeys, err := repository.GetDerivedFileKeys(fileData)
 	if err != nil {
-		os.Remove(filePath)
-		return models.FileData{}, err
+		return nil, err
 	}
 
-	// Check if group's path exists
-	err = utils.DirectoryExists(groupDir, true)
+	return append([]string{fileRepository.BuildFileDataKey(fileData)}, derivedKeys...), nil
+}
+
+// DeleteReleasedFiles removes the files of released FileData after the release committed, blobs only when no reference is left
+func (repository ReceiptImageRepository) DeleteReleasedFiles(releasedFileData []models.FileData, keys []string) {
+	fileRepository := NewFileRepository(nil)
+	fileBlobRepository := NewFileBlobRepository(nil)
+
+	for _, key := range keys {
+		fileRepository.DeleteFile(key)
+	}
+
+	for _, fileData := range releasedFileData {
+		if len(fileData.BlobHash) == 0 {
+			continue
+		}
+
+		_, err := fileBlobRepository.DeleteBlobIfUnreferenced(fileData.BlobHash)
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, "Error deleting unreferenced blob: ", err.Error())
+		}
+	}
+}
+
+func (repository ReceiptImageRepository) CreateReceiptImagePreviews(fileData models.FileData) error {
+	sourceBytes, err := repository.getPreviewSourceBytes(fileData)
 	if err != nil {
-		return models.FileData{}, err
+		return err
+	}
+
+	for _, size := range models.GetPreviewImageSizes() {
+		_, err = repository.createReceiptImagePreview(fileData, sourceBytes, size)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Rebuild file path with correct file id
-	filePath, err = fileRepository.BuildFilePath(utils.UintToString(fileData.ReceiptId), utils.UintToString(fileData.ID), fileData.Name)
+	return nil
+}
+
+// GetReceiptImagePreview returns a stored preview, previews missing for older images are generated and stored on first use
+func (repository ReceiptImageRepository) GetReceiptImagePreview(fileData models.FileData, size models.ImageSize) ([]byte, error) {
+	fileRepository := NewFileRepository(repository.TX)
+
+	previewBytes, err := fileRepository.ReadFile(fileRepository.BuildFileDataPreviewKey(fileData, size))
+	if err == nil {
+		return previewBytes, nil
+	}
+
+	if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	sourceBytes, err := repository.getPreviewSourceBytes(fileData)
 	if err != nil {
-		return models.FileData{}, err
+		return nil, err
 	}
 
-	err = utils.WriteFile(filePath, fileBytes)
+	return repository.createReceiptImagePreview(fileData, sourceBytes, size)
+}
+
+func (repository ReceiptImageRepository) createReceiptImagePreview(fileData models.FileData, sourceBytes []byte, size models.ImageSize) ([]byte, error) {
+	fileRepository := NewFileRepository(repository.TX)
+
+	previewBytes, err := fileRepository.ResizeImage(sourceBytes, size.GetMaxDimension())
 	if err != nil {
-		return models.FileData{}, err
+		return nil, err
 	}
 
-	return fileData, nil
+	err = fileRepository.WriteFile(fileRepository.BuildFileDataPreviewKey(fileData, size), previewBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return previewBytes, nil
+}
+
+// getPreviewSourceBytes returns the image previews are made from, the first page for pdfs
+func (repository ReceiptImageRepository) getPreviewSourceBytes(fileData models.FileData) ([]byte, error) {
+	fileRepository := NewFileRepository(repository.TX)
+
+	if fileData.FileType == constants.ApplicationPdf {
+		page, err := repository.GetPdfPage(utils.UintToString(fileData.ID), "1")
+		if err == nil {
+			pageBytes, err := fileRepository.ReadFileDataPage(fileData, page)
+			if err == nil {
+				return pageBytes, nil
+			}
+		}
+	}
+
+	return fileRepository.GetBytesForFileData(fileData)
+}
+
+// CreatePdfPages renders each page of a pdf FileData and stores it next to the pdf
+func (repository ReceiptImageRepository) CreatePdfPages(fileData models.FileData, fileBytes []byte) ([]models.FileDataPage, error) {
+	fileRepository := NewFileRepository(repository.TX)
+	db := repository.GetDB()
+
+	renderedPages, err := fileRepository.ConvertPdfToJpgPages(fileBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]models.FileDataPage, 0, len(renderedPages))
+	for i, pageBytes := range renderedPages {
+		page := models.FileDataPage{
+			FileDataId: fileData.ID,
+			PageNumber: uint(i + 1),
+			Name:       fmt.Sprintf("page-%d.jpg", i+1),
+			FileType:   constants.ImageJpeg,
+			Size:       uint(len(pageBytes)),
+		}
+
+		pageKey := fileRepository.BuildFileDataPageKey(fileData, page)
+		err = fileRepository.WriteFile(pageKey, pageBytes)
+		if err != nil {
+			return pages, err
+		}
+
+		err = db.Model(&models.FileDataPage{}).Create(&page).Error
+		if err != nil {
+			fileRepository.DeleteFile(pageKey)
+			return pages, err
+		}
+
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+func (repository ReceiptImageRepository) UpdateReceiptImageBarcodes(receiptImageId uint, barcodes []models.Barcode) error {
+	db := repository.GetDB()
+
+	return db.Model(&models.FileData{BaseModel: models.BaseModel{ID: receiptImageId}}).
+		Select("barcodes").
+		Updates(&models.FileData{Barcodes: barcodes}).Error
+}
+
+func (repository ReceiptImageRepository) GetPdfPage(fileDataId string, pageNumber string) (models.FileDataPage, error) {
+	db := repository.GetDB()
+	var page models.FileDataPage
+
+	err := db.Model(&models.FileDataPage{}).Where("file_data_id = ? AND page_number = ?", fileDataId, pageNumber).First(&page).Error
+	if err != nil {
+		return models.FileDataPage{}, err
+	}
+
+	return page, nil
+}
+
+// GetPdfPageKeys returns the storage keys of a FileData's page previews, so they can be removed with it
+func (repository ReceiptImageRepository) GetPdfPageKeys(fileData models.FileData) ([]string, error) {
+	fileRepository := NewFileRepository(repository.TX)
+	db := repository.GetDB()
+	pages := make([]models.FileDataPage, 0)
+	keys := make([]string, 0)
+
+	err := db.Model(&models.FileDataPage{}).Where("file_data_id = ?", fileData.ID).Find(&pages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		keys = append(keys, fileRepository.BuildFileDataPageKey(fileData, page))
+	}
+
+	return keys, nil
+}
+
+// GetDerivedFileKeys returns the keys of every file generated from a FileData, page previews and downscaled previews
+func (repository ReceiptImageRepository) GetDerivedFileKeys(fileData models.FileData) ([]string, error) {
+	fileRepository := NewFileRepository(repository.TX)
+
+	keys, err := repository.GetPdfPageKeys(fileData)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, size := range models.GetPreviewImageSizes() {
+		keys = append(keys, fileRepository.BuildFileDataPreviewKey(fileData, size))
+	}
+
+	return keys, nil
 }
 
 func (repository ReceiptImageRepository) GetReceiptImageById(receiptImageId uint) (models.FileData, error) {
@@ -0,0 +1,259 @@
+package repositories
+
+import (
+	"bytes"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func createTestPdfFileData() models.FileData {
+	db := GetDB()
+	CreateTestGroupWithUsers()
+
+	receipt := models.Receipt{
+		Name:         "Test Receipt",
+		Amount:       decimal.NewFromFloat(10.00),
+		Date:         time.Now(),
+		PaidByUserID: 1,
+		Status:       models.OPEN,
+		GroupId:      1,
+	}
+	db.Create(&receipt)
+
+	fileData := models.FileData{
+		Name:      "receipt.pdf",
+		FileType:  "application/pdf",
+		ReceiptId: receipt.ID,
+	}
+	db.Create(&fileData)
+
+	for i := 1; i <= 2; i++ {
+		db.Create(&models.FileDataPage{
+			FileDataId: fileData.ID,
+			PageNumber: uint(i),
+			Name:       "page-" + utils.UintToString(uint(i)) + ".jpg",
+			FileType:   "image/jpeg",
+		})
+	}
+
+	return fileData
+}
+
+func TestShouldGetPdfPageKeys(t *testing.T) {
+	defer TruncateTestDb()
+	fileData := createTestPdfFileData()
+
+	repository := NewReceiptImageRepository(nil)
+	keys, err := repository.GetPdfPageKeys(fileData)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	expectedPrefix := "receipts/" + utils.UintToString(fileData.ReceiptId) + "/" + utils.UintToString(fileData.ID)
+	expected := []string{expectedPrefix + "-page-1.jpg", expectedPrefix + "-page-2.jpg"}
+	if len(keys) != 2 || keys[0] != expected[0] || keys[1] != expected[1] {
+		utils.PrintTestError(t, keys, expected)
+	}
+}
+
+func TestShouldStoreReceiptImageUnderStableKey(t *testing.T) {
+	defer TruncateTestDb()
+	defer storage.SetStorage(nil)
+	db := GetDB()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+	fileData := createTestPdfFileData()
+	pngBytes := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	fileRepository := NewFileRepository(nil)
+	err := fileRepository.WriteFile(fileRepository.BuildFileDataKey(fileData), pngBytes)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	// Renaming the group mustn't affect where the file is
+	db.Model(&models.Group{}).Where("id = ?", 1).Update("name", "Renamed group")
+
+	storedBytes, err := fileRepository.GetRawBytesForFileData(fileData)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if !bytes.Equal(storedBytes, pngBytes) {
+		utils.PrintTestError(t, storedBytes, pngBytes)
+	}
+}
+
+func TestShouldGetPdfPage(t *testing.T) {
+	defer TruncateTestDb()
+	fileData := createTestPdfFileData()
+
+	repository := NewReceiptImageRepository(nil)
+	page, err := repository.GetPdfPage(utils.UintToString(fileData.ID), "2")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if page.PageNumber != 2 || page.Name != "page-2.jpg" {
+		utils.PrintTestError(t, page, "page 2")
+	}
+
+	_, err = repository.GetPdfPage(utils.UintToString(fileData.ID), "3")
+	if err == nil {
+		utils.PrintTestError(t, err, "an error")
+	}
+}
+
+func TestShouldDeletePdfPagesWithFileData(t *testing.T) {
+	defer TruncateTestDb()
+	db := GetDB()
+	fileData := createTestPdfFileData()
+
+	err := db.Delete(&fileData).Error
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	var count int64
+	db.Model(&models.FileDataPage{}).Count(&count)
+	if count != 0 {
+		utils.PrintTestError(t, count, 0)
+	}
+}
+
+func TestShouldUpdateReceiptImageBarcodes(t *testing.T) {
+	defer TruncateTestDb()
+	fileData := createTestPdfFileData()
+	barcodes := []models.Barcode{{Type: "QR-Code", Data: "https://example.com/order/42"}}
+
+	repository := NewReceiptImageRepository(nil)
+	err := repository.UpdateReceiptImageBarcodes(fileData.ID, barcodes)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	updatedFileData, err := repository.GetReceiptImageById(fileData.ID)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(updatedFileData.Barcodes) != 1 || updatedFileData.Barcodes[0] != barcodes[0] {
+		utils.PrintTestError(t, updatedFileData.Barcodes, barcodes)
+	}
+
+	if updatedFileData.Name != fileData.Name {
+		utils.PrintTestError(t, updatedFileData.Name, fileData.Name)
+	}
+}
+
+func TestShouldGetDerivedFileKeys(t *testing.T) {
+	defer TruncateTestDb()
+	fileData := createTestPdfFileData()
+
+	repository := NewReceiptImageRepository(nil)
+	keys, err := repository.GetDerivedFileKeys(fileData)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	previewPrefix := "receipts/" + utils.UintToString(fileData.ReceiptId) + "/" + utils.UintToString(fileData.ID) + "/"
+	if len(keys) != 4 || keys[2] != previewPrefix+"thumb.jpg" || keys[3] != previewPrefix+"medium.jpg" {
+		utils.PrintTestError(t, keys, "2 page keys followed by the thumb and medium preview keys")
+	}
+}
+
+func TestShouldGetStoredReceiptImagePreview(t *testing.T) {
+	defer TruncateTestDb()
+	defer storage.SetStorage(nil)
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+	fileData := createTestPdfFileData()
+
+	fileRepository := NewFileRepository(nil)
+	fileRepository.WriteFile(fileRepository.BuildFileDataPreviewKey(fileData, models.IMAGE_SIZE_THUMB), []byte("thumb"))
+
+	repository := NewReceiptImageRepository(nil)
+	previewBytes, err := repository.GetReceiptImagePreview(fileData, models.IMAGE_SIZE_THUMB)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if string(previewBytes) != "thumb" {
+		utils.PrintTestError(t, string(previewBytes), "thumb")
+	}
+}
+
+func TestShouldKeepSharedBlobUntilLastFileDataIsReleased(t *testing.T) {
+	defer TruncateTestDb()
+	defer storage.SetStorage(nil)
+	db := GetDB()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+	fileRepository := NewFileRepository(nil)
+	fileBlobRepository := NewFileBlobRepository(nil)
+	repository := NewReceiptImageRepository(nil)
+
+	fileData := createTestPdfFileData()
+	fileBlob, _, _ := fileBlobRepository.StoreBlob([]byte("%PDF-1.4"))
+	fileData.BlobHash = fileBlob.Hash
+	db.Model(&fileData).Update("blob_hash", fileBlob.Hash)
+
+	copiedFileData := models.FileData{Name: "copy.pdf", FileType: "application/pdf", ReceiptId: fileData.ReceiptId, BlobHash: fileBlob.Hash}
+	db.Create(&copiedFileData)
+	fileBlobRepository.AddReference(fileBlob.Hash)
+
+	pages, err := repository.CopyFileDataPages(fileData, copiedFileData)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(pages) != 2 || fileRepository.BuildFileDataPageKey(copiedFileData, pages[0]) != fileRepository.BuildBlobDerivedKey(fileBlob.Hash, "page-1.jpg") {
+		utils.PrintTestError(t, pages, "2 pages shared through the blob")
+	}
+
+	unusedKeys, err := repository.ReleaseFileData(fileData)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(unusedKeys) != 0 {
+		utils.PrintTestError(t, unusedKeys, "no keys for a blob")
+	}
+
+	repository.DeleteReleasedFiles([]models.FileData{fileData}, unusedKeys)
+	exists, _ := fileRepository.FileExists(fileRepository.BuildBlobKey(fileBlob.Hash))
+	if !exists {
+		utils.PrintTestError(t, exists, "the blob to be kept while the copy still uses it")
+	}
+
+	unusedKeys, err = repository.ReleaseFileData(copiedFileData)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	repository.DeleteReleasedFiles([]models.FileData{copiedFileData}, unusedKeys)
+	storedKeys, _ := storage.GetStorage().List("blobs/")
+	if len(storedKeys) != 0 {
+		utils.PrintTestError(t, storedKeys, "the blob and its pages to be deleted")
+	}
+
+	_, err = fileBlobRepository.GetFileBlobByHash(fileBlob.Hash)
+	if err == nil {
+		utils.PrintTestError(t, err, "record not found")
+	}
+
+}
@@ -65,15 +65,21 @@ func (repository ReceiptProcessingSettingsRepository) CreateReceiptProcessingSet
 	}
 
 	settings := models.ReceiptProcessingSettings{
-		Name:          command.Name,
-		Description:   command.Description,
-		AiType:        command.AiType,
-		Url:           command.Url,
-		Key:           encryptedKey,
-		Model:         command.Model,
-		IsVisionModel: command.IsVisionModel,
-		OcrEngine:     &command.OcrEngine,
-		PromptId:      command.PromptId,
+		Name:                   command.Name,
+		Description:            command.Description,
+		AiType:                 command.AiType,
+		Url:                    command.Url,
+		Key:                    encryptedKey,
+		Model:                  command.Model,
+		IsVisionModel:          command.IsVisionModel,
+		OcrEngine:              &command.OcrEngine,
+		PromptId:               command.PromptId,
+		PreprocessingSteps:     command.PreprocessingSteps,
+		OcrLanguages:           command.OcrLanguages,
+		OcrPageSegMode:         command.OcrPageSegMode,
+		OcrCharWhitelist:       command.OcrCharWhitelist,
+		OcrCharBlacklist:       command.OcrCharBlacklist,
+		OcrConfidenceThreshold: command.OcrConfidenceThreshold,
 	}
 
 	err := db.Create(&settings).Error
@@ -114,6 +120,12 @@ func (repository ReceiptProcessingSettingsRepository) UpdateReceiptProcessingSet
 	settings.IsVisionModel = command.IsVisionModel
 	settings.OcrEngine = &command.OcrEngine
 	settings.PromptId = command.PromptId
+	settings.PreprocessingSteps = command.PreprocessingSteps
+	settings.OcrLanguages = command.OcrLanguages
+	settings.OcrPageSegMode = command.OcrPageSegMode
+	settings.OcrCharWhitelist = command.OcrCharWhitelist
+	settings.OcrCharBlacklist = command.OcrCharBlacklist
+	settings.OcrConfidenceThreshold = command.OcrConfidenceThreshold
 
 	if updateKey {
 		key, err := utils.EncryptAndEncodeToBase64(config.GetEncryptionKey(), command.Key)
@@ -4,12 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/constants"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/utils"
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
@@ -28,49 +27,6 @@ func NewReceiptRepository(tx *gorm.DB) ReceiptRepository {
 	return repository
 }
 
-func (repository ReceiptRepository) BeforeUpdateReceipt(currentReceipt models.Receipt, updatedReceipt models.Receipt) (err error) {
-	db := repository.GetDB()
-	if updatedReceipt.GroupId > 0 && currentReceipt.GroupId != updatedReceipt.GroupId && len(currentReceipt.ImageFiles) > 0 {
-		var oldGroup models.Group
-		var newGroup models.Group
-
-		err = db.Table("groups").Where("id = ?", currentReceipt.GroupId).Select("id", "name").Find(&oldGroup).Error
-		if err != nil {
-			return err
-		}
-
-		err = db.Table("groups").Where("id = ?", updatedReceipt.GroupId).Select("id", "name").Find(&newGroup).Error
-		if err != nil {
-			return err
-		}
-
-		oldGroupPath, err := utils.BuildGroupPathString(utils.UintToString(oldGroup.ID), oldGroup.Name)
-		if err != nil {
-			return err
-		}
-
-		newGroupPath, err := utils.BuildGroupPathString(utils.UintToString(newGroup.ID), newGroup.Name)
-		if err != nil {
-			return err
-		}
-
-		for _, fileData := range currentReceipt.ImageFiles {
-			filename := utils.BuildFileName(utils.UintToString(currentReceipt.ID), utils.UintToString(fileData.ID), fileData.Name)
-
-			oldFilePath := filepath.Join(oldGroupPath, filename)
-			newFilePathPath := filepath.Join(newGroupPath, filename)
-
-			err := os.Rename(oldFilePath, newFilePathPath)
-			if err != nil {
-				return err
-			}
-		}
-
-	}
-
-	return nil
-}
-
 func createFailedUpdateSystemTask(command commands.UpsertSystemTaskCommand, err error) {
 	endedAt := time.Now()
 	command.EndedAt = &endedAt
@@ -131,12 +87,7 @@ func (repository ReceiptRepository) UpdateReceipt(id string, command commands.Up
 	err = db.Transaction(func(tx *gorm.DB) error {
 		repository.SetTransaction(tx)
 
-		txErr := repository.BeforeUpdateReceipt(currentReceipt, updatedReceipt)
-		if txErr != nil {
-			return txErr
-		}
-
-		txErr = tx.Session(&gorm.Session{FullSaveAssociations: true}).Model(&currentReceipt).Updates(&updatedReceipt).Error
+		txErr := tx.Session(&gorm.Session{FullSaveAssociations: true}).Model(&currentReceipt).Updates(&updatedReceipt).Error
 		if txErr != nil {
 			return txErr
 		}
@@ -490,6 +441,22 @@ func (repository ReceiptRepository) CreateReceipt(
 	return fullyLoadedReceipt, nil
 }
 
+func (repository ReceiptRepository) GetGroupIdsByReceiptIds(receiptIds []string) ([]uint, error) {
+	db := repository.GetDB()
+	var groupIds []uint
+
+	if len(receiptIds) == 0 {
+		return groupIds, nil
+	}
+
+	err := db.Model(&models.Receipt{}).Where("id IN ?", receiptIds).Distinct().Pluck("group_id", &groupIds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return groupIds, nil
+}
+
 func (repository ReceiptRepository) GetReceiptById(receiptId string) (models.Receipt, error) {
 	db := GetDB()
 	var receipt models.Receipt
@@ -501,11 +468,13 @@ func (repository ReceiptRepository) GetReceiptById(receiptId string) (models.Rec
 	return receipt, nil
 }
 
+// GetPagedReceiptsByGroupId spans every group of the user for the all group, narrowed down to apiKeyGroupIds when a restricted api key is used
 func (repository ReceiptRepository) GetPagedReceiptsByGroupId(
 	userId uint,
 	groupId string,
 	pagedRequest commands.ReceiptPagedRequestCommand,
 	associations []string,
+	apiKeyGroupIds []uint,
 ) ([]models.Receipt, int64, error) {
 	var receipts []models.Receipt
 	var count int64
@@ -533,6 +502,12 @@ func (repository ReceiptRepository) GetPagedReceiptsByGroupId(
 		if err != nil {
 			return nil, 0, err
 		}
+
+		if len(apiKeyGroupIds) > 0 {
+			groupIds = slices.DeleteFunc(groupIds, func(groupId uint) bool {
+				return !slices.Contains(apiKeyGroupIds, groupId)
+			})
+		}
 		query = query.Where("group_id IN ?", groupIds)
 	} else {
 		query = query.Where("group_id = ?", groupId)
@@ -758,6 +733,26 @@ func (repository ReceiptRepository) GetReceiptGroupIdByReceiptId(id string) (uin
 	return receipt.GroupId, nil
 }
 
+// GetReceiptIdsWithImagesByGroupId returns the receipts of a group that have images, optionally only those with status
+func (repository ReceiptRepository) GetReceiptIdsWithImagesByGroupId(groupId uint, status models.ReceiptStatus) ([]uint, error) {
+	db := repository.GetDB()
+	var receiptIds []uint
+
+	query := db.Model(models.Receipt{}).
+		Where("group_id = ?", groupId).
+		Where("id IN (?)", db.Model(models.FileData{}).Select("receipt_id"))
+	if len(status) > 0 {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Order("id").Pluck("id", &receiptIds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return receiptIds, nil
+}
+
 func (repository ReceiptRepository) FilterLinkedItemsFromReceiptItems(receipt *models.Receipt) {
 	if len(receipt.ReceiptItems) == 0 {
 		return
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ResumableUploadRepository struct {
+	BaseRepository
+}
+
+func NewResumableUploadRepository(tx *gorm.DB) ResumableUploadRepository {
+	repository := ResumableUploadRepository{BaseRepository: BaseRepository{
+		DB: GetDB(),
+		TX: tx,
+	}}
+	return repository
+}
+
+func (repository ResumableUploadRepository) CreateResumableUpload(upload models.ResumableUpload) (models.ResumableUpload, error) {
+	db := repository.GetDB()
+
+	err := db.Create(&upload).Error
+	if err != nil {
+		return models.ResumableUpload{}, err
+	}
+
+	return upload, nil
+}
+
+func (repository ResumableUploadRepository) GetResumableUploadByUploadId(uploadId string) (models.ResumableUpload, error) {
+	db := repository.GetDB()
+	var upload models.ResumableUpload
+
+	err := db.Model(&models.ResumableUpload{}).Where("upload_id = ?", uploadId).First(&upload).Error
+	if err != nil {
+		return models.ResumableUpload{}, err
+	}
+
+	return upload, nil
+}
+
+func (repository ResumableUploadRepository) UpdateResumableUploadProgress(upload models.ResumableUpload) error {
+	db := repository.GetDB()
+
+	return db.Model(&models.ResumableUpload{}).
+		Where("id = ?", upload.ID).
+		Select("upload_offset", "expires_at", "completed_at").
+		Updates(&upload).Error
+}
+
+// CompleteResumableUpload marks an upload as completed, it returns false when it already was
+func (repository ResumableUploadRepository) CompleteResumableUpload(id uint, completedAt time.Time) (bool, error) {
+	result := repository.GetDB().Model(&models.ResumableUpload{}).
+		Where("id = ? AND completed_at IS NULL", id).
+		Update("completed_at", completedAt)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (repository ResumableUploadRepository) DeleteResumableUpload(id uint) error {
+	db := repository.GetDB()
+
+	return db.Delete(&models.ResumableUpload{}, id).Error
+}
+
+func (repository ResumableUploadRepository) GetResumableUploadsExpiredBefore(cutOff time.Time) ([]models.ResumableUpload, error) {
+	db := repository.GetDB()
+	uploads := make([]models.ResumableUpload, 0)
+
+	err := db.Model(&models.ResumableUpload{}).Where("expires_at < ?", cutOff).Find(&uploads).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return uploads, nil
+}
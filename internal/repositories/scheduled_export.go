@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"encoding/json"
+	"receipt-wrangler/api/internal/commands"
+	config "receipt-wrangler/api/internal/env"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScheduledExportRepository struct {
+	BaseRepository
+}
+
+func NewScheduledExportRepository(tx *gorm.DB) ScheduledExportRepository {
+	repository := ScheduledExportRepository{BaseRepository: BaseRepository{
+		DB: GetDB(),
+		TX: tx,
+	}}
+	return repository
+}
+
+func (repository ScheduledExportRepository) GetScheduledExportById(id string) (models.ScheduledExport, error) {
+	db := repository.GetDB()
+	var scheduledExport models.ScheduledExport
+
+	err := db.Model(&models.ScheduledExport{}).Where("id = ?", id).First(&scheduledExport).Error
+	if err != nil {
+		return models.ScheduledExport{}, err
+	}
+
+	return scheduledExport, nil
+}
+
+func (repository ScheduledExportRepository) GetScheduledExportsByGroupId(groupId string) ([]models.ScheduledExport, error) {
+	db := repository.GetDB()
+	scheduledExports := make([]models.ScheduledExport, 0)
+
+	err := db.Model(&models.ScheduledExport{}).Where("group_id = ?", groupId).Order("id").Find(&scheduledExports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduledExports, nil
+}
+
+func (repository ScheduledExportRepository) GetEnabledScheduledExports() ([]models.ScheduledExport, error) {
+	db := repository.GetDB()
+	var scheduledExports []models.ScheduledExport
+
+	err := db.Model(&models.ScheduledExport{}).Where("enabled = ?", true).Find(&scheduledExports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduledExports, nil
+}
+
+// CreateScheduledExport saves a new export, the user saving it is the one it runs as
+func (repository ScheduledExportRepository) CreateScheduledExport(
+	groupId uint,
+	userId uint,
+	command commands.UpsertScheduledExportCommand,
+) (models.ScheduledExport, error) {
+	db := repository.GetDB()
+	scheduledExport := models.ScheduledExport{GroupId: groupId}
+
+	err := repository.applyCommand(&scheduledExport, userId, command, true)
+	if err != nil {
+		return models.ScheduledExport{}, err
+	}
+
+	err = db.Create(&scheduledExport).Error
+	if err != nil {
+		return models.ScheduledExport{}, err
+	}
+
+	return scheduledExport, nil
+}
+
+// UpdateScheduledExport keeps the stored WebDAV password unless updatePassword is set
+func (repository ScheduledExportRepository) UpdateScheduledExport(
+	scheduledExport models.ScheduledExport,
+	userId uint,
+	command commands.UpsertScheduledExportCommand,
+	updatePassword bool,
+) (models.ScheduledExport, error) {
+	db := repository.GetDB()
+
+	err := repository.applyCommand(&scheduledExport, userId, command, updatePassword)
+	if err != nil {
+		return models.ScheduledExport{}, err
+	}
+
+	err = db.Save(&scheduledExport).Error
+	if err != nil {
+		return models.ScheduledExport{}, err
+	}
+
+	return scheduledExport, nil
+}
+
+func (repository ScheduledExportRepository) applyCommand(
+	scheduledExport *models.ScheduledExport,
+	userId uint,
+	command commands.UpsertScheduledExportCommand,
+	updatePassword bool,
+) error {
+	filter, err := json.Marshal(command.Filter)
+	if err != nil {
+		return err
+	}
+
+	scheduledExport.UserId = userId
+	scheduledExport.Name = command.Name
+	scheduledExport.Filter = filter
+	scheduledExport.Format = command.Format
+	scheduledExport.IncludeCustomFields = command.IncludeCustomFields
+	scheduledExport.IncludeComments = command.IncludeComments
+	scheduledExport.IncludeImages = command.IncludeImages
+	scheduledExport.Schedule = command.Schedule
+	scheduledExport.DestinationType = command.DestinationType
+	scheduledExport.Destination = command.Destination
+	scheduledExport.WebDavUsername = command.WebDavUsername
+	scheduledExport.Enabled = command.Enabled
+
+	if updatePassword {
+		scheduledExport.WebDavPassword = ""
+		if len(command.WebDavPassword) > 0 {
+			encodedPassword, err := utils.EncryptAndEncodeToBase64(config.GetEncryptionKey(), command.WebDavPassword)
+			if err != nil {
+				return err
+			}
+			scheduledExport.WebDavPassword = encodedPassword
+		}
+	}
+
+	return nil
+}
+
+func (repository ScheduledExportRepository) SetScheduledExportLastRunAt(id uint, lastRunAt time.Time) error {
+	db := repository.GetDB()
+
+	return db.Model(&models.ScheduledExport{}).Where("id = ?", id).Update("last_run_at", lastRunAt).Error
+}
+
+func (repository ScheduledExportRepository) DeleteScheduledExport(id uint) error {
+	db := repository.GetDB()
+
+	return db.Delete(&models.ScheduledExport{}, id).Error
+}
+
+func (repository ScheduledExportRepository) DeleteScheduledExportsByGroupId(groupId uint) error {
+	db := repository.GetDB()
+
+	return db.Where("group_id = ?", groupId).Delete(&models.ScheduledExport{}).Error
+}
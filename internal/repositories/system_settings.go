@@ -91,8 +91,14 @@ func (repository SystemSettingsRepository) UpdateSystemSettings(command commands
 		return models.SystemSettings{}, err
 	}
 
+	// Clients that predate the retention setting leave it out, which must not turn retention off
+	omittedFields := []string{"TaskQueueConfigurations"}
+	if command.AuditLogRetentionDays == nil {
+		omittedFields = append(omittedFields, "AuditLogRetentionDays")
+	}
+
 	err = db.Transaction(func(tx *gorm.DB) error {
-		txErr := tx.Model(&updatedSettings).Select("*").Omit("TaskQueueConfigurations").Where("id = ?", existingSettings.ID).Updates(&updatedSettings).Error
+		txErr := tx.Model(&updatedSettings).Select("*").Omit(omittedFields...).Where("id = ?", existingSettings.ID).Updates(&updatedSettings).Error
 		if txErr != nil {
 			return txErr
 		}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldKeepAuditLogRetentionDaysWhenUpdateOmitsIt(t *testing.T) {
+	defer TruncateTestDb()
+	db := GetDB()
+	db.Create(&models.SystemSettings{AuditLogRetentionDays: 30})
+	repository := NewSystemSettingsRepository(nil)
+
+	_, err := repository.UpdateSystemSettings(commands.UpsertSystemSettingsCommand{CurrencyDisplay: "$"})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	var systemSettings models.SystemSettings
+	db.First(&systemSettings)
+	if systemSettings.AuditLogRetentionDays != 30 || systemSettings.CurrencyDisplay != "$" {
+		utils.PrintTestError(t, systemSettings.AuditLogRetentionDays, 30)
+	}
+
+	retentionDays := 0
+	_, err = repository.UpdateSystemSettings(commands.UpsertSystemSettingsCommand{AuditLogRetentionDays: &retentionDays})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	db.First(&systemSettings)
+	if systemSettings.AuditLogRetentionDays != 0 {
+		utils.PrintTestError(t, systemSettings.AuditLogRetentionDays, 0)
+	}
+}
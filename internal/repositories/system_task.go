@@ -7,6 +7,7 @@ import (
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/structs"
+	"time"
 )
 
 type SystemTaskRepository struct {
@@ -115,6 +116,7 @@ func (repository SystemTaskRepository) CreateSystemTask(command commands.UpsertS
 		GroupId:                command.GroupId,
 		AssociatedSystemTaskId: command.AssociatedSystemTaskId,
 		AsynqTaskId:            command.AsynqTaskId,
+		OcrWords:               command.OcrWords,
 	}
 
 	err := db.Create(&systemTask).Error
@@ -164,3 +166,44 @@ func (repository SystemTaskRepository) AssociateSystemTaskToReceipt(receiptId ui
 	db := repository.GetDB()
 	return db.Model(&models.SystemTask{}).Where("id = ?", systemTaskId).Update("receipt_id", receiptId).Error
 }
+
+// DeleteSystemTasksCreatedBefore deletes old tasks, optionally only those with status, newer tasks pointing at them are detached
+func (repository SystemTaskRepository) DeleteSystemTasksCreatedBefore(date time.Time, status models.SystemTaskStatus) (int64, error) {
+	db := repository.GetDB()
+	var deletedCount int64
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tasksToDelete := tx.Model(&models.SystemTask{}).Select("id").Where("created_at < ?", date)
+		if len(status) > 0 {
+			tasksToDelete = tasksToDelete.Where("status = ?", status)
+		}
+
+		var ids []uint
+		err := tasksToDelete.Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		for start := 0; start < len(ids); start += 500 {
+			end := min(start+500, len(ids))
+			batch := ids[start:end]
+
+			err = tx.Model(&models.SystemTask{}).
+				Where("associated_system_task_id IN ?", batch).
+				Update("associated_system_task_id", nil).Error
+			if err != nil {
+				return err
+			}
+
+			result := tx.Where("id IN ?", batch).Delete(&models.SystemTask{})
+			if result.Error != nil {
+				return result.Error
+			}
+			deletedCount += result.RowsAffected
+		}
+
+		return nil
+	})
+
+	return deletedCount, err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
 	"receipt-wrangler/api/internal/commands"
 	"receipt-wrangler/api/internal/models"
@@ -20,11 +21,15 @@ func NewTagsRepository(tx *gorm.DB) TagsRepository {
 	return repository
 }
 
-func (repository TagsRepository) GetAllTags(querySelect string) ([]models.Tag, error) {
+// GetAllTags returns the global tags and those of groupIds, or every tag when groupIds is nil
+func (repository TagsRepository) GetAllTags(querySelect string, groupIds []uint) ([]models.Tag, error) {
 	db := repository.GetDB()
 	var tags []models.Tag
 
-	err := db.Table("tags").Select(querySelect).Find(&tags).Error
+	err := db.Table("tags").
+		Select(querySelect).
+		Scopes(repository.GroupScope("tags", groupIds)).
+		Find(&tags).Error
 	if err != nil {
 		return nil, err
 	}
@@ -38,8 +43,14 @@ func (repository TagsRepository) CreateTag(command commands.UpsertTagCommand) (m
 
 	tag.Name = command.Name
 	tag.Description = command.Description
+	tag.GroupId = command.GroupId
 
-	err := db.Model(&tag).Create(&tag).Error
+	err := repository.validateNameIsAvailable(tag.Name, tag.GroupId, 0)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	err = db.Model(&tag).Create(&tag).Error
 	if err != nil {
 		return models.Tag{}, err
 	}
@@ -47,7 +58,7 @@ func (repository TagsRepository) CreateTag(command commands.UpsertTagCommand) (m
 	return tag, nil
 }
 
-func (repository TagsRepository) GetAllPagedTags(pagedRequestCommand commands.PagedRequestCommand) ([]models.TagView, error) {
+func (repository TagsRepository) GetAllPagedTags(pagedRequestCommand commands.PagedRequestCommand, groupIds []uint) ([]models.TagView, error) {
 	db := repository.GetDB()
 	var tags []models.TagView
 	quotedAlias := "\"NumberOfReceipts\""
@@ -58,11 +69,12 @@ func (repository TagsRepository) GetAllPagedTags(pagedRequestCommand commands.Pa
 
 	query := repository.Sort(db, pagedRequestCommand.OrderBy, pagedRequestCommand.SortDirection)
 	query = query.Scopes(repository.Paginate(pagedRequestCommand.Page, pagedRequestCommand.PageSize))
-	selectString := fmt.Sprintf("tags.id, tags.name, tags.description, COUNT(DISTINCT receipt_tags.receipt_id) as %s", quotedAlias)
+	selectString := fmt.Sprintf("tags.id, tags.name, tags.description, tags.group_id, COUNT(DISTINCT receipt_tags.receipt_id) as %s", quotedAlias)
 	query = query.Table("tags").
 		Select(selectString).
 		Joins("LEFT JOIN receipt_tags ON tags.id = receipt_tags.tag_id").
-		Group("tags.id, tags.name")
+		Scopes(repository.GroupScope("tags", groupIds)).
+		Group("tags.id, tags.name, tags.description, tags.group_id")
 
 	err := query.Scan(&tags).Error
 	if err != nil {
@@ -74,9 +86,23 @@ func (repository TagsRepository) GetAllPagedTags(pagedRequestCommand commands.Pa
 
 func (repository TagsRepository) UpdateTag(tagId string, command commands.UpsertTagCommand) (models.Tag, error) {
 	db := repository.GetDB()
+	var existingTag models.Tag
 	var updatedTag models.Tag
 
-	err := db.Model(models.Tag{}).Where("id = ?", tagId).Updates(command).Error
+	err := db.Model(models.Tag{}).Where("id = ?", tagId).First(&existingTag).Error
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	err = repository.validateNameIsAvailable(command.Name, existingTag.GroupId, existingTag.ID)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	// The group a tag belongs to is fixed at creation
+	command.GroupId = nil
+
+	err = db.Model(models.Tag{}).Where("id = ?", tagId).Updates(command).Error
 	if err != nil {
 		return models.Tag{}, err
 	}
@@ -111,3 +137,59 @@ func (repository TagsRepository) DeleteTag(tagId uint) error {
 
 	return nil
 }
+
+func (repository TagsRepository) DeleteTagsByGroupId(groupId uint) error {
+	db := repository.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var tagIds []uint
+		err := tx.Model(&models.Tag{}).Where("group_id = ?", groupId).Pluck("id", &tagIds).Error
+		if err != nil || len(tagIds) == 0 {
+			return err
+		}
+
+		err = tx.Where("tag_id IN ?", tagIds).Delete(&models.ReceiptTag{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("DELETE FROM item_tags WHERE tag_id IN ?", tagIds).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("group_id = ?", groupId).Delete(&models.Tag{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository TagsRepository) CountTagsOutsideGroup(tagIds []uint, groupId uint) (int64, error) {
+	return repository.CountOutsideGroup("tags", tagIds, groupId)
+}
+
+func (repository TagsRepository) validateNameIsAvailable(name string, groupId *uint, excludeId uint) error {
+	db := repository.GetDB()
+	var count int64
+
+	query := db.Model(&models.Tag{}).Where("name = ? AND id <> ?", name, excludeId)
+	if groupId == nil {
+		query = query.Where("group_id IS NULL")
+	} else {
+		query = query.Where("group_id = ?", *groupId)
+	}
+
+	err := query.Count(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return errors.New("tag with this name already exists")
+	}
+
+	return nil
+}
@@ -139,9 +139,9 @@ func CreateTestCategories() {
 func TruncateTestDb() {
 	db := GetDB()
 
-	// Get all table names
+	// Get all table names, applied migrations stay since the schema is kept
 	var tables []string
-	db.Raw("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'").Scan(&tables)
+	db.Raw("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'").Scan(&tables)
 
 	// Disable foreign key constraints temporarily
 	db.Exec("PRAGMA foreign_keys = OFF")
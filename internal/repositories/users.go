@@ -167,3 +167,46 @@ func (repository UserRepository) IsFirstAdminToLogin() (bool, error) {
 
 	return foundUser.ID == 0, nil
 }
+
+// GetFirstAdminUserId returns the oldest admin, used by maintenance commands that need a user to act as
+func (repository UserRepository) GetFirstAdminUserId() (uint, error) {
+	foundUser := models.User{}
+
+	err := repository.
+		GetDB().
+		Select("id").
+		Model(models.User{}).
+		Where("user_role = ?", models.ADMIN).
+		Order("id").
+		First(&foundUser).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	return foundUser.ID, nil
+}
+
+func (repository UserRepository) GetUserByUsername(username string) (structs.UserView, error) {
+	var user structs.UserView
+
+	err := repository.GetDB().Model(models.User{}).Where("username = ?", username).First(&user).Error
+	if err != nil {
+		return structs.UserView{}, err
+	}
+
+	return user, nil
+}
+
+func (repository UserRepository) UpdateUserPassword(userId uint, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return repository.GetDB().Model(models.User{}).Where("id = ?", userId).UpdateColumn("password", hashedPassword).Error
+}
+
+func (repository UserRepository) UpdateUserRole(userId uint, userRole models.UserRole) error {
+	return repository.GetDB().Model(models.User{}).Where("id = ?", userId).Update("user_role", userRole).Error
+}
@@ -13,6 +13,7 @@ func BuildApiKeyRouter() *chi.Mux {
 	apiKeyRouter.With(middleware.UnifiedAuthMiddleware).Post("/", handlers.CreateApiKey)
 	apiKeyRouter.With(middleware.UnifiedAuthMiddleware).Post("/paged", handlers.GetPagedApiKeys)
 	apiKeyRouter.With(middleware.UnifiedAuthMiddleware).Put("/{id}", handlers.UpdateApiKey)
+	apiKeyRouter.With(middleware.UnifiedAuthMiddleware).Post("/{id}/rotate", handlers.RotateApiKey)
 	apiKeyRouter.With(middleware.UnifiedAuthMiddleware).Delete("/{id}", handlers.DeleteApiKey)
 
 	return apiKeyRouter
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"receipt-wrangler/api/internal/handlers"
+	"receipt-wrangler/api/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func BuildAuditLogRouter() *chi.Mux {
+	auditLogRouter := chi.NewRouter()
+
+	auditLogRouter.Use(middleware.UnifiedAuthMiddleware)
+	auditLogRouter.Post("/getPagedAuditLogs", handlers.GetPagedAuditLogs)
+
+	return auditLogRouter
+}
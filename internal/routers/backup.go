@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"receipt-wrangler/api/internal/handlers"
+	"receipt-wrangler/api/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func BuildBackupRouter() *chi.Mux {
+	backupRouter := chi.NewRouter()
+
+	backupRouter.Use(middleware.UnifiedAuthMiddleware)
+	backupRouter.Get("/", handlers.GetInstanceBackup)
+	backupRouter.Get("/group/{groupId}", handlers.GetGroupBackup)
+	backupRouter.Post("/restore", handlers.RestoreBackup)
+
+	return backupRouter
+}
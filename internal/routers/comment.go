@@ -10,8 +10,8 @@ func BuildCommentRouter() *chi.Mux {
 	commentRouter := chi.NewRouter()
 
 	commentRouter.Use(middleware.UnifiedAuthMiddleware)
-	commentRouter.Post("/", handlers.AddComment)
-	commentRouter.Delete("/{commentId}", handlers.DeleteComment)
+	commentRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromBody)).Post("/", handlers.AddComment)
+	commentRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromCommentUrl)).Delete("/{commentId}", handlers.DeleteComment)
 
 	return commentRouter
 }
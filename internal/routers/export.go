@@ -13,6 +13,7 @@ func BuildExportRouter() *chi.Mux {
 	exportRouter.Use(middleware.UnifiedAuthMiddleware)
 	exportRouter.Post("/", handlers.ExportReceiptsById)
 	exportRouter.Post("/{groupId}", handlers.ExportAllReceiptsFromGroup)
+	exportRouter.Get("/reports/{expenseReportId}", handlers.GetExpenseReport)
 
 	return exportRouter
 }
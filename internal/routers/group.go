@@ -16,10 +16,23 @@ func BuildGroupRouter() *chi.Mux {
 	groupRouter.Put("/{groupId}", handlers.UpdateGroup)
 	groupRouter.Put("/{groupId}/groupSettings", handlers.UpdateGroupSettings)
 	groupRouter.Put("/{groupId}/groupReceiptSettings", handlers.UpdateGroupReceiptSettings)
+	groupRouter.Get("/{groupId}/ledgerSettings", handlers.GetGroupLedgerSettings)
+	groupRouter.Put("/{groupId}/ledgerSettings", handlers.UpdateGroupLedgerSettings)
+	groupRouter.Get("/{groupId}/scheduledExports", handlers.GetScheduledExportsForGroup)
+	groupRouter.Post("/{groupId}/scheduledExports", handlers.CreateScheduledExport)
+	groupRouter.Put("/{groupId}/scheduledExports/{scheduledExportId}", handlers.UpdateScheduledExport)
+	groupRouter.Delete("/{groupId}/scheduledExports/{scheduledExportId}", handlers.DeleteScheduledExport)
+	groupRouter.Post("/{groupId}/scheduledExports/{scheduledExportId}/run", handlers.RunScheduledExport)
 	groupRouter.With(middleware.CanDeleteGroup).Delete("/{groupId}", handlers.DeleteGroup)
-	groupRouter.Post("/{groupId}/pollGroupEmail", handlers.PollGroupEmail)
+	groupRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromUrl)).Post("/{groupId}/pollGroupEmail", handlers.PollGroupEmail)
 	groupRouter.Post("/getPagedGroups", handlers.GetPagedGroups)
 	groupRouter.Get("/{groupId}/ocrText", handlers.GetOcrTextForGroup)
+	groupRouter.Get("/{groupId}/invites", handlers.GetPendingGroupInvites)
+	groupRouter.Post("/{groupId}/invites", handlers.CreateGroupInvite)
+	groupRouter.Delete("/{groupId}/invites/{inviteId}", handlers.RevokeGroupInvite)
+	groupRouter.Post("/{groupId}/archive", handlers.ArchiveGroup)
+	groupRouter.Post("/{groupId}/unarchive", handlers.UnarchiveGroup)
+	groupRouter.Post("/bulkArchive", handlers.BulkArchiveGroups)
 
 	return groupRouter
 }
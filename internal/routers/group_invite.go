@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/handlers"
+	"receipt-wrangler/api/internal/middleware"
+)
+
+func BuildGroupInviteRouter() *chi.Mux {
+	groupInviteRouter := chi.NewRouter()
+
+	groupInviteRouter.Get("/{token}", handlers.GetGroupInviteInfo)
+	groupInviteRouter.With(middleware.UnifiedAuthMiddleware).Post("/{token}/accept", handlers.AcceptGroupInvite)
+	groupInviteRouter.With(middleware.SetGeneralBodyData("signUpCommand", commands.SignUpCommand{})).Post("/{token}/signUp", handlers.SignUpWithGroupInvite)
+
+	return groupInviteRouter
+}
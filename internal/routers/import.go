@@ -11,6 +11,8 @@ func BuildImportRouter() *chi.Mux {
 
 	router.Use(middleware.UnifiedAuthMiddleware)
 	router.Post("/importConfigJson", handlers.ImportConfigJson)
+	router.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromImportOptions)).Post("/receiptsCsv", handlers.ImportReceiptsCsv)
+	router.Post("/expenses", handlers.ImportExpenses)
 
 	return router
 }
@@ -11,12 +11,12 @@ func BuildReceiptRouter() *chi.Mux {
 	receiptRouter.Use(middleware.UnifiedAuthMiddleware)
 	receiptRouter.Get("/hasAccess", handlers.HasAccess)
 	receiptRouter.Get("/{id}", handlers.GetReceipt)
-	receiptRouter.Put("/{id}", handlers.UpdateReceipt)
+	receiptRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromReceiptUrlAndBody)).Put("/{id}", handlers.UpdateReceipt)
 	receiptRouter.Post("/group/{groupId}", handlers.GetPagedReceiptsForGroup)
-	receiptRouter.Post("/bulkStatusUpdate", handlers.BulkReceiptStatusUpdate)
-	receiptRouter.Post("/", handlers.CreateReceipt)
-	receiptRouter.Post("/{id}/duplicate", handlers.DuplicateReceipt)
-	receiptRouter.Post("/quickScan", handlers.QuickScan)
-	receiptRouter.Delete("/{id}", handlers.DeleteReceipt)
+	receiptRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromBody)).Post("/bulkStatusUpdate", handlers.BulkReceiptStatusUpdate)
+	receiptRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromBody)).Post("/", handlers.CreateReceipt)
+	receiptRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromReceiptUrl)).Post("/{id}/duplicate", handlers.DuplicateReceipt)
+	receiptRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromBody)).Post("/quickScan", handlers.QuickScan)
+	receiptRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromReceiptUrl)).Delete("/{id}", handlers.DeleteReceipt)
 	return receiptRouter
 }
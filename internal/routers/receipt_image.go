@@ -10,11 +10,13 @@ func BuildReceiptImageRouter() *chi.Mux {
 	receiptImageRouter := chi.NewRouter()
 
 	receiptImageRouter.Use(middleware.UnifiedAuthMiddleware)
+	receiptImageRouter.Get("/hash/{hash}", handlers.GetReceiptImageBlob)
 	receiptImageRouter.Get("/{id}", handlers.GetReceiptImage)
 	receiptImageRouter.Get("/{id}/download", handlers.DownloadReceiptImage)
+	receiptImageRouter.Get("/{id}/pages/{pageNumber}", handlers.GetReceiptImagePage)
 	receiptImageRouter.Post("/magicFill", handlers.MagicFillFromImage)
-	receiptImageRouter.Delete("/{id}", handlers.RemoveReceiptImage)
-	receiptImageRouter.Post("/", handlers.UploadReceiptImage)
+	receiptImageRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromReceiptImageUrl)).Delete("/{id}", handlers.RemoveReceiptImage)
+	receiptImageRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromBody)).Post("/", handlers.UploadReceiptImage)
 	receiptImageRouter.Post("/convertToJpg", handlers.ConvertToJpg)
 
 	return receiptImageRouter
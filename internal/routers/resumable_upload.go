@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"receipt-wrangler/api/internal/handlers"
+	"receipt-wrangler/api/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// BuildResumableUploadRouter serves the tus protocol, completed uploads are quick scanned or attached to a receipt
+func BuildResumableUploadRouter() *chi.Mux {
+	resumableUploadRouter := chi.NewRouter()
+
+	resumableUploadRouter.Use(middleware.UnifiedAuthMiddleware)
+	resumableUploadRouter.Options("/", handlers.GetResumableUploadOptions)
+	resumableUploadRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromUploadMetadata)).Post("/", handlers.CreateResumableUpload)
+	resumableUploadRouter.Head("/{uploadId}", handlers.GetResumableUploadOffset)
+	resumableUploadRouter.With(middleware.ValidateGroupIsActive(middleware.GroupIdFromResumableUploadUrl)).Patch("/{uploadId}", handlers.PatchResumableUpload)
+	resumableUploadRouter.Delete("/{uploadId}", handlers.DeleteResumableUpload)
+
+	return resumableUploadRouter
+}
@@ -133,5 +133,21 @@ func BuildRootRouter() *chi.Mux {
 	apiKeyRouter := BuildApiKeyRouter()
 	rootRouter.Mount("/api/apiKey", apiKeyRouter)
 
+	// Audit Log router
+	auditLogRouter := BuildAuditLogRouter()
+	rootRouter.Mount("/api/auditLog", auditLogRouter)
+
+	// Group Invite Router
+	groupInviteRouter := BuildGroupInviteRouter()
+	rootRouter.Mount("/api/groupInvite", groupInviteRouter)
+
+	// Resumable Upload Router
+	resumableUploadRouter := BuildResumableUploadRouter()
+	rootRouter.Mount("/api/upload", resumableUploadRouter)
+
+	// Backup Router
+	backupRouter := BuildBackupRouter()
+	rootRouter.Mount("/api/backup", backupRouter)
+
 	return rootRouter
 }
@@ -14,6 +14,7 @@ func BuildSystemTaskRouter() *chi.Mux {
 	systemTaskRouter.Post("/getPagedSystemTasks", handlers.GetSystemTasks)
 	systemTaskRouter.Post("/getPagedActivities", handlers.GetActivitiesForGroups)
 	systemTaskRouter.Post("/rerunActivity/{id}", handlers.RerunActivity)
+	systemTaskRouter.Post("/storageIntegrityCheck", handlers.RunStorageIntegrityCheck)
 
 	return systemTaskRouter
 }
@@ -68,8 +68,15 @@ func (service *ApiKeyService) CreateApiKey(userId uint, command commands.UpsertA
 		CreatedBy:   &userId,
 	}
 
-	apiKeyRepository := repositories.NewApiKeyRepository(service.TX)
-	_, err = apiKeyRepository.CreateApiKey(apiKey)
+	err = service.GetDB().Transaction(func(tx *gorm.DB) error {
+		apiKeyRepository := repositories.NewApiKeyRepository(tx)
+		_, txErr := apiKeyRepository.CreateApiKey(apiKey)
+		if txErr != nil {
+			return txErr
+		}
+
+		return apiKeyRepository.UpdateApiKeyRestrictions(b64Id, command.ExpiresAt, command.AllowedGroupIds, command.ResourceScopes)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -89,6 +96,15 @@ func (service *ApiKeyService) GenerateApiKeyHmac(secret string) (string, error)
 	return utils.Base64Encode(hmac), nil
 }
 
+func (service *ApiKeyService) GetIdFromV1ApiKey(apiKey string) (string, error) {
+	parts := strings.Split(apiKey, ".")
+	if len(parts) != constants.V1PartLength {
+		return "", errors.New("invalid api key structure")
+	}
+
+	return parts[2], nil
+}
+
 func (service *ApiKeyService) ValidateV1ApiKey(apiKey string) (models.ApiKey, error) {
 	parts := strings.Split(apiKey, ".")
 	if len(parts) != constants.V1PartLength {
@@ -114,11 +130,23 @@ func (service *ApiKeyService) ValidateV1ApiKey(apiKey string) (models.ApiKey, er
 		return models.ApiKey{}, err
 	}
 
-	if b64hmac != apiKeyData.Hmac {
-		return models.ApiKey{}, errors.New("invalid api key secret")
+	if apiKeyData.IsExpired() {
+		return models.ApiKey{}, errors.New("api key has expired")
 	}
 
-	return apiKeyData, nil
+	if b64hmac == apiKeyData.Hmac {
+		return apiKeyData, nil
+	}
+
+	// The secret from before the last rotation keeps working during the grace period
+	if len(apiKeyData.PreviousHmac) > 0 &&
+		b64hmac == apiKeyData.PreviousHmac &&
+		apiKeyData.PreviousHmacExpiresAt != nil &&
+		apiKeyData.PreviousHmacExpiresAt.After(time.Now()) {
+		return apiKeyData, nil
+	}
+
+	return models.ApiKey{}, errors.New("invalid api key secret")
 }
 
 func (service *ApiKeyService) GetClaimsFromApiKey(key models.ApiKey) (validator.ValidatedClaims, error) {
@@ -135,6 +163,8 @@ func (service *ApiKeyService) GetClaimsFromApiKey(key models.ApiKey) (validator.
 		Displayname:        user.DisplayName,
 		UserRole:           user.UserRole,
 		ApiKeyScope:        models.ApiKeyScope(key.Scope),
+		ApiKeyId:           key.ID,
+		ApiKeyGroupIds:     key.GetAllowedGroupIds(),
 		RegisteredClaims:   jwt.RegisteredClaims{},
 	}
 
@@ -177,8 +207,132 @@ func (service *ApiKeyService) UpdateApiKey(apiKeyId string, userId uint, command
 		return errors.New("API key not found")
 	}
 
-	// Update the API key
-	return apiKeyRepository.UpdateApiKey(apiKeyId, userId, command.Name, command.Description, command.Scope)
+	return service.GetDB().Transaction(func(tx *gorm.DB) error {
+		txApiKeyRepository := repositories.NewApiKeyRepository(tx)
+		txErr := txApiKeyRepository.UpdateApiKey(apiKeyId, userId, command.Name, command.Description, command.Scope)
+		if txErr != nil {
+			return txErr
+		}
+
+		// Restrictions the update leaves out are kept, so clients that only rename a key can't lift them
+		if command.HasExpiresAt() {
+			txErr = txApiKeyRepository.UpdateApiKeyExpiresAt(apiKeyId, command.ExpiresAt)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		if command.AllowedGroupIds != nil {
+			txErr = txApiKeyRepository.ReplaceApiKeyAllowedGroups(apiKeyId, command.AllowedGroupIds)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		if command.ResourceScopes != nil {
+			txErr = txApiKeyRepository.ReplaceApiKeyResourceScopes(apiKeyId, command.ResourceScopes)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		return nil
+	})
+}
+
+// Keys may only be restricted to groups their user belongs to
+func (service *ApiKeyService) ValidateAllowedGroupIds(userId uint, groupIds []uint) error {
+	groupService := NewGroupService(service.TX)
+
+	for _, groupId := range groupIds {
+		err := groupService.ValidateGroupRole(models.VIEWER, utils.UintToString(groupId), utils.UintToString(userId))
+		if err != nil {
+			return fmt.Errorf("user does not have access to group %d", groupId)
+		}
+	}
+
+	return nil
+}
+
+// Issues a new secret for the key. The previous secret stays valid for the grace period.
+func (service *ApiKeyService) RotateApiKey(apiKeyId string, userId uint, command commands.RotateApiKeyCommand) (string, error) {
+	apiKeyRepository := repositories.NewApiKeyRepository(service.TX)
+
+	existingKey, err := apiKeyRepository.GetApiKeyById(apiKeyId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("API key not found")
+		}
+		return "", err
+	}
+
+	if existingKey.UserID == nil || *existingKey.UserID != userId {
+		return "", errors.New("API key not found")
+	}
+
+	if existingKey.IsExpired() {
+		return "", errors.New("expired API keys cannot be rotated")
+	}
+
+	secret, err := utils.GetRandomString(64)
+	if err != nil {
+		return "", err
+	}
+
+	b64secret := utils.Base64Encode([]byte(secret))
+
+	b64hmac, err := service.GenerateApiKeyHmac(secret)
+	if err != nil {
+		return "", err
+	}
+
+	gracePeriod := time.Duration(command.GetGracePeriodHours()) * time.Hour
+	err = apiKeyRepository.RotateApiKeyHmac(apiKeyId, b64hmac, time.Now().Add(gracePeriod))
+	if err != nil {
+		return "", err
+	}
+
+	return service.BuildV1ApiKey(existingKey.Prefix, existingKey.Version, existingKey.ID, b64secret), nil
+}
+
+// Sends a notification to the owner of every key expiring within the window, once per expiry date
+func (service *ApiKeyService) NotifyExpiringApiKeys(window time.Duration) (int, error) {
+	apiKeyRepository := repositories.NewApiKeyRepository(service.TX)
+	notificationRepository := repositories.NewNotificationRepository(service.TX)
+
+	apiKeys, err := apiKeyRepository.GetApiKeysExpiringBefore(time.Now().Add(window))
+	if err != nil {
+		return 0, err
+	}
+
+	notifiedCount := 0
+	for _, apiKey := range apiKeys {
+		body := fmt.Sprintf(
+			"Your API key '%s' expires on %s. Rotate it or create a new key to avoid interruptions.",
+			apiKey.Name,
+			apiKey.ExpiresAt.Format(time.RFC1123),
+		)
+
+		err = notificationRepository.SendNotificationToUsers(
+			[]uint{*apiKey.UserID},
+			"API Key Expiring Soon",
+			body,
+			models.NOTIFICATION_TYPE_NORMAL,
+			nil,
+		)
+		if err != nil {
+			return notifiedCount, err
+		}
+
+		err = apiKeyRepository.UpdateApiKeyExpiryNotifiedAt(apiKey.ID, time.Now())
+		if err != nil {
+			return notifiedCount, err
+		}
+
+		notifiedCount++
+	}
+
+	return notifiedCount, nil
 }
 
 func (service *ApiKeyService) GetPagedApiKeys(command commands.PagedApiKeyRequestCommand, userId string) ([]models.ApiKeyView, int64, error) {
@@ -191,18 +345,7 @@ func (service *ApiKeyService) GetPagedApiKeys(command commands.PagedApiKeyReques
 
 	apiKeyViews := make([]models.ApiKeyView, len(apiKeys))
 	for i, apiKey := range apiKeys {
-		apiKeyViews[i] = models.ApiKeyView{
-			ID:              apiKey.ID,
-			CreatedAt:       apiKey.CreatedAt,
-			UpdatedAt:       apiKey.UpdatedAt,
-			CreatedBy:       apiKey.CreatedBy,
-			CreatedByString: apiKey.CreatedByString,
-			Name:            apiKey.Name,
-			Description:     apiKey.Description,
-			UserID:          apiKey.UserID,
-			Scope:           apiKey.Scope,
-			LastUsedAt:      apiKey.LastUsedAt,
-		}
+		apiKeyViews[i] = apiKey.ToView()
 	}
 
 	return apiKeyViews, count, nil
@@ -1438,3 +1438,219 @@ func TestApiKeyService_UpdateApiKey_DatabaseError(t *testing.T) {
 		utils.PrintTestError(t, unchangedApiKey.UserID, nil)
 	}
 }
+
+func TestApiKeyService_ValidateV1ApiKey_RejectsExpiredKey(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer repositories.TruncateTestDb()
+
+	pepperService := NewPepperService(nil)
+	err := pepperService.InitPepper()
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	apiKeyService := NewApiKeyService(nil)
+	generatedKey, err := apiKeyService.CreateApiKey(1, commands.UpsertApiKeyCommand{
+		Name:      "Expiring Key",
+		Scope:     "r",
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+		return
+	}
+
+	_, err = apiKeyService.ValidateV1ApiKey(generatedKey)
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	apiKeyId, _ := apiKeyService.GetIdFromV1ApiKey(generatedKey)
+	repositories.GetDB().Model(&models.ApiKey{}).Where("id = ?", apiKeyId).Update("expires_at", time.Now().Add(-time.Minute))
+
+	_, err = apiKeyService.ValidateV1ApiKey(generatedKey)
+	if err == nil || err.Error() != "api key has expired" {
+		utils.PrintTestError(t, err, "api key has expired")
+	}
+}
+
+func TestApiKeyService_RotateApiKey(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer repositories.TruncateTestDb()
+
+	pepperService := NewPepperService(nil)
+	err := pepperService.InitPepper()
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	tests := map[string]struct {
+		gracePeriodHours int
+		oldKeyValid      bool
+	}{
+		"with grace period":    {gracePeriodHours: 1, oldKeyValid: true},
+		"without grace period": {gracePeriodHours: 0, oldKeyValid: false},
+	}
+
+	apiKeyService := NewApiKeyService(nil)
+	for name, test := range tests {
+		oldKey, err := apiKeyService.CreateApiKey(1, commands.UpsertApiKeyCommand{Name: name, Scope: "rw"})
+		if err != nil {
+			utils.PrintTestError(t, err, "no error")
+			return
+		}
+
+		apiKeyId, _ := apiKeyService.GetIdFromV1ApiKey(oldKey)
+		gracePeriodHours := test.gracePeriodHours
+		newKey, err := apiKeyService.RotateApiKey(apiKeyId, 1, commands.RotateApiKeyCommand{GracePeriodHours: &gracePeriodHours})
+		if err != nil {
+			utils.PrintTestError(t, err, "no error")
+			return
+		}
+
+		newKeyId, _ := apiKeyService.GetIdFromV1ApiKey(newKey)
+		if newKeyId != apiKeyId {
+			utils.PrintTestError(t, newKeyId, apiKeyId)
+		}
+
+		_, err = apiKeyService.ValidateV1ApiKey(newKey)
+		if err != nil {
+			utils.PrintTestError(t, err, "no error")
+		}
+
+		_, err = apiKeyService.ValidateV1ApiKey(oldKey)
+		if (err == nil) != test.oldKeyValid {
+			utils.PrintTestError(t, err, test.oldKeyValid)
+			t.Log(name)
+		}
+	}
+}
+
+func TestApiKeyService_RotateApiKey_RejectsOtherUsersKey(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer repositories.TruncateTestDb()
+
+	pepperService := NewPepperService(nil)
+	err := pepperService.InitPepper()
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	apiKeyService := NewApiKeyService(nil)
+	generatedKey, err := apiKeyService.CreateApiKey(1, commands.UpsertApiKeyCommand{Name: "Key", Scope: "rw"})
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+		return
+	}
+
+	apiKeyId, _ := apiKeyService.GetIdFromV1ApiKey(generatedKey)
+	_, err = apiKeyService.RotateApiKey(apiKeyId, 2, commands.RotateApiKeyCommand{})
+	if err == nil || err.Error() != "API key not found" {
+		utils.PrintTestError(t, err, "API key not found")
+	}
+}
+
+func TestApiKeyService_NotifyExpiringApiKeys(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer repositories.TruncateTestDb()
+
+	pepperService := NewPepperService(nil)
+	err := pepperService.InitPepper()
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	user := models.User{Username: "owner", DisplayName: "Owner", Password: "password"}
+	repositories.GetDB().Create(&user)
+
+	soon := time.Now().Add(2 * 24 * time.Hour)
+	later := time.Now().Add(30 * 24 * time.Hour)
+
+	apiKeyService := NewApiKeyService(nil)
+	apiKeyService.CreateApiKey(user.ID, commands.UpsertApiKeyCommand{Name: "Soon", Scope: "r", ExpiresAt: &soon})
+	apiKeyService.CreateApiKey(user.ID, commands.UpsertApiKeyCommand{Name: "Later", Scope: "r", ExpiresAt: &later})
+	apiKeyService.CreateApiKey(user.ID, commands.UpsertApiKeyCommand{Name: "Never", Scope: "r"})
+
+	window := 7 * 24 * time.Hour
+	notifiedCount, err := apiKeyService.NotifyExpiringApiKeys(window)
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if notifiedCount != 1 {
+		utils.PrintTestError(t, notifiedCount, 1)
+	}
+
+	// Keys are only notified once per expiry date
+	notifiedCount, err = apiKeyService.NotifyExpiringApiKeys(window)
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if notifiedCount != 0 {
+		utils.PrintTestError(t, notifiedCount, 0)
+	}
+
+	var notifications []models.Notification
+	repositories.GetDB().Where("user_id = ?", user.ID).Find(&notifications)
+	if len(notifications) != 1 || !strings.Contains(notifications[0].Body, "Soon") {
+		utils.PrintTestError(t, notifications, "one notification for the Soon key")
+	}
+}
+
+func TestApiKeyService_UpdateApiKey_KeepsOmittedRestrictions(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	err := NewPepperService(nil).InitPepper()
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	userId := uint(1)
+	expiresAt := time.Now().Add(24 * time.Hour)
+	apiKeyService := NewApiKeyService(nil)
+	generatedKey, err := apiKeyService.CreateApiKey(userId, commands.UpsertApiKeyCommand{
+		Name:            "Restricted",
+		Scope:           "r",
+		ExpiresAt:       &expiresAt,
+		AllowedGroupIds: []uint{1},
+		ResourceScopes:  []models.ApiKeyResourceScope{models.API_KEY_RESOURCE_RECEIPTS_READ},
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+	apiKeyId := strings.Split(generatedKey, ".")[2]
+
+	err = apiKeyService.UpdateApiKey(apiKeyId, userId, commands.UpsertApiKeyCommand{Name: "Renamed", Scope: "r"})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	apiKey, _ := repositories.NewApiKeyRepository(nil).GetApiKeyById(apiKeyId)
+	if apiKey.Name != "Renamed" || apiKey.ExpiresAt == nil || len(apiKey.GetAllowedGroupIds()) != 1 || len(apiKey.ResourceScopes) != 1 {
+		utils.PrintTestError(t, apiKey, "renamed key with its restrictions")
+	}
+
+	// Explicit empty restrictions remove them
+	err = apiKeyService.UpdateApiKey(apiKeyId, userId, commands.UpsertApiKeyCommand{
+		Name:            "Renamed",
+		Scope:           "r",
+		AllowedGroupIds: []uint{},
+		ResourceScopes:  []models.ApiKeyResourceScope{},
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	apiKey, _ = repositories.NewApiKeyRepository(nil).GetApiKeyById(apiKeyId)
+	if len(apiKey.GetAllowedGroupIds()) != 0 || len(apiKey.ResourceScopes) != 0 || apiKey.ExpiresAt == nil {
+		utils.PrintTestError(t, apiKey, "key without group and resource restrictions")
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"encoding/json"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditLogService struct {
+	BaseService
+}
+
+func NewAuditLogService(tx *gorm.DB) AuditLogService {
+	service := AuditLogService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+func (service AuditLogService) CreateAuditLog(command commands.UpsertAuditLogCommand) (models.AuditLog, error) {
+	auditLogRepository := repositories.NewAuditLogRepository(service.TX)
+
+	before, err := service.serializeSnapshot(command.Before)
+	if err != nil {
+		return models.AuditLog{}, err
+	}
+
+	after, err := service.serializeSnapshot(command.After)
+	if err != nil {
+		return models.AuditLog{}, err
+	}
+
+	auditLog := models.AuditLog{
+		BaseModel: models.BaseModel{
+			CreatedBy: command.ActorUserId,
+		},
+		Action:        command.Action,
+		ActorUserId:   command.ActorUserId,
+		ActorApiKeyId: command.ActorApiKeyId,
+		IpAddress:     command.IpAddress,
+		EntityType:    command.EntityType,
+		EntityId:      command.EntityId,
+		GroupId:       command.GroupId,
+		Before:        before,
+		After:         after,
+		Description:   command.Description,
+	}
+
+	return auditLogRepository.CreateAuditLog(auditLog)
+}
+
+// Audit logging should never fail the action being audited, so errors are only logged
+func (service AuditLogService) RecordAuditLog(command commands.UpsertAuditLogCommand) {
+	_, err := service.CreateAuditLog(command)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, "Failed to record audit log: "+err.Error())
+	}
+}
+
+// HasRecentAuditLog returns whether the action was recorded for the entity within interval
+func (service AuditLogService) HasRecentAuditLog(action models.AuditAction, entityId string, interval time.Duration) (bool, error) {
+	auditLogRepository := repositories.NewAuditLogRepository(service.TX)
+	return auditLogRepository.HasAuditLogSince(action, entityId, time.Now().Add(-interval))
+}
+
+func (service AuditLogService) GetPagedAuditLogs(command commands.PagedAuditLogRequestCommand) ([]models.AuditLog, int64, error) {
+	auditLogRepository := repositories.NewAuditLogRepository(service.TX)
+	return auditLogRepository.GetPagedAuditLogs(command)
+}
+
+func (service AuditLogService) DeleteExpiredAuditLogs() (int64, error) {
+	systemSettingsRepository := repositories.NewSystemSettingsRepository(service.TX)
+	auditLogRepository := repositories.NewAuditLogRepository(service.TX)
+
+	systemSettings, err := systemSettingsRepository.GetSystemSettings()
+	if err != nil {
+		return 0, err
+	}
+
+	// A retention of 0 days keeps audit logs forever
+	if systemSettings.AuditLogRetentionDays <= 0 {
+		return 0, nil
+	}
+
+	cutOffDate := time.Now().AddDate(0, 0, -systemSettings.AuditLogRetentionDays)
+	return auditLogRepository.DeleteAuditLogsCreatedBefore(cutOffDate)
+}
+
+func (service AuditLogService) serializeSnapshot(snapshot any) (string, error) {
+	if snapshot == nil {
+		return "", nil
+	}
+
+	bytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLogService_CreateAuditLogSerializesSnapshots(t *testing.T) {
+	defer repositories.TruncateTestDb()
+
+	userId := uint(1)
+	auditLogService := NewAuditLogService(nil)
+	auditLog, err := auditLogService.CreateAuditLog(commands.UpsertAuditLogCommand{
+		Action:      models.AUDIT_USER_ROLE_CHANGED,
+		ActorUserId: &userId,
+		IpAddress:   "127.0.0.1",
+		EntityType:  models.AUDIT_ENTITY_USER,
+		EntityId:    "2",
+		Before:      map[string]string{"userRole": "USER"},
+		After:       map[string]string{"userRole": "ADMIN"},
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if auditLog.Before != `{"userRole":"USER"}` {
+		utils.PrintTestError(t, auditLog.Before, `{"userRole":"USER"}`)
+	}
+
+	if auditLog.After != `{"userRole":"ADMIN"}` {
+		utils.PrintTestError(t, auditLog.After, `{"userRole":"ADMIN"}`)
+	}
+
+	if auditLog.CreatedBy == nil || *auditLog.CreatedBy != userId {
+		utils.PrintTestError(t, auditLog.CreatedBy, userId)
+	}
+}
+
+func TestAuditLogService_CreateAuditLogRejectsInvalidAction(t *testing.T) {
+	defer repositories.TruncateTestDb()
+
+	auditLogService := NewAuditLogService(nil)
+	_, err := auditLogService.CreateAuditLog(commands.UpsertAuditLogCommand{
+		Action:     "NOT_AN_ACTION",
+		EntityType: models.AUDIT_ENTITY_USER,
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid AuditAction") {
+		utils.PrintTestError(t, err, "invalid AuditAction")
+	}
+}
+
+func TestAuditLogService_DeleteExpiredAuditLogs(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	db := repositories.GetDB()
+
+	db.Create(&models.SystemSettings{AuditLogRetentionDays: 30})
+
+	oldAuditLog := models.AuditLog{
+		BaseModel:  models.BaseModel{CreatedAt: time.Now().AddDate(0, 0, -31)},
+		Action:     models.AUDIT_LOGIN,
+		EntityType: models.AUDIT_ENTITY_USER,
+	}
+	recentAuditLog := models.AuditLog{
+		BaseModel:  models.BaseModel{CreatedAt: time.Now().AddDate(0, 0, -1)},
+		Action:     models.AUDIT_LOGIN,
+		EntityType: models.AUDIT_ENTITY_USER,
+	}
+	db.Create(&oldAuditLog)
+	db.Create(&recentAuditLog)
+
+	auditLogService := NewAuditLogService(nil)
+	deletedCount, err := auditLogService.DeleteExpiredAuditLogs()
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if deletedCount != 1 {
+		utils.PrintTestError(t, deletedCount, 1)
+	}
+
+	var remainingCount int64
+	db.Model(&models.AuditLog{}).Count(&remainingCount)
+	if remainingCount != 1 {
+		utils.PrintTestError(t, remainingCount, 1)
+	}
+}
+
+func TestAuditLogService_DeleteExpiredAuditLogsKeepsLogsWhenRetentionIsDisabled(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	db := repositories.GetDB()
+
+	db.Create(&models.SystemSettings{})
+	db.Model(&models.SystemSettings{}).Where("1 = 1").Update("audit_log_retention_days", 0)
+
+	oldAuditLog := models.AuditLog{
+		BaseModel:  models.BaseModel{CreatedAt: time.Now().AddDate(-5, 0, 0)},
+		Action:     models.AUDIT_LOGIN,
+		EntityType: models.AUDIT_ENTITY_USER,
+	}
+	db.Create(&oldAuditLog)
+
+	auditLogService := NewAuditLogService(nil)
+	deletedCount, err := auditLogService.DeleteExpiredAuditLogs()
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if deletedCount != 0 {
+		utils.PrintTestError(t, deletedCount, 0)
+	}
+}
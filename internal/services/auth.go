@@ -10,6 +10,7 @@ import (
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
+	"slices"
 	"time"
 
 	"github.com/auth0/go-jwt-middleware/v2/validator"
@@ -195,6 +196,15 @@ func GetAppData(userId uint, r *http.Request) (structs.AppData, error) {
 		return appData, err
 	}
 
+	if r != nil {
+		claims := structs.GetClaimsIfPresent(r)
+		if claims != nil {
+			groups = slices.DeleteFunc(groups, func(group models.Group) bool {
+				return !claims.CanAccessGroup(group.ID)
+			})
+		}
+	}
+
 	users, err := userRepository.GetAllUserViews()
 	if err != nil {
 		return appData, err
@@ -205,12 +215,17 @@ func GetAppData(userId uint, r *http.Request) (structs.AppData, error) {
 		return appData, err
 	}
 
-	categories, err := categoryRepository.GetAllCategories("*")
+	groupIds := make([]uint, len(groups))
+	for i, group := range groups {
+		groupIds[i] = group.ID
+	}
+
+	categories, err := categoryRepository.GetAllCategories("*", groupIds)
 	if err != nil {
 		return appData, err
 	}
 
-	tags, err := tagRepository.GetAllTags("*")
+	tags, err := tagRepository.GetAllTags("*", groupIds)
 	if err != nil {
 		return appData, err
 	}
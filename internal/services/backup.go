@@ -0,0 +1,864 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BackupService struct {
+	BaseService
+}
+
+func NewBackupService(tx *gorm.DB) BackupService {
+	service := BackupService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+// CreateGroupBackup builds a backup archive of the given groups, the all group can't be backed up
+func (service BackupService) CreateGroupBackup(groupIds []uint) ([]byte, error) {
+	db := service.GetDB()
+	groups := make([]models.Group, 0)
+
+	err := service.preloadBackupGroups(db.Model(&models.Group{})).
+		Where("id IN ? AND is_all_group = ?", groupIds, false).
+		Order("id").
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) != len(groupIds) {
+		return nil, errors.New("group not found")
+	}
+
+	return service.buildBackupArchive(groups)
+}
+
+// CreateInstanceBackup builds a backup archive of every group
+func (service BackupService) CreateInstanceBackup() ([]byte, error) {
+	db := service.GetDB()
+	groups := make([]models.Group, 0)
+
+	err := service.preloadBackupGroups(db.Model(&models.Group{})).
+		Where("is_all_group = ?", false).
+		Order("id").
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return service.buildBackupArchive(groups)
+}
+
+func (service BackupService) preloadBackupGroups(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("GroupMembers").
+		Preload("GroupSettings.SubjectLineRegexes").
+		Preload("GroupSettings.EmailWhiteList").
+		Preload("GroupReceiptSettings")
+}
+
+func (service BackupService) buildBackupArchive(groups []models.Group) ([]byte, error) {
+	db := service.GetDB()
+	fileRepository := repositories.NewFileRepository(service.TX)
+
+	backup := structs.Backup{
+		Version:   constants.BackupVersion,
+		CreatedAt: time.Now(),
+	}
+	groupIds := make([]uint, 0, len(groups))
+	userIds := make(map[uint]bool)
+	categoryIds := make(map[uint]bool)
+	tagIds := make(map[uint]bool)
+	customFieldIds := make(map[uint]bool)
+	fileNames := []string{constants.BackupDataFileName}
+	fileContents := [][]byte{nil}
+
+	addUserId := func(userId *uint) {
+		if userId != nil && *userId > 0 {
+			userIds[*userId] = true
+		}
+	}
+
+	for _, group := range groups {
+		groupIds = append(groupIds, group.ID)
+
+		groupBackup := structs.GroupBackup{Group: group}
+		for _, groupMember := range group.GroupMembers {
+			addUserId(&groupMember.UserID)
+		}
+		addUserId(group.GroupSettings.EmailDefaultReceiptPaidById)
+
+		err := db.Model(&models.Receipt{}).
+			Where("group_id = ?", group.ID).
+			Preload("Categories").
+			Preload("Tags").
+			Preload("ImageFiles").
+			Preload("ReceiptItems", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Preload("ReceiptItems.Categories").
+			Preload("ReceiptItems.Tags").
+			Preload("ReceiptItems.LinkedItems").
+			Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Preload("CustomFields").
+			Order("id").
+			Find(&groupBackup.Receipts).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, receipt := range groupBackup.Receipts {
+			addUserId(&receipt.PaidByUserID)
+			for _, category := range receipt.Categories {
+				categoryIds[category.ID] = true
+			}
+			for _, tag := range receipt.Tags {
+				tagIds[tag.ID] = true
+			}
+
+			for _, item := range receipt.ReceiptItems {
+				addUserId(item.ChargedToUserId)
+				for _, category := range item.Categories {
+					categoryIds[category.ID] = true
+				}
+				for _, tag := range item.Tags {
+					tagIds[tag.ID] = true
+				}
+			}
+
+			for _, comment := range receipt.Comments {
+				addUserId(comment.UserId)
+			}
+
+			for _, customFieldValue := range receipt.CustomFields {
+				customFieldIds[customFieldValue.CustomFieldId] = true
+			}
+
+			for _, fileData := range receipt.ImageFiles {
+				fileBytes, err := fileRepository.GetRawBytesForFileData(fileData)
+				if err != nil {
+					return nil, fmt.Errorf("error reading file %d of receipt %d: %w", fileData.ID, receipt.ID, err)
+				}
+
+				fileNames = append(fileNames, buildBackupFileName(fileData.ID))
+				fileContents = append(fileContents, fileBytes)
+			}
+		}
+
+		err = db.Model(&models.Dashboard{}).
+			Where("group_id = ?", group.ID).
+			Preload("Widgets", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Order("id").
+			Find(&groupBackup.Dashboards).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dashboard := range groupBackup.Dashboards {
+			addUserId(&dashboard.UserID)
+		}
+
+		backup.Groups = append(backup.Groups, groupBackup)
+	}
+
+	err := db.Model(&models.Category{}).
+		Where("group_id IN ? OR id IN ?", groupIds, mapKeys(categoryIds)).
+		Order("id").
+		Find(&backup.Categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&models.Tag{}).
+		Where("group_id IN ? OR id IN ?", groupIds, mapKeys(tagIds)).
+		Order("id").
+		Find(&backup.Tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&models.CustomField{}).
+		Where("group_id IN ? OR id IN ?", groupIds, mapKeys(customFieldIds)).
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Order("id").
+		Find(&backup.CustomFields).Error
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]models.User, 0)
+	err = db.Model(&models.User{}).Where("id IN ?", mapKeys(userIds)).Order("id").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		backup.Users = append(backup.Users, structs.BackupUser{
+			Id:          user.ID,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			IsDummyUser: user.IsDummyUser,
+		})
+	}
+
+	backupBytes, err := json.Marshal(backup)
+	if err != nil {
+		return nil, err
+	}
+	fileContents[0] = backupBytes
+
+	return fileRepository.ZipFiles(fileNames, fileContents)
+}
+
+// RestoreBackup creates the groups of a backup archive as new groups owned by userId.
+// Users are matched by username and created as dummy users when missing, global categories, tags and custom fields are matched by name.
+// Everything is restored in one transaction, blobs written before a failed restore are deleted again unless something else references them.
+func (service BackupService) RestoreBackup(archiveBytes []byte, userId uint) (structs.BackupRestoreResult, error) {
+	backup, archiveFiles, err := service.ReadBackupArchive(archiveBytes)
+	if err != nil {
+		return structs.BackupRestoreResult{}, err
+	}
+
+	restore := backupRestore{
+		userId:               userId,
+		archiveFiles:         archiveFiles,
+		userIds:              make(map[uint]uint),
+		groupIds:             make(map[uint]uint),
+		categories:           make(map[uint]models.Category),
+		tags:                 make(map[uint]models.Tag),
+		customFieldIds:       make(map[uint]uint),
+		customFieldOptionIds: make(map[uint]uint),
+		blobHashes:           make(map[string]bool),
+		result: structs.BackupRestoreResult{
+			Groups:       make([]models.Group, 0),
+			CreatedUsers: make([]string, 0),
+		},
+	}
+
+	err = service.GetDB().Transaction(func(tx *gorm.DB) error {
+		restore.tx = tx
+
+		err := restore.restoreUsers(backup.Users)
+		if err != nil {
+			return err
+		}
+
+		for _, groupBackup := range backup.Groups {
+			err = restore.restoreGroup(groupBackup.Group)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = restore.restoreCategories(backup.Categories)
+		if err != nil {
+			return err
+		}
+
+		err = restore.restoreTags(backup.Tags)
+		if err != nil {
+			return err
+		}
+
+		err = restore.restoreCustomFields(backup.CustomFields)
+		if err != nil {
+			return err
+		}
+
+		for _, groupBackup := range backup.Groups {
+			for _, receipt := range groupBackup.Receipts {
+				err = restore.restoreReceipt(receipt)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, dashboard := range groupBackup.Dashboards {
+				err = restore.restoreDashboard(dashboard)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		fileBlobRepository := repositories.NewFileBlobRepository(nil)
+		for hash := range restore.blobHashes {
+			_, cleanUpErr := fileBlobRepository.DeleteBlobIfUnreferenced(hash)
+			if cleanUpErr != nil {
+				logging.LogStd(logging.LOG_LEVEL_ERROR, "Error cleaning up blob of failed restore: ", cleanUpErr.Error())
+			}
+		}
+		return structs.BackupRestoreResult{}, err
+	}
+
+	return restore.result, nil
+}
+
+// ReadBackupArchive returns the backup data of an archive along with its files by name
+func (service BackupService) ReadBackupArchive(archiveBytes []byte) (structs.Backup, map[string]*zip.File, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(archiveBytes), int64(len(archiveBytes)))
+	if err != nil {
+		return structs.Backup{}, nil, errors.New("backup is not a valid zip archive")
+	}
+
+	archiveFiles := make(map[string]*zip.File)
+	for _, file := range zipReader.File {
+		archiveFiles[file.Name] = file
+	}
+
+	dataFile, ok := archiveFiles[constants.BackupDataFileName]
+	if !ok {
+		return structs.Backup{}, nil, fmt.Errorf("backup is missing %s", constants.BackupDataFileName)
+	}
+
+	dataBytes, err := readZipFile(dataFile)
+	if err != nil {
+		return structs.Backup{}, nil, err
+	}
+
+	var backup structs.Backup
+	err = json.Unmarshal(dataBytes, &backup)
+	if err != nil {
+		return structs.Backup{}, nil, err
+	}
+
+	if backup.Version < 1 || backup.Version > constants.BackupVersion {
+		return structs.Backup{}, nil, fmt.Errorf("unsupported backup version %d", backup.Version)
+	}
+
+	return backup, archiveFiles, nil
+}
+
+type backupRestore struct {
+	tx                   *gorm.DB
+	userId               uint
+	archiveFiles         map[string]*zip.File
+	userIds              map[uint]uint
+	groupIds             map[uint]uint
+	categories           map[uint]models.Category
+	tags                 map[uint]models.Tag
+	customFieldIds       map[uint]uint
+	customFieldOptionIds map[uint]uint
+	blobHashes           map[string]bool
+	result               structs.BackupRestoreResult
+}
+
+func (restore *backupRestore) restoreUsers(backupUsers []structs.BackupUser) error {
+	userRepository := repositories.NewUserRepository(restore.tx)
+
+	for _, backupUser := range backupUsers {
+		var user models.User
+		err := restore.tx.Model(&models.User{}).Where("username = ?", backupUser.Username).Limit(1).Find(&user).Error
+		if err != nil {
+			return err
+		}
+
+		if user.ID == 0 {
+			password, err := utils.GetRandomString(32)
+			if err != nil {
+				return err
+			}
+
+			user, err = userRepository.CreateUser(commands.SignUpCommand{
+				Username:    backupUser.Username,
+				DisplayName: backupUser.DisplayName,
+				Password:    password,
+				IsDummyUser: true,
+			})
+			if err != nil {
+				return err
+			}
+
+			restore.result.CreatedUsers = append(restore.result.CreatedUsers, user.Username)
+		}
+
+		restore.userIds[backupUser.Id] = user.ID
+	}
+
+	return nil
+}
+
+func (restore *backupRestore) restoreGroup(backupGroup models.Group) error {
+	group := models.Group{
+		BaseModel: restore.restoreBaseModel(backupGroup.BaseModel),
+		Name:      backupGroup.Name,
+		Status:    backupGroup.Status,
+	}
+
+	err := restore.tx.Omit(clause.Associations).Create(&group).Error
+	if err != nil {
+		return err
+	}
+	restore.groupIds[backupGroup.ID] = group.ID
+
+	groupMembers := []models.GroupMember{{UserID: restore.userId, GroupID: group.ID, GroupRole: models.OWNER}}
+	for _, backupGroupMember := range backupGroup.GroupMembers {
+		userId, ok := restore.userIds[backupGroupMember.UserID]
+		if !ok || userId == restore.userId {
+			continue
+		}
+
+		groupMembers = append(groupMembers, models.GroupMember{
+			UserID:    userId,
+			GroupID:   group.ID,
+			GroupRole: backupGroupMember.GroupRole,
+		})
+	}
+
+	err = restore.tx.Create(&groupMembers).Error
+	if err != nil {
+		return err
+	}
+
+	// System emails and prompts belong to the instance, so email integration has to be set up again
+	backupSettings := backupGroup.GroupSettings
+	groupSettings := models.GroupSettings{
+		GroupId:                     group.ID,
+		EmailToRead:                 backupSettings.EmailToRead,
+		EmailDefaultReceiptStatus:   backupSettings.EmailDefaultReceiptStatus,
+		EmailDefaultReceiptPaidById: restore.mapOptionalUserId(backupSettings.EmailDefaultReceiptPaidById),
+	}
+	for _, subjectLineRegex := range backupSettings.SubjectLineRegexes {
+		groupSettings.SubjectLineRegexes = append(groupSettings.SubjectLineRegexes, models.SubjectLineRegex{Regex: subjectLineRegex.Regex})
+	}
+	for _, whiteListEmail := range backupSettings.EmailWhiteList {
+		groupSettings.EmailWhiteList = append(groupSettings.EmailWhiteList, models.GroupSettingsWhiteListEmail{Email: whiteListEmail.Email})
+	}
+
+	err = restore.tx.Omit("SystemEmail", "EmailDefaultReceiptPaidBy", "Prompt", "FallbackPrompt").Create(&groupSettings).Error
+	if err != nil {
+		return err
+	}
+
+	groupReceiptSettings := backupGroup.GroupReceiptSettings
+	groupReceiptSettings.BaseModel = models.BaseModel{}
+	groupReceiptSettings.GroupId = group.ID
+	err = restore.tx.Create(&groupReceiptSettings).Error
+	if err != nil {
+		return err
+	}
+
+	restore.result.Groups = append(restore.result.Groups, group)
+	return nil
+}
+
+func (restore *backupRestore) restoreCategories(backupCategories []models.Category) error {
+	for _, backupCategory := range backupCategories {
+		groupId := restore.mapOptionalGroupId(backupCategory.GroupId)
+
+		var category models.Category
+		if groupId == nil {
+			err := restore.tx.Model(&models.Category{}).Where("name = ? AND group_id IS NULL", backupCategory.Name).Limit(1).Find(&category).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if category.ID == 0 {
+			category = models.Category{
+				BaseModel:   restore.restoreBaseModel(backupCategory.BaseModel),
+				Name:        backupCategory.Name,
+				Description: backupCategory.Description,
+				GroupId:     groupId,
+			}
+
+			err := restore.tx.Create(&category).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		restore.categories[backupCategory.ID] = category
+	}
+
+	return nil
+}
+
+func (restore *backupRestore) restoreTags(backupTags []models.Tag) error {
+	for _, backupTag := range backupTags {
+		groupId := restore.mapOptionalGroupId(backupTag.GroupId)
+
+		var tag models.Tag
+		if groupId == nil {
+			err := restore.tx.Model(&models.Tag{}).Where("name = ? AND group_id IS NULL", backupTag.Name).Limit(1).Find(&tag).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if tag.ID == 0 {
+			tag = models.Tag{
+				BaseModel:   restore.restoreBaseModel(backupTag.BaseModel),
+				Name:        backupTag.Name,
+				Description: backupTag.Description,
+				GroupId:     groupId,
+			}
+
+			err := restore.tx.Create(&tag).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		restore.tags[backupTag.ID] = tag
+	}
+
+	return nil
+}
+
+// Global custom fields are matched by name and type, missing select options are added to them
+func (restore *backupRestore) restoreCustomFields(backupCustomFields []models.CustomField) error {
+	for _, backupCustomField := range backupCustomFields {
+		groupId := restore.mapOptionalGroupId(backupCustomField.GroupId)
+
+		var customField models.CustomField
+		if groupId == nil {
+			err := restore.tx.Model(&models.CustomField{}).
+				Where("name = ? AND type = ? AND group_id IS NULL", backupCustomField.Name, backupCustomField.Type).
+				Preload("Options").
+				Limit(1).
+				Find(&customField).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if customField.ID == 0 {
+			customField = models.CustomField{
+				BaseModel:   restore.restoreBaseModel(backupCustomField.BaseModel),
+				Name:        backupCustomField.Name,
+				Type:        backupCustomField.Type,
+				Description: backupCustomField.Description,
+				GroupId:     groupId,
+			}
+
+			err := restore.tx.Omit(clause.Associations).Create(&customField).Error
+			if err != nil {
+				return err
+			}
+		}
+		restore.customFieldIds[backupCustomField.ID] = customField.ID
+
+		for _, backupOption := range backupCustomField.Options {
+			option := models.CustomFieldOption{}
+			for _, existingOption := range customField.Options {
+				if existingOption.Value == backupOption.Value {
+					option = existingOption
+					break
+				}
+			}
+
+			if option.ID == 0 {
+				option = models.CustomFieldOption{
+					BaseModel:     restore.restoreBaseModel(backupOption.BaseModel),
+					Value:         backupOption.Value,
+					CustomFieldId: customField.ID,
+				}
+
+				err := restore.tx.Omit(clause.Associations).Create(&option).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			restore.customFieldOptionIds[backupOption.ID] = option.ID
+		}
+	}
+
+	return nil
+}
+
+func (restore *backupRestore) restoreReceipt(backupReceipt models.Receipt) error {
+	receiptImageRepository := repositories.NewReceiptImageRepository(restore.tx)
+
+	receipt := models.Receipt{
+		BaseModel:    restore.restoreBaseModel(backupReceipt.BaseModel),
+		Name:         backupReceipt.Name,
+		Amount:       backupReceipt.Amount,
+		Date:         backupReceipt.Date,
+		ResolvedDate: backupReceipt.ResolvedDate,
+		PaidByUserID: restore.mapUserId(backupReceipt.PaidByUserID),
+		Status:       backupReceipt.Status,
+		GroupId:      restore.groupIds[backupReceipt.GroupId],
+	}
+
+	err := restore.tx.Omit(clause.Associations).Create(&receipt).Error
+	if err != nil {
+		return err
+	}
+
+	err = restore.appendCategoriesAndTags(&receipt, backupReceipt.Categories, backupReceipt.Tags)
+	if err != nil {
+		return err
+	}
+
+	items := make(map[uint]models.Item)
+	for _, backupItem := range backupReceipt.ReceiptItems {
+		item := models.Item{
+			BaseModel:       restore.restoreBaseModel(backupItem.BaseModel),
+			Amount:          backupItem.Amount,
+			ChargedToUserId: restore.mapOptionalUserId(backupItem.ChargedToUserId),
+			IsTaxed:         backupItem.IsTaxed,
+			Name:            backupItem.Name,
+			ReceiptId:       receipt.ID,
+			Status:          backupItem.Status,
+		}
+
+		err = restore.tx.Omit(clause.Associations).Create(&item).Error
+		if err != nil {
+			return err
+		}
+
+		err = restore.appendCategoriesAndTags(&item, backupItem.Categories, backupItem.Tags)
+		if err != nil {
+			return err
+		}
+
+		items[backupItem.ID] = item
+	}
+
+	for _, backupItem := range backupReceipt.ReceiptItems {
+		linkedItems := make([]models.Item, 0)
+		for _, backupLinkedItem := range backupItem.LinkedItems {
+			linkedItem, ok := items[backupLinkedItem.ID]
+			if ok {
+				linkedItems = append(linkedItems, linkedItem)
+			}
+		}
+
+		if len(linkedItems) > 0 {
+			item := items[backupItem.ID]
+			err = restore.tx.Model(&item).Omit("LinkedItems.*").Association("LinkedItems").Append(&linkedItems)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Comments are restored oldest first, so replies can point to their new parent
+	backupComments := backupReceipt.Comments
+	sort.Slice(backupComments, func(i, j int) bool { return backupComments[i].ID < backupComments[j].ID })
+	commentIds := make(map[uint]uint)
+	for _, backupComment := range backupComments {
+		comment := models.Comment{
+			BaseModel:      restore.restoreBaseModel(backupComment.BaseModel),
+			Comment:        backupComment.Comment,
+			ReceiptId:      receipt.ID,
+			UserId:         restore.mapOptionalUserId(backupComment.UserId),
+			AdditionalInfo: backupComment.AdditionalInfo,
+		}
+		if backupComment.CommentId != nil {
+			parentId, ok := commentIds[*backupComment.CommentId]
+			if ok {
+				comment.CommentId = &parentId
+			}
+		}
+
+		err = restore.tx.Omit(clause.Associations).Create(&comment).Error
+		if err != nil {
+			return err
+		}
+
+		commentIds[backupComment.ID] = comment.ID
+	}
+
+	for _, backupValue := range backupReceipt.CustomFields {
+		customFieldId, ok := restore.customFieldIds[backupValue.CustomFieldId]
+		if !ok {
+			continue
+		}
+
+		value := models.CustomFieldValue{
+			BaseModel:     restore.restoreBaseModel(backupValue.BaseModel),
+			ReceiptId:     receipt.ID,
+			CustomFieldId: customFieldId,
+			StringValue:   backupValue.StringValue,
+			DateValue:     backupValue.DateValue,
+			CurrencyValue: backupValue.CurrencyValue,
+			BooleanValue:  backupValue.BooleanValue,
+		}
+		if backupValue.SelectValue != nil {
+			optionId, ok := restore.customFieldOptionIds[*backupValue.SelectValue]
+			if ok {
+				value.SelectValue = &optionId
+			}
+		}
+
+		err = restore.tx.Omit(clause.Associations).Create(&value).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, backupFileData := range backupReceipt.ImageFiles {
+		archiveFile, ok := restore.archiveFiles[buildBackupFileName(backupFileData.ID)]
+		if !ok {
+			return fmt.Errorf("backup is missing file %d of receipt %d", backupFileData.ID, backupReceipt.ID)
+		}
+
+		fileBytes, err := readZipFile(archiveFile)
+		if err != nil {
+			return err
+		}
+
+		restore.blobHashes[utils.Sha256Hash(fileBytes)] = true
+		_, err = receiptImageRepository.CreateReceiptImage(models.FileData{
+			BaseModel: restore.restoreBaseModel(backupFileData.BaseModel),
+			Name:      backupFileData.Name,
+			ReceiptId: receipt.ID,
+			Barcodes:  backupFileData.Barcodes,
+		}, fileBytes)
+		if err != nil {
+			return err
+		}
+
+		restore.result.Files++
+	}
+
+	restore.result.Receipts++
+	return nil
+}
+
+func (restore *backupRestore) restoreDashboard(backupDashboard models.Dashboard) error {
+	dashboard := models.Dashboard{
+		BaseModel: restore.restoreBaseModel(backupDashboard.BaseModel),
+		Name:      backupDashboard.Name,
+		UserID:    restore.mapUserId(backupDashboard.UserID),
+		GroupID:   restore.groupIds[backupDashboard.GroupID],
+	}
+	for _, backupWidget := range backupDashboard.Widgets {
+		dashboard.Widgets = append(dashboard.Widgets, models.Widget{
+			BaseModel:     restore.restoreBaseModel(backupWidget.BaseModel),
+			Name:          backupWidget.Name,
+			WidgetType:    backupWidget.WidgetType,
+			Configuration: backupWidget.Configuration,
+		})
+	}
+
+	return restore.tx.Omit("User", "Group").Create(&dashboard).Error
+}
+
+func (restore *backupRestore) appendCategoriesAndTags(model interface{}, backupCategories []models.Category, backupTags []models.Tag) error {
+	categories := make([]models.Category, 0, len(backupCategories))
+	for _, backupCategory := range backupCategories {
+		category, ok := restore.categories[backupCategory.ID]
+		if ok {
+			categories = append(categories, category)
+		}
+	}
+
+	tags := make([]models.Tag, 0, len(backupTags))
+	for _, backupTag := range backupTags {
+		tag, ok := restore.tags[backupTag.ID]
+		if ok {
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(categories) > 0 {
+		err := restore.tx.Model(model).Omit("Categories.*").Association("Categories").Append(&categories)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(tags) > 0 {
+		err := restore.tx.Model(model).Omit("Tags.*").Association("Tags").Append(&tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreBaseModel keeps the timestamps of a backed up row, the id is assigned on insert
+func (restore *backupRestore) restoreBaseModel(baseModel models.BaseModel) models.BaseModel {
+	return models.BaseModel{
+		CreatedAt:       baseModel.CreatedAt,
+		UpdatedAt:       baseModel.UpdatedAt,
+		CreatedBy:       restore.mapOptionalUserId(baseModel.CreatedBy),
+		CreatedByString: baseModel.CreatedByString,
+	}
+}
+
+// mapUserId falls back to the restoring user for users missing from the backup
+func (restore *backupRestore) mapUserId(userId uint) uint {
+	mappedUserId, ok := restore.userIds[userId]
+	if !ok {
+		return restore.userId
+	}
+
+	return mappedUserId
+}
+
+func (restore *backupRestore) mapOptionalUserId(userId *uint) *uint {
+	if userId == nil {
+		return nil
+	}
+
+	mappedUserId, ok := restore.userIds[*userId]
+	if !ok {
+		return nil
+	}
+
+	return &mappedUserId
+}
+
+// Categories, tags and custom fields of groups missing from the backup are restored as global ones
+func (restore *backupRestore) mapOptionalGroupId(groupId *uint) *uint {
+	if groupId == nil {
+		return nil
+	}
+
+	mappedGroupId, ok := restore.groupIds[*groupId]
+	if !ok {
+		return nil
+	}
+
+	return &mappedGroupId
+}
+
+func buildBackupFileName(fileDataId uint) string {
+	return constants.BackupFilesDirectory + "/" + utils.UintToString(fileDataId)
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+func mapKeys(values map[uint]bool) []uint {
+	keys := make([]uint, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"encoding/json"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var backupTestPngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func setUpBackupTest(t *testing.T) models.Receipt {
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+
+	groupId := uint(1)
+	globalCategory := models.Category{Name: "Food"}
+	groupCategory := models.Category{Name: "Group Category", GroupId: &groupId}
+	globalTag := models.Tag{Name: "Shared"}
+	db.Create(&globalCategory)
+	db.Create(&groupCategory)
+	db.Create(&globalTag)
+
+	customField := models.CustomField{
+		Name:    "Store",
+		Type:    models.SELECT,
+		Options: []models.CustomFieldOption{{Value: "a"}, {Value: "b"}},
+	}
+	db.Create(&customField)
+
+	chargedToUserId := uint(3)
+	commentUserId := uint(2)
+	selectValue := customField.Options[1].ID
+	receipt := models.Receipt{
+		Name:         "Test Receipt",
+		Amount:       decimal.NewFromFloat(10.00),
+		Date:         time.Now(),
+		PaidByUserID: 2,
+		Status:       models.OPEN,
+		GroupId:      1,
+		Categories:   []models.Category{globalCategory},
+		Tags:         []models.Tag{globalTag},
+		ReceiptItems: []models.Item{
+			{Name: "Split", Amount: decimal.NewFromFloat(5), ChargedToUserId: &chargedToUserId, Status: models.ITEM_OPEN, Categories: []models.Category{groupCategory}},
+			{Name: "Linked", Amount: decimal.NewFromFloat(5), Status: models.ITEM_OPEN},
+		},
+		Comments:     []models.Comment{{Comment: "Parent", UserId: &commentUserId}},
+		CustomFields: []models.CustomFieldValue{{CustomFieldId: customField.ID, SelectValue: &selectValue}},
+	}
+	db.Create(&receipt)
+
+	db.Model(&receipt.ReceiptItems[0]).Association("LinkedItems").Append(&receipt.ReceiptItems[1])
+	db.Create(&models.Comment{Comment: "Reply", ReceiptId: receipt.ID, UserId: &commentUserId, CommentId: &receipt.Comments[0].ID})
+
+	fileBlob, _, _ := repositories.NewFileBlobRepository(nil).StoreBlob(backupTestPngBytes)
+	db.Create(&models.FileData{Name: "receipt.png", FileType: "image/png", ReceiptId: receipt.ID, BlobHash: fileBlob.Hash, Checksum: fileBlob.Hash})
+
+	db.Create(&models.Dashboard{
+		Name:    "Dashboard",
+		UserID:  1,
+		GroupID: 1,
+		Widgets: []models.Widget{{Name: "Summary", WidgetType: models.GROUP_SUMMARY, Configuration: json.RawMessage(`{}`)}},
+	})
+
+	return receipt
+}
+
+func tearDownBackupTest() {
+	repositories.TruncateTestDb()
+	storage.SetStorage(nil)
+}
+
+func TestShouldRestoreGroupBackupAsNewGroup(t *testing.T) {
+	defer tearDownBackupTest()
+	setUpBackupTest(t)
+	db := repositories.GetDB()
+	service := NewBackupService(nil)
+
+	archive, err := service.CreateGroupBackup([]uint{1})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	// Missing users are created as dummy users
+	db.Model(&models.User{}).Where("id = ?", 3).Update("username", "renamed")
+
+	result, err := service.RestoreBackup(archive, 4)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(result.Groups) != 1 || result.Receipts != 1 || result.Files != 1 {
+		utils.PrintTestError(t, result, "1 group with 1 receipt and 1 file")
+		return
+	}
+
+	if len(result.CreatedUsers) != 1 || result.CreatedUsers[0] != "test2" {
+		utils.PrintTestError(t, result.CreatedUsers, "test2")
+	}
+
+	groupId := result.Groups[0].ID
+	var owner models.GroupMember
+	db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", groupId, 4).First(&owner)
+	if owner.GroupRole != models.OWNER {
+		utils.PrintTestError(t, owner.GroupRole, models.OWNER)
+	}
+
+	var receipt models.Receipt
+	err = db.Model(&models.Receipt{}).
+		Where("group_id = ?", groupId).
+		Preload("Categories").
+		Preload("Tags").
+		Preload("ImageFiles").
+		Preload("ReceiptItems.Categories").
+		Preload("ReceiptItems.LinkedItems").
+		Preload("Comments").
+		Preload("CustomFields").
+		First(&receipt).Error
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if receipt.PaidByUserID != 2 || len(receipt.Categories) != 1 || len(receipt.Tags) != 1 {
+		utils.PrintTestError(t, receipt, "receipt paid by user 2 with category and tag")
+	}
+
+	var globalCategoryCount int64
+	db.Model(&models.Category{}).Where("name = ?", "Food").Count(&globalCategoryCount)
+	if globalCategoryCount != 1 {
+		utils.PrintTestError(t, globalCategoryCount, 1)
+	}
+
+	var dummyUser models.User
+	db.Model(&models.User{}).Where("username = ?", "test2").First(&dummyUser)
+	if !dummyUser.IsDummyUser {
+		utils.PrintTestError(t, dummyUser.IsDummyUser, true)
+	}
+
+	if len(receipt.ReceiptItems) != 2 {
+		utils.PrintTestError(t, len(receipt.ReceiptItems), 2)
+		return
+	}
+
+	split := receipt.ReceiptItems[0]
+	if split.ChargedToUserId == nil || *split.ChargedToUserId != dummyUser.ID {
+		utils.PrintTestError(t, split.ChargedToUserId, dummyUser.ID)
+	}
+
+	if len(split.Categories) != 1 || split.Categories[0].GroupId == nil || *split.Categories[0].GroupId != groupId {
+		utils.PrintTestError(t, split.Categories, "category of the restored group")
+	}
+
+	if len(split.LinkedItems) != 1 || split.LinkedItems[0].ID != receipt.ReceiptItems[1].ID {
+		utils.PrintTestError(t, split.LinkedItems, "linked item")
+	}
+
+	if len(receipt.Comments) != 2 || receipt.Comments[1].CommentId == nil || *receipt.Comments[1].CommentId != receipt.Comments[0].ID {
+		utils.PrintTestError(t, receipt.Comments, "reply to restored comment")
+	}
+
+	var option models.CustomFieldOption
+	db.Model(&models.CustomFieldOption{}).Where("value = ?", "b").First(&option)
+	if len(receipt.CustomFields) != 1 || receipt.CustomFields[0].SelectValue == nil || *receipt.CustomFields[0].SelectValue != option.ID {
+		utils.PrintTestError(t, receipt.CustomFields, "select value b")
+	}
+
+	if len(receipt.ImageFiles) != 1 {
+		utils.PrintTestError(t, len(receipt.ImageFiles), 1)
+		return
+	}
+
+	fileBlob, _ := repositories.NewFileBlobRepository(nil).GetFileBlobByHash(receipt.ImageFiles[0].BlobHash)
+	if fileBlob.RefCount != 2 {
+		utils.PrintTestError(t, fileBlob.RefCount, 2)
+	}
+
+	var dashboard models.Dashboard
+	db.Model(&models.Dashboard{}).Where("group_id = ?", groupId).Preload("Widgets").First(&dashboard)
+	if dashboard.UserID != 1 || len(dashboard.Widgets) != 1 {
+		utils.PrintTestError(t, dashboard, "dashboard of user 1 with a widget")
+	}
+}
+
+func TestShouldRollBackFailedRestore(t *testing.T) {
+	defer tearDownBackupTest()
+	setUpBackupTest(t)
+	db := repositories.GetDB()
+	service := NewBackupService(nil)
+
+	archive, _ := service.CreateGroupBackup([]uint{1})
+	backup, _, _ := service.ReadBackupArchive(archive)
+
+	// The archive no longer has the receipt's file
+	backupBytes, _ := json.Marshal(backup)
+	brokenArchive, _ := repositories.NewFileRepository(nil).ZipFiles([]string{constants.BackupDataFileName}, [][]byte{backupBytes})
+
+	var groupCount int64
+	db.Model(&models.Group{}).Count(&groupCount)
+
+	_, err := service.RestoreBackup(brokenArchive, 1)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	var groupCountAfterRestore int64
+	db.Model(&models.Group{}).Count(&groupCountAfterRestore)
+	if groupCountAfterRestore != groupCount {
+		utils.PrintTestError(t, groupCountAfterRestore, groupCount)
+	}
+}
+
+func TestShouldRejectNewerBackupVersion(t *testing.T) {
+	backupBytes, _ := json.Marshal(structs.Backup{Version: constants.BackupVersion + 1})
+	archive, _ := repositories.NewFileRepository(nil).ZipFiles([]string{constants.BackupDataFileName}, [][]byte{backupBytes})
+
+	_, _, err := NewBackupService(nil).ReadBackupArchive(archive)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
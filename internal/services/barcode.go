@@ -0,0 +1,304 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type BarcodeService struct {
+	BaseService
+}
+
+func NewBarcodeService(tx *gorm.DB) BarcodeService {
+	service := BarcodeService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+type zbarBarcodes struct {
+	Sources []struct {
+		Indexes []struct {
+			Symbols []struct {
+				Type string `xml:"type,attr"`
+				Data struct {
+					Format string `xml:"format,attr"`
+					Value  string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"symbol"`
+		} `xml:"index"`
+	} `xml:"source"`
+}
+
+// ReadBarcodes decodes every barcode and QR code of an image, or of every page of a pdf
+func (service BarcodeService) ReadBarcodes(path string) ([]models.Barcode, error) {
+	fileRepository := repositories.NewFileRepository(service.TX)
+	fileBytes, err := utils.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	isPdf, err := fileRepository.IsPdf(fileBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isPdf {
+		return service.readBarcodesFromImage(path)
+	}
+
+	pages, err := fileRepository.ConvertPdfToJpgPages(fileBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	barcodes := make([]models.Barcode, 0)
+	for _, page := range pages {
+		pagePath, err := fileRepository.WriteTempFile(page)
+		if err != nil {
+			return nil, err
+		}
+
+		pageBarcodes, err := service.readBarcodesFromImage(pagePath)
+		os.Remove(pagePath)
+		if err != nil {
+			return nil, err
+		}
+
+		barcodes = append(barcodes, pageBarcodes...)
+	}
+
+	return barcodes, nil
+}
+
+func (service BarcodeService) readBarcodesFromImage(path string) ([]models.Barcode, error) {
+	var outputBuffer bytes.Buffer
+	cmd := exec.Command("zbarimg", "--quiet", "--xml", path)
+	cmd.Stdout = &outputBuffer
+	cmd.Stderr = io.Discard
+
+	err := cmd.Run()
+	if err != nil {
+		// zbarimg exits with 4 when the image has no barcodes
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 4 {
+			return []models.Barcode{}, nil
+		}
+
+		return nil, err
+	}
+
+	return ParseZbarXml(outputBuffer.Bytes())
+}
+
+func ParseZbarXml(output []byte) ([]models.Barcode, error) {
+	var result zbarBarcodes
+	barcodes := make([]models.Barcode, 0)
+
+	err := xml.Unmarshal(output, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range result.Sources {
+		for _, index := range source.Indexes {
+			for _, symbol := range index.Symbols {
+				data := symbol.Data.Value
+
+				// Binary payloads are base64 encoded by zbar
+				if symbol.Data.Format == "base64" {
+					decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+					if err != nil {
+						return nil, err
+					}
+					data = string(decoded)
+				}
+
+				barcodes = append(barcodes, models.Barcode{Type: symbol.Type, Data: data})
+			}
+		}
+	}
+
+	return barcodes, nil
+}
+
+// GetFiscalReceipt returns the receipt fields of the first barcode in a known fiscal or payment format
+func (service BarcodeService) GetFiscalReceipt(barcodes []models.Barcode) (structs.FiscalReceipt, bool) {
+	for _, barcode := range barcodes {
+		fiscalReceipt, ok := ParseFiscalQrCode(barcode.Data)
+		if ok {
+			return fiscalReceipt, true
+		}
+	}
+
+	return structs.FiscalReceipt{}, false
+}
+
+func ParseFiscalQrCode(data string) (structs.FiscalReceipt, bool) {
+	switch {
+	case strings.HasPrefix(data, "SPC"):
+		return parseSwissQrBill(data)
+	case strings.HasPrefix(data, "BCD"):
+		return parseEpcQr(data)
+	case strings.HasPrefix(data, "_R1-AT"):
+		return parseRksvQr(data)
+	case strings.HasPrefix(data, "V0;"):
+		return parseKassenSichVQr(data)
+	case strings.HasPrefix(data, "t=") && strings.Contains(data, "&s="):
+		return parseFnsQr(data)
+	}
+
+	return structs.FiscalReceipt{}, false
+}
+
+func splitQrLines(data string) []string {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+
+	return lines
+}
+
+// Swiss QR-bill: creditor name on line 6, amount on line 19
+func parseSwissQrBill(data string) (structs.FiscalReceipt, bool) {
+	lines := splitQrLines(data)
+	if len(lines) < 20 || lines[0] != "SPC" {
+		return structs.FiscalReceipt{}, false
+	}
+
+	result := structs.FiscalReceipt{Format: structs.SWISS_QR_BILL, Merchant: lines[5]}
+	amount, err := decimal.NewFromString(lines[18])
+	if err == nil {
+		result.Amount = &amount
+	}
+
+	return result, true
+}
+
+// EPC QR (SEPA credit transfer): beneficiary name on line 6, amount as EUR12.34 on line 8
+func parseEpcQr(data string) (structs.FiscalReceipt, bool) {
+	lines := splitQrLines(data)
+	if len(lines) < 7 || lines[0] != "BCD" || lines[3] != "SCT" {
+		return structs.FiscalReceipt{}, false
+	}
+
+	result := structs.FiscalReceipt{Format: structs.EPC_QR, Merchant: lines[5]}
+	if len(lines) > 7 && len(lines[7]) > 3 {
+		amount, err := decimal.NewFromString(lines[7][3:])
+		if err == nil {
+			result.Amount = &amount
+		}
+	}
+
+	return result, true
+}
+
+// Austrian RKSV: _R1-AT1_cashBoxId_receiptNumber_dateTime_amountNormal_amountReduced1_amountReduced2_amountZero_amountSpecial_...
+func parseRksvQr(data string) (structs.FiscalReceipt, bool) {
+	parts := strings.Split(data, "_")
+	if len(parts) < 10 {
+		return structs.FiscalReceipt{}, false
+	}
+
+	result := structs.FiscalReceipt{Format: structs.AT_RKSV}
+	date, err := time.Parse("2006-01-02T15:04:05", parts[4])
+	if err == nil {
+		result.Date = &date
+	}
+
+	total := decimal.Zero
+	for _, part := range parts[5:10] {
+		amount, err := decimal.NewFromString(strings.ReplaceAll(part, ",", "."))
+		if err != nil {
+			return result, true
+		}
+		total = total.Add(amount)
+	}
+	result.Amount = &total
+
+	return result, true
+}
+
+// German KassenSichV: V0;serial;processType;Beleg^gross1_..._gross5^payments;transaction;counter;start;end;algorithm;timeFormat;signature;publicKey
+func parseKassenSichVQr(data string) (structs.FiscalReceipt, bool) {
+	parts := strings.Split(data, ";")
+	if len(parts) < 10 {
+		return structs.FiscalReceipt{}, false
+	}
+
+	result := structs.FiscalReceipt{Format: structs.DE_KASSENSICHV}
+
+	processData := strings.Split(parts[3], "^")
+	if len(processData) > 1 && processData[0] == "Beleg" {
+		total := decimal.Zero
+		valid := true
+		for _, grossAmount := range strings.Split(processData[1], "_") {
+			amount, err := decimal.NewFromString(grossAmount)
+			if err != nil {
+				valid = false
+				break
+			}
+			total = total.Add(amount)
+		}
+
+		if valid {
+			result.Amount = &total
+		}
+	}
+
+	// The time format field isn't reliable, printers label iso times as unixTime too
+	date, err := time.Parse(time.RFC3339, parts[6])
+	if err == nil {
+		result.Date = &date
+	} else {
+		seconds, err := strconv.ParseInt(parts[6], 10, 64)
+		if err == nil {
+			date = time.Unix(seconds, 0).UTC()
+			result.Date = &date
+		}
+	}
+
+	return result, true
+}
+
+// Russian FNS: t=20190523T1523&s=1234.00&fn=...&i=...&fp=...&n=1
+func parseFnsQr(data string) (structs.FiscalReceipt, bool) {
+	values, err := url.ParseQuery(data)
+	if err != nil {
+		return structs.FiscalReceipt{}, false
+	}
+
+	result := structs.FiscalReceipt{Format: structs.RU_FNS}
+	amount, err := decimal.NewFromString(values.Get("s"))
+	if err == nil {
+		result.Amount = &amount
+	}
+
+	for _, layout := range []string{"20060102T150405", "20060102T1504"} {
+		date, err := time.Parse(layout, values.Get("t"))
+		if err == nil {
+			result.Date = &date
+			break
+		}
+	}
+
+	return result, true
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestShouldParseZbarXml(t *testing.T) {
+	output := `<barcodes xmlns='http://zbar.sourceforge.net/2008/barcode'>
+<source href='receipt.jpg'>
+<index num='0'>
+<symbol type='QR-Code' quality='1' orientation='UP'><data><![CDATA[https://example.com/order/42]]></data></symbol>
+<symbol type='EAN-13' quality='1' orientation='UP'><data><![CDATA[4006381333931]]></data></symbol>
+<symbol type='QR-Code' quality='1' orientation='UP'><data format='base64' length='5'><![CDATA[aGVsbG8=]]></data></symbol>
+</index>
+</source>
+</barcodes>`
+
+	barcodes, err := ParseZbarXml([]byte(output))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	expected := []models.Barcode{
+		{Type: "QR-Code", Data: "https://example.com/order/42"},
+		{Type: "EAN-13", Data: "4006381333931"},
+		{Type: "QR-Code", Data: "hello"},
+	}
+
+	if len(barcodes) != len(expected) {
+		utils.PrintTestError(t, barcodes, expected)
+		return
+	}
+
+	for i := range expected {
+		if barcodes[i] != expected[i] {
+			utils.PrintTestError(t, barcodes[i], expected[i])
+		}
+	}
+}
+
+func TestShouldParseFiscalQrCodes(t *testing.T) {
+	swissQrBill := "SPC\r\n0200\r\n1\r\nCH4431999123000889012\r\nS\r\nRobert Schneider AG\r\nRue du Lac\r\n1268\r\n2501\r\nBiel\r\nCH\r\n\r\n\r\n\r\n\r\n\r\n\r\n\r\n1949.75\r\nCHF\r\nS\r\nPia-Maria Rutschmann-Schnyder\r\n"
+	epcQr := "BCD\n002\n1\nSCT\nBFSWDE33BER\nWikimedia Foerdergesellschaft\nDE33100205000001194700\nEUR123.45\n\n\nSpende"
+	rksv := "_R1-AT1_DEMO-CASH-BOX817_83469_2015-11-25T19:20:11_10,00_2,50_0,00_0,00_0,00_jUJc9g==_-3667961875706356849_3e/Gq4/HJPw=_RjAfM+2ZwxM="
+	kassenSichV := "V0;955002-00;Kassenbeleg-V1;Beleg^75.33_7.99_0.00_0.00_0.00^10.00:Bar_5.00:Bar:CHF_5.00:Bar:USD_64.30:Unbar;18;112;2019-07-10T18:41:04.000Z;2019-07-10T18:41:04.000Z;ecdsa-plain-SHA256;unixTime;MEYCIQ;BHhWOeisRpPBTGQ1W4VUH95TXx2GARf8e2NYZXJoInjtGqnxJ8sZ3CQpYgjI+LYEmW5A37sLWHsyU7nSJUBemyU="
+	fns := "t=20190523T1523&s=1234.50&fn=9289000100273916&i=27634&fp=1523415232&n=1"
+	rksvDate := time.Date(2015, 11, 25, 19, 20, 11, 0, time.UTC)
+	kassenSichVDate := time.Date(2019, 7, 10, 18, 41, 4, 0, time.UTC)
+	fnsDate := time.Date(2019, 5, 23, 15, 23, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		data     string
+		format   structs.FiscalQrFormat
+		merchant string
+		amount   string
+		date     *time.Time
+	}{
+		"swiss qr bill": {
+			data:     swissQrBill,
+			format:   structs.SWISS_QR_BILL,
+			merchant: "Robert Schneider AG",
+			amount:   "1949.75",
+		},
+		"epc qr": {
+			data:     epcQr,
+			format:   structs.EPC_QR,
+			merchant: "Wikimedia Foerdergesellschaft",
+			amount:   "123.45",
+		},
+		"austrian rksv": {
+			data:   rksv,
+			format: structs.AT_RKSV,
+			amount: "12.5",
+			date:   &rksvDate,
+		},
+		"german kassensichv": {
+			data:   kassenSichV,
+			format: structs.DE_KASSENSICHV,
+			amount: "83.32",
+			date:   &kassenSichVDate,
+		},
+		"russian fns": {
+			data:   fns,
+			format: structs.RU_FNS,
+			amount: "1234.5",
+			date:   &fnsDate,
+		},
+	}
+
+	for name, test := range tests {
+		result, ok := ParseFiscalQrCode(test.data)
+		if !ok {
+			utils.PrintTestError(t, ok, name)
+			continue
+		}
+
+		if result.Format != test.format || result.Merchant != test.merchant {
+			utils.PrintTestError(t, result, name)
+		}
+
+		if result.Amount == nil || !result.Amount.Equal(decimal.RequireFromString(test.amount)) {
+			utils.PrintTestError(t, result.Amount, test.amount)
+		}
+
+		if test.date != nil && (result.Date == nil || !result.Date.Equal(*test.date)) {
+			utils.PrintTestError(t, result.Date, test.date)
+		}
+	}
+}
+
+func TestShouldNotParseUnknownQrCodes(t *testing.T) {
+	for _, data := range []string{"https://example.com/order/42", "4006381333931", "SPC\n0200"} {
+		_, ok := ParseFiscalQrCode(data)
+		if ok {
+			utils.PrintTestError(t, ok, data)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/migrations"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const databaseTransferBatchSize = 500
+
+type DatabaseTransferService struct {
+	BaseService
+	Target *gorm.DB
+}
+
+func NewDatabaseTransferService(tx *gorm.DB, target *gorm.DB) DatabaseTransferService {
+	service := DatabaseTransferService{
+		BaseService: BaseService{
+			DB: repositories.GetDB(),
+			TX: tx,
+		},
+		Target: target,
+	}
+	return service
+}
+
+type transferTable struct {
+	name        string
+	schema      *schema.Schema
+	columns     []string
+	primaryKeys []string
+}
+
+// TransferDatabase copies every table into an empty target database, keeping ids.
+// The copy runs in one transaction on the target and is rolled back unless row counts and checksums match.
+// A dry run only reads the source and checks the target could be written to.
+func (service DatabaseTransferService) TransferDatabase(dryRun bool) (structs.DatabaseTransferResult, error) {
+	source := service.GetDB()
+	result := structs.DatabaseTransferResult{
+		DryRun: dryRun,
+		Tables: make([]structs.DatabaseTransferTableResult, 0),
+	}
+
+	statuses, err := migrations.GetStatus(source)
+	if err != nil {
+		return result, err
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil || status.Unknown {
+			return result, fmt.Errorf("source database has to be at schema version %d, run migrate up first", migrations.GetLatestVersion())
+		}
+	}
+
+	tables, err := service.getTransferTables(source)
+	if err != nil {
+		return result, err
+	}
+
+	precision := getTransferTimePrecision(service.Target)
+
+	if dryRun {
+		err = checkTransferTargetIsEmpty(service.Target, tables)
+		if err != nil {
+			return result, err
+		}
+
+		for _, table := range tables {
+			tableResult, err := checksumTransferTable(source, table, precision)
+			if err != nil {
+				return result, err
+			}
+			result.Tables = append(result.Tables, tableResult)
+		}
+
+		return result, nil
+	}
+
+	_, err = migrations.Up(service.Target)
+	if err != nil {
+		return result, err
+	}
+
+	err = checkTransferTargetIsEmpty(service.Target, tables)
+	if err != nil {
+		return result, err
+	}
+
+	err = service.Target.Transaction(func(tx *gorm.DB) error {
+		err := setTransferForeignKeyChecks(tx, false)
+		if err != nil {
+			return err
+		}
+
+		for _, table := range tables {
+			sourceResult, err := copyTransferTable(source, tx, table, precision)
+			if err != nil {
+				return fmt.Errorf("failed to copy %s: %w", table.name, err)
+			}
+
+			targetResult, err := checksumTransferTable(tx, table, precision)
+			if err != nil {
+				return err
+			}
+
+			if sourceResult.Rows != targetResult.Rows {
+				return fmt.Errorf("%s has %d rows in the source but %d in the target", table.name, sourceResult.Rows, targetResult.Rows)
+			}
+			if sourceResult.Checksum != targetResult.Checksum {
+				return fmt.Errorf("%s checksum does not match after copying", table.name)
+			}
+
+			logging.LogStd(logging.LOG_LEVEL_INFO, fmt.Sprintf("Transferred %d rows of %s", sourceResult.Rows, table.name))
+			result.Tables = append(result.Tables, sourceResult)
+		}
+
+		err = resetTransferSequences(tx, tables)
+		if err != nil {
+			return err
+		}
+
+		return setTransferForeignKeyChecks(tx, true)
+	})
+	if err != nil {
+		result.Tables = make([]structs.DatabaseTransferTableResult, 0)
+		return result, err
+	}
+
+	return result, nil
+}
+
+// getTransferTables returns the model tables in the order auto migrate creates them, so rows they reference
+// are copied first, followed by the many2many join tables. Schema migrations are written by migrate up instead.
+func (service DatabaseTransferService) getTransferTables(source *gorm.DB) ([]transferTable, error) {
+	tables := make([]transferTable, 0)
+	joinTables := make([]transferTable, 0)
+	seenJoinTables := make(map[string]bool)
+
+	orderedModels := migrations.GetModels()
+	migrator, ok := source.Migrator().(interface {
+		ReorderModels(values []interface{}, autoAdd bool) []interface{}
+	})
+	if ok {
+		orderedModels = migrator.ReorderModels(orderedModels, false)
+	}
+
+	for _, model := range orderedModels {
+		statement := &gorm.Statement{DB: source}
+		err := statement.Parse(model)
+		if err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, buildTransferTable(statement.Schema))
+
+		for _, relationship := range statement.Schema.Relationships.Many2Many {
+			joinTable := relationship.JoinTable
+			if joinTable == nil || seenJoinTables[joinTable.Table] {
+				continue
+			}
+
+			seenJoinTables[joinTable.Table] = true
+			joinTables = append(joinTables, buildTransferTable(joinTable))
+		}
+	}
+
+	return append(tables, joinTables...), nil
+}
+
+func buildTransferTable(tableSchema *schema.Schema) transferTable {
+	primaryKeys := tableSchema.PrimaryFieldDBNames
+	if len(primaryKeys) == 0 {
+		primaryKeys = tableSchema.DBNames
+	}
+
+	return transferTable{
+		name:        tableSchema.Table,
+		schema:      tableSchema,
+		columns:     tableSchema.DBNames,
+		primaryKeys: primaryKeys,
+	}
+}
+
+func checkTransferTargetIsEmpty(target *gorm.DB, tables []transferTable) error {
+	if target.Migrator().HasTable(&models.SchemaMigration{}) {
+		err := migrations.CheckSchemaVersion(target)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, table := range tables {
+		if !target.Migrator().HasTable(table.name) {
+			continue
+		}
+
+		var count int64
+		err := target.Table(table.name).Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("target database is not empty, %s has %d rows", table.name, count)
+		}
+	}
+
+	return nil
+}
+
+// copyTransferTable copies a table in batches and returns the row count and checksum of what was read
+func copyTransferTable(source *gorm.DB, target *gorm.DB, table transferTable, precision time.Duration) (structs.DatabaseTransferTableResult, error) {
+	batch := make([]map[string]interface{}, 0, databaseTransferBatchSize)
+	writeBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := target.Table(table.name).Create(&batch).Error
+		batch = make([]map[string]interface{}, 0, databaseTransferBatchSize)
+		return err
+	}
+
+	tableResult, err := readTransferTable(source, table, precision, func(values []interface{}) error {
+		row := make(map[string]interface{}, len(table.columns))
+		for i, column := range table.columns {
+			row[column] = values[i]
+		}
+		batch = append(batch, row)
+
+		if len(batch) < databaseTransferBatchSize {
+			return nil
+		}
+		return writeBatch()
+	})
+	if err != nil {
+		return tableResult, err
+	}
+
+	return tableResult, writeBatch()
+}
+
+func checksumTransferTable(db *gorm.DB, table transferTable, precision time.Duration) (structs.DatabaseTransferTableResult, error) {
+	return readTransferTable(db, table, precision, func(values []interface{}) error {
+		return nil
+	})
+}
+
+// readTransferTable reads a table ordered by its primary key, hashing the normalized values of every row
+func readTransferTable(db *gorm.DB, table transferTable, precision time.Duration, handleRow func(values []interface{}) error) (structs.DatabaseTransferTableResult, error) {
+	tableResult := structs.DatabaseTransferTableResult{Table: table.name}
+	hash := sha256.New()
+
+	orderColumns := make([]clause.OrderByColumn, 0, len(table.primaryKeys))
+	for _, primaryKey := range table.primaryKeys {
+		orderColumns = append(orderColumns, clause.OrderByColumn{Column: clause.Column{Name: primaryKey}})
+	}
+
+	rows, err := db.Table(table.name).
+		Select(table.columns).
+		Clauses(clause.OrderBy{Columns: orderColumns}).
+		Rows()
+	if err != nil {
+		return tableResult, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values := make([]interface{}, len(table.columns))
+		pointers := make([]interface{}, len(table.columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return tableResult, err
+		}
+
+		for i, column := range table.columns {
+			field := table.schema.LookUpField(column)
+			values[i] = normalizeTransferValue(field, values[i], precision)
+			hash.Write([]byte(formatTransferValue(field, values[i])))
+			hash.Write([]byte{0x1f})
+		}
+		hash.Write([]byte{0x1e})
+
+		err = handleRow(values)
+		if err != nil {
+			return tableResult, err
+		}
+
+		tableResult.Rows++
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return tableResult, err
+	}
+
+	tableResult.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return tableResult, nil
+}
+
+// normalizeTransferValue converts what a driver returns into a value every engine accepts for the column
+func normalizeTransferValue(field *schema.Field, value interface{}, precision time.Duration) interface{} {
+	if bytes, ok := value.([]byte); ok && (field == nil || field.DataType != schema.Bytes) {
+		value = string(bytes)
+	}
+
+	if field != nil && field.DataType == schema.Bool {
+		switch typedValue := value.(type) {
+		case int64:
+			return typedValue != 0
+		case string:
+			parsedValue, err := strconv.ParseBool(typedValue)
+			if err == nil {
+				return parsedValue
+			}
+		}
+	}
+
+	// Engines keep different fractions of a second, so times are cut to what the target keeps
+	if timeValue, ok := value.(time.Time); ok {
+		return timeValue.UTC().Truncate(precision)
+	}
+
+	return value
+}
+
+// formatTransferValue formats a normalized value the same way whichever engine it was read from
+func formatTransferValue(field *schema.Field, value interface{}) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return "\x00"
+	case bool:
+		return strconv.FormatBool(typedValue)
+	case time.Time:
+		return typedValue.Format(time.RFC3339Nano)
+	case float32:
+		return decimal.NewFromFloat32(typedValue).String()
+	case float64:
+		return decimal.NewFromFloat(typedValue).String()
+	case string:
+		if field == nil || field.DataType != schema.String {
+			parsedDecimal, err := decimal.NewFromString(typedValue)
+			if err == nil {
+				return parsedDecimal.String()
+			}
+		}
+		return typedValue
+	}
+
+	return fmt.Sprint(value)
+}
+
+func getTransferTimePrecision(target *gorm.DB) time.Duration {
+	if target.Dialector.Name() == "mysql" {
+		return time.Millisecond
+	}
+
+	return time.Microsecond
+}
+
+func setTransferForeignKeyChecks(tx *gorm.DB, enabled bool) error {
+	switch tx.Dialector.Name() {
+	case "mysql":
+		if enabled {
+			return tx.Exec("SET FOREIGN_KEY_CHECKS = 1").Error
+		}
+		return tx.Exec("SET FOREIGN_KEY_CHECKS = 0").Error
+	case "sqlite":
+		if !enabled {
+			return tx.Exec("PRAGMA defer_foreign_keys = ON").Error
+		}
+	}
+
+	return nil
+}
+
+// resetTransferSequences moves postgres sequences past the copied ids, mysql and sqlite do this on insert
+func resetTransferSequences(tx *gorm.DB, tables []transferTable) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	for _, table := range tables {
+		field := table.schema.PrioritizedPrimaryField
+		if field == nil || !field.AutoIncrement {
+			continue
+		}
+
+		column := clause.Column{Name: field.DBName}
+		err := tx.Exec(
+			"SELECT setval(pg_get_serial_sequence(?, ?), COALESCE(MAX(?), 1), MAX(?) IS NOT NULL) FROM ?",
+			table.name,
+			field.DBName,
+			column,
+			column,
+			clause.Table{Name: table.name},
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"path/filepath"
+	"receipt-wrangler/api/internal/migrations"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTransferTargetDb(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "target.db")+"?_pragma=foreign_keys(1)&_pragma=synchronous(OFF)&_pragma=journal_mode(MEMORY)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func createTransferTestData(t *testing.T) models.Receipt {
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+
+	category := models.Category{Name: "Food"}
+	db.Create(&category)
+
+	receipt := models.Receipt{
+		Name:         "Groceries",
+		Amount:       decimal.RequireFromString("12.34"),
+		Date:         time.Date(2025, 3, 4, 5, 6, 7, 123456789, time.UTC),
+		PaidByUserID: 1,
+		GroupId:      1,
+		Status:       models.OPEN,
+		Categories:   []models.Category{category},
+	}
+	err := db.Create(&receipt).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.Model(&models.Group{}).Where("id = ?", 2).Update("is_all_group", true)
+
+	return receipt
+}
+
+func TestShouldTransferDatabaseKeepingIdsAndValues(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	receipt := createTransferTestData(t)
+	target := openTransferTargetDb(t)
+
+	result, err := NewDatabaseTransferService(nil, target).TransferDatabase(false)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.DryRun || len(result.Tables) == 0 {
+		utils.PrintTestError(t, result, "transferred tables")
+	}
+
+	var transferredReceipt models.Receipt
+	err = target.Preload("Categories").First(&transferredReceipt, receipt.ID).Error
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if transferredReceipt.Name != "Groceries" || !transferredReceipt.Amount.Equal(receipt.Amount) {
+		utils.PrintTestError(t, transferredReceipt, receipt)
+	}
+
+	if len(transferredReceipt.Categories) != 1 || transferredReceipt.Categories[0].Name != "Food" {
+		utils.PrintTestError(t, transferredReceipt.Categories, "Food")
+	}
+
+	var groups []models.Group
+	target.Order("id").Find(&groups)
+	if len(groups) != 2 || groups[0].IsAllGroup || !groups[1].IsAllGroup {
+		utils.PrintTestError(t, groups, "groups with their all group flags")
+	}
+
+	statuses, err := migrations.GetStatus(target)
+	if err != nil || len(statuses) != len(migrations.GetMigrations()) || statuses[len(statuses)-1].AppliedAt == nil {
+		utils.PrintTestError(t, statuses, "migrated target")
+	}
+
+	newCategory := models.Category{Name: "Travel"}
+	err = target.Create(&newCategory).Error
+	if err != nil || newCategory.ID <= receipt.Categories[0].ID {
+		utils.PrintTestError(t, newCategory.ID, "id after the copied ids")
+	}
+}
+
+func TestShouldNotTransferDatabaseIntoNonEmptyTarget(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	createTransferTestData(t)
+	target := openTransferTargetDb(t)
+
+	_, err := NewDatabaseTransferService(nil, target).TransferDatabase(false)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	_, err = NewDatabaseTransferService(nil, target).TransferDatabase(false)
+	if err == nil || !strings.Contains(err.Error(), "not empty") {
+		utils.PrintTestError(t, err, "target database is not empty")
+	}
+}
+
+func TestShouldNotWriteTargetOnDatabaseTransferDryRun(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	createTransferTestData(t)
+	target := openTransferTargetDb(t)
+
+	result, err := NewDatabaseTransferService(nil, target).TransferDatabase(true)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if !result.DryRun {
+		utils.PrintTestError(t, result.DryRun, true)
+	}
+
+	receiptRows := int64(-1)
+	for _, table := range result.Tables {
+		if table.Table == "receipts" {
+			receiptRows = table.Rows
+		}
+		if len(table.Checksum) == 0 {
+			utils.PrintTestError(t, table, "table checksum")
+		}
+	}
+	if receiptRows != 1 {
+		utils.PrintTestError(t, receiptRows, 1)
+	}
+
+	tables, err := target.Migrator().GetTables()
+	if err != nil || len(tables) != 0 {
+		utils.PrintTestError(t, tables, "no tables")
+	}
+}
+
+func TestShouldFormatTransferValuesTheSameAcrossEngines(t *testing.T) {
+	precision := time.Microsecond
+	sqliteTime := normalizeTransferValue(nil, time.Date(2025, 1, 1, 1, 0, 0, 123456789, time.FixedZone("", 3600)), precision)
+	postgresTime := normalizeTransferValue(nil, time.Date(2025, 1, 1, 0, 0, 0, 123456000, time.UTC), precision)
+
+	if formatTransferValue(nil, sqliteTime) != formatTransferValue(nil, postgresTime) {
+		utils.PrintTestError(t, formatTransferValue(nil, sqliteTime), formatTransferValue(nil, postgresTime))
+	}
+
+	if formatTransferValue(nil, float64(12.5)) != formatTransferValue(nil, "12.50") {
+		utils.PrintTestError(t, formatTransferValue(nil, "12.50"), "12.5")
+	}
+}
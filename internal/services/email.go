@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"receipt-wrangler/api/internal/env"
+	"strings"
+)
+
+// Swapped out in tests so no mail server is needed
+var sendMail = smtp.SendMail
+
+type EmailService struct {
+}
+
+func NewEmailService() EmailService {
+	return EmailService{}
+}
+
+func (service EmailService) SendEmail(to []string, subject string, body string) error {
+	smtpConfig, err := env.GetSmtpConfig()
+	if err != nil {
+		return err
+	}
+
+	if !env.IsSmtpConfigured() {
+		return errors.New("outgoing email is not configured")
+	}
+
+	var auth smtp.Auth
+	if len(smtpConfig.Username) > 0 {
+		auth = smtp.PlainAuth("", smtpConfig.Username, smtpConfig.Password, smtpConfig.Host)
+	}
+
+	for _, address := range to {
+		if strings.ContainsAny(address, "\r\n") {
+			return errors.New("invalid email recipient")
+		}
+	}
+
+	headers := []string{
+		"From: " + sanitizeHeaderValue(smtpConfig.From),
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", sanitizeHeaderValue(subject)),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+	}
+	message := strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+
+	address := fmt.Sprintf("%s:%d", smtpConfig.Host, smtpConfig.Port)
+	return sendMail(address, auth, smtpConfig.From, to, []byte(message))
+}
+
+// Header values can contain user supplied text like group names, line breaks in them would start new headers
+func sanitizeHeaderValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,767 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var tricountDateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+}
+
+type ExpenseImportService struct {
+	BaseService
+}
+
+func NewExpenseImportService(tx *gorm.DB) ExpenseImportService {
+	service := ExpenseImportService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+// externalExpense is an expense of Splitwise or Tricount, shares are in the order of the export
+type externalExpense struct {
+	ExternalId  string
+	Description string
+	Date        time.Time
+	IsPayment   bool
+	Shares      []externalShare
+}
+
+type externalShare struct {
+	Participant string
+	Paid        decimal.Decimal
+	Owed        decimal.Decimal
+}
+
+func (share externalShare) net() decimal.Decimal {
+	return share.Paid.Sub(share.Owed)
+}
+
+// externalExport is a parsed export, ExternalGroupId is empty when the export doesn't identify its group
+type externalExport struct {
+	ExternalGroupId string
+	Participants    []string
+	Expenses        []externalExpense
+}
+
+type splitwiseJsonExport struct {
+	Expenses []splitwiseJsonExpense `json:"expenses"`
+}
+
+type splitwiseJsonExpense struct {
+	Id          json.Number `json:"id"`
+	GroupId     json.Number `json:"group_id"`
+	Description string      `json:"description"`
+	Payment     bool        `json:"payment"`
+	Date        time.Time   `json:"date"`
+	DeletedAt   *time.Time  `json:"deleted_at"`
+	Users       []struct {
+		User struct {
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name"`
+		} `json:"user"`
+		PaidShare string `json:"paid_share"`
+		OwedShare string `json:"owed_share"`
+	} `json:"users"`
+}
+
+// ImportExpenses imports a Splitwise or Tricount export into a new group in one transaction.
+// Expenses become receipts paid by whoever is owed, with an item for every share that is still owed.
+// Expenses and the group are recorded as imported, so importing the same export again only adds new expenses.
+func (service ExpenseImportService) ImportExpenses(
+	command commands.ImportExpensesCommand,
+	userId uint,
+	userRole models.UserRole,
+) (structs.ExpenseImportResult, error) {
+	result := structs.ExpenseImportResult{
+		CreatedUsers:           make([]string, 0),
+		UnassignedParticipants: make([]string, 0),
+		AddedMembers:           make([]string, 0),
+	}
+
+	export, err := parseExternalExport(command.Source, command.File)
+	if err != nil {
+		return result, err
+	}
+
+	if len(export.ExternalGroupId) == 0 {
+		export.ExternalGroupId = command.GroupName
+	}
+
+	err = service.GetDB().Transaction(func(tx *gorm.DB) error {
+		groupId, err := service.findOrCreateImportGroup(tx, command, export.ExternalGroupId, userId)
+		if err != nil {
+			return err
+		}
+
+		userIds, err := service.mapParticipantsToUsers(tx, command.UserMappings, export.Participants, groupId, userId, userRole, &result)
+		if err != nil {
+			return err
+		}
+
+		for _, expense := range export.Expenses {
+			var recordCount int64
+			err = tx.Model(&models.ExternalImportRecord{}).
+				Where("source = ? AND imported_by_user_id = ? AND external_group_id = ? AND external_id = ?", command.Source, userId, export.ExternalGroupId, expense.ExternalId).
+				Count(&recordCount).Error
+			if err != nil {
+				return err
+			}
+
+			if recordCount > 0 {
+				result.SkippedExpenses++
+				continue
+			}
+
+			for _, receipt := range buildExternalExpenseReceipts(expense, userIds, groupId, userId) {
+				err = tx.Create(&receipt).Error
+				if err != nil {
+					return err
+				}
+				result.Receipts++
+			}
+
+			err = tx.Create(&models.ExternalImportRecord{
+				Source:           command.Source,
+				ImportedByUserId: userId,
+				ExternalGroupId:  export.ExternalGroupId,
+				ExternalId:       expense.ExternalId,
+				GroupId:          groupId,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Group{}).Where("id = ?", groupId).Preload("GroupMembers").First(&result.Group).Error
+	})
+	if err != nil {
+		return structs.ExpenseImportResult{}, err
+	}
+
+	return result, nil
+}
+
+// findOrCreateImportGroup returns the group of the user's earlier import of the same export, or creates it owned by the user
+func (service ExpenseImportService) findOrCreateImportGroup(tx *gorm.DB, command commands.ImportExpensesCommand, externalGroupId string, userId uint) (uint, error) {
+	var record models.ExternalImportRecord
+	err := tx.Model(&models.ExternalImportRecord{}).
+		Where("source = ? AND imported_by_user_id = ? AND external_group_id = ? AND external_id = ?", command.Source, userId, externalGroupId, "").
+		Limit(1).
+		Find(&record).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if record.ID > 0 {
+		err = NewGroupService(tx).ValidateGroupsAreActive([]string{utils.UintToString(record.GroupId)})
+		if err != nil {
+			return 0, err
+		}
+
+		var ownerCount int64
+		err = tx.Model(&models.GroupMember{}).
+			Where("group_id = ? AND user_id = ? AND group_role = ?", record.GroupId, userId, models.OWNER).
+			Count(&ownerCount).Error
+		if err != nil {
+			return 0, err
+		}
+
+		if ownerCount == 0 {
+			return 0, errors.New("export was imported to a group the user does not own")
+		}
+
+		return record.GroupId, nil
+	}
+
+	group, err := repositories.NewGroupRepository(tx).CreateGroup(commands.UpsertGroupCommand{
+		Name:   command.GroupName,
+		Status: models.GROUP_ACTIVE,
+	}, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Create(&models.ExternalImportRecord{
+		Source:           command.Source,
+		ImportedByUserId: userId,
+		ExternalGroupId:  externalGroupId,
+		GroupId:          group.ID,
+	}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return group.ID, nil
+}
+
+// ValidateUserMappings makes sure participants are only mapped to users who already share a group with the importer
+func (service ExpenseImportService) ValidateUserMappings(command commands.ImportExpensesCommand, userId uint) (structs.ValidatorError, error) {
+	vErr := structs.ValidatorError{
+		Errors: make(map[string]string),
+	}
+
+	groupmateIds, err := service.getGroupmateUserIds(service.GetDB(), userId)
+	if err != nil {
+		return vErr, err
+	}
+
+	for participant, mappedUserId := range command.UserMappings {
+		if !slices.Contains(groupmateIds, mappedUserId) {
+			vErr.Errors["userMappings"] = fmt.Sprintf("%s can only be mapped to a user sharing a group with you", participant)
+		}
+	}
+
+	return vErr, nil
+}
+
+// getGroupmateUserIds returns the user and everyone who is a member of one of the user's groups
+func (service ExpenseImportService) getGroupmateUserIds(tx *gorm.DB, userId uint) ([]uint, error) {
+	userIds := make([]uint, 0)
+	err := tx.Model(&models.GroupMember{}).
+		Distinct("user_id").
+		Where("group_id IN (?)", tx.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", userId)).
+		Pluck("user_id", &userIds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(userIds, userId) {
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, nil
+}
+
+// mapParticipantsToUsers resolves participants through the mappings, then by username among the users sharing a group with the importer.
+// Admins get dummy users for the rest, other importers leave them unassigned. Resolved users are made members of the group.
+func (service ExpenseImportService) mapParticipantsToUsers(
+	tx *gorm.DB,
+	userMappings map[string]uint,
+	participants []string,
+	groupId uint,
+	userId uint,
+	userRole models.UserRole,
+	result *structs.ExpenseImportResult,
+) (map[string]uint, error) {
+	userRepository := repositories.NewUserRepository(tx)
+	userIds := make(map[string]uint)
+
+	groupmateIds, err := service.getGroupmateUserIds(tx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, participant := range participants {
+		var user models.User
+		mappedUserId, isMapped := userMappings[participant]
+
+		if isMapped {
+			if !slices.Contains(groupmateIds, mappedUserId) {
+				return nil, fmt.Errorf("%s can only be mapped to a user sharing a group with the importer", participant)
+			}
+			err = tx.Model(&models.User{}).Where("id = ?", mappedUserId).Limit(1).Find(&user).Error
+		} else {
+			err = tx.Model(&models.User{}).
+				Where("LOWER(username) = ? AND id IN ?", strings.ToLower(participant), groupmateIds).
+				Limit(1).
+				Find(&user).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if isMapped && user.ID == 0 {
+			return nil, fmt.Errorf("user %d mapped to %s does not exist", mappedUserId, participant)
+		}
+
+		if user.ID == 0 && userRole != models.ADMIN {
+			result.UnassignedParticipants = append(result.UnassignedParticipants, participant)
+			continue
+		}
+
+		if user.ID == 0 {
+			password, err := utils.GetRandomString(32)
+			if err != nil {
+				return nil, err
+			}
+
+			user, err = userRepository.CreateUser(commands.SignUpCommand{
+				Username:    participant,
+				DisplayName: participant,
+				Password:    password,
+				IsDummyUser: true,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			result.CreatedUsers = append(result.CreatedUsers, participant)
+		}
+
+		userIds[participant] = user.ID
+
+		var memberCount int64
+		err = tx.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", groupId, user.ID).Count(&memberCount).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if memberCount == 0 && user.ID != userId {
+			err = tx.Create(&models.GroupMember{GroupID: groupId, UserID: user.ID, GroupRole: models.VIEWER}).Error
+			if err != nil {
+				return nil, err
+			}
+			result.AddedMembers = append(result.AddedMembers, participant)
+		}
+	}
+
+	return userIds, nil
+}
+
+// buildExternalExpenseReceipts creates a receipt for every participant that is owed money, debts are assigned largest first.
+// Payers' own shares and payments are resolved items, other shares stay open.
+func buildExternalExpenseReceipts(expense externalExpense, userIds map[string]uint, groupId uint, createdBy uint) []models.Receipt {
+	creditors := make([]externalShare, 0)
+	debtors := make([]externalShare, 0)
+	for _, share := range expense.Shares {
+		if share.net().IsPositive() {
+			creditors = append(creditors, share)
+		} else if share.net().IsNegative() {
+			debtors = append(debtors, share)
+		}
+	}
+
+	sort.SliceStable(creditors, func(i, j int) bool {
+		return creditors[i].net().GreaterThan(creditors[j].net())
+	})
+	sort.SliceStable(debtors, func(i, j int) bool {
+		return debtors[i].net().LessThan(debtors[j].net())
+	})
+
+	// Nobody owes anything, the largest payer gets the receipt with everyone's own share
+	if len(creditors) == 0 {
+		for _, share := range expense.Shares {
+			if len(creditors) == 0 || share.Paid.GreaterThan(creditors[0].Paid) {
+				creditors = []externalShare{share}
+			}
+		}
+	}
+
+	remainingDebts := make([]decimal.Decimal, len(debtors))
+	for i, debtor := range debtors {
+		remainingDebts[i] = debtor.net().Neg()
+	}
+
+	openItemStatus := models.ITEM_OPEN
+	if expense.IsPayment {
+		openItemStatus = models.ITEM_RESOLVED
+	}
+
+	receipts := make([]models.Receipt, 0, len(creditors))
+	for _, creditor := range creditors {
+		items := make([]models.Item, 0)
+		remainingCredit := creditor.net()
+
+		for i, debtor := range debtors {
+			if !remainingCredit.IsPositive() {
+				break
+			}
+
+			if !remainingDebts[i].IsPositive() {
+				continue
+			}
+
+			amount := decimal.Min(remainingCredit, remainingDebts[i])
+			remainingCredit = remainingCredit.Sub(amount)
+			remainingDebts[i] = remainingDebts[i].Sub(amount)
+			items = append(items, buildExternalShareItem(expense, debtor.Participant, amount, openItemStatus, userIds))
+		}
+
+		if creditor.Owed.IsPositive() {
+			items = append(items, buildExternalShareItem(expense, creditor.Participant, creditor.Owed, models.ITEM_RESOLVED, userIds))
+		}
+
+		receipt := models.Receipt{
+			BaseModel:    models.BaseModel{CreatedBy: &createdBy},
+			Name:         expense.Description,
+			Amount:       creditor.Paid,
+			Date:         expense.Date,
+			GroupId:      groupId,
+			PaidByUserID: getParticipantUserId(userIds, creditor.Participant, createdBy),
+			Status:       models.RESOLVED,
+			ReceiptItems: items,
+		}
+
+		for _, item := range items {
+			if item.Status == models.ITEM_OPEN {
+				receipt.Status = models.OPEN
+			}
+		}
+
+		if receipt.Status == models.RESOLVED {
+			resolvedDate := expense.Date
+			receipt.ResolvedDate = &resolvedDate
+		}
+
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts
+}
+
+// getParticipantUserId returns the participant's user, receipts paid by an unassigned participant are paid by the importer
+func getParticipantUserId(userIds map[string]uint, participant string, importerUserId uint) uint {
+	userId, ok := userIds[participant]
+	if !ok {
+		return importerUserId
+	}
+	return userId
+}
+
+// buildExternalShareItem charges the share to the participant's user, shares of unassigned participants are charged to nobody
+func buildExternalShareItem(expense externalExpense, participant string, amount decimal.Decimal, status models.ItemStatus, userIds map[string]uint) models.Item {
+	item := models.Item{
+		Name:   fmt.Sprintf("%s - %s", expense.Description, participant),
+		Amount: amount,
+		Status: status,
+	}
+
+	chargedToUserId, ok := userIds[participant]
+	if ok {
+		item.ChargedToUserId = &chargedToUserId
+	}
+
+	return item
+}
+
+func parseExternalExport(source models.ExternalImportSource, file []byte) (externalExport, error) {
+	file = bytes.TrimPrefix(file, []byte("\xef\xbb\xbf"))
+
+	switch source {
+	case models.SPLITWISE:
+		trimmedFile := bytes.TrimSpace(file)
+		if bytes.HasPrefix(trimmedFile, []byte("{")) || bytes.HasPrefix(trimmedFile, []byte("[")) {
+			return parseSplitwiseJson(trimmedFile)
+		}
+		return parseSplitwiseCsv(file)
+	case models.TRICOUNT:
+		return parseTricountCsv(file)
+	}
+
+	return externalExport{}, fmt.Errorf("unsupported source %s", source)
+}
+
+// parseSplitwiseJson reads the expenses of the Splitwise api, either as an array or wrapped in an expenses object
+func parseSplitwiseJson(file []byte) (externalExport, error) {
+	var splitwiseExport splitwiseJsonExport
+	if bytes.HasPrefix(file, []byte("[")) {
+		err := json.Unmarshal(file, &splitwiseExport.Expenses)
+		if err != nil {
+			return externalExport{}, err
+		}
+	} else {
+		err := json.Unmarshal(file, &splitwiseExport)
+		if err != nil {
+			return externalExport{}, err
+		}
+	}
+
+	export := externalExport{
+		Participants: make([]string, 0),
+		Expenses:     make([]externalExpense, 0),
+	}
+	participants := make(map[string]bool)
+
+	for _, splitwiseExpense := range splitwiseExport.Expenses {
+		if splitwiseExpense.DeletedAt != nil {
+			continue
+		}
+
+		if len(export.ExternalGroupId) == 0 && splitwiseExpense.GroupId.String() != "0" {
+			export.ExternalGroupId = splitwiseExpense.GroupId.String()
+		}
+
+		expense := externalExpense{
+			ExternalId:  splitwiseExpense.Id.String(),
+			Description: splitwiseExpense.Description,
+			Date:        splitwiseExpense.Date,
+			IsPayment:   splitwiseExpense.Payment,
+		}
+
+		for _, splitwiseUser := range splitwiseExpense.Users {
+			participant := strings.TrimSpace(splitwiseUser.User.FirstName + " " + splitwiseUser.User.LastName)
+			paid, err := decimal.NewFromString(splitwiseUser.PaidShare)
+			if err != nil {
+				return externalExport{}, fmt.Errorf("expense %s has an invalid paid share", expense.ExternalId)
+			}
+
+			owed, err := decimal.NewFromString(splitwiseUser.OwedShare)
+			if err != nil {
+				return externalExport{}, fmt.Errorf("expense %s has an invalid owed share", expense.ExternalId)
+			}
+
+			expense.Shares = append(expense.Shares, externalShare{Participant: participant, Paid: paid, Owed: owed})
+			if !participants[participant] {
+				participants[participant] = true
+				export.Participants = append(export.Participants, participant)
+			}
+		}
+
+		export.Expenses = append(export.Expenses, expense)
+	}
+
+	return export, nil
+}
+
+// parseSplitwiseCsv reads a group export, which has a net balance column per participant and ends with the total balance
+func parseSplitwiseCsv(file []byte) (externalExport, error) {
+	records, err := readExternalCsv(file)
+	if err != nil {
+		return externalExport{}, err
+	}
+
+	header := records[0]
+	if len(header) < 6 || header[0] != "Date" || header[3] != "Cost" {
+		return externalExport{}, errors.New("file is not a Splitwise export")
+	}
+
+	export := externalExport{
+		Participants: header[5:],
+		Expenses:     make([]externalExpense, 0),
+	}
+	externalIds := newExternalIdBuilder()
+
+	for _, record := range records[1:] {
+		if len(record) < len(header) || record[1] == "Total balance" {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			return externalExport{}, fmt.Errorf("expense %s has an invalid date", record[1])
+		}
+
+		cost, err := parseImportDecimal(record[3], models.DOT)
+		if err != nil {
+			return externalExport{}, fmt.Errorf("expense %s has an invalid cost", record[1])
+		}
+
+		expense := externalExpense{
+			ExternalId:  externalIds.build(record),
+			Description: record[1],
+			Date:        date,
+			IsPayment:   record[2] == "Payment",
+		}
+
+		nets := make([]decimal.Decimal, len(export.Participants))
+		creditorCount := 0
+		for i := range export.Participants {
+			nets[i], err = parseImportDecimal(record[5+i], models.DOT)
+			if err != nil {
+				return externalExport{}, fmt.Errorf("expense %s has an invalid balance", record[1])
+			}
+
+			if nets[i].IsPositive() {
+				creditorCount++
+			}
+		}
+
+		// Only the net balance is exported, a single payer paid the whole cost
+		for i, participant := range export.Participants {
+			share := externalShare{Participant: participant}
+			if nets[i].IsNegative() {
+				share.Owed = nets[i].Neg()
+			} else if nets[i].IsPositive() && creditorCount == 1 {
+				share.Paid = cost
+				share.Owed = cost.Sub(nets[i])
+			} else if nets[i].IsPositive() {
+				share.Paid = nets[i]
+			} else {
+				continue
+			}
+
+			expense.Shares = append(expense.Shares, share)
+		}
+
+		export.Expenses = append(export.Expenses, expense)
+	}
+
+	return export, nil
+}
+
+// parseTricountCsv reads a Tricount export, which has a paid by column and an impacted to column per participant
+func parseTricountCsv(file []byte) (externalExport, error) {
+	records, err := readExternalCsv(file)
+	if err != nil {
+		return externalExport{}, err
+	}
+
+	columnIndexes := make(map[string]int)
+	export := externalExport{
+		Participants: make([]string, 0),
+		Expenses:     make([]externalExpense, 0),
+	}
+	impactedIndexes := make([]int, 0)
+
+	for i, column := range records[0] {
+		column = strings.TrimSpace(column)
+		columnIndexes[strings.ToLower(column)] = i
+
+		if strings.HasPrefix(strings.ToLower(column), "impacted to ") {
+			export.Participants = append(export.Participants, strings.TrimSpace(column[len("impacted to "):]))
+			impactedIndexes = append(impactedIndexes, i)
+		}
+	}
+
+	findColumn := func(names ...string) int {
+		for _, name := range names {
+			index, ok := columnIndexes[name]
+			if ok {
+				return index
+			}
+		}
+		return -1
+	}
+
+	titleIndex := findColumn("title", "description")
+	amountIndex := findColumn("amount in default currency", "amount")
+	dateIndex := findColumn("date & time", "date")
+	typeIndex := findColumn("transaction type", "type")
+	paidByIndex := findColumn("paid by")
+	if titleIndex < 0 || amountIndex < 0 || dateIndex < 0 || paidByIndex < 0 || len(impactedIndexes) == 0 {
+		return externalExport{}, errors.New("file is not a Tricount export")
+	}
+
+	externalIds := newExternalIdBuilder()
+	for _, record := range records[1:] {
+		if len(record) < len(records[0]) {
+			continue
+		}
+
+		var date time.Time
+		for _, layout := range tricountDateLayouts {
+			date, err = time.Parse(layout, strings.TrimSpace(record[dateIndex]))
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return externalExport{}, fmt.Errorf("expense %s has an invalid date", record[titleIndex])
+		}
+
+		amount, err := parseImportDecimal(record[amountIndex], models.DOT)
+		if err != nil {
+			return externalExport{}, fmt.Errorf("expense %s has an invalid amount", record[titleIndex])
+		}
+
+		transactionType := ""
+		if typeIndex >= 0 {
+			transactionType = strings.ToLower(record[typeIndex])
+		}
+
+		expense := externalExpense{
+			ExternalId:  externalIds.build(record),
+			Description: record[titleIndex],
+			Date:        date,
+			IsPayment:   strings.Contains(transactionType, "transfer"),
+		}
+
+		for i, participant := range export.Participants {
+			impacted, err := parseImportDecimal(record[impactedIndexes[i]], models.DOT)
+			if err != nil {
+				impacted = decimal.Zero
+			}
+
+			if !impacted.IsZero() {
+				expense.Shares = append(expense.Shares, externalShare{Participant: participant, Owed: impacted.Abs()})
+			}
+		}
+
+		paidBy := strings.TrimSpace(record[paidByIndex])
+		paidByShare := -1
+		for i, share := range expense.Shares {
+			if share.Participant == paidBy {
+				paidByShare = i
+			}
+		}
+		if paidByShare < 0 {
+			expense.Shares = append(expense.Shares, externalShare{Participant: paidBy})
+			paidByShare = len(expense.Shares) - 1
+		}
+		if !slices.Contains(export.Participants, paidBy) {
+			export.Participants = append(export.Participants, paidBy)
+		}
+		expense.Shares[paidByShare].Paid = amount.Abs()
+
+		// The receiver of an income owes it to the impacted participants
+		if strings.Contains(transactionType, "income") {
+			for i := range expense.Shares {
+				expense.Shares[i].Paid, expense.Shares[i].Owed = expense.Shares[i].Owed, expense.Shares[i].Paid
+			}
+		}
+
+		export.Expenses = append(export.Expenses, expense)
+	}
+
+	return export, nil
+}
+
+func readExternalCsv(file []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(file))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	return records, nil
+}
+
+// externalIdBuilder identifies csv rows, which have no ids, by their content and how often it occurred before
+type externalIdBuilder struct {
+	occurrences map[string]int
+}
+
+func newExternalIdBuilder() externalIdBuilder {
+	return externalIdBuilder{occurrences: make(map[string]int)}
+}
+
+func (builder externalIdBuilder) build(record []string) string {
+	hash := sha256.Sum256([]byte(strings.Join(record, "\x1f")))
+	id := hex.EncodeToString(hash[:16])
+
+	builder.occurrences[id]++
+	return fmt.Sprintf("%s-%d", id, builder.occurrences[id])
+}
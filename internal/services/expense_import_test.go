@@ -0,0 +1,315 @@
+package services
+
+import (
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+const splitwiseTestCsv = "Date,Description,Category,Cost,Currency,Alice,Bob,test\n" +
+	"2024-01-01,Dinner,Dining out,30.00,EUR,20.00,-10.00,-10.00\n" +
+	"2024-01-02,Alice paid Bob,Payment,10.00,EUR,10.00,-10.00,0.00\n" +
+	"\n" +
+	"2024-01-03,Total balance, , ,EUR,30.00,-20.00,-10.00\n"
+
+func getImportedTestReceipts(groupId uint) []models.Receipt {
+	var receipts []models.Receipt
+	repositories.GetDB().Model(&models.Receipt{}).
+		Where("group_id = ?", groupId).
+		Preload("ReceiptItems").
+		Order("id").
+		Find(&receipts)
+	return receipts
+}
+
+func TestShouldImportSplitwiseCsvOnce(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	db := repositories.GetDB()
+	service := NewExpenseImportService(nil)
+
+	command := commands.ImportExpensesCommand{
+		File:         []byte(splitwiseTestCsv),
+		Source:       models.SPLITWISE,
+		GroupName:    "Trip",
+		UserMappings: map[string]uint{"Bob": 2},
+	}
+
+	result, err := service.ImportExpenses(command, 1, models.ADMIN)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.Receipts != 2 || result.SkippedExpenses != 0 || len(result.CreatedUsers) != 1 || result.CreatedUsers[0] != "Alice" {
+		utils.PrintTestError(t, result, "2 receipts and Alice created")
+		return
+	}
+
+	var alice models.User
+	db.Model(&models.User{}).Where("username = ?", "Alice").First(&alice)
+	if !alice.IsDummyUser {
+		utils.PrintTestError(t, alice.IsDummyUser, true)
+	}
+
+	if len(result.Group.GroupMembers) != 3 {
+		utils.PrintTestError(t, len(result.Group.GroupMembers), 3)
+	}
+
+	receipts := getImportedTestReceipts(result.Group.ID)
+	if len(receipts) != 2 {
+		utils.PrintTestError(t, len(receipts), 2)
+		return
+	}
+
+	dinner := receipts[0]
+	if dinner.PaidByUserID != alice.ID || !dinner.Amount.Equal(decimal.NewFromInt(30)) || dinner.Status != models.OPEN || len(dinner.ReceiptItems) != 3 {
+		utils.PrintTestError(t, dinner, "open dinner of 30 paid by Alice with 3 items")
+		return
+	}
+
+	expectedItems := []struct {
+		userId uint
+		status models.ItemStatus
+	}{{2, models.ITEM_OPEN}, {1, models.ITEM_OPEN}, {alice.ID, models.ITEM_RESOLVED}}
+	for i, expected := range expectedItems {
+		item := dinner.ReceiptItems[i]
+		if *item.ChargedToUserId != expected.userId || item.Status != expected.status || !item.Amount.Equal(decimal.NewFromInt(10)) {
+			utils.PrintTestError(t, item, expected)
+		}
+	}
+
+	payment := receipts[1]
+	if payment.Status != models.RESOLVED || len(payment.ReceiptItems) != 1 || payment.ReceiptItems[0].Status != models.ITEM_RESOLVED || *payment.ReceiptItems[0].ChargedToUserId != 2 {
+		utils.PrintTestError(t, payment, "resolved payment to Bob")
+	}
+
+	// Importing again adds nothing
+	secondResult, err := service.ImportExpenses(command, 1, models.ADMIN)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if secondResult.Group.ID != result.Group.ID || secondResult.Receipts != 0 || secondResult.SkippedExpenses != 2 || len(secondResult.CreatedUsers) != 0 {
+		utils.PrintTestError(t, secondResult, "same group with 2 skipped expenses")
+	}
+
+	if len(getImportedTestReceipts(result.Group.ID)) != 2 {
+		utils.PrintTestError(t, len(getImportedTestReceipts(result.Group.ID)), 2)
+	}
+}
+
+func TestShouldNotImportSplitwiseCsvToGroupOfOtherUser(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	service := NewExpenseImportService(nil)
+
+	command := commands.ImportExpensesCommand{File: []byte(splitwiseTestCsv), Source: models.SPLITWISE, GroupName: "Trip"}
+	result, err := service.ImportExpenses(command, 1, models.ADMIN)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	// The group was handed over to another user since the import
+	repositories.GetDB().Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", result.Group.ID, 1).Update("group_role", models.EDITOR)
+
+	_, err = service.ImportExpenses(command, 1, models.ADMIN)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+func TestShouldKeepImportsOfSameGroupNameApartPerUser(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	service := NewExpenseImportService(nil)
+
+	// Neither export identifies its group, so both are known by the same name
+	command := commands.ImportExpensesCommand{File: []byte(splitwiseTestCsv), Source: models.SPLITWISE, GroupName: "Roommates"}
+	firstResult, err := service.ImportExpenses(command, 1, models.ADMIN)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	secondResult, err := service.ImportExpenses(command, 4, models.USER)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if secondResult.Group.ID == firstResult.Group.ID || secondResult.Receipts != 2 || secondResult.SkippedExpenses != 0 {
+		utils.PrintTestError(t, secondResult, "own group with 2 imported expenses")
+	}
+
+	// Each user importing again still only skips their own expenses
+	againResult, err := service.ImportExpenses(command, 4, models.USER)
+	if err != nil || againResult.Group.ID != secondResult.Group.ID || againResult.SkippedExpenses != 2 {
+		utils.PrintTestError(t, againResult, "same group with 2 skipped expenses")
+	}
+}
+
+func TestShouldOnlyAssignGroupmatesWhenUserImports(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	db := repositories.GetDB()
+	service := NewExpenseImportService(nil)
+
+	// User 4 only shares a group with itself, user 1 named test is not a groupmate
+	command := commands.ImportExpensesCommand{
+		File:         []byte(splitwiseTestCsv),
+		Source:       models.SPLITWISE,
+		GroupName:    "Trip",
+		UserMappings: map[string]uint{"Bob": 1},
+	}
+
+	vErr, err := service.ValidateUserMappings(command, 4)
+	if err != nil || len(vErr.Errors) != 1 {
+		utils.PrintTestError(t, vErr.Errors, "error for mapping a user outside the importer's groups")
+	}
+
+	_, err = service.ImportExpenses(command, 4, models.USER)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	var userCount int64
+	db.Model(&models.User{}).Count(&userCount)
+
+	command.UserMappings = nil
+	result, err := service.ImportExpenses(command, 4, models.USER)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(result.CreatedUsers) != 0 || len(result.AddedMembers) != 0 || len(result.UnassignedParticipants) != 3 {
+		utils.PrintTestError(t, result, "3 unassigned participants and no new users or members")
+	}
+
+	var userCountAfterImport int64
+	db.Model(&models.User{}).Count(&userCountAfterImport)
+	if userCountAfterImport != userCount {
+		utils.PrintTestError(t, userCountAfterImport, userCount)
+	}
+
+	if len(result.Group.GroupMembers) != 1 || result.Group.GroupMembers[0].UserID != 4 {
+		utils.PrintTestError(t, result.Group.GroupMembers, "only the importer")
+	}
+
+	dinner := getImportedTestReceipts(result.Group.ID)[0]
+	if dinner.PaidByUserID != 4 || len(dinner.ReceiptItems) != 3 || dinner.ReceiptItems[0].ChargedToUserId != nil {
+		utils.PrintTestError(t, dinner, "dinner paid by the importer with unassigned items")
+	}
+}
+
+func TestShouldImportSplitwiseJsonWithSeveralPayers(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	json := `{"expenses": [
+		{
+			"id": 10, "group_id": 77, "description": "Cabin", "payment": false, "date": "2024-03-01T12:00:00Z",
+			"users": [
+				{"user": {"first_name": "Alice", "last_name": "A"}, "paid_share": "20.0", "owed_share": "5.0"},
+				{"user": {"first_name": "Bob", "last_name": "B"}, "paid_share": "10.0", "owed_share": "5.0"},
+				{"user": {"first_name": "Carol", "last_name": null}, "paid_share": "0.0", "owed_share": "20.0"}
+			]
+		},
+		{
+			"id": 11, "group_id": 77, "description": "Deleted", "payment": false, "date": "2024-03-02T12:00:00Z",
+			"deleted_at": "2024-03-03T12:00:00Z", "users": []
+		}
+	]}`
+
+	command := commands.ImportExpensesCommand{File: []byte(json), Source: models.SPLITWISE, GroupName: "Cabin"}
+	result, err := NewExpenseImportService(nil).ImportExpenses(command, 1, models.ADMIN)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.Receipts != 2 || len(result.CreatedUsers) != 3 {
+		utils.PrintTestError(t, result, "2 receipts and 3 created users")
+		return
+	}
+
+	var record models.ExternalImportRecord
+	repositories.GetDB().Model(&models.ExternalImportRecord{}).Where("external_id = ?", "10").First(&record)
+	if record.ExternalGroupId != "77" || record.GroupId != result.Group.ID {
+		utils.PrintTestError(t, record, "record of expense 10 in splitwise group 77")
+	}
+
+	// Carol's debt is split between both payers
+	receipts := getImportedTestReceipts(result.Group.ID)
+	expectedAmounts := [][]int64{{20, 15, 5}, {10, 5, 5}}
+	for i, expected := range expectedAmounts {
+		receipt := receipts[i]
+		if !receipt.Amount.Equal(decimal.NewFromInt(expected[0])) ||
+			len(receipt.ReceiptItems) != 2 ||
+			!receipt.ReceiptItems[0].Amount.Equal(decimal.NewFromInt(expected[1])) ||
+			!receipt.ReceiptItems[1].Amount.Equal(decimal.NewFromInt(expected[2])) {
+			utils.PrintTestError(t, receipt, expected)
+		}
+	}
+}
+
+func TestShouldImportTricountCsv(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	csv := "Title,Amount,Currency,Date & time,Transaction type,Paid by,Impacted to Alice,Impacted to Bob\n" +
+		"Groceries,-40.00,EUR,2024-02-01 10:00:00,Normal,Alice,-20.00,-20.00\n" +
+		"Pay back,10.00,EUR,02/02/2024,Money transfer,Bob,10.00,\n" +
+		"Deposit,30.00,EUR,03/02/2024,Income,Alice,15.00,15.00\n"
+
+	command := commands.ImportExpensesCommand{File: []byte(csv), Source: models.TRICOUNT, GroupName: "Flat"}
+	result, err := NewExpenseImportService(nil).ImportExpenses(command, 1, models.ADMIN)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.Receipts != 3 {
+		utils.PrintTestError(t, result.Receipts, 3)
+		return
+	}
+
+	var bob models.User
+	repositories.GetDB().Model(&models.User{}).Where("username = ?", "Bob").First(&bob)
+
+	receipts := getImportedTestReceipts(result.Group.ID)
+	groceries := receipts[0]
+	if !groceries.Amount.Equal(decimal.NewFromInt(40)) || groceries.Status != models.OPEN || *groceries.ReceiptItems[0].ChargedToUserId != bob.ID {
+		utils.PrintTestError(t, groceries, "open groceries of 40 owed by Bob")
+	}
+
+	payBack := receipts[1]
+	if payBack.PaidByUserID != bob.ID || payBack.Status != models.RESOLVED {
+		utils.PrintTestError(t, payBack, "resolved transfer from Bob")
+	}
+
+	// Alice received the deposit, so she owes Bob his part
+	deposit := receipts[2]
+	if deposit.PaidByUserID != bob.ID || len(deposit.ReceiptItems) != 1 || !deposit.ReceiptItems[0].Amount.Equal(decimal.NewFromInt(15)) {
+		utils.PrintTestError(t, deposit, "deposit owed to Bob")
+	}
+}
+
+func TestShouldRejectUnknownExportFormat(t *testing.T) {
+	_, err := parseExternalExport(models.TRICOUNT, []byte("a,b\n1,2\n"))
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	_, err = parseExternalExport(models.SPLITWISE, []byte("a,b\n1,2\n"))
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"fmt"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const expenseReportDateFormat = "2006-01-02"
+
+var expenseReportItemColumns = []float64{pdfMargin, 290, 400, 470}
+
+type expenseReportTotal struct {
+	Name     string
+	Receipts int
+	Amount   decimal.Decimal
+}
+
+// ExpenseReportPdfExporter renders a cover summary and a page per receipt with its items and images
+type ExpenseReportPdfExporter struct {
+	tx *gorm.DB
+}
+
+func NewExpenseReportPdfExporter(tx *gorm.DB) ExpenseReportPdfExporter {
+	return ExpenseReportPdfExporter{tx: tx}
+}
+
+func (exporter ExpenseReportPdfExporter) Export(receipts []models.Receipt) ([]byte, error) {
+	sortedReceipts := make([]models.Receipt, len(receipts))
+	copy(sortedReceipts, receipts)
+	sort.SliceStable(sortedReceipts, func(i, j int) bool {
+		if sortedReceipts[i].Date.Equal(sortedReceipts[j].Date) {
+			return sortedReceipts[i].ID < sortedReceipts[j].ID
+		}
+		return sortedReceipts[i].Date.Before(sortedReceipts[j].Date)
+	})
+
+	document := newPdfDocument()
+	exporter.writeCover(document, sortedReceipts)
+
+	for _, receipt := range sortedReceipts {
+		document.AddPage()
+		exporter.writeReceipt(document, receipt)
+	}
+
+	return document.Bytes()
+}
+
+func (exporter ExpenseReportPdfExporter) GetFileName() string {
+	return constants.ExpenseReportFileName
+}
+
+func (exporter ExpenseReportPdfExporter) GetContentType() string {
+	return constants.ApplicationPdf
+}
+
+func (exporter ExpenseReportPdfExporter) writeCover(document *pdfDocument, receipts []models.Receipt) {
+	total := decimal.Zero
+	for _, receipt := range receipts {
+		total = total.Add(receipt.Amount)
+	}
+
+	document.WriteLine("Expense Report", 22, true)
+	document.MoveDown(4)
+	document.WriteLine("Generated "+time.Now().Format(expenseReportDateFormat), 10, false)
+	document.MoveDown(10)
+
+	dateRange := "-"
+	if len(receipts) > 0 {
+		dateRange = fmt.Sprintf(
+			"%s to %s",
+			receipts[0].Date.Format(expenseReportDateFormat),
+			receipts[len(receipts)-1].Date.Format(expenseReportDateFormat),
+		)
+	}
+
+	document.WriteColumns([]string{"Receipts", fmt.Sprint(len(receipts))}, []float64{pdfMargin, 200}, 11, false)
+	document.WriteColumns([]string{"Date range", dateRange}, []float64{pdfMargin, 200}, 11, false)
+	document.WriteColumns([]string{"Total", formatExpenseReportAmount(total)}, []float64{pdfMargin, 200}, 11, true)
+
+	document.MoveDown(16)
+	document.WriteLine("Totals by category", 14, true)
+	writeExpenseReportTotals(document, "Category", getExpenseReportCategoryTotals(receipts))
+	document.WriteLine("Receipts with several categories count toward each of them.", 8, false)
+
+	document.MoveDown(16)
+	document.WriteLine("Totals by paid by", 14, true)
+	writeExpenseReportTotals(document, "Paid by", getExpenseReportPaidByTotals(receipts))
+}
+
+func (exporter ExpenseReportPdfExporter) writeReceipt(document *pdfDocument, receipt models.Receipt) {
+	document.WriteLine(receipt.Name, 16, true)
+	document.MoveDown(6)
+
+	fields := [][]string{
+		{"Date", receipt.Date.Format(expenseReportDateFormat)},
+		{"Amount", formatExpenseReportAmount(receipt.Amount)},
+		{"Paid by", getLedgerUserName(receipt.PaidByUser, receipt.PaidByUserID)},
+		{"Status", string(receipt.Status)},
+		{"Categories", joinExpenseReportNames(getCategoryNames(receipt.Categories))},
+		{"Tags", joinExpenseReportNames(getTagNames(receipt.Tags))},
+	}
+	if receipt.ResolvedDate != nil {
+		fields = append(fields, []string{"Resolved", receipt.ResolvedDate.Format(expenseReportDateFormat)})
+	}
+
+	for _, field := range fields {
+		document.WriteColumns(field, []float64{pdfMargin, 150}, 10, false)
+	}
+
+	if len(receipt.ReceiptItems) > 0 {
+		document.MoveDown(12)
+		document.WriteLine("Items", 12, true)
+		document.WriteColumns([]string{"Name", "Charged to", "Status", "Amount"}, expenseReportItemColumns, 9, true)
+		document.Rule()
+
+		for _, item := range receipt.ReceiptItems {
+			chargedTo := "-"
+			if item.ChargedToUserId != nil {
+				chargedTo = getLedgerUserName(item.ChargedToUser, *item.ChargedToUserId)
+			}
+
+			document.WriteColumns(
+				[]string{item.Name, chargedTo, string(item.Status), formatExpenseReportAmount(item.Amount)},
+				expenseReportItemColumns,
+				9,
+				false,
+			)
+		}
+	}
+
+	receiptImageRepository := repositories.NewReceiptImageRepository(exporter.tx)
+	for _, fileData := range receipt.ImageFiles {
+		// Small gaps at the bottom of a page aren't worth a shrunk image
+		if document.RemainingHeight() < 200 {
+			document.AddPage()
+		}
+		document.MoveDown(12)
+
+		imageBytes, err := receiptImageRepository.GetReceiptImagePreview(fileData, models.IMAGE_SIZE_MEDIUM)
+		if err == nil {
+			err = document.Image(imageBytes, document.RemainingHeight()-6)
+		}
+
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, fmt.Sprintf("Could not embed image %d in expense report: %s", fileData.ID, err.Error()))
+			document.WriteLine(fmt.Sprintf("Image %s could not be embedded", fileData.Name), 9, false)
+		}
+	}
+}
+
+func writeExpenseReportTotals(document *pdfDocument, nameHeader string, totals []expenseReportTotal) {
+	columns := []float64{pdfMargin, 330, 420}
+
+	document.WriteColumns([]string{nameHeader, "Receipts", "Amount"}, columns, 10, true)
+	document.Rule()
+	for _, total := range totals {
+		document.WriteColumns(
+			[]string{total.Name, fmt.Sprint(total.Receipts), formatExpenseReportAmount(total.Amount)},
+			columns,
+			10,
+			false,
+		)
+	}
+}
+
+// getExpenseReportCategoryTotals sums receipts per category, largest first
+func getExpenseReportCategoryTotals(receipts []models.Receipt) []expenseReportTotal {
+	totalsByName := make(map[string]*expenseReportTotal)
+	for _, receipt := range receipts {
+		names := getCategoryNames(receipt.Categories)
+		if len(names) == 0 {
+			names = []string{"Uncategorized"}
+		}
+
+		for _, name := range names {
+			addExpenseReportTotal(totalsByName, name, receipt.Amount)
+		}
+	}
+
+	totals := getSortedExpenseReportTotals(totalsByName)
+	sort.SliceStable(totals, func(i, j int) bool {
+		return totals[i].Amount.GreaterThan(totals[j].Amount)
+	})
+
+	return totals
+}
+
+func getExpenseReportPaidByTotals(receipts []models.Receipt) []expenseReportTotal {
+	totalsByName := make(map[string]*expenseReportTotal)
+	for _, receipt := range receipts {
+		addExpenseReportTotal(totalsByName, getLedgerUserName(receipt.PaidByUser, receipt.PaidByUserID), receipt.Amount)
+	}
+
+	return getSortedExpenseReportTotals(totalsByName)
+}
+
+func addExpenseReportTotal(totalsByName map[string]*expenseReportTotal, name string, amount decimal.Decimal) {
+	total, ok := totalsByName[name]
+	if !ok {
+		total = &expenseReportTotal{Name: name, Amount: decimal.Zero}
+		totalsByName[name] = total
+	}
+
+	total.Receipts++
+	total.Amount = total.Amount.Add(amount)
+}
+
+func getSortedExpenseReportTotals(totalsByName map[string]*expenseReportTotal) []expenseReportTotal {
+	totals := make([]expenseReportTotal, 0, len(totalsByName))
+	for _, total := range totalsByName {
+		totals = append(totals, *total)
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Name < totals[j].Name
+	})
+
+	return totals
+}
+
+func getCategoryNames(categories []models.Category) []string {
+	names := make([]string, 0, len(categories))
+	for _, category := range categories {
+		names = append(names, category.Name)
+	}
+
+	return names
+}
+
+func getTagNames(tags []models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+
+	return names
+}
+
+func joinExpenseReportNames(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func formatExpenseReportAmount(amount decimal.Decimal) string {
+	return amount.StringFixed(2)
+}
+
+type ExpenseReportService struct {
+	BaseService
+}
+
+func NewExpenseReportService(tx *gorm.DB) ExpenseReportService {
+	service := ExpenseReportService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+// CreateExpenseReport renders a report of receipts, stores it until it expires and notifies the user with a download link.
+// The result is recorded as a system task.
+func (service ExpenseReportService) CreateExpenseReport(
+	receiptIds []string,
+	groupId *uint,
+	ranByUserId uint,
+	asynqTaskId string,
+) (models.SystemTask, error) {
+	systemTaskService := NewSystemTaskService(service.TX)
+	systemTaskCommand := commands.UpsertSystemTaskCommand{
+		Type:                 models.EXPENSE_REPORT,
+		AssociatedEntityType: models.NOOP_ENTITY_TYPE,
+		StartedAt:            time.Now(),
+		RanByUserId:          &ranByUserId,
+		GroupId:              groupId,
+		AsynqTaskId:          asynqTaskId,
+	}
+
+	expenseReport, err := service.createExpenseReport(receiptIds, groupId, ranByUserId)
+	if err != nil {
+		systemTask, taskErr := systemTaskService.CreateSystemTaskFromError(systemTaskCommand, err)
+		if taskErr != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, taskErr.Error())
+		}
+		return systemTask, err
+	}
+
+	systemTaskCommand.AssociatedEntityId = expenseReport.ID
+	systemTaskCommand.ResultDescription = fmt.Sprintf("Created expense report with %d receipts", expenseReport.ReceiptCount)
+	return systemTaskService.CreateSystemTaskFromError(systemTaskCommand, nil)
+}
+
+func (service ExpenseReportService) createExpenseReport(receiptIds []string, groupId *uint, ranByUserId uint) (models.ExpenseReport, error) {
+	receiptRepository := repositories.NewReceiptRepository(service.TX)
+	receipts, err := receiptRepository.GetReceiptsByIds(receiptIds, GetExportReceiptAssociations())
+	if err != nil {
+		return models.ExpenseReport{}, err
+	}
+
+	pdfBytes, err := NewExpenseReportPdfExporter(service.TX).Export(receipts)
+	if err != nil {
+		return models.ExpenseReport{}, err
+	}
+
+	fileRepository := repositories.NewFileRepository(service.TX)
+	var expenseReport models.ExpenseReport
+
+	err = service.GetDB().Transaction(func(tx *gorm.DB) error {
+		expenseReportRepository := repositories.NewExpenseReportRepository(tx)
+		var txErr error
+
+		expenseReport, txErr = expenseReportRepository.CreateExpenseReport(models.ExpenseReport{
+			UserId:       ranByUserId,
+			GroupId:      groupId,
+			FileName:     constants.ExpenseReportFileName,
+			ReceiptCount: len(receipts),
+			ExpiresAt:    time.Now().Add(constants.ExpenseReportRetention),
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		notificationBody := fmt.Sprintf(
+			"Your expense report with %d receipts is ready to download for %d days. %s",
+			len(receipts),
+			int(constants.ExpenseReportRetention.Hours()/24),
+			repositories.BuildParamaterisedString("expenseReportId", expenseReport.ID, "", "link"),
+		)
+		txErr = repositories.NewNotificationRepository(tx).
+			SendNotificationToUsers([]uint{ranByUserId}, "Expense Report Ready", notificationBody, models.NOTIFICATION_TYPE_NORMAL, []interface{}{})
+		if txErr != nil {
+			return txErr
+		}
+
+		// Written last so a failed write rolls back the report and its notification
+		return fileRepository.WriteFile(expenseReportRepository.BuildExpenseReportKey(expenseReport), pdfBytes)
+	})
+	if err != nil {
+		return models.ExpenseReport{}, err
+	}
+
+	return expenseReport, nil
+}
+
+// GetExpenseReportPdf returns the pdf of a report that hasn't expired
+func (service ExpenseReportService) GetExpenseReportPdf(expenseReport models.ExpenseReport) ([]byte, error) {
+	if expenseReport.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("expense report has expired")
+	}
+
+	expenseReportRepository := repositories.NewExpenseReportRepository(service.TX)
+	return repositories.NewFileRepository(service.TX).ReadFile(expenseReportRepository.BuildExpenseReportKey(expenseReport))
+}
+
+func (service ExpenseReportService) DeleteExpiredExpenseReports() (int64, error) {
+	expenseReportRepository := repositories.NewExpenseReportRepository(service.TX)
+	fileRepository := repositories.NewFileRepository(service.TX)
+
+	expenseReports, err := expenseReportRepository.GetExpenseReportsExpiredBefore(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var deletedCount int64
+	for _, expenseReport := range expenseReports {
+		err = fileRepository.DeleteFile(expenseReportRepository.BuildExpenseReportKey(expenseReport))
+		if err != nil {
+			return deletedCount, err
+		}
+
+		err = expenseReportRepository.DeleteExpenseReport(expenseReport.ID)
+		if err != nil {
+			return deletedCount, err
+		}
+		deletedCount++
+	}
+
+	return deletedCount, nil
+}
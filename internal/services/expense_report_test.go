@@ -0,0 +1,203 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func getExpenseReportTestPng() []byte {
+	testImage := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	testImage.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var buffer bytes.Buffer
+	png.Encode(&buffer, testImage)
+	return buffer.Bytes()
+}
+
+func setUpExpenseReportTest(t *testing.T) []string {
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+
+	category := models.Category{Name: "Travel"}
+	db.Create(&category)
+
+	receiptIds := make([]string, 0)
+	for i := 1; i <= 2; i++ {
+		receipt := models.Receipt{
+			Name:         fmt.Sprintf("Taxi (%d)", i),
+			Amount:       decimal.NewFromFloat(12.5),
+			Date:         time.Date(2025, 3, i, 0, 0, 0, 0, time.UTC),
+			PaidByUserID: 1,
+			Status:       models.OPEN,
+			GroupId:      1,
+			Categories:   []models.Category{category},
+			ReceiptItems: []models.Item{{Name: "Ride", Amount: decimal.NewFromFloat(12.5), Status: models.ITEM_OPEN}},
+		}
+		db.Create(&receipt)
+		receiptIds = append(receiptIds, utils.UintToString(receipt.ID))
+	}
+
+	// The medium preview is what gets embedded, storing it keeps image conversion out of the test
+	fileData := models.FileData{Name: "taxi.png", FileType: "image/png", ReceiptId: 1}
+	db.Create(&fileData)
+	fileRepository := repositories.NewFileRepository(nil)
+	fileRepository.WriteFile(fileRepository.BuildFileDataPreviewKey(fileData, models.IMAGE_SIZE_MEDIUM), getExpenseReportTestPng())
+
+	return receiptIds
+}
+
+func tearDownExpenseReportTest() {
+	repositories.TruncateTestDb()
+	storage.SetStorage(nil)
+}
+
+// validatePdfXref checks that every object offset in the cross reference table points at its object
+func validatePdfXref(t *testing.T, pdfBytes []byte) {
+	startXref := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdfBytes)
+	if startXref == nil {
+		utils.PrintTestError(t, "no startxref", "startxref")
+		return
+	}
+
+	xrefOffset, _ := strconv.Atoi(string(startXref[1]))
+	if !bytes.HasPrefix(pdfBytes[xrefOffset:], []byte("xref\n")) {
+		utils.PrintTestError(t, string(pdfBytes[xrefOffset:xrefOffset+10]), "xref")
+		return
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdfBytes[xrefOffset:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		expected := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(pdfBytes[offset:], []byte(expected)) {
+			utils.PrintTestError(t, string(pdfBytes[offset:offset+10]), expected)
+		}
+	}
+}
+
+func TestShouldRenderExpenseReportPdf(t *testing.T) {
+	defer tearDownExpenseReportTest()
+	receiptIds := setUpExpenseReportTest(t)
+
+	receipts, err := repositories.NewReceiptRepository(nil).GetReceiptsByIds(receiptIds, GetExportReceiptAssociations())
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	pdfBytes, err := NewExpenseReportPdfExporter(nil).Export(receipts)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if !bytes.HasPrefix(pdfBytes, []byte("%PDF-1.4")) {
+		utils.PrintTestError(t, string(pdfBytes[:8]), "%PDF-1.4")
+	}
+
+	// A cover and a page per receipt, with the image of the first receipt
+	if !bytes.Contains(pdfBytes, []byte("/Count 3")) || bytes.Count(pdfBytes, []byte("/Subtype /Image")) != 1 {
+		utils.PrintTestError(t, string(pdfBytes), "3 pages and 1 image")
+	}
+
+	validatePdfXref(t, pdfBytes)
+}
+
+func TestShouldSummarizeExpenseReportTotals(t *testing.T) {
+	receipts := []models.Receipt{
+		{Amount: decimal.NewFromInt(10), PaidByUserID: 1, PaidByUser: models.User{DisplayName: "Ann"}, Categories: []models.Category{{Name: "Food"}, {Name: "Travel"}}},
+		{Amount: decimal.NewFromInt(5), PaidByUserID: 2, PaidByUser: models.User{DisplayName: "Bob"}, Categories: []models.Category{{Name: "Travel"}}},
+		{Amount: decimal.NewFromInt(1), PaidByUserID: 1, PaidByUser: models.User{DisplayName: "Ann"}},
+	}
+
+	categoryTotals := getExpenseReportCategoryTotals(receipts)
+	expectedCategoryTotals := []expenseReportTotal{
+		{Name: "Travel", Receipts: 2, Amount: decimal.NewFromInt(15)},
+		{Name: "Food", Receipts: 1, Amount: decimal.NewFromInt(10)},
+		{Name: "Uncategorized", Receipts: 1, Amount: decimal.NewFromInt(1)},
+	}
+	for i, expected := range expectedCategoryTotals {
+		if categoryTotals[i].Name != expected.Name || categoryTotals[i].Receipts != expected.Receipts || !categoryTotals[i].Amount.Equal(expected.Amount) {
+			utils.PrintTestError(t, categoryTotals[i], expected)
+		}
+	}
+
+	paidByTotals := getExpenseReportPaidByTotals(receipts)
+	if len(paidByTotals) != 2 || paidByTotals[0].Name != "Ann" || !paidByTotals[0].Amount.Equal(decimal.NewFromInt(11)) {
+		utils.PrintTestError(t, paidByTotals, "Ann paid 11")
+	}
+}
+
+func TestShouldCreateExpenseReportAndNotifyUser(t *testing.T) {
+	defer tearDownExpenseReportTest()
+	receiptIds := setUpExpenseReportTest(t)
+	db := repositories.GetDB()
+	groupId := uint(1)
+
+	service := NewExpenseReportService(nil)
+	systemTask, err := service.CreateExpenseReport(receiptIds, &groupId, 1, "")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if systemTask.Type != models.EXPENSE_REPORT || systemTask.Status != models.SYSTEM_TASK_SUCCEEDED {
+		utils.PrintTestError(t, systemTask, "succeeded expense report task")
+	}
+
+	expenseReport, err := repositories.NewExpenseReportRepository(nil).GetExpenseReportById(utils.UintToString(systemTask.AssociatedEntityId))
+	if err != nil || expenseReport.UserId != 1 || expenseReport.ReceiptCount != 2 {
+		utils.PrintTestError(t, expenseReport, "report of 2 receipts for user 1")
+		return
+	}
+
+	var notification models.Notification
+	db.Model(&models.Notification{}).Where("user_id = ?", 1).First(&notification)
+	expectedLink := repositories.BuildParamaterisedString("expenseReportId", expenseReport.ID, "", "link")
+	if !bytes.Contains([]byte(notification.Body), []byte(expectedLink)) {
+		utils.PrintTestError(t, notification.Body, expectedLink)
+	}
+
+	pdfBytes, err := service.GetExpenseReportPdf(expenseReport)
+	if err != nil || !bytes.HasPrefix(pdfBytes, []byte("%PDF")) {
+		utils.PrintTestError(t, err, "stored pdf")
+	}
+
+	// Expired reports are removed with their file
+	db.Model(&models.ExpenseReport{}).Where("id = ?", expenseReport.ID).Update("expires_at", time.Now().Add(-time.Hour))
+	deletedCount, err := service.DeleteExpiredExpenseReports()
+	if err != nil || deletedCount != 1 {
+		utils.PrintTestError(t, deletedCount, 1)
+	}
+
+	exists, _ := repositories.NewFileRepository(nil).FileExists(repositories.NewExpenseReportRepository(nil).BuildExpenseReportKey(expenseReport))
+	if exists {
+		utils.PrintTestError(t, exists, false)
+	}
+}
+
+func TestShouldWrapAndEscapePdfText(t *testing.T) {
+	lines := wrapPdfText("one two three four", 10, false, 45)
+	if len(lines) != 2 || lines[0] != "one two" || lines[1] != "three four" {
+		utils.PrintTestError(t, lines, []string{"one two", "three four"})
+	}
+
+	escaped := escapePdfText("(a\\b) €5 ✓")
+	if escaped != "\\(a\\\\b\\) \x805 ?" {
+		utils.PrintTestError(t, escaped, "escaped text")
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	config "receipt-wrangler/api/internal/env"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"time"
+)
+
+// ExportDestination is where scheduled exports deliver their files
+type ExportDestination interface {
+	Write(fileName string, fileBytes []byte) error
+}
+
+func GetExportDestination(scheduledExport models.ScheduledExport) (ExportDestination, error) {
+	switch scheduledExport.DestinationType {
+	case models.LOCAL_DIRECTORY:
+		exportDirectory := config.GetExportDirectory()
+		if len(exportDirectory) == 0 {
+			return nil, errors.New("EXPORT_DIRECTORY is not set")
+		}
+
+		return LocalDirectoryExportDestination{
+			ExportDirectory: exportDirectory,
+			GroupId:         scheduledExport.GroupId,
+			Directory:       scheduledExport.Destination,
+		}, nil
+	case models.WEBDAV:
+		// The server uploads to any url it is given, so only exports saved by an admin may reach the network
+		user, err := repositories.NewUserRepository(nil).GetUserById(scheduledExport.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if user.UserRole != models.ADMIN {
+			return nil, errors.New("webdav exports have to be saved by an admin")
+		}
+
+		password := ""
+		if len(scheduledExport.WebDavPassword) > 0 {
+			decryptedPassword, err := utils.DecryptB64EncodedData(config.GetEncryptionKey(), scheduledExport.WebDavPassword)
+			if err != nil {
+				return nil, err
+			}
+			password = decryptedPassword
+		}
+
+		return WebDavExportDestination{
+			Url:      scheduledExport.Destination,
+			Username: scheduledExport.WebDavUsername,
+			Password: password,
+			Client:   &http.Client{Timeout: 5 * time.Minute},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid destination type %s", scheduledExport.DestinationType)
+}
+
+// LocalDirectoryExportDestination writes into Directory below the group's own directory of the export directory
+type LocalDirectoryExportDestination struct {
+	ExportDirectory string
+	GroupId         uint
+	Directory       string
+}
+
+func (destination LocalDirectoryExportDestination) Write(fileName string, fileBytes []byte) error {
+	groupDirectory := filepath.Join(destination.ExportDirectory, utils.UintToString(destination.GroupId))
+	directory := filepath.Join(groupDirectory, destination.Directory)
+
+	relativePath, err := filepath.Rel(groupDirectory, directory)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, "../") {
+		return errors.New("destination is outside of the group's export directory")
+	}
+
+	err = os.MkdirAll(directory, 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(directory, fileName), fileBytes, 0644)
+}
+
+// WebDavExportDestination uploads files into a WebDAV collection with a PUT, the collection has to exist
+type WebDavExportDestination struct {
+	Url      string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+func (destination WebDavExportDestination) Write(fileName string, fileBytes []byte) error {
+	fileUrl := strings.TrimSuffix(destination.Url, "/") + "/" + url.PathEscape(fileName)
+
+	request, err := http.NewRequest(http.MethodPut, fileUrl, bytes.NewReader(fileBytes))
+	if err != nil {
+		return err
+	}
+
+	if len(destination.Username) > 0 {
+		request.SetBasicAuth(destination.Username, destination.Password)
+	}
+
+	response, err := destination.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK &&
+		response.StatusCode != http.StatusCreated &&
+		response.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("webdav upload failed with status %s", response.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/env"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GroupInviteService struct {
+	BaseService
+}
+
+func NewGroupInviteService(tx *gorm.DB) GroupInviteService {
+	service := GroupInviteService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+func (service GroupInviteService) CreateGroupInvite(
+	groupId string,
+	userId uint,
+	command commands.CreateGroupInviteCommand,
+) (structs.GroupInviteResult, error) {
+	groupRepository := repositories.NewGroupRepository(service.TX)
+	groupInviteRepository := repositories.NewGroupInviteRepository(service.TX)
+
+	group, err := groupRepository.GetGroupById(groupId, false, false, false)
+	if err != nil {
+		return structs.GroupInviteResult{}, err
+	}
+
+	if group.IsAllGroup {
+		return structs.GroupInviteResult{}, errors.New("cannot invite users to the all group")
+	}
+
+	if len(command.Email) > 0 && !env.IsSmtpConfigured() {
+		return structs.GroupInviteResult{}, errors.New("outgoing email is not configured")
+	}
+
+	token, err := utils.GetRandomString(32)
+	if err != nil {
+		return structs.GroupInviteResult{}, err
+	}
+
+	invite, err := groupInviteRepository.CreateGroupInvite(models.GroupInvite{
+		BaseModel: models.BaseModel{
+			CreatedBy: &userId,
+		},
+		GroupId:   group.ID,
+		GroupRole: command.GroupRole,
+		TokenHash: utils.Sha256Hash([]byte(token)),
+		Email:     command.Email,
+		ExpiresAt: time.Now().Add(time.Duration(command.ExpiresInHours) * time.Hour),
+		MaxUses:   command.MaxUses,
+	})
+	if err != nil {
+		return structs.GroupInviteResult{}, err
+	}
+
+	result := structs.GroupInviteResult{
+		Invite: invite,
+		Token:  token,
+		Url:    service.BuildInviteUrl(token),
+	}
+
+	if len(command.Email) > 0 {
+		err = service.sendInviteEmail(group, result)
+		if err != nil {
+			return structs.GroupInviteResult{}, err
+		}
+	}
+
+	return result, nil
+}
+
+func (service GroupInviteService) BuildInviteUrl(token string) string {
+	return env.GetPublicUrl() + "/invite/" + token
+}
+
+func (service GroupInviteService) GetPendingGroupInvites(groupId string) ([]models.GroupInvite, error) {
+	groupInviteRepository := repositories.NewGroupInviteRepository(service.TX)
+	return groupInviteRepository.GetPendingGroupInvitesByGroupId(groupId)
+}
+
+func (service GroupInviteService) RevokeGroupInvite(groupId string, inviteId string) error {
+	groupInviteRepository := repositories.NewGroupInviteRepository(service.TX)
+
+	invite, err := groupInviteRepository.GetGroupInviteById(inviteId)
+	if err != nil {
+		return err
+	}
+
+	if utils.UintToString(invite.GroupId) != groupId {
+		return errors.New("invite does not belong to group")
+	}
+
+	return groupInviteRepository.RevokeGroupInvite(inviteId)
+}
+
+func (service GroupInviteService) GetPendingInviteByToken(token string) (models.GroupInvite, error) {
+	groupInviteRepository := repositories.NewGroupInviteRepository(service.TX)
+
+	invite, err := groupInviteRepository.GetGroupInviteByTokenHash(utils.Sha256Hash([]byte(token)))
+	if err != nil {
+		return models.GroupInvite{}, err
+	}
+
+	if !invite.IsPending() {
+		return models.GroupInvite{}, errors.New("invite is no longer valid")
+	}
+
+	return invite, nil
+}
+
+func (service GroupInviteService) GetGroupInviteInfo(token string) (structs.GroupInviteInfo, error) {
+	groupRepository := repositories.NewGroupRepository(service.TX)
+
+	invite, err := service.GetPendingInviteByToken(token)
+	if err != nil {
+		return structs.GroupInviteInfo{}, err
+	}
+
+	group, err := groupRepository.GetGroupById(utils.UintToString(invite.GroupId), false, false, false)
+	if err != nil {
+		return structs.GroupInviteInfo{}, err
+	}
+
+	return structs.GroupInviteInfo{
+		GroupName: group.Name,
+		GroupRole: invite.GroupRole,
+		ExpiresAt: invite.ExpiresAt,
+	}, nil
+}
+
+// Adds the user to the invite's group. Accepting an invite for a group the user is already in is an error.
+func (service GroupInviteService) AcceptGroupInvite(token string, userId uint) (models.GroupMember, error) {
+	invite, err := service.GetPendingInviteByToken(token)
+	if err != nil {
+		return models.GroupMember{}, err
+	}
+
+	groupMember := models.GroupMember{
+		UserID:    userId,
+		GroupID:   invite.GroupId,
+		GroupRole: invite.GroupRole,
+	}
+
+	err = service.GetDB().Transaction(func(tx *gorm.DB) error {
+		var memberCount int64
+		txErr := tx.Model(&models.GroupMember{}).
+			Where("user_id = ? AND group_id = ?", userId, invite.GroupId).
+			Count(&memberCount).Error
+		if txErr != nil {
+			return txErr
+		}
+
+		if memberCount > 0 {
+			return errors.New("user is already a member of this group")
+		}
+
+		groupInviteRepository := repositories.NewGroupInviteRepository(tx)
+		txErr = groupInviteRepository.UseGroupInvite(invite.ID, userId)
+		if txErr != nil {
+			return txErr
+		}
+
+		return tx.Model(&models.GroupMember{}).Create(&groupMember).Error
+	})
+	if err != nil {
+		return models.GroupMember{}, err
+	}
+
+	return groupMember, nil
+}
+
+// Signs up a new user and adds them to the invite's group
+func (service GroupInviteService) SignUpWithGroupInvite(token string, command commands.SignUpCommand) (models.User, error) {
+	var user models.User
+
+	_, err := service.GetPendingInviteByToken(token)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	err = service.GetDB().Transaction(func(tx *gorm.DB) error {
+		userRepository := repositories.NewUserRepository(tx)
+
+		command.IsDummyUser = false
+
+		createdUser, txErr := userRepository.CreateUser(command)
+		if txErr != nil {
+			return txErr
+		}
+
+		groupInviteService := NewGroupInviteService(tx)
+		_, txErr = groupInviteService.AcceptGroupInvite(token, createdUser.ID)
+		if txErr != nil {
+			return txErr
+		}
+
+		user = createdUser
+		return nil
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+func (service GroupInviteService) sendInviteEmail(group models.Group, result structs.GroupInviteResult) error {
+	emailService := NewEmailService()
+
+	subject := fmt.Sprintf("You have been invited to %s", group.Name)
+	body := fmt.Sprintf(
+		"You have been invited to join the group '%s' on Receipt Wrangler as %s.\n\nAccept the invite here: %s\n\nThis invite expires on %s.",
+		group.Name,
+		result.Invite.GroupRole,
+		result.Url,
+		result.Invite.ExpiresAt.Format(time.RFC1123),
+	)
+
+	return emailService.SendEmail([]string{result.Invite.Email}, subject, body)
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"net/smtp"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createTestGroupInvite(t *testing.T, maxUses int) string {
+	groupInviteService := NewGroupInviteService(nil)
+	result, err := groupInviteService.CreateGroupInvite("1", 1, commands.CreateGroupInviteCommand{
+		GroupRole:      models.EDITOR,
+		ExpiresInHours: 24,
+		MaxUses:        maxUses,
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	return result.Token
+}
+
+func TestGroupInviteService_CreateGroupInviteStoresTokenHash(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	t.Setenv("PUBLIC_URL", "https://receipts.example.com/")
+
+	groupInviteService := NewGroupInviteService(nil)
+	result, err := groupInviteService.CreateGroupInvite("1", 1, commands.CreateGroupInviteCommand{
+		GroupRole:      models.VIEWER,
+		ExpiresInHours: 24,
+		MaxUses:        1,
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if result.Invite.TokenHash == result.Token || result.Invite.TokenHash != utils.Sha256Hash([]byte(result.Token)) {
+		utils.PrintTestError(t, result.Invite.TokenHash, "sha256 hash of the token")
+	}
+
+	expectedUrl := "https://receipts.example.com/invite/" + result.Token
+	if result.Url != expectedUrl {
+		utils.PrintTestError(t, result.Url, expectedUrl)
+	}
+}
+
+func TestGroupInviteService_AcceptGroupInvite(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	token := createTestGroupInvite(t, 1)
+	groupInviteService := NewGroupInviteService(nil)
+
+	groupMember, err := groupInviteService.AcceptGroupInvite(token, 4)
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if groupMember.GroupID != 1 || groupMember.GroupRole != models.EDITOR {
+		utils.PrintTestError(t, groupMember, "editor of group 1")
+	}
+
+	// Single use invites are used up
+	_, err = groupInviteService.GetPendingInviteByToken(token)
+	if err == nil {
+		utils.PrintTestError(t, err, "invite is no longer valid")
+	}
+
+	pendingInvites, _ := groupInviteService.GetPendingGroupInvites("1")
+	if len(pendingInvites) != 0 {
+		utils.PrintTestError(t, len(pendingInvites), 0)
+	}
+}
+
+func TestGroupInviteService_AcceptGroupInviteRejectsExistingMember(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	token := createTestGroupInvite(t, 5)
+	groupInviteService := NewGroupInviteService(nil)
+
+	_, err := groupInviteService.AcceptGroupInvite(token, 2)
+	if err == nil || err.Error() != "user is already a member of this group" {
+		utils.PrintTestError(t, err, "user is already a member of this group")
+	}
+
+	// A rejected accept does not use up the invite
+	invite, _ := groupInviteService.GetPendingInviteByToken(token)
+	if invite.UseCount != 0 {
+		utils.PrintTestError(t, invite.UseCount, 0)
+	}
+}
+
+func TestGroupInviteService_RevokedAndExpiredInvitesAreRejected(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	groupInviteService := NewGroupInviteService(nil)
+	revokedToken := createTestGroupInvite(t, 1)
+	expiredToken := createTestGroupInvite(t, 1)
+
+	revokedInvite, _ := groupInviteService.GetPendingInviteByToken(revokedToken)
+	err := groupInviteService.RevokeGroupInvite("1", utils.UintToString(revokedInvite.ID))
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	repositories.GetDB().
+		Model(&models.GroupInvite{}).
+		Where("token_hash = ?", utils.Sha256Hash([]byte(expiredToken))).
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	for _, token := range []string{revokedToken, expiredToken} {
+		_, err = groupInviteService.AcceptGroupInvite(token, 4)
+		if err == nil {
+			utils.PrintTestError(t, err, "invite is no longer valid")
+		}
+	}
+}
+
+func TestGroupInviteService_RevokeGroupInviteRejectsOtherGroup(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	groupInviteService := NewGroupInviteService(nil)
+	token := createTestGroupInvite(t, 1)
+	invite, _ := groupInviteService.GetPendingInviteByToken(token)
+
+	err := groupInviteService.RevokeGroupInvite("2", utils.UintToString(invite.ID))
+	if err == nil {
+		utils.PrintTestError(t, err, "invite does not belong to group")
+	}
+}
+
+func TestGroupInviteService_SignUpWithGroupInvite(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	token := createTestGroupInvite(t, 1)
+	groupInviteService := NewGroupInviteService(nil)
+
+	user, err := groupInviteService.SignUpWithGroupInvite(token, commands.SignUpCommand{
+		Username:    "invited",
+		Password:    "password",
+		DisplayName: "Invited User",
+		IsDummyUser: true,
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if user.IsDummyUser {
+		utils.PrintTestError(t, user.IsDummyUser, false)
+	}
+
+	groupMemberRepository := repositories.NewGroupMemberRepository(nil)
+	groupMember, err := groupMemberRepository.GetGroupMemberByUserIdAndGroupId(utils.UintToString(user.ID), "1")
+	if err != nil || groupMember.GroupRole != models.EDITOR {
+		utils.PrintTestError(t, groupMember.GroupRole, models.EDITOR)
+	}
+}
+
+func TestGroupInviteService_CreateGroupInviteSendsEmail(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "587")
+	t.Setenv("SMTP_FROM", "wrangler@example.com")
+
+	var sentTo []string
+	var sentMessage string
+	originalSendMail := sendMail
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo = to
+		sentMessage = string(msg)
+		return nil
+	}
+	defer func() { sendMail = originalSendMail }()
+
+	groupInviteService := NewGroupInviteService(nil)
+	result, err := groupInviteService.CreateGroupInvite("1", 1, commands.CreateGroupInviteCommand{
+		GroupRole:      models.VIEWER,
+		ExpiresInHours: 24,
+		MaxUses:        1,
+		Email:          "friend@example.com",
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, "no error")
+	}
+
+	if len(sentTo) != 1 || sentTo[0] != "friend@example.com" {
+		utils.PrintTestError(t, sentTo, "friend@example.com")
+	}
+
+	if !strings.Contains(sentMessage, result.Url) {
+		utils.PrintTestError(t, sentMessage, "message containing the invite url")
+	}
+}
+
+func TestEmailService_SendEmailEncodesHeaders(t *testing.T) {
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "587")
+	t.Setenv("SMTP_FROM", "wrangler@example.com")
+
+	var sentMessage string
+	originalSendMail := sendMail
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentMessage = string(msg)
+		return nil
+	}
+	defer func() { sendMail = originalSendMail }()
+
+	emailService := NewEmailService()
+	err := emailService.SendEmail([]string{"friend@example.com"}, "You have been invited to Trip\r\nBcc: victim@example.com", "body")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	headers := strings.Split(sentMessage, "\r\n\r\n")[0]
+	if strings.Contains(headers, "\r\nBcc:") {
+		utils.PrintTestError(t, headers, "headers without an injected Bcc header")
+	}
+
+	err = emailService.SendEmail([]string{"friend@example.com\r\nBcc: victim@example.com"}, "Subject", "body")
+	if err == nil {
+		utils.PrintTestError(t, err, "invalid email recipient")
+	}
+}
@@ -4,11 +4,10 @@ import (
 	"errors"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
-	"os"
-	"receipt-wrangler/api/internal/logging"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/utils"
+	"time"
 )
 
 type GroupService struct {
@@ -113,6 +112,47 @@ func (service GroupService) DeleteGroup(groupId string, allowAllGroupDelete bool
 			}
 		}
 
+		// Delete group invites
+		groupInviteRepository := repositories.NewGroupInviteRepository(tx)
+		txErr = groupInviteRepository.DeleteGroupInvitesByGroupId(groupId)
+		if txErr != nil {
+			return txErr
+		}
+
+		// Delete external import records, so the group can be imported again
+		txErr = tx.Where("group_id = ?", groupId).Delete(&models.ExternalImportRecord{}).Error
+		if txErr != nil {
+			return txErr
+		}
+
+		// Delete ledger export settings
+		txErr = repositories.NewGroupLedgerSettingsRepository(tx).DeleteGroupLedgerSettingsByGroupId(group.ID)
+		if txErr != nil {
+			return txErr
+		}
+
+		// Delete scheduled exports, their scheduler entries are removed when they next run
+		txErr = repositories.NewScheduledExportRepository(tx).DeleteScheduledExportsByGroupId(group.ID)
+		if txErr != nil {
+			return txErr
+		}
+
+		// Delete group scoped categories, tags and custom fields
+		txErr = repositories.NewCategoryRepository(tx).DeleteCategoriesByGroupId(group.ID)
+		if txErr != nil {
+			return txErr
+		}
+
+		txErr = repositories.NewTagsRepository(tx).DeleteTagsByGroupId(group.ID)
+		if txErr != nil {
+			return txErr
+		}
+
+		txErr = repositories.NewCustomFieldRepository(tx).DeleteCustomFieldsByGroupId(group.ID)
+		if txErr != nil {
+			return txErr
+		}
+
 		// Delete group members
 		txErr = tx.Where("group_id = ?", groupId).Delete(&models.GroupMember{}).Error
 		if txErr != nil {
@@ -144,17 +184,6 @@ func (service GroupService) DeleteGroup(groupId string, allowAllGroupDelete bool
 			return txErr
 		}
 
-		// Remove group's directory
-		groupPath, txErr := utils.BuildGroupPathString(utils.UintToString(group.ID), group.Name)
-		if txErr != nil {
-			return txErr
-		}
-
-		txErr = os.Remove(groupPath)
-		if txErr != nil {
-			logging.LogStd(logging.LOG_LEVEL_INFO, txErr.Error())
-		}
-
 		return nil
 	})
 	if err != nil {
@@ -180,3 +209,61 @@ func (service GroupService) ValidateGroupRole(role models.GroupRole, groupId str
 
 	return nil
 }
+
+func (service GroupService) SetGroupStatus(groupId string, status models.GroupStatus) (models.Group, error) {
+	groupRepository := repositories.NewGroupRepository(service.TX)
+
+	uintGroupId, err := utils.StringToUint(groupId)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	isAllGroup, err := groupRepository.IsAllGroup(uintGroupId)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	if isAllGroup {
+		return models.Group{}, errors.New("cannot change status of all group")
+	}
+
+	err = groupRepository.UpdateGroupStatus(groupId, status)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	return groupRepository.GetGroupById(groupId, false, false, false)
+}
+
+// ArchiveInactiveGroups archives every active group without receipt activity in the last inactiveMonths months
+func (service GroupService) ArchiveInactiveGroups(inactiveMonths int) ([]uint, error) {
+	groupRepository := repositories.NewGroupRepository(service.TX)
+	cutoff := time.Now().AddDate(0, -inactiveMonths, 0)
+
+	groupIds, err := groupRepository.GetInactiveGroupIds(cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	err = groupRepository.ArchiveGroups(groupIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupIds, nil
+}
+
+func (service GroupService) ValidateGroupsAreActive(groupIds []string) error {
+	groupRepository := repositories.NewGroupRepository(service.TX)
+
+	count, err := groupRepository.CountArchivedGroups(groupIds)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return errors.New("group is archived")
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"fmt"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// ledgerTransaction is a receipt as double entry postings, the payer's credit is the last posting
+type ledgerTransaction struct {
+	Receipt  models.Receipt
+	Payer    string
+	Currency string
+	Postings []ledgerPosting
+}
+
+type ledgerPosting struct {
+	Account string
+	User    string
+	Amount  decimal.Decimal
+}
+
+// ledgerBuilder turns receipts into transactions with the ledger settings of their groups.
+// Every item is posted to its category's account for the user it is charged to, what is left of the receipt is the payer's.
+type ledgerBuilder struct {
+	settingsByGroupId map[uint]models.GroupLedgerSettings
+}
+
+func newLedgerBuilder(settingsByGroupId map[uint]models.GroupLedgerSettings) ledgerBuilder {
+	return ledgerBuilder{settingsByGroupId: settingsByGroupId}
+}
+
+// buildTransactions returns the transactions ordered by receipt date
+func (builder ledgerBuilder) buildTransactions(receipts []models.Receipt) []ledgerTransaction {
+	sortedReceipts := make([]models.Receipt, len(receipts))
+	copy(sortedReceipts, receipts)
+	sort.SliceStable(sortedReceipts, func(i, j int) bool {
+		if sortedReceipts[i].Date.Equal(sortedReceipts[j].Date) {
+			return sortedReceipts[i].ID < sortedReceipts[j].ID
+		}
+		return sortedReceipts[i].Date.Before(sortedReceipts[j].Date)
+	})
+
+	transactions := make([]ledgerTransaction, 0, len(sortedReceipts))
+	for _, receipt := range sortedReceipts {
+		transactions = append(transactions, builder.buildTransaction(receipt))
+	}
+
+	return transactions
+}
+
+func (builder ledgerBuilder) buildTransaction(receipt models.Receipt) ledgerTransaction {
+	settings := builder.getSettings(receipt.GroupId)
+	payer := getLedgerUserName(receipt.PaidByUser, receipt.PaidByUserID)
+	receiptAccount := getLedgerCategoryAccount(settings, receipt.Categories)
+
+	transaction := ledgerTransaction{
+		Receipt:  receipt,
+		Payer:    payer,
+		Currency: settings.Currency,
+		Postings: make([]ledgerPosting, 0),
+	}
+
+	addPosting := func(account string, user string, amount decimal.Decimal) {
+		account = account + ":" + sanitizeLedgerAccountComponent(user)
+		for i, posting := range transaction.Postings {
+			if posting.Account == account {
+				transaction.Postings[i].Amount = posting.Amount.Add(amount)
+				return
+			}
+		}
+		transaction.Postings = append(transaction.Postings, ledgerPosting{Account: account, User: user, Amount: amount})
+	}
+
+	remainingAmount := receipt.Amount
+	for _, item := range receipt.ReceiptItems {
+		user := payer
+		if item.ChargedToUserId != nil {
+			user = getLedgerUserName(item.ChargedToUser, *item.ChargedToUserId)
+		}
+
+		account := receiptAccount
+		if len(item.Categories) > 0 {
+			account = getLedgerCategoryAccount(settings, item.Categories)
+		}
+
+		addPosting(account, user, item.Amount)
+		remainingAmount = remainingAmount.Sub(item.Amount)
+	}
+
+	if !remainingAmount.IsZero() || len(transaction.Postings) == 0 {
+		addPosting(receiptAccount, payer, remainingAmount)
+	}
+
+	transaction.Postings = append(transaction.Postings, ledgerPosting{
+		Account: settings.PaidByAccount + ":" + sanitizeLedgerAccountComponent(payer),
+		User:    payer,
+		Amount:  receipt.Amount.Neg(),
+	})
+
+	return transaction
+}
+
+func (builder ledgerBuilder) getSettings(groupId uint) models.GroupLedgerSettings {
+	settings, ok := builder.settingsByGroupId[groupId]
+	if !ok {
+		return models.GroupLedgerSettings{
+			GroupId:               groupId,
+			Currency:              constants.LedgerDefaultCurrency,
+			PaidByAccount:         constants.LedgerDefaultPaidByAccount,
+			DefaultExpenseAccount: constants.LedgerDefaultExpenseAccount,
+		}
+	}
+
+	return settings
+}
+
+// getLedgerCategoryAccount prefers a mapped category, otherwise the first category is posted to an account named after it
+func getLedgerCategoryAccount(settings models.GroupLedgerSettings, categories []models.Category) string {
+	if len(categories) == 0 {
+		return settings.DefaultExpenseAccount
+	}
+
+	for _, category := range categories {
+		for _, categoryAccount := range settings.CategoryAccounts {
+			if categoryAccount.CategoryId == category.ID {
+				return categoryAccount.Account
+			}
+		}
+	}
+
+	return constants.LedgerExpenseAccountPrefix + ":" + sanitizeLedgerAccountComponent(categories[0].Name)
+}
+
+func getLedgerUserName(user models.User, userId uint) string {
+	if len(user.DisplayName) > 0 {
+		return user.DisplayName
+	}
+
+	if len(user.Username) > 0 {
+		return user.Username
+	}
+
+	return "User " + utils.UintToString(userId)
+}
+
+// sanitizeLedgerAccountComponent makes a name usable as part of an account, like Beancount requires:
+// letters, digits and dashes, starting with an uppercase letter or digit
+func sanitizeLedgerAccountComponent(name string) string {
+	var builder strings.Builder
+	lastWasDash := true
+
+	for _, character := range name {
+		if unicode.IsLetter(character) || unicode.IsDigit(character) {
+			if builder.Len() == 0 {
+				character = unicode.ToUpper(character)
+			}
+			builder.WriteRune(character)
+			lastWasDash = false
+		} else if !lastWasDash {
+			builder.WriteRune('-')
+			lastWasDash = true
+		}
+	}
+
+	component := strings.TrimSuffix(builder.String(), "-")
+	if len(component) == 0 {
+		return "Unknown"
+	}
+
+	return component
+}
+
+// sanitizeLedgerTag keeps the characters Beancount and hledger both allow in tags
+func sanitizeLedgerTag(name string) string {
+	tag := strings.Map(func(character rune) rune {
+		if unicode.IsLetter(character) || unicode.IsDigit(character) || character == '-' || character == '_' {
+			return character
+		}
+		return '-'
+	}, name)
+
+	return strings.Trim(tag, "-")
+}
+
+func formatLedgerAmount(amount decimal.Decimal) string {
+	if amount.Exponent() < -2 {
+		return amount.String()
+	}
+
+	return amount.StringFixed(2)
+}
+
+// getLedgerSplitDescription lists what each user owes, for formats without postings
+func getLedgerSplitDescription(transaction ledgerTransaction) string {
+	shares := make([]string, 0, len(transaction.Postings))
+	for _, posting := range transaction.Postings[:len(transaction.Postings)-1] {
+		shares = append(shares, fmt.Sprintf("%s %s", posting.User, formatLedgerAmount(posting.Amount)))
+	}
+
+	return fmt.Sprintf("Paid by %s: %s", transaction.Payer, strings.Join(shares, ", "))
+}
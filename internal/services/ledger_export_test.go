@@ -0,0 +1,205 @@
+package services
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func getLedgerTestSettings() map[uint]models.GroupLedgerSettings {
+	return map[uint]models.GroupLedgerSettings{
+		1: {
+			GroupId:               1,
+			Currency:              "EUR",
+			PaidByAccount:         "Liabilities:Payable",
+			DefaultExpenseAccount: "Expenses:Misc",
+			CategoryAccounts:      []models.LedgerCategoryAccount{{CategoryId: 1, Account: "Expenses:Food"}},
+		},
+	}
+}
+
+func getLedgerTestReceipts() []models.Receipt {
+	chargedToUserId := uint(2)
+	return []models.Receipt{
+		{
+			BaseModel:    models.BaseModel{ID: 2},
+			GroupId:      1,
+			Name:         "Hardware & \"tools\"",
+			Date:         time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			Amount:       decimal.NewFromInt(5),
+			PaidByUserID: 2,
+			PaidByUser:   models.User{DisplayName: "Jim"},
+			Status:       models.RESOLVED,
+			Categories:   []models.Category{{BaseModel: models.BaseModel{ID: 3}, Name: "home improvement"}},
+		},
+		{
+			BaseModel:    models.BaseModel{ID: 1},
+			GroupId:      1,
+			Name:         "Groceries",
+			Date:         time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Amount:       decimal.NewFromInt(30),
+			PaidByUserID: 1,
+			PaidByUser:   models.User{DisplayName: "Ann Lee"},
+			Status:       models.OPEN,
+			Categories:   []models.Category{{BaseModel: models.BaseModel{ID: 1}, Name: "Food"}},
+			Tags:         []models.Tag{{Name: "weekly shop"}},
+			ReceiptItems: []models.Item{
+				{
+					Amount:          decimal.NewFromInt(10),
+					ChargedToUserId: &chargedToUserId,
+					ChargedToUser:   models.User{DisplayName: "Jim"},
+				},
+			},
+		},
+	}
+}
+
+func TestShouldBuildLedgerTransactionsWithPostingsPerUser(t *testing.T) {
+	transactions := newLedgerBuilder(getLedgerTestSettings()).buildTransactions(getLedgerTestReceipts())
+	if len(transactions) != 2 || transactions[0].Receipt.ID != 1 {
+		utils.PrintTestError(t, transactions, "transactions ordered by date")
+		return
+	}
+
+	expectedPostings := []ledgerPosting{
+		{Account: "Expenses:Food:Jim", User: "Jim", Amount: decimal.NewFromInt(10)},
+		{Account: "Expenses:Food:Ann-Lee", User: "Ann Lee", Amount: decimal.NewFromInt(20)},
+		{Account: "Liabilities:Payable:Ann-Lee", User: "Ann Lee", Amount: decimal.NewFromInt(-30)},
+	}
+	postings := transactions[0].Postings
+	if len(postings) != len(expectedPostings) {
+		utils.PrintTestError(t, postings, expectedPostings)
+		return
+	}
+
+	for i, expected := range expectedPostings {
+		if postings[i].Account != expected.Account || postings[i].User != expected.User || !postings[i].Amount.Equal(expected.Amount) {
+			utils.PrintTestError(t, postings[i], expected)
+		}
+	}
+
+	// Unmapped categories get an account named after them
+	if transactions[1].Postings[0].Account != "Expenses:Home-improvement:Jim" {
+		utils.PrintTestError(t, transactions[1].Postings[0].Account, "Expenses:Home-improvement:Jim")
+	}
+}
+
+func TestShouldUseDefaultLedgerSettingsForUnknownGroup(t *testing.T) {
+	receipt := models.Receipt{GroupId: 5, Amount: decimal.NewFromInt(1), PaidByUserID: 7}
+	transaction := newLedgerBuilder(map[uint]models.GroupLedgerSettings{}).buildTransaction(receipt)
+
+	if transaction.Currency != "USD" ||
+		transaction.Postings[0].Account != "Expenses:Uncategorized:User-7" ||
+		transaction.Postings[1].Account != "Liabilities:Payable:User-7" {
+		utils.PrintTestError(t, transaction, "default accounts")
+	}
+}
+
+func TestShouldExportBeancount(t *testing.T) {
+	bytes, err := NewBeancountReceiptExporter(getLedgerTestSettings()).Export(getLedgerTestReceipts())
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	expected := `2025-01-01 open Expenses:Food:Ann-Lee
+2025-01-01 open Expenses:Food:Jim
+2025-02-01 open Expenses:Home-improvement:Jim
+2025-01-01 open Liabilities:Payable:Ann-Lee
+2025-02-01 open Liabilities:Payable:Jim
+
+2025-01-01 ! "Ann Lee" "Groceries" #weekly-shop
+  receipt-id: "1"
+  Expenses:Food:Jim  10.00 EUR
+  Expenses:Food:Ann-Lee  20.00 EUR
+  Liabilities:Payable:Ann-Lee  -30.00 EUR
+
+2025-02-01 * "Jim" "Hardware & \"tools\""
+  receipt-id: "2"
+  Expenses:Home-improvement:Jim  5.00 EUR
+  Liabilities:Payable:Jim  -5.00 EUR
+`
+	if string(bytes) != expected {
+		utils.PrintTestError(t, string(bytes), expected)
+	}
+}
+
+func TestShouldExportHledger(t *testing.T) {
+	bytes, err := NewHledgerReceiptExporter(getLedgerTestSettings()).Export(getLedgerTestReceipts())
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	expected := `2025-01-01 ! Ann Lee | Groceries  ; receipt-id:1, weekly-shop:
+    Expenses:Food:Jim  10.00 EUR
+    Expenses:Food:Ann-Lee  20.00 EUR
+    Liabilities:Payable:Ann-Lee  -30.00 EUR
+
+2025-02-01 * Jim | Hardware & "tools"  ; receipt-id:2
+    Expenses:Home-improvement:Jim  5.00 EUR
+    Liabilities:Payable:Jim  -5.00 EUR
+`
+	if string(bytes) != expected {
+		utils.PrintTestError(t, string(bytes), expected)
+	}
+}
+
+func TestShouldExportQif(t *testing.T) {
+	bytes, err := NewQifReceiptExporter(getLedgerTestSettings()).Export(getLedgerTestReceipts())
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	expected := `!Type:Cash
+D01/01/2025
+T-30.00
+PGroceries
+MPaid by Ann Lee: Jim 10.00, Ann Lee 20.00
+LExpenses:Food:Jim
+SExpenses:Food:Jim
+EJim
+$-10.00
+SExpenses:Food:Ann-Lee
+EAnn Lee
+$-20.00
+^
+D02/01/2025
+T-5.00
+PHardware & "tools"
+MPaid by Jim: Jim 5.00
+LExpenses:Home-improvement:Jim
+C*
+^
+`
+	if string(bytes) != expected {
+		utils.PrintTestError(t, string(bytes), expected)
+	}
+}
+
+func TestShouldExportOfx(t *testing.T) {
+	bytes, err := NewOfxReceiptExporter(getLedgerTestSettings()).Export(getLedgerTestReceipts())
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	ofx := string(bytes)
+	expectedParts := []string{
+		"<CURDEF>EUR</CURDEF>",
+		"<DTSTART>20250101000000</DTSTART>",
+		"<DTEND>20250201000000</DTEND>",
+		"<TRNAMT>-30.00</TRNAMT>\n<FITID>receipt-1</FITID>\n<NAME>Groceries</NAME>\n<MEMO>Paid by Ann Lee: Jim 10.00, Ann Lee 20.00</MEMO>",
+		"<NAME>Hardware &amp; &#34;tools&#34;</NAME>",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(ofx, part) {
+			utils.PrintTestError(t, ofx, part)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"sort"
+	"strings"
+	"time"
+)
+
+const ledgerJournalDateFormat = "2006-01-02"
+
+type BeancountReceiptExporter struct {
+	ledgerBuilder
+}
+
+func NewBeancountReceiptExporter(settingsByGroupId map[uint]models.GroupLedgerSettings) BeancountReceiptExporter {
+	return BeancountReceiptExporter{ledgerBuilder: newLedgerBuilder(settingsByGroupId)}
+}
+
+// Export writes a Beancount journal, accounts are opened on the date they are first used
+func (exporter BeancountReceiptExporter) Export(receipts []models.Receipt) ([]byte, error) {
+	transactions := exporter.buildTransactions(receipts)
+	var buffer bytes.Buffer
+
+	openDates := make(map[string]time.Time)
+	for _, transaction := range transactions {
+		for _, posting := range transaction.Postings {
+			_, ok := openDates[posting.Account]
+			if !ok {
+				openDates[posting.Account] = transaction.Receipt.Date
+			}
+		}
+	}
+
+	accounts := make([]string, 0, len(openDates))
+	for account := range openDates {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	for _, account := range accounts {
+		fmt.Fprintf(&buffer, "%s open %s\n", openDates[account].Format(ledgerJournalDateFormat), account)
+	}
+
+	for _, transaction := range transactions {
+		receipt := transaction.Receipt
+
+		fmt.Fprintf(
+			&buffer,
+			"\n%s %s %s %s",
+			receipt.Date.Format(ledgerJournalDateFormat),
+			getLedgerJournalFlag(receipt),
+			quoteBeancountString(transaction.Payer),
+			quoteBeancountString(receipt.Name),
+		)
+		for _, tag := range receipt.Tags {
+			tagName := sanitizeLedgerTag(tag.Name)
+			if len(tagName) > 0 {
+				fmt.Fprintf(&buffer, " #%s", tagName)
+			}
+		}
+		buffer.WriteString("\n")
+		fmt.Fprintf(&buffer, "  receipt-id: %s\n", quoteBeancountString(utils.UintToString(receipt.ID)))
+
+		for _, posting := range transaction.Postings {
+			fmt.Fprintf(&buffer, "  %s  %s %s\n", posting.Account, formatLedgerAmount(posting.Amount), transaction.Currency)
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (exporter BeancountReceiptExporter) GetFileName() string {
+	return "receipts.beancount"
+}
+
+func (exporter BeancountReceiptExporter) GetContentType() string {
+	return constants.TextPlain
+}
+
+type HledgerReceiptExporter struct {
+	ledgerBuilder
+}
+
+func NewHledgerReceiptExporter(settingsByGroupId map[uint]models.GroupLedgerSettings) HledgerReceiptExporter {
+	return HledgerReceiptExporter{ledgerBuilder: newLedgerBuilder(settingsByGroupId)}
+}
+
+// Export writes an hledger journal, the receipt id and tags are transaction tags
+func (exporter HledgerReceiptExporter) Export(receipts []models.Receipt) ([]byte, error) {
+	transactions := exporter.buildTransactions(receipts)
+	var buffer bytes.Buffer
+
+	for i, transaction := range transactions {
+		receipt := transaction.Receipt
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+
+		tags := []string{"receipt-id:" + utils.UintToString(receipt.ID)}
+		for _, tag := range receipt.Tags {
+			tagName := sanitizeLedgerTag(tag.Name)
+			if len(tagName) > 0 {
+				tags = append(tags, tagName+":")
+			}
+		}
+
+		fmt.Fprintf(
+			&buffer,
+			"%s %s %s | %s  ; %s\n",
+			receipt.Date.Format(ledgerJournalDateFormat),
+			getLedgerJournalFlag(receipt),
+			sanitizeHledgerDescription(strings.ReplaceAll(transaction.Payer, "|", "-")),
+			sanitizeHledgerDescription(receipt.Name),
+			strings.Join(tags, ", "),
+		)
+
+		for _, posting := range transaction.Postings {
+			fmt.Fprintf(&buffer, "    %s  %s %s\n", posting.Account, formatLedgerAmount(posting.Amount), transaction.Currency)
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (exporter HledgerReceiptExporter) GetFileName() string {
+	return "receipts.journal"
+}
+
+func (exporter HledgerReceiptExporter) GetContentType() string {
+	return constants.TextPlain
+}
+
+// getLedgerJournalFlag marks resolved receipts as cleared and the others as pending
+func getLedgerJournalFlag(receipt models.Receipt) string {
+	if receipt.Status == models.RESOLVED {
+		return "*"
+	}
+
+	return "!"
+}
+
+func quoteBeancountString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// sanitizeHledgerDescription keeps descriptions on one line and out of the comment
+func sanitizeHledgerDescription(value string) string {
+	return strings.NewReplacer("\n", " ", "\r", " ", ";", ",").Replace(value)
+}
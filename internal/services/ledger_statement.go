@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"time"
+)
+
+const ofxNameMaxLength = 32
+
+type OfxReceiptExporter struct {
+	ledgerBuilder
+}
+
+func NewOfxReceiptExporter(settingsByGroupId map[uint]models.GroupLedgerSettings) OfxReceiptExporter {
+	return OfxReceiptExporter{ledgerBuilder: newLedgerBuilder(settingsByGroupId)}
+}
+
+// Export writes an OFX 2.2 bank statement with a debit for every receipt, the split is kept in the memo
+func (exporter OfxReceiptExporter) Export(receipts []models.Receipt) ([]byte, error) {
+	transactions := exporter.buildTransactions(receipts)
+	var buffer bytes.Buffer
+
+	currency := constants.LedgerDefaultCurrency
+	if len(transactions) > 0 {
+		currency = transactions[0].Currency
+	}
+
+	now := time.Now().UTC()
+	startDate := now
+	endDate := now
+	if len(transactions) > 0 {
+		startDate = transactions[0].Receipt.Date
+		endDate = transactions[len(transactions)-1].Receipt.Date
+	}
+
+	buffer.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	buffer.WriteString("<?OFX OFXHEADER=\"200\" VERSION=\"220\" SECURITY=\"NONE\" OLDFILEUID=\"NONE\" NEWFILEUID=\"NONE\"?>\n")
+	buffer.WriteString("<OFX>\n")
+	buffer.WriteString("<SIGNONMSGSRSV1><SONRS>\n")
+	buffer.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	fmt.Fprintf(&buffer, "<DTSERVER>%s</DTSERVER>\n", formatOfxDate(now))
+	buffer.WriteString("<LANGUAGE>ENG</LANGUAGE>\n")
+	buffer.WriteString("</SONRS></SIGNONMSGSRSV1>\n")
+	buffer.WriteString("<BANKMSGSRSV1><STMTTRNRS>\n")
+	buffer.WriteString("<TRNUID>0</TRNUID>\n")
+	buffer.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	buffer.WriteString("<STMTRS>\n")
+	fmt.Fprintf(&buffer, "<CURDEF>%s</CURDEF>\n", escapeOfxText(currency))
+	buffer.WriteString("<BANKACCTFROM><BANKID>receipt-wrangler</BANKID><ACCTID>receipts</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n")
+	buffer.WriteString("<BANKTRANLIST>\n")
+	fmt.Fprintf(&buffer, "<DTSTART>%s</DTSTART>\n", formatOfxDate(startDate))
+	fmt.Fprintf(&buffer, "<DTEND>%s</DTEND>\n", formatOfxDate(endDate))
+
+	for _, transaction := range transactions {
+		receipt := transaction.Receipt
+
+		buffer.WriteString("<STMTTRN>\n")
+		buffer.WriteString("<TRNTYPE>DEBIT</TRNTYPE>\n")
+		fmt.Fprintf(&buffer, "<DTPOSTED>%s</DTPOSTED>\n", formatOfxDate(receipt.Date))
+		fmt.Fprintf(&buffer, "<TRNAMT>%s</TRNAMT>\n", formatLedgerAmount(receipt.Amount.Neg()))
+		fmt.Fprintf(&buffer, "<FITID>receipt-%s</FITID>\n", utils.UintToString(receipt.ID))
+		fmt.Fprintf(&buffer, "<NAME>%s</NAME>\n", escapeOfxText(truncateRunes(receipt.Name, ofxNameMaxLength)))
+		fmt.Fprintf(&buffer, "<MEMO>%s</MEMO>\n", escapeOfxText(getLedgerSplitDescription(transaction)))
+		if transaction.Currency != currency {
+			fmt.Fprintf(&buffer, "<CURRENCY><CURRATE>1</CURRATE><CURSYM>%s</CURSYM></CURRENCY>\n", escapeOfxText(transaction.Currency))
+		}
+		buffer.WriteString("</STMTTRN>\n")
+	}
+
+	buffer.WriteString("</BANKTRANLIST>\n")
+	buffer.WriteString("</STMTRS>\n")
+	buffer.WriteString("</STMTTRNRS></BANKMSGSRSV1>\n")
+	buffer.WriteString("</OFX>\n")
+
+	return buffer.Bytes(), nil
+}
+
+func (exporter OfxReceiptExporter) GetFileName() string {
+	return "receipts.ofx"
+}
+
+func (exporter OfxReceiptExporter) GetContentType() string {
+	return constants.ApplicationOfx
+}
+
+type QifReceiptExporter struct {
+	ledgerBuilder
+}
+
+func NewQifReceiptExporter(settingsByGroupId map[uint]models.GroupLedgerSettings) QifReceiptExporter {
+	return QifReceiptExporter{ledgerBuilder: newLedgerBuilder(settingsByGroupId)}
+}
+
+// Export writes a QIF cash account with a split line for every posting of a user
+func (exporter QifReceiptExporter) Export(receipts []models.Receipt) ([]byte, error) {
+	transactions := exporter.buildTransactions(receipts)
+	var buffer bytes.Buffer
+
+	buffer.WriteString("!Type:Cash\n")
+	for _, transaction := range transactions {
+		receipt := transaction.Receipt
+		debitPostings := transaction.Postings[:len(transaction.Postings)-1]
+
+		fmt.Fprintf(&buffer, "D%s\n", receipt.Date.Format("01/02/2006"))
+		fmt.Fprintf(&buffer, "T%s\n", formatLedgerAmount(receipt.Amount.Neg()))
+		fmt.Fprintf(&buffer, "P%s\n", sanitizeQifText(receipt.Name))
+		fmt.Fprintf(&buffer, "M%s\n", sanitizeQifText(getLedgerSplitDescription(transaction)))
+		fmt.Fprintf(&buffer, "L%s\n", debitPostings[0].Account)
+		if receipt.Status == models.RESOLVED {
+			buffer.WriteString("C*\n")
+		}
+
+		if len(debitPostings) > 1 {
+			for _, posting := range debitPostings {
+				fmt.Fprintf(&buffer, "S%s\n", posting.Account)
+				fmt.Fprintf(&buffer, "E%s\n", sanitizeQifText(posting.User))
+				fmt.Fprintf(&buffer, "$%s\n", formatLedgerAmount(posting.Amount.Neg()))
+			}
+		}
+
+		buffer.WriteString("^\n")
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (exporter QifReceiptExporter) GetFileName() string {
+	return "receipts.qif"
+}
+
+func (exporter QifReceiptExporter) GetContentType() string {
+	return constants.ApplicationQif
+}
+
+func formatOfxDate(date time.Time) string {
+	return date.UTC().Format("20060102150405")
+}
+
+func escapeOfxText(value string) string {
+	var buffer bytes.Buffer
+	xml.EscapeText(&buffer, []byte(value))
+	return buffer.String()
+}
+
+func truncateRunes(value string, maxLength int) string {
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+
+	return string(runes[:maxLength])
+}
+
+// sanitizeQifText keeps values on their line, QIF has no escaping
+func sanitizeQifText(value string) string {
+	return strings.NewReplacer("\n", " ", "\r", " ").Replace(value)
+}
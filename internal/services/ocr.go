@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/otiai10/gosseract/v2"
 	"gopkg.in/gographics/imagick.v3/imagick"
@@ -9,6 +10,7 @@ import (
 	"image"
 	"image/jpeg"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -49,30 +51,31 @@ func (service OcrService) ReadImage(path string) (string, commands.UpsertSystemT
 		StartedAt:            time.Now(),
 	}
 
-	imageBytes, err := service.prepareImage(path)
+	fileBytes, err := utils.ReadFile(path)
 	if err != nil {
 		systemTaskCommand.Status = models.SYSTEM_TASK_FAILED
 		systemTaskCommand.ResultDescription = err.Error()
 		return "", systemTaskCommand, err
 	}
 
-	if service.ReceiptProcessingSettings.OcrEngine != nil && *service.ReceiptProcessingSettings.OcrEngine == models.TESSERACT_NEW {
-		text, err = service.ReadImageWithTesseract(imageBytes)
-		if err != nil {
-			systemTaskCommand.Status = models.SYSTEM_TASK_FAILED
-			systemTaskCommand.ResultDescription = err.Error()
-			return "", systemTaskCommand, err
+	// Debug image of the processed image, or of the first ocr'd page of a pdf
+	var imageBytes []byte
+	fileRepository := repositories.NewFileRepository(service.TX)
+	isPdf, _ := fileRepository.IsPdf(fileBytes)
+	if isPdf {
+		text, systemTaskCommand.OcrWords, imageBytes, err = service.readPdf(path, fileBytes)
+	} else {
+		imageBytes, err = service.prepareImage(path)
+		if err == nil {
+			text, systemTaskCommand.OcrWords, err = service.readPreparedImage(imageBytes)
 		}
 	}
-
-	if service.ReceiptProcessingSettings.OcrEngine != nil && *service.ReceiptProcessingSettings.OcrEngine == models.EASY_OCR_NEW {
-		text, err = service.ReadImageWithEasyOcr(imageBytes)
-		if err != nil {
-			systemTaskCommand.Status = models.SYSTEM_TASK_FAILED
-			systemTaskCommand.ResultDescription = err.Error()
-			return "", systemTaskCommand, err
-		}
+	if err != nil {
+		systemTaskCommand.Status = models.SYSTEM_TASK_FAILED
+		systemTaskCommand.ResultDescription = err.Error()
+		return "", systemTaskCommand, err
 	}
+
 	endTime := time.Now()
 	elapsedTime := endTime.Sub(startTime)
 	logging.LogStd(logging.LOG_LEVEL_INFO, "OCR and Image processing took: ", elapsedTime)
@@ -99,26 +102,177 @@ func (service OcrService) ReadImage(path string) (string, commands.UpsertSystemT
 	return text, systemTaskCommand, nil
 }
 
-func (service OcrService) ReadImageWithTesseract(preparedImageBytes []byte) (string, error) {
+func (service OcrService) readPreparedImage(imageBytes []byte) (string, []models.OcrWord, error) {
+	ocrEngine := service.ReceiptProcessingSettings.OcrEngine
+
+	if ocrEngine != nil && *ocrEngine == models.TESSERACT_NEW {
+		return service.ReadImageWithTesseract(imageBytes)
+	}
+
+	if ocrEngine != nil && *ocrEngine == models.EASY_OCR_NEW {
+		text, err := service.ReadImageWithEasyOcr(imageBytes)
+		return text, nil, err
+	}
+
+	return "", nil, nil
+}
+
+// readPdf reads a pdf page by page. Pages with an embedded text layer use it, the others are rendered and ocr'd.
+func (service OcrService) readPdf(path string, pdfBytes []byte) (string, []models.OcrWord, []byte, error) {
+	var renderedPages [][]byte
+	var debugImageBytes []byte
+	words := make([]models.OcrWord, 0)
+
+	textLayerPages, err := service.ReadPdfTextLayer(path)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_INFO, "Could not read pdf text layer, falling back to ocr: ", err.Error())
+	}
+
+	needsOcr := len(textLayerPages) == 0
+	for _, pageText := range textLayerPages {
+		if len(strings.TrimSpace(pageText)) == 0 {
+			needsOcr = true
+		}
+	}
+
+	if needsOcr {
+		fileRepository := repositories.NewFileRepository(service.TX)
+		renderedPages, err = fileRepository.ConvertPdfToJpgPages(pdfBytes)
+		if err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	numPages := max(len(textLayerPages), len(renderedPages))
+	pageTexts := make([]string, numPages)
+	for i := 0; i < numPages; i++ {
+		if i < len(textLayerPages) && len(strings.TrimSpace(textLayerPages[i])) > 0 {
+			pageTexts[i] = textLayerPages[i]
+			continue
+		}
+
+		if i >= len(renderedPages) {
+			continue
+		}
+
+		preparedPage, err := service.prepareImageBlob(renderedPages[i])
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		pageText, pageWords, err := service.readPreparedImage(preparedPage)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		if debugImageBytes == nil {
+			debugImageBytes = preparedPage
+		}
+		pageTexts[i] = pageText
+		words = append(words, pageWords...)
+	}
+
+	return BuildPagedOcrText(pageTexts), words, debugImageBytes, nil
+}
+
+// ReadPdfTextLayer returns the embedded text of each page of a pdf, pages without text are empty
+func (service OcrService) ReadPdfTextLayer(path string) ([]string, error) {
+	var textBuffer bytes.Buffer
+	cmd := exec.Command("pdftotext", "-layout", "-enc", "UTF-8", path, "-")
+	cmd.Stdout = &textBuffer
+	cmd.Stderr = io.Discard
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	return SplitPdfTextPages(textBuffer.String()), nil
+}
+
+// SplitPdfTextPages splits pdftotext output, which ends every page with a form feed
+func SplitPdfTextPages(output string) []string {
+	pages := strings.Split(output, "\f")
+	if len(pages) > 1 && len(strings.TrimSpace(pages[len(pages)-1])) == 0 {
+		pages = pages[:len(pages)-1]
+	}
+
+	return pages
+}
+
+// BuildPagedOcrText joins the text of multi page documents with page markers, so the model knows where pages start
+func BuildPagedOcrText(pageTexts []string) string {
+	if len(pageTexts) == 1 {
+		return pageTexts[0]
+	}
+
+	var builder strings.Builder
+	for i, pageText := range pageTexts {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("--- Page %d of %d ---\n", i+1, len(pageTexts)))
+		builder.WriteString(strings.TrimSpace(pageText))
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func (service OcrService) ReadImageWithTesseract(preparedImageBytes []byte) (string, []models.OcrWord, error) {
+	settings := service.ReceiptProcessingSettings
 	client := gosseract.NewClient()
 	defer client.Close()
 
-	err := client.SetVariable("tessedit_char_blacklist", "!@#$%^&*()_+=-[]}{;:'\"\\|~`<>/?")
+	err := client.SetLanguage(settings.GetOcrLanguages()...)
 	if err != nil {
-		return "", nil
+		return "", nil, err
+	}
+
+	if settings.OcrPageSegMode != nil {
+		err = client.SetPageSegMode(gosseract.PageSegMode(*settings.OcrPageSegMode))
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	if len(settings.OcrCharWhitelist) > 0 {
+		err = client.SetWhitelist(settings.OcrCharWhitelist)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	err = client.SetBlacklist(settings.GetOcrCharBlacklist())
+	if err != nil {
+		return "", nil, err
 	}
 
 	err = client.SetImageFromBytes(preparedImageBytes)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	text, err := client.Text()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return text, nil
+	boxes, err := client.GetBoundingBoxes(gosseract.RIL_WORD)
+	if err != nil {
+		return "", nil, err
+	}
+
+	words := make([]models.OcrWord, 0, len(boxes))
+	for _, box := range boxes {
+		if len(strings.TrimSpace(box.Word)) == 0 {
+			continue
+		}
+
+		words = append(words, models.OcrWord{Word: box.Word, Confidence: box.Confidence})
+	}
+
+	return text, words, nil
 }
 
 func (service OcrService) ReadImageWithEasyOcr(preparedImageBytes []byte) (string, error) {
@@ -131,7 +285,9 @@ func (service OcrService) ReadImageWithEasyOcr(preparedImageBytes []byte) (strin
 
 	var textBuffer bytes.Buffer
 	var text string
-	cmd := exec.Command("easyocr", "-l", "en", "-f", tempPath, "--detail", "0", "--gpu", "0", "--verbose", "0")
+	args := append([]string{"-l"}, service.ReceiptProcessingSettings.GetOcrLanguages()...)
+	args = append(args, "-f", tempPath, "--detail", "0", "--gpu", "0", "--verbose", "0")
+	cmd := exec.Command("easyocr", args...)
 	cmd.Stdout = &textBuffer
 	cmd.Stderr = io.Discard
 
@@ -144,6 +300,24 @@ func (service OcrService) ReadImageWithEasyOcr(preparedImageBytes []byte) (strin
 	return text, nil
 }
 
+// GetLowConfidenceWords returns the distinct words below the settings' confidence threshold, in reading order
+func (service OcrService) GetLowConfidenceWords(words []models.OcrWord) []string {
+	threshold := service.ReceiptProcessingSettings.GetOcrConfidenceThreshold()
+	seen := make(map[string]bool)
+	result := make([]string, 0)
+
+	for _, word := range words {
+		if word.Confidence >= threshold || seen[word.Word] {
+			continue
+		}
+
+		seen[word.Word] = true
+		result = append(result, word.Word)
+	}
+
+	return result
+}
+
 func (service OcrService) writeDebuggingFiles(ocrText string, path string, imageBytes []byte, ocrDuration time.Duration) error {
 	fileRepository := repositories.NewFileRepository(nil)
 	pathParts := strings.Split(path, "/")
@@ -161,6 +335,12 @@ func (service OcrService) writeDebuggingFiles(ocrText string, path string, image
 		return err
 	}
 
+	// Pdfs read entirely from their text layer have no image
+	if len(imageBytes) == 0 {
+		fmt.Println("OCR Text saved to: ", textFilePath)
+		return nil
+	}
+
 	img, _, err := image.Decode(bytes.NewReader(imageBytes))
 	if err != nil {
 		return err
@@ -192,53 +372,188 @@ func (service OcrService) writeDebuggingFiles(ocrText string, path string, image
 
 func (service OcrService) prepareImage(path string) ([]byte, error) {
 	mw := imagick.NewMagickWand()
+	defer mw.Destroy()
+
 	err := mw.ReadImage(path)
 	if err != nil {
 		return nil, err
 	}
 
-	err = mw.TrimImage(0)
+	err = service.applyPreprocessingSteps(mw, service.ReceiptProcessingSettings.GetPreprocessingSteps())
 	if err != nil {
 		return nil, err
 	}
 
-	err = mw.SetImageType(imagick.IMAGE_TYPE_BILEVEL)
+	return mw.GetImageBlob()
+}
+
+func (service OcrService) prepareImageBlob(imageBytes []byte) ([]byte, error) {
+	mw := imagick.NewMagickWand()
+	defer mw.Destroy()
+
+	err := mw.ReadImageBlob(imageBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	err = mw.BlurImage(0, 1.5)
+	err = service.applyPreprocessingSteps(mw, service.ReceiptProcessingSettings.GetPreprocessingSteps())
 	if err != nil {
 		return nil, err
 	}
 
-	err = mw.SharpenImage(0, 1)
+	return mw.GetImageBlob()
+}
+
+// PreviewPreprocessing runs steps, or the settings' own pipeline when steps is empty, and returns the result as a png
+func (service OcrService) PreviewPreprocessing(imageBytes []byte, steps []models.ImagePreprocessingStep) ([]byte, error) {
+	mw := imagick.NewMagickWand()
+	defer mw.Destroy()
+
+	err := mw.ReadImageBlob(imageBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	err = mw.EnhanceImage()
-	if err != nil {
-		return nil, err
+	if len(steps) == 0 {
+		steps = service.ReceiptProcessingSettings.GetPreprocessingSteps()
 	}
 
-	err = mw.ContrastImage(false)
+	err = service.applyPreprocessingSteps(mw, steps)
 	if err != nil {
 		return nil, err
 	}
 
-	err = mw.DeskewImage(.40)
+	err = mw.SetImageFormat("png")
 	if err != nil {
 		return nil, err
 	}
 
-	if service.ReceiptProcessingSettings.OcrEngine != nil &&
-		*service.ReceiptProcessingSettings.OcrEngine == models.EASY_OCR_NEW {
-		err = mw.ScaleImage(mw.GetImageWidth()/2, mw.GetImageHeight()/2)
+	return mw.GetImageBlob()
+}
+
+func (service OcrService) applyPreprocessingSteps(mw *imagick.MagickWand, steps []models.ImagePreprocessingStep) error {
+	for i, step := range steps {
+		err := service.applyPreprocessingStep(mw, step)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("preprocessing step %d (%s) failed: %w", i, step.Type, err)
 		}
 	}
 
-	return mw.GetImageBlob()
+	return nil
+}
+
+func (service OcrService) applyPreprocessingStep(mw *imagick.MagickWand, step models.ImagePreprocessingStep) error {
+	switch step.Type {
+	case models.PREPROCESS_TRIM:
+		return mw.TrimImage(step.GetAmount(0))
+	case models.PREPROCESS_GRAYSCALE:
+		return mw.TransformImageColorspace(imagick.COLORSPACE_GRAY)
+	case models.PREPROCESS_BILEVEL:
+		return mw.SetImageType(imagick.IMAGE_TYPE_BILEVEL)
+	case models.PREPROCESS_ADAPTIVE_THRESHOLD:
+		width := uint(15)
+		height := uint(15)
+		offset := 0.0
+		if step.Width != nil {
+			width = *step.Width
+		}
+		if step.Height != nil {
+			height = *step.Height
+		}
+		if step.Offset != nil {
+			offset = *step.Offset
+		}
+		return mw.AdaptiveThresholdImage(width, height, offset)
+	case models.PREPROCESS_BLUR:
+		return mw.BlurImage(0, step.GetAmount(1.5))
+	case models.PREPROCESS_SHARPEN:
+		return mw.SharpenImage(0, step.GetAmount(1))
+	case models.PREPROCESS_ENHANCE:
+		return mw.EnhanceImage()
+	case models.PREPROCESS_CONTRAST:
+		return mw.ContrastImage(false)
+	case models.PREPROCESS_NORMALIZE:
+		return mw.NormalizeImage()
+	case models.PREPROCESS_DESKEW:
+		return mw.DeskewImage(step.GetAmount(0.40))
+	case models.PREPROCESS_DENOISE:
+		size := uint(step.GetAmount(3))
+		return mw.StatisticImage(imagick.STATISTIC_MEDIAN, size, size)
+	case models.PREPROCESS_RESIZE_TO_DPI:
+		return resizeToDpi(mw, step.GetAmount(300))
+	case models.PREPROCESS_SCALE:
+		width, height, err := getScaledImageSize(mw, step.GetAmount(100)/100)
+		if err != nil {
+			return err
+		}
+		return mw.ScaleImage(width, height)
+	case models.PREPROCESS_PERSPECTIVE_CROP:
+		return perspectiveCrop(mw, step.Points)
+	}
+
+	return fmt.Errorf("unknown preprocessing step %s", step.Type)
+}
+
+// Images without resolution metadata are assumed to be 72 dpi
+func resizeToDpi(mw *imagick.MagickWand, dpi float64) error {
+	currentDpi, _, err := mw.GetImageResolution()
+	if err != nil {
+		return err
+	}
+
+	if currentDpi <= 0 {
+		currentDpi = 72
+	}
+
+	factor := dpi / currentDpi
+	width, height, err := getScaledImageSize(mw, factor)
+	if err != nil {
+		return err
+	}
+
+	err = mw.ResizeImage(width, height, imagick.FILTER_LANCZOS)
+	if err != nil {
+		return err
+	}
+
+	return mw.SetImageResolution(dpi, dpi)
+}
+
+// Low resolution metadata could otherwise scale an image far beyond what OCR needs
+func getScaledImageSize(mw *imagick.MagickWand, factor float64) (uint, uint, error) {
+	width := uint(float64(mw.GetImageWidth()) * factor)
+	height := uint(float64(mw.GetImageHeight()) * factor)
+	if width > models.MaxPreprocessedImageSide || height > models.MaxPreprocessedImageSide {
+		return 0, 0, fmt.Errorf("scaled image of %dx%d exceeds the maximum side of %d pixels", width, height, models.MaxPreprocessedImageSide)
+	}
+
+	return width, height, nil
+}
+
+// perspectiveCrop maps the quadrilateral given by points onto an upright rectangle and crops to it
+func perspectiveCrop(mw *imagick.MagickWand, points []float64) error {
+	if len(points) != 8 {
+		return errors.New("perspective crop requires 4 corner points")
+	}
+
+	distance := func(x1, y1, x2, y2 float64) float64 {
+		return math.Hypot(x2-x1, y2-y1)
+	}
+
+	width := math.Max(distance(points[0], points[1], points[2], points[3]), distance(points[6], points[7], points[4], points[5]))
+	height := math.Max(distance(points[0], points[1], points[6], points[7]), distance(points[2], points[3], points[4], points[5]))
+
+	args := []float64{
+		points[0], points[1], 0, 0,
+		points[2], points[3], width, 0,
+		points[4], points[5], width, height,
+		points[6], points[7], 0, height,
+	}
+
+	err := mw.DistortImage(imagick.DISTORTION_PERSPECTIVE, args, false)
+	if err != nil {
+		return err
+	}
+
+	return mw.CropImage(uint(width), uint(height), 0, 0)
 }
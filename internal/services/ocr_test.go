@@ -0,0 +1,94 @@
+package services
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldGetLowConfidenceWords(t *testing.T) {
+	threshold := 80.0
+	ocrService := NewOcrService(nil, models.ReceiptProcessingSettings{OcrConfidenceThreshold: &threshold})
+
+	words := []models.OcrWord{
+		{Word: "TOTAL", Confidence: 95},
+		{Word: "12,5O", Confidence: 41},
+		{Word: "EUR", Confidence: 80},
+		{Word: "Bäckerei", Confidence: 62},
+		{Word: "12,5O", Confidence: 38},
+	}
+
+	result := ocrService.GetLowConfidenceWords(words)
+	expected := []string{"12,5O", "Bäckerei"}
+
+	if len(result) != len(expected) {
+		utils.PrintTestError(t, result, expected)
+		return
+	}
+
+	for i := range expected {
+		if result[i] != expected[i] {
+			utils.PrintTestError(t, result, expected)
+		}
+	}
+}
+
+func TestShouldUseDefaultOcrConfidenceThreshold(t *testing.T) {
+	ocrService := NewOcrService(nil, models.ReceiptProcessingSettings{})
+
+	result := ocrService.GetLowConfidenceWords([]models.OcrWord{
+		{Word: "Summe", Confidence: models.DefaultOcrConfidenceThreshold},
+		{Word: "Mwst", Confidence: models.DefaultOcrConfidenceThreshold - 1},
+	})
+
+	if len(result) != 1 || result[0] != "Mwst" {
+		utils.PrintTestError(t, result, []string{"Mwst"})
+	}
+}
+
+func TestShouldSplitPdfTextPages(t *testing.T) {
+	tests := map[string]struct {
+		output   string
+		expected []string
+	}{
+		"single page": {
+			output:   "REWE\nSumme 12,50\n\f",
+			expected: []string{"REWE\nSumme 12,50\n"},
+		},
+		"page without text layer": {
+			output:   "Page one\n\f\fPage three\n\f",
+			expected: []string{"Page one\n", "", "Page three\n"},
+		},
+		"no form feed": {
+			output:   "Page one",
+			expected: []string{"Page one"},
+		},
+	}
+
+	for name, test := range tests {
+		result := SplitPdfTextPages(test.output)
+		if len(result) != len(test.expected) {
+			utils.PrintTestError(t, result, name)
+			continue
+		}
+
+		for i := range test.expected {
+			if result[i] != test.expected[i] {
+				utils.PrintTestError(t, result, name)
+			}
+		}
+	}
+}
+
+func TestShouldBuildPagedOcrText(t *testing.T) {
+	singlePage := BuildPagedOcrText([]string{"Total 5.00"})
+	if singlePage != "Total 5.00" {
+		utils.PrintTestError(t, singlePage, "Total 5.00")
+	}
+
+	multiPage := BuildPagedOcrText([]string{"Store\n", "Total 5.00\n"})
+	expected := "--- Page 1 of 2 ---\nStore\n\n--- Page 2 of 2 ---\nTotal 5.00\n"
+	if multiPage != expected {
+		utils.PrintTestError(t, multiPage, expected)
+	}
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"strings"
+)
+
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+)
+
+// Widths of the printable ascii characters in the standard Helvetica fonts, in thousandths of the font size
+var pdfHelveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var pdfHelveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+type pdfImage struct {
+	data   []byte
+	width  int
+	height int
+}
+
+type pdfPage struct {
+	content    bytes.Buffer
+	imageNames []string
+}
+
+// pdfDocument writes A4 pages with text in the standard Helvetica fonts, lines and jpeg images.
+// Text is laid out from the top of the page, a new page is started when the cursor reaches the bottom margin.
+type pdfDocument struct {
+	pages  []*pdfPage
+	images []pdfImage
+	y      float64
+}
+
+func newPdfDocument() *pdfDocument {
+	document := &pdfDocument{}
+	document.AddPage()
+	return document
+}
+
+func (document *pdfDocument) AddPage() {
+	document.pages = append(document.pages, &pdfPage{})
+	document.y = pdfPageHeight - pdfMargin
+}
+
+func (document *pdfDocument) currentPage() *pdfPage {
+	return document.pages[len(document.pages)-1]
+}
+
+// EnsureSpace starts a new page if less than height is left on the current one
+func (document *pdfDocument) EnsureSpace(height float64) {
+	if document.y-height < pdfMargin {
+		document.AddPage()
+	}
+}
+
+func (document *pdfDocument) MoveDown(height float64) {
+	document.y -= height
+}
+
+// WriteLine writes text at the cursor and moves it down, text wider than the page is wrapped
+func (document *pdfDocument) WriteLine(text string, size float64, bold bool) {
+	for _, line := range wrapPdfText(text, size, bold, pdfPageWidth-2*pdfMargin) {
+		document.EnsureSpace(size * 1.4)
+		document.MoveDown(size * 1.4)
+		document.Text(pdfMargin, document.y, line, size, bold)
+	}
+}
+
+// WriteColumns writes one line of cells starting at the given x positions, cells are truncated to their column
+func (document *pdfDocument) WriteColumns(cells []string, columns []float64, size float64, bold bool) {
+	document.EnsureSpace(size * 1.4)
+	document.MoveDown(size * 1.4)
+
+	for i, cell := range cells {
+		right := pdfPageWidth - pdfMargin
+		if i+1 < len(columns) {
+			right = columns[i+1] - 6
+		}
+		document.Text(columns[i], document.y, truncatePdfText(cell, size, bold, right-columns[i]), size, bold)
+	}
+}
+
+func (document *pdfDocument) Text(x float64, y float64, text string, size float64, bold bool) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+
+	fmt.Fprintf(&document.currentPage().content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapePdfText(text))
+}
+
+// Rule draws a horizontal line across the page below the cursor
+func (document *pdfDocument) Rule() {
+	document.EnsureSpace(8)
+	document.MoveDown(6)
+	fmt.Fprintf(&document.currentPage().content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, document.y, pdfPageWidth-pdfMargin, document.y)
+	document.MoveDown(2)
+}
+
+// Image draws an image below the cursor scaled to the page width and at most maxHeight high.
+// Images other than rgb jpegs are decoded and encoded as one, so any format the image package reads can be embedded.
+func (document *pdfDocument) Image(imageBytes []byte, maxHeight float64) error {
+	config, format, err := image.DecodeConfig(bytes.NewReader(imageBytes))
+	if err != nil {
+		return err
+	}
+
+	if format != "jpeg" || config.ColorModel != color.YCbCrModel {
+		decodedImage, _, err := image.Decode(bytes.NewReader(imageBytes))
+		if err != nil {
+			return err
+		}
+
+		rgbImage := image.NewRGBA(decodedImage.Bounds())
+		draw.Draw(rgbImage, rgbImage.Bounds(), decodedImage, decodedImage.Bounds().Min, draw.Src)
+
+		var buffer bytes.Buffer
+		err = jpeg.Encode(&buffer, rgbImage, &jpeg.Options{Quality: 85})
+		if err != nil {
+			return err
+		}
+		imageBytes = buffer.Bytes()
+	}
+
+	if config.Width == 0 || config.Height == 0 {
+		return fmt.Errorf("image has no size")
+	}
+
+	width := pdfPageWidth - 2*pdfMargin
+	height := width * float64(config.Height) / float64(config.Width)
+	if height > maxHeight {
+		height = maxHeight
+		width = height * float64(config.Width) / float64(config.Height)
+	}
+
+	document.EnsureSpace(height + 6)
+	document.MoveDown(height + 6)
+
+	name := fmt.Sprintf("Im%d", len(document.images)+1)
+	document.images = append(document.images, pdfImage{data: imageBytes, width: config.Width, height: config.Height})
+	page := document.currentPage()
+	page.imageNames = append(page.imageNames, name)
+	fmt.Fprintf(&page.content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", width, height, pdfMargin, document.y, name)
+
+	return nil
+}
+
+// RemainingHeight returns the space left between the cursor and the bottom margin
+func (document *pdfDocument) RemainingHeight() float64 {
+	return document.y - pdfMargin
+}
+
+// Bytes writes the document, objects are numbered catalog, pages, fonts, images and then a page and its content per page
+func (document *pdfDocument) Bytes() ([]byte, error) {
+	var buffer bytes.Buffer
+	offsets := make([]int, 0)
+
+	startObject := func() int {
+		offsets = append(offsets, buffer.Len())
+		objectNumber := len(offsets)
+		fmt.Fprintf(&buffer, "%d 0 obj\n", objectNumber)
+		return objectNumber
+	}
+
+	buffer.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	firstImageObject := 5
+	firstPageObject := firstImageObject + len(document.images)
+	pageReferences := make([]string, 0, len(document.pages))
+	for i := range document.pages {
+		pageReferences = append(pageReferences, fmt.Sprintf("%d 0 R", firstPageObject+i*2))
+	}
+
+	startObject()
+	buffer.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	startObject()
+	fmt.Fprintf(&buffer, "<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(pageReferences, " "), len(document.pages))
+
+	startObject()
+	buffer.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	startObject()
+	buffer.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	for _, pdfImage := range document.images {
+		startObject()
+		fmt.Fprintf(
+			&buffer,
+			"<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n",
+			pdfImage.width,
+			pdfImage.height,
+			len(pdfImage.data),
+		)
+		buffer.Write(pdfImage.data)
+		buffer.WriteString("\nendstream\nendobj\n")
+	}
+
+	imageNumber := 0
+	for _, page := range document.pages {
+		pageObject := startObject()
+		imageReferences := make([]string, 0, len(page.imageNames))
+		for _, name := range page.imageNames {
+			imageReferences = append(imageReferences, fmt.Sprintf("/%s %d 0 R", name, firstImageObject+imageNumber))
+			imageNumber++
+		}
+		fmt.Fprintf(
+			&buffer,
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> /XObject << %s >> >> /Contents %d 0 R >>\nendobj\n",
+			pdfPageWidth,
+			pdfPageHeight,
+			strings.Join(imageReferences, " "),
+			pageObject+1,
+		)
+
+		var content bytes.Buffer
+		writer := zlib.NewWriter(&content)
+		_, err := writer.Write(page.content.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		err = writer.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		startObject()
+		fmt.Fprintf(&buffer, "<< /Filter /FlateDecode /Length %d >>\nstream\n", content.Len())
+		buffer.Write(content.Bytes())
+		buffer.WriteString("\nendstream\nendobj\n")
+	}
+
+	xrefOffset := buffer.Len()
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+
+	return buffer.Bytes(), nil
+}
+
+// encodePdfText converts text to WinAnsi, characters the standard fonts can't show become ?
+func encodePdfText(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, character := range text {
+		switch {
+		case character == '€':
+			encoded = append(encoded, 0x80)
+		case character == '\t' || character == '\n' || character == '\r':
+			encoded = append(encoded, ' ')
+		case character >= 0x20 && character < 0x7f, character >= 0xa0 && character <= 0xff:
+			encoded = append(encoded, byte(character))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+
+	return encoded
+}
+
+func escapePdfText(text string) string {
+	var builder strings.Builder
+	for _, character := range encodePdfText(text) {
+		if character == '(' || character == ')' || character == '\\' {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(character)
+	}
+
+	return builder.String()
+}
+
+func getPdfTextWidth(text string, size float64, bold bool) float64 {
+	widths := pdfHelveticaWidths
+	if bold {
+		widths = pdfHelveticaBoldWidths
+	}
+
+	total := 0
+	for _, character := range encodePdfText(text) {
+		if character >= 0x20 && character < 0x7f {
+			total += widths[character-0x20]
+		} else {
+			total += 556
+		}
+	}
+
+	return float64(total) * size / 1000
+}
+
+func truncatePdfText(text string, size float64, bold bool, maxWidth float64) string {
+	if getPdfTextWidth(text, size, bold) <= maxWidth {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && getPdfTextWidth(string(runes)+"...", size, bold) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "..."
+}
+
+// wrapPdfText breaks text into lines at spaces, words wider than a line are truncated
+func wrapPdfText(text string, size float64, bold bool, maxWidth float64) []string {
+	lines := make([]string, 0)
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if len(line) > 0 {
+			candidate = line + " " + word
+		}
+
+		if getPdfTextWidth(candidate, size, bold) <= maxWidth {
+			line = candidate
+			continue
+		}
+
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		line = truncatePdfText(word, size, bold, maxWidth)
+	}
+
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
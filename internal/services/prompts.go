@@ -59,6 +59,10 @@ Follow the same process as described for categories for tags.
 Tags to chose from: @tags
 
 Receipt text: @ocrText
+
+The OCR engine was unsure about these words, they may be misread, so do not trust them over the rest of the text: @lowConfidenceWords
+
+Barcodes and QR codes found on the receipt, they may contain the store name, amount or date: @barcodes
 `)
 
 	if defaultPromptCount > 0 {
@@ -1,15 +1,20 @@
 package services
 
 import (
+	"fmt"
+	"path"
+	"receipt-wrangler/api/internal/constants"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
+	"sort"
 	"strings"
 )
 
 type ReceiptCsvService struct {
 	CsvService
+	Options structs.ReceiptCsvOptions
 }
 
 func NewReceiptCsvService() ReceiptCsvService {
@@ -25,19 +30,30 @@ func (service *ReceiptCsvService) BuildReceiptCsv(receipts []models.Receipt) (st
 	items := make([]models.Item, 0)
 
 	headers := []string{
-		"Id",
-		"Added At",
-		"Receipt Date",
-		"Name",
-		"Paid By",
-		"Amount",
-		"Status",
-		"Categories",
-		"Tags",
-		"Resolved Date",
+		constants.ReceiptCsvIdColumn,
+		constants.ReceiptCsvAddedAtColumn,
+		constants.ReceiptCsvDateColumn,
+		constants.ReceiptCsvNameColumn,
+		constants.ReceiptCsvPaidByColumn,
+		constants.ReceiptCsvAmountColumn,
+		constants.ReceiptCsvStatusColumn,
+		constants.ReceiptCsvCategoriesColumn,
+		constants.ReceiptCsvTagsColumn,
+		constants.ReceiptCsvResolvedDateColumn,
+	}
+
+	customFields := make([]models.CustomField, 0)
+	if service.Options.IncludeCustomFields {
+		customFields = service.getReceiptCustomFields(receipts)
+		headers = append(headers, service.BuildCustomFieldHeaders(customFields)...)
 	}
+
+	if service.Options.IncludeImages {
+		headers = append(headers, constants.ReceiptCsvImagesColumn)
+	}
+
 	rowData := make([][]string, 0, len(receipts))
-	dateFormat := "2006-01-02"
+	dateFormat := constants.ReceiptCsvDateFormat
 
 	for _, receipt := range receipts {
 		resolvedDateString := ""
@@ -60,6 +76,19 @@ func (service *ReceiptCsvService) BuildReceiptCsv(receipts []models.Receipt) (st
 			service.BuildTagString(receipt.Tags),
 			resolvedDateString,
 		}
+
+		for _, customField := range customFields {
+			newRow = append(newRow, service.getCustomFieldValueString(receipt, customField))
+		}
+
+		if service.Options.IncludeImages {
+			imagePaths := make([]string, 0, len(receipt.ImageFiles))
+			for _, fileData := range receipt.ImageFiles {
+				imagePaths = append(imagePaths, service.BuildImageFilePath(fileData))
+			}
+			newRow = append(newRow, strings.Join(imagePaths, constants.ReceiptCsvListSeparator))
+		}
+
 		rowData = append(rowData, newRow)
 	}
 
@@ -74,6 +103,13 @@ func (service *ReceiptCsvService) BuildReceiptCsv(receipts []models.Receipt) (st
 		return structs.ReceiptCsvResult{}, err
 	}
 
+	if service.Options.IncludeComments {
+		csvResult.CommentCsvBytes, err = service.BuildCommentCsv(receipts)
+		if err != nil {
+			return structs.ReceiptCsvResult{}, err
+		}
+	}
+
 	return csvResult, nil
 }
 
@@ -118,13 +154,126 @@ func (service *ReceiptCsvService) BuildItemCsv(items []models.Item) ([]byte, err
 	return buffer.Bytes(), nil
 }
 
+// BuildCommentCsv lists the comments of receipts, replies reference the comment they answer
+func (service *ReceiptCsvService) BuildCommentCsv(receipts []models.Receipt) ([]byte, error) {
+	headers := []string{
+		"Id",
+		"Receipt Id",
+		"Receipt Name",
+		"Reply To",
+		"User",
+		"Added At",
+		"Comment",
+	}
+	rowData := make([][]string, 0)
+
+	for _, receipt := range receipts {
+		for _, comment := range receipt.Comments {
+			replyTo := ""
+			if comment.CommentId != nil {
+				replyTo = utils.UintToString(*comment.CommentId)
+			}
+
+			rowData = append(rowData, []string{
+				utils.UintToString(comment.ID),
+				utils.UintToString(receipt.ID),
+				receipt.Name,
+				replyTo,
+				comment.User.DisplayName,
+				comment.CreatedAt.Format(constants.ReceiptCsvDateFormat),
+				comment.Comment,
+			})
+		}
+	}
+
+	buffer, err := service.CsvService.BuildCsv(headers, rowData)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// BuildCustomFieldHeaders names custom field columns after their field, fields sharing a name get their id appended
+func (service *ReceiptCsvService) BuildCustomFieldHeaders(customFields []models.CustomField) []string {
+	nameCounts := make(map[string]int)
+	for _, customField := range customFields {
+		nameCounts[customField.Name]++
+	}
+
+	headers := make([]string, 0, len(customFields))
+	for _, customField := range customFields {
+		header := customField.Name
+		if nameCounts[customField.Name] > 1 {
+			header = fmt.Sprintf("%s (%d)", customField.Name, customField.ID)
+		}
+		headers = append(headers, header)
+	}
+
+	return headers
+}
+
+// BuildImageFilePath returns the path of an image in the zip, unique per file so names can't clash
+func (service *ReceiptCsvService) BuildImageFilePath(fileData models.FileData) string {
+	fileName := strings.NewReplacer("/", "_", "\\", "_", constants.ReceiptCsvListSeparator, "_").Replace(fileData.Name)
+	return path.Join("images", utils.UintToString(fileData.ReceiptId), utils.UintToString(fileData.ID)+"-"+fileName)
+}
+
+// getReceiptCustomFields returns the custom fields that have a value on any of the receipts, ordered by id
+func (service *ReceiptCsvService) getReceiptCustomFields(receipts []models.Receipt) []models.CustomField {
+	customFieldsById := make(map[uint]models.CustomField)
+	for _, receipt := range receipts {
+		for _, customFieldValue := range receipt.CustomFields {
+			customFieldsById[customFieldValue.CustomFieldId] = customFieldValue.CustomField
+		}
+	}
+
+	customFields := make([]models.CustomField, 0, len(customFieldsById))
+	for _, customField := range customFieldsById {
+		customFields = append(customFields, customField)
+	}
+	sort.Slice(customFields, func(i, j int) bool {
+		return customFields[i].ID < customFields[j].ID
+	})
+
+	return customFields
+}
+
+// getCustomFieldValueString formats a value the way csv imports parse it
+func (service *ReceiptCsvService) getCustomFieldValueString(receipt models.Receipt, customField models.CustomField) string {
+	for _, customFieldValue := range receipt.CustomFields {
+		if customFieldValue.CustomFieldId != customField.ID {
+			continue
+		}
+
+		switch {
+		case customFieldValue.StringValue != nil:
+			return *customFieldValue.StringValue
+		case customFieldValue.DateValue != nil:
+			return customFieldValue.DateValue.Format(constants.ReceiptCsvDateFormat)
+		case customFieldValue.CurrencyValue != nil:
+			return customFieldValue.CurrencyValue.String()
+		case customFieldValue.BooleanValue != nil:
+			return fmt.Sprint(*customFieldValue.BooleanValue)
+		case customFieldValue.SelectValue != nil:
+			for _, option := range customField.Options {
+				if option.ID == *customFieldValue.SelectValue {
+					return option.Value
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
 func (service *ReceiptCsvService) BuildCategoryString(categories []models.Category) string {
 	categoryNames := make([]string, 0, len(categories))
 	for _, category := range categories {
 		categoryNames = append(categoryNames, category.Name)
 	}
 
-	return strings.Join(categoryNames, ",")
+	return strings.Join(categoryNames, constants.ReceiptCsvListSeparator)
 }
 
 func (service *ReceiptCsvService) BuildTagString(tags []models.Tag) string {
@@ -133,7 +282,7 @@ func (service *ReceiptCsvService) BuildTagString(tags []models.Tag) string {
 		tagNames = append(tagNames, tag.Name)
 	}
 
-	return strings.Join(tagNames, ",")
+	return strings.Join(tagNames, constants.ReceiptCsvListSeparator)
 }
 
 func (service *ReceiptCsvService) GetZippedCsvFiles(receipts []models.Receipt) ([]byte, error) {
@@ -142,14 +291,46 @@ func (service *ReceiptCsvService) GetZippedCsvFiles(receipts []models.Receipt) (
 		return nil, err
 	}
 
+	fileNames := []string{"receipts.csv", "items.csv"}
+	fileContents := [][]byte{csvResult.ReceiptCsvBytes, csvResult.ReceiptItemCsvBytes}
+
+	if service.Options.IncludeComments {
+		fileNames = append(fileNames, "comments.csv")
+		fileContents = append(fileContents, csvResult.CommentCsvBytes)
+	}
+
 	fileRepository := repositories.NewFileRepository(nil)
-	zip, err := fileRepository.ZipFiles(
-		[]string{"receipts.csv", "items.csv"},
-		[][]byte{csvResult.ReceiptCsvBytes, csvResult.ReceiptItemCsvBytes},
-	)
+	if service.Options.IncludeImages {
+		for _, receipt := range receipts {
+			for _, fileData := range receipt.ImageFiles {
+				// Original files, without converting pdfs or heic images
+				imageBytes, err := fileRepository.GetRawBytesForFileData(fileData)
+				if err != nil {
+					return nil, err
+				}
+
+				fileNames = append(fileNames, service.BuildImageFilePath(fileData))
+				fileContents = append(fileContents, imageBytes)
+			}
+		}
+	}
+
+	zip, err := fileRepository.ZipFiles(fileNames, fileContents)
 	if err != nil {
 		return nil, err
 	}
 
 	return zip, nil
 }
+
+func (service *ReceiptCsvService) Export(receipts []models.Receipt) ([]byte, error) {
+	return service.GetZippedCsvFiles(receipts)
+}
+
+func (service *ReceiptCsvService) GetFileName() string {
+	return "data.zip"
+}
+
+func (service *ReceiptCsvService) GetContentType() string {
+	return constants.ApplicationZip
+}
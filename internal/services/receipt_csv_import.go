@@ -0,0 +1,513 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var nonNumericRegex = regexp.MustCompile(`[^0-9.,\-]`)
+
+type ReceiptCsvImportService struct {
+	BaseService
+}
+
+func NewReceiptCsvImportService(tx *gorm.DB) ReceiptCsvImportService {
+	service := ReceiptCsvImportService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+// receiptCsvImport holds the receipts built from a csv, along with the preview shown to the user
+type receiptCsvImport struct {
+	receipts []commands.UpsertReceiptCommand
+	preview  structs.ReceiptCsvImportPreview
+}
+
+// receiptCsvImportLookups are the names csv values are resolved against
+type receiptCsvImportLookups struct {
+	users        []models.User
+	categories   map[string]models.Category
+	tags         map[string]models.Tag
+	customFields map[uint]models.CustomField
+}
+
+// PreviewReceiptCsvImport parses a csv with the command's mapping and validates every row without importing anything.
+// Problems with the file itself, like a mapped column missing from the header, are returned as a validator error.
+func (service ReceiptCsvImportService) PreviewReceiptCsvImport(command commands.ImportReceiptCsvCommand, userId uint) (structs.ReceiptCsvImportPreview, structs.ValidatorError, error) {
+	receiptImport, vErr, err := service.buildReceiptCsvImport(command, userId)
+	if err != nil || len(vErr.Errors) > 0 {
+		return structs.ReceiptCsvImportPreview{}, vErr, err
+	}
+
+	return receiptImport.preview, vErr, nil
+}
+
+// ImportReceiptCsv creates the receipts of a csv in one transaction and records the result as a system task.
+// The csv is validated again, so nothing is imported if any row became invalid since the preview.
+func (service ReceiptCsvImportService) ImportReceiptCsv(
+	command commands.ImportReceiptCsvCommand,
+	ranByUserId uint,
+	asynqTaskId string,
+) (models.SystemTask, error) {
+	systemTaskService := NewSystemTaskService(service.TX)
+	systemTaskCommand := commands.UpsertSystemTaskCommand{
+		Type:                 models.RECEIPT_CSV_IMPORT,
+		AssociatedEntityType: models.NOOP_ENTITY_TYPE,
+		StartedAt:            time.Now(),
+		RanByUserId:          &ranByUserId,
+		GroupId:              &command.GroupId,
+		AsynqTaskId:          asynqTaskId,
+	}
+
+	importedCount, err := service.importReceipts(command, ranByUserId)
+	if err != nil {
+		systemTask, taskErr := systemTaskService.CreateSystemTaskFromError(systemTaskCommand, err)
+		if taskErr != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, taskErr.Error())
+		}
+		return systemTask, err
+	}
+
+	systemTaskCommand.ResultDescription = fmt.Sprintf("Imported %d receipts", importedCount)
+	return systemTaskService.CreateSystemTaskFromError(systemTaskCommand, nil)
+}
+
+func (service ReceiptCsvImportService) importReceipts(command commands.ImportReceiptCsvCommand, ranByUserId uint) (int, error) {
+	groupService := NewGroupService(service.TX)
+	err := groupService.ValidateGroupsAreActive([]string{utils.UintToString(command.GroupId)})
+	if err != nil {
+		return 0, err
+	}
+
+	receiptImport, vErr, err := service.buildReceiptCsvImport(command, ranByUserId)
+	if err != nil {
+		return 0, err
+	}
+
+	for key, message := range vErr.Errors {
+		return 0, fmt.Errorf("%s: %s", key, message)
+	}
+
+	if receiptImport.preview.InvalidRows > 0 {
+		return 0, fmt.Errorf("csv has %d invalid rows", receiptImport.preview.InvalidRows)
+	}
+
+	err = service.GetDB().Transaction(func(tx *gorm.DB) error {
+		notificationRepository := repositories.NewNotificationRepository(tx)
+
+		for _, receiptCommand := range receiptImport.receipts {
+			receipt, err := receiptCommand.ToReceipt()
+			if err != nil {
+				return err
+			}
+			receipt.CreatedBy = &ranByUserId
+
+			err = tx.Model(models.Receipt{}).Select("*").Create(&receipt).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// One notification for the whole import instead of one per receipt
+		notificationBody := fmt.Sprintf(
+			"%d receipts have been imported to the group %s.",
+			len(receiptImport.receipts),
+			repositories.BuildParamaterisedString("groupId", command.GroupId, "name", "string"),
+		)
+		return notificationRepository.SendNotificationToGroup(command.GroupId, "Receipts Imported", notificationBody, models.NOTIFICATION_TYPE_NORMAL, []interface{}{ranByUserId})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(receiptImport.receipts), nil
+}
+
+func (service ReceiptCsvImportService) buildReceiptCsvImport(command commands.ImportReceiptCsvCommand, userId uint) (receiptCsvImport, structs.ValidatorError, error) {
+	command.SetDefaults()
+	vErr := structs.ValidatorError{Errors: make(map[string]string)}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(command.Csv, []byte("\xef\xbb\xbf"))))
+	reader.Comma = []rune(command.Delimiter)[0]
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		vErr.Errors["file"] = "File is not a valid csv"
+		return receiptCsvImport{}, vErr, nil
+	}
+
+	columnIndexes := make(map[string]int)
+	for i, column := range header {
+		columnIndexes[strings.TrimSpace(column)] = i
+	}
+
+	mapping := *command.Mapping
+	mappedColumns := map[string]string{
+		"mapping.name":       mapping.Name,
+		"mapping.date":       mapping.Date,
+		"mapping.amount":     mapping.Amount,
+		"mapping.paidBy":     mapping.PaidBy,
+		"mapping.categories": mapping.Categories,
+		"mapping.tags":       mapping.Tags,
+		"mapping.status":     mapping.Status,
+	}
+	for customFieldId, column := range mapping.CustomFields {
+		mappedColumns[fmt.Sprintf("mapping.customFields.%d", customFieldId)] = column
+	}
+
+	for key, column := range mappedColumns {
+		_, ok := columnIndexes[column]
+		if len(column) > 0 && !ok {
+			vErr.Errors[key] = fmt.Sprintf("Column %s not found", column)
+		}
+	}
+
+	lookups, err := service.getReceiptCsvImportLookups(command.GroupId)
+	if err != nil {
+		return receiptCsvImport{}, vErr, err
+	}
+
+	for customFieldId := range mapping.CustomFields {
+		_, ok := lookups.customFields[customFieldId]
+		if !ok {
+			vErr.Errors[fmt.Sprintf("mapping.customFields.%d", customFieldId)] = "Custom field not found"
+		}
+	}
+
+	if len(vErr.Errors) > 0 {
+		return receiptCsvImport{}, vErr, nil
+	}
+
+	receiptImport := receiptCsvImport{
+		receipts: make([]commands.UpsertReceiptCommand, 0),
+		preview:  structs.ReceiptCsvImportPreview{Rows: make([]structs.ReceiptCsvImportRow, 0)},
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			vErr.Errors["file"] = fmt.Sprintf("Line %d is not valid csv", line)
+			return receiptCsvImport{}, vErr, nil
+		}
+
+		if len(strings.TrimSpace(strings.Join(record, ""))) == 0 {
+			continue
+		}
+
+		getValue := func(column string) string {
+			index, ok := columnIndexes[column]
+			if len(column) == 0 || !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		receiptCommand, rowErrors := service.buildReceiptCommand(command, lookups, getValue)
+		for key, message := range receiptCommand.Validate(userId, true).Errors {
+			_, hasParseError := rowErrors[key]
+			if !hasParseError {
+				rowErrors[key] = message
+			}
+		}
+
+		receipt, err := receiptCommand.ToReceipt()
+		if err != nil {
+			return receiptCsvImport{}, vErr, err
+		}
+
+		receiptImport.preview.Rows = append(receiptImport.preview.Rows, structs.ReceiptCsvImportRow{
+			Line:    line,
+			Receipt: receipt,
+			Errors:  structs.ValidatorError{Errors: rowErrors},
+		})
+
+		if len(rowErrors) > 0 {
+			receiptImport.preview.InvalidRows++
+			continue
+		}
+
+		receiptImport.preview.ValidRows++
+		receiptImport.receipts = append(receiptImport.receipts, receiptCommand)
+	}
+
+	return receiptImport, vErr, nil
+}
+
+func (service ReceiptCsvImportService) buildReceiptCommand(
+	command commands.ImportReceiptCsvCommand,
+	lookups receiptCsvImportLookups,
+	getValue func(column string) string,
+) (commands.UpsertReceiptCommand, map[string]string) {
+	mapping := *command.Mapping
+	rowErrors := make(map[string]string)
+	receiptCommand := commands.UpsertReceiptCommand{
+		Name:       getValue(mapping.Name),
+		GroupId:    command.GroupId,
+		Status:     command.DefaultStatus,
+		Categories: make([]commands.UpsertCategoryCommand, 0),
+		Tags:       make([]commands.UpsertTagCommand, 0),
+	}
+
+	dateValue := getValue(mapping.Date)
+	if len(dateValue) > 0 {
+		date, err := time.Parse(command.GetDateLayout(), dateValue)
+		if err != nil {
+			rowErrors["date"] = fmt.Sprintf("Date must be formatted as %s", command.DateFormat)
+		}
+		receiptCommand.Date = date
+	}
+
+	amountValue := getValue(mapping.Amount)
+	if len(amountValue) > 0 {
+		amount, err := parseImportDecimal(amountValue, command.DecimalSeparator)
+		if err != nil {
+			rowErrors["amount"] = "Amount is not a number"
+		}
+		receiptCommand.Amount = amount
+	}
+
+	if command.DefaultPaidByUserId != nil {
+		receiptCommand.PaidByUserID = *command.DefaultPaidByUserId
+	}
+
+	paidByValue := getValue(mapping.PaidBy)
+	if len(paidByValue) > 0 {
+		userId, err := findImportUser(lookups.users, paidByValue)
+		if err != nil {
+			rowErrors["paidByUserId"] = err.Error()
+		}
+		receiptCommand.PaidByUserID = userId
+	}
+
+	statusValue := getValue(mapping.Status)
+	if len(statusValue) > 0 {
+		status := models.ReceiptStatus(strings.ReplaceAll(strings.ToUpper(statusValue), " ", "_"))
+		_, err := status.Value()
+		if err != nil {
+			rowErrors["status"] = fmt.Sprintf("Status %s is invalid", statusValue)
+		}
+		receiptCommand.Status = status
+	}
+
+	for _, name := range splitImportList(getValue(mapping.Categories)) {
+		category, ok := lookups.categories[strings.ToLower(name)]
+		if !ok {
+			rowErrors["categories"] = fmt.Sprintf("Category %s does not exist", name)
+			continue
+		}
+		receiptCommand.Categories = append(receiptCommand.Categories, commands.UpsertCategoryCommand{
+			Id:          &category.ID,
+			Name:        category.Name,
+			Description: category.Description,
+			GroupId:     category.GroupId,
+		})
+	}
+
+	for _, name := range splitImportList(getValue(mapping.Tags)) {
+		tag, ok := lookups.tags[strings.ToLower(name)]
+		if !ok {
+			rowErrors["tags"] = fmt.Sprintf("Tag %s does not exist", name)
+			continue
+		}
+		receiptCommand.Tags = append(receiptCommand.Tags, commands.UpsertTagCommand{
+			Id:          &tag.ID,
+			Name:        tag.Name,
+			Description: tag.Description,
+			GroupId:     tag.GroupId,
+		})
+	}
+
+	for customFieldId, column := range mapping.CustomFields {
+		value := getValue(column)
+		if len(value) == 0 {
+			continue
+		}
+
+		customFieldValue, err := parseImportCustomFieldValue(command, lookups.customFields[customFieldId], value)
+		if err != nil {
+			rowErrors[fmt.Sprintf("customFields.%d", customFieldId)] = err.Error()
+			continue
+		}
+		receiptCommand.CustomFields = append(receiptCommand.CustomFields, customFieldValue)
+	}
+
+	return receiptCommand, rowErrors
+}
+
+func (service ReceiptCsvImportService) getReceiptCsvImportLookups(groupId uint) (receiptCsvImportLookups, error) {
+	db := service.GetDB()
+	lookups := receiptCsvImportLookups{
+		users:        make([]models.User, 0),
+		categories:   make(map[string]models.Category),
+		tags:         make(map[string]models.Tag),
+		customFields: make(map[uint]models.CustomField),
+	}
+
+	err := db.Model(&models.User{}).
+		Joins("JOIN group_members ON group_members.user_id = users.id").
+		Where("group_members.group_id = ?", groupId).
+		Find(&lookups.users).Error
+	if err != nil {
+		return lookups, err
+	}
+
+	// Group categories and tags come last, so they win over global ones with the same name
+	categories := make([]models.Category, 0)
+	err = db.Model(&models.Category{}).
+		Where("group_id = ? OR group_id IS NULL", groupId).
+		Order("group_id IS NOT NULL, id").
+		Find(&categories).Error
+	if err != nil {
+		return lookups, err
+	}
+	for _, category := range categories {
+		lookups.categories[strings.ToLower(category.Name)] = category
+	}
+
+	tags := make([]models.Tag, 0)
+	err = db.Model(&models.Tag{}).
+		Where("group_id = ? OR group_id IS NULL", groupId).
+		Order("group_id IS NOT NULL, id").
+		Find(&tags).Error
+	if err != nil {
+		return lookups, err
+	}
+	for _, tag := range tags {
+		lookups.tags[strings.ToLower(tag.Name)] = tag
+	}
+
+	customFields := make([]models.CustomField, 0)
+	err = db.Model(&models.CustomField{}).
+		Where("group_id = ? OR group_id IS NULL", groupId).
+		Preload("Options").
+		Find(&customFields).Error
+	if err != nil {
+		return lookups, err
+	}
+	for _, customField := range customFields {
+		lookups.customFields[customField.ID] = customField
+	}
+
+	return lookups, nil
+}
+
+// findImportUser matches a group member by display name, which the export writes, or by username
+func findImportUser(users []models.User, value string) (uint, error) {
+	var matchedUserId uint
+	matches := 0
+
+	for _, user := range users {
+		if strings.EqualFold(user.Username, value) {
+			return user.ID, nil
+		}
+
+		if strings.EqualFold(user.DisplayName, value) {
+			matchedUserId = user.ID
+			matches++
+		}
+	}
+
+	if matches == 0 {
+		return 0, fmt.Errorf("User %s is not a member of the group", value)
+	}
+
+	if matches > 1 {
+		return 0, fmt.Errorf("More than one group member is named %s", value)
+	}
+
+	return matchedUserId, nil
+}
+
+// parseImportDecimal reads amounts like 1.234,56 or $1,234.56, everything but digits and separators is dropped
+func parseImportDecimal(value string, decimalSeparator models.CurrencySeparator) (decimal.Decimal, error) {
+	value = nonNumericRegex.ReplaceAllString(value, "")
+
+	if decimalSeparator == models.COMMA {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	return decimal.NewFromString(value)
+}
+
+func parseImportCustomFieldValue(command commands.ImportReceiptCsvCommand, customField models.CustomField, value string) (commands.UpsertCustomFieldValueCommand, error) {
+	customFieldValue := commands.UpsertCustomFieldValueCommand{CustomFieldId: customField.ID}
+
+	switch customField.Type {
+	case models.TEXT:
+		customFieldValue.StringValue = &value
+	case models.DATE:
+		date, err := time.Parse(command.GetDateLayout(), value)
+		if err != nil {
+			return customFieldValue, fmt.Errorf("%s must be formatted as %s", customField.Name, command.DateFormat)
+		}
+		customFieldValue.DateValue = &date
+	case models.CURRENCY:
+		amount, err := parseImportDecimal(value, command.DecimalSeparator)
+		if err != nil {
+			return customFieldValue, fmt.Errorf("%s is not a number", customField.Name)
+		}
+		customFieldValue.CurrencyValue = &amount
+	case models.BOOLEAN:
+		switch strings.ToLower(value) {
+		case "true", "yes", "1":
+			booleanValue := true
+			customFieldValue.BooleanValue = &booleanValue
+		case "false", "no", "0":
+			booleanValue := false
+			customFieldValue.BooleanValue = &booleanValue
+		default:
+			return customFieldValue, fmt.Errorf("%s must be true or false", customField.Name)
+		}
+	case models.SELECT:
+		for _, option := range customField.Options {
+			if strings.EqualFold(option.Value, value) {
+				optionId := option.ID
+				customFieldValue.SelectValue = &optionId
+				return customFieldValue, nil
+			}
+		}
+		return customFieldValue, fmt.Errorf("%s has no option %s", customField.Name, value)
+	}
+
+	return customFieldValue, nil
+}
+
+func splitImportList(value string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(value, constants.ReceiptCsvListSeparator) {
+		name = strings.TrimSpace(name)
+		if len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func setUpReceiptCsvImportTest() {
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+	db.Model(&models.User{}).Where("id = ?", 2).Update("display_name", "Jim")
+	db.Create(&models.Category{Name: "Groceries"})
+	db.Create(&models.Tag{Name: "Bill"})
+}
+
+func TestShouldPreviewExportedReceiptCsv(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpReceiptCsvImportTest()
+
+	date := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	receiptCsvService := NewReceiptCsvService()
+	exported, err := receiptCsvService.BuildReceiptCsv([]models.Receipt{
+		{
+			BaseModel:  models.BaseModel{ID: 1, CreatedAt: date},
+			Date:       date,
+			Name:       "test",
+			PaidByUser: models.User{DisplayName: "Jim"},
+			Amount:     decimal.NewFromFloat(123.45),
+			Status:     models.NEEDS_ATTENTION,
+			Categories: []models.Category{{Name: "Groceries"}},
+			Tags:       []models.Tag{{Name: "Bill"}},
+		},
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	command := commands.ImportReceiptCsvCommand{Csv: exported.ReceiptCsvBytes, GroupId: 1}
+	preview, vErr, err := NewReceiptCsvImportService(nil).PreviewReceiptCsvImport(command, 1)
+	if err != nil || len(vErr.Errors) > 0 {
+		utils.PrintTestError(t, vErr, err)
+		return
+	}
+
+	if preview.ValidRows != 1 || preview.InvalidRows != 0 {
+		utils.PrintTestError(t, preview, "1 valid row")
+		return
+	}
+
+	receipt := preview.Rows[0].Receipt
+	if receipt.Name != "test" ||
+		!receipt.Date.Equal(date) ||
+		!receipt.Amount.Equal(decimal.NewFromFloat(123.45)) ||
+		receipt.PaidByUserID != 2 ||
+		receipt.Status != models.NEEDS_ATTENTION ||
+		len(receipt.Categories) != 1 ||
+		len(receipt.Tags) != 1 {
+		utils.PrintTestError(t, receipt, "exported receipt")
+	}
+
+	if preview.Rows[0].Line != 2 {
+		utils.PrintTestError(t, preview.Rows[0].Line, 2)
+	}
+}
+
+func TestShouldReportRowErrorsInReceiptCsvPreview(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpReceiptCsvImportTest()
+
+	csv := "Name,Receipt Date,Amount,Paid By,Categories,Tags,Status\n" +
+		"bad date,2025-13-01,10,Jim,,,OPEN\n" +
+		"bad amount,2025-01-01,ten,Jim,,,OPEN\n" +
+		"unknown category,2025-01-01,10,Jim,Unknown,,OPEN\n" +
+		"ambiguous user,2025-01-01,10,asdf,,,OPEN\n" +
+		"unknown status,2025-01-01,10,test,,,LOST\n" +
+		",2025-01-01,10,test,,,OPEN\n"
+
+	command := commands.ImportReceiptCsvCommand{Csv: []byte(csv), GroupId: 1}
+	preview, vErr, err := NewReceiptCsvImportService(nil).PreviewReceiptCsvImport(command, 1)
+	if err != nil || len(vErr.Errors) > 0 {
+		utils.PrintTestError(t, vErr, err)
+		return
+	}
+
+	expectedErrorKeys := []string{"date", "amount", "categories", "paidByUserId", "status", "name"}
+	if preview.InvalidRows != len(expectedErrorKeys) || len(preview.Rows) != len(expectedErrorKeys) {
+		utils.PrintTestError(t, preview.InvalidRows, len(expectedErrorKeys))
+		return
+	}
+
+	for i, key := range expectedErrorKeys {
+		_, ok := preview.Rows[i].Errors.Errors[key]
+		if !ok {
+			utils.PrintTestError(t, preview.Rows[i].Errors, key)
+		}
+	}
+}
+
+func TestShouldPreviewReceiptCsvWithCustomMappingAndFormats(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpReceiptCsvImportTest()
+
+	customField := models.CustomField{Name: "Paid", Type: models.BOOLEAN}
+	repositories.GetDB().Create(&customField)
+
+	csv := "Beschreibung;Datum;Betrag;Bezahlt\n" +
+		"Einkauf;31.01.2025;1.234,56;ja\n"
+
+	paidByUserId := uint(1)
+	command := commands.ImportReceiptCsvCommand{
+		Csv:     []byte(csv),
+		GroupId: 1,
+		Mapping: &commands.ReceiptCsvColumnMapping{
+			Name:         "Beschreibung",
+			Date:         "Datum",
+			Amount:       "Betrag",
+			CustomFields: map[uint]string{customField.ID: "Bezahlt"},
+		},
+		DateFormat:          "DD.MM.YYYY",
+		DecimalSeparator:    models.COMMA,
+		Delimiter:           ";",
+		DefaultPaidByUserId: &paidByUserId,
+		DefaultStatus:       models.OPEN,
+	}
+
+	preview, vErr, err := NewReceiptCsvImportService(nil).PreviewReceiptCsvImport(command, 1)
+	if err != nil || len(vErr.Errors) > 0 {
+		utils.PrintTestError(t, vErr, err)
+		return
+	}
+
+	if preview.InvalidRows != 1 || len(preview.Rows) != 1 {
+		utils.PrintTestError(t, preview, "1 invalid row")
+		return
+	}
+
+	// ja is not a boolean
+	_, ok := preview.Rows[0].Errors.Errors["customFields."+utils.UintToString(customField.ID)]
+	if !ok {
+		utils.PrintTestError(t, preview.Rows[0].Errors, "custom field error")
+	}
+
+	receipt := preview.Rows[0].Receipt
+	if !receipt.Amount.Equal(decimal.NewFromFloat(1234.56)) || !receipt.Date.Equal(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		utils.PrintTestError(t, receipt, "1234.56 on 2025-01-31")
+	}
+}
+
+func TestShouldRejectReceiptCsvWithMissingColumn(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpReceiptCsvImportTest()
+
+	command := commands.ImportReceiptCsvCommand{Csv: []byte("Name,Amount\ntest,10\n"), GroupId: 1}
+	_, vErr, err := NewReceiptCsvImportService(nil).PreviewReceiptCsvImport(command, 1)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	_, ok := vErr.Errors["mapping.date"]
+	if !ok {
+		utils.PrintTestError(t, vErr, "mapping.date error")
+	}
+}
+
+func TestShouldImportReceiptCsv(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpReceiptCsvImportTest()
+	db := repositories.GetDB()
+
+	csv := "Name,Receipt Date,Amount,Paid By,Categories,Tags,Status\n" +
+		"first,2025-01-01,10,Jim,Groceries,Bill,OPEN\n" +
+		"second,2025-01-02,\"1,000.50\",test,,,resolved\n"
+
+	command := commands.ImportReceiptCsvCommand{Csv: []byte(csv), GroupId: 1}
+	systemTask, err := NewReceiptCsvImportService(nil).ImportReceiptCsv(command, 1, "")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if systemTask.Type != models.RECEIPT_CSV_IMPORT || systemTask.Status != models.SYSTEM_TASK_SUCCEEDED {
+		utils.PrintTestError(t, systemTask, "succeeded csv import task")
+	}
+
+	var receipts []models.Receipt
+	db.Model(&models.Receipt{}).Where("group_id = ?", 1).Preload("Categories").Preload("Tags").Order("id").Find(&receipts)
+	if len(receipts) != 2 {
+		utils.PrintTestError(t, len(receipts), 2)
+		return
+	}
+
+	if len(receipts[0].Categories) != 1 || len(receipts[0].Tags) != 1 {
+		utils.PrintTestError(t, receipts[0], "receipt with category and tag")
+	}
+
+	if receipts[1].Status != models.RESOLVED || !receipts[1].Amount.Equal(decimal.NewFromFloat(1000.50)) {
+		utils.PrintTestError(t, receipts[1], "resolved receipt of 1000.50")
+	}
+
+	if receipts[1].CreatedBy == nil || *receipts[1].CreatedBy != 1 {
+		utils.PrintTestError(t, receipts[1].CreatedBy, 1)
+	}
+}
+
+func TestShouldNotImportReceiptCsvWithInvalidRows(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	setUpReceiptCsvImportTest()
+	db := repositories.GetDB()
+
+	csv := "Name,Receipt Date,Amount,Paid By,Categories,Tags,Status\n" +
+		"first,2025-01-01,10,Jim,,,OPEN\n" +
+		"second,2025-01-02,10,nobody,,,OPEN\n"
+
+	command := commands.ImportReceiptCsvCommand{Csv: []byte(csv), GroupId: 1}
+	systemTask, err := NewReceiptCsvImportService(nil).ImportReceiptCsv(command, 1, "")
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	if systemTask.Status != models.SYSTEM_TASK_FAILED {
+		utils.PrintTestError(t, systemTask.Status, models.SYSTEM_TASK_FAILED)
+	}
+
+	var receiptCount int64
+	db.Model(&models.Receipt{}).Count(&receiptCount)
+	if receiptCount != 0 {
+		utils.PrintTestError(t, receiptCount, 0)
+	}
+}
@@ -1,8 +1,14 @@
 package services
 
 import (
+	"archive/zip"
+	"bytes"
 	"github.com/shopspring/decimal"
+	"io"
 	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
 	"testing"
 	"time"
@@ -110,3 +116,149 @@ func TestShouldBuildItemCsv(t *testing.T) {
 		utils.PrintTestError(t, string(result), expected)
 	}
 }
+
+func TestShouldBuildReceiptCsvWithCustomFieldsAndImages(t *testing.T) {
+	expected :=
+		"Id,Added At,Receipt Date,Name,Paid By,Amount,Status,Categories,Tags,Resolved Date,Store (1),Warranty,Store (3),Paid With,Images\n" +
+			"1,2025-01-01,2025-01-01,test,Jim,10,OPEN,,,,Corner shop,2026-01-01,,Card,\"images/1/4-a_b.jpg,images/1/5-scan.pdf\"\n" +
+			"2,2025-01-01,2025-01-01,other,Jim,5,OPEN,,,,,,Market,,\n"
+
+	date := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	warrantyDate := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := "Corner shop"
+	market := "Market"
+	selectedOption := uint(7)
+
+	storeField := models.CustomField{BaseModel: models.BaseModel{ID: 1}, Name: "Store", Type: models.TEXT}
+	otherStoreField := models.CustomField{BaseModel: models.BaseModel{ID: 3}, Name: "Store", Type: models.TEXT}
+	warrantyField := models.CustomField{BaseModel: models.BaseModel{ID: 2}, Name: "Warranty", Type: models.DATE}
+	paidWithField := models.CustomField{
+		BaseModel: models.BaseModel{ID: 4},
+		Name:      "Paid With",
+		Type:      models.SELECT,
+		Options:   []models.CustomFieldOption{{BaseModel: models.BaseModel{ID: 7}, Value: "Card"}},
+	}
+
+	receipts := []models.Receipt{
+		{
+			BaseModel:  models.BaseModel{ID: 1, CreatedAt: date},
+			Date:       date,
+			Name:       "test",
+			PaidByUser: models.User{DisplayName: "Jim"},
+			Amount:     decimal.NewFromInt(10),
+			Status:     models.OPEN,
+			CustomFields: []models.CustomFieldValue{
+				{CustomFieldId: 4, CustomField: paidWithField, SelectValue: &selectedOption},
+				{CustomFieldId: 2, CustomField: warrantyField, DateValue: &warrantyDate},
+				{CustomFieldId: 1, CustomField: storeField, StringValue: &store},
+			},
+			ImageFiles: []models.FileData{
+				{BaseModel: models.BaseModel{ID: 4}, ReceiptId: 1, Name: "a/b.jpg"},
+				{BaseModel: models.BaseModel{ID: 5}, ReceiptId: 1, Name: "scan.pdf"},
+			},
+		},
+		{
+			BaseModel:  models.BaseModel{ID: 2, CreatedAt: date},
+			Date:       date,
+			Name:       "other",
+			PaidByUser: models.User{DisplayName: "Jim"},
+			Amount:     decimal.NewFromInt(5),
+			Status:     models.OPEN,
+			CustomFields: []models.CustomFieldValue{
+				{CustomFieldId: 3, CustomField: otherStoreField, StringValue: &market},
+			},
+		},
+	}
+
+	service := NewReceiptCsvService()
+	service.Options = structs.ReceiptCsvOptions{IncludeCustomFields: true, IncludeImages: true}
+
+	result, err := service.BuildReceiptCsv(receipts)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if string(result.ReceiptCsvBytes) != expected {
+		utils.PrintTestError(t, string(result.ReceiptCsvBytes), expected)
+	}
+
+	if result.CommentCsvBytes != nil {
+		utils.PrintTestError(t, string(result.CommentCsvBytes), "no comments csv")
+	}
+}
+
+func TestShouldZipCommentsAndOriginalImages(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+	defer storage.SetStorage(nil)
+
+	date := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	parentId := uint(1)
+	fileData := models.FileData{BaseModel: models.BaseModel{ID: 2}, ReceiptId: 1, Name: "receipt.png"}
+
+	fileRepository := repositories.NewFileRepository(nil)
+	err := fileRepository.WriteFile(fileRepository.BuildFileDataKey(fileData), []byte("original"))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	receipts := []models.Receipt{
+		{
+			BaseModel:  models.BaseModel{ID: 1, CreatedAt: date},
+			Date:       date,
+			Name:       "test",
+			PaidByUser: models.User{DisplayName: "Jim"},
+			Amount:     decimal.NewFromInt(10),
+			Status:     models.OPEN,
+			Comments: []models.Comment{
+				{BaseModel: models.BaseModel{ID: 1, CreatedAt: date}, ReceiptId: 1, Comment: "Who paid?", User: models.User{DisplayName: "Jane"}},
+				{BaseModel: models.BaseModel{ID: 2, CreatedAt: date}, ReceiptId: 1, Comment: "Jim, with cash", CommentId: &parentId, User: models.User{DisplayName: "Jim"}},
+			},
+			ImageFiles: []models.FileData{fileData},
+		},
+	}
+
+	service := NewReceiptCsvService()
+	service.Options = structs.ReceiptCsvOptions{IncludeComments: true, IncludeImages: true}
+
+	zipBytes, err := service.GetZippedCsvFiles(receipts)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		fileReader, err := file.Open()
+		if err != nil {
+			utils.PrintTestError(t, err, nil)
+			return
+		}
+		content, _ := io.ReadAll(fileReader)
+		fileReader.Close()
+		files[file.Name] = string(content)
+	}
+
+	expectedComments := "Id,Receipt Id,Receipt Name,Reply To,User,Added At,Comment\n" +
+		"1,1,test,,Jane,2025-01-01,Who paid?\n" +
+		"2,1,test,1,Jim,2025-01-01,\"Jim, with cash\"\n"
+	if files["comments.csv"] != expectedComments {
+		utils.PrintTestError(t, files["comments.csv"], expectedComments)
+	}
+
+	if files["images/1/2-receipt.png"] != "original" {
+		utils.PrintTestError(t, files["images/1/2-receipt.png"], "original")
+	}
+
+	if len(files) != 4 {
+		utils.PrintTestError(t, len(files), 4)
+	}
+}
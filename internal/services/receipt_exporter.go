@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+
+	"gorm.io/gorm"
+)
+
+// ReceiptExporter writes receipts in one export format
+type ReceiptExporter interface {
+	Export(receipts []models.Receipt) ([]byte, error)
+	GetFileName() string
+	GetContentType() string
+}
+
+type ExportService struct {
+	BaseService
+}
+
+func NewExportService(tx *gorm.DB) ExportService {
+	service := ExportService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+// ExportReceipts writes receipts with the exporter of the format, csv is the default
+func (service ExportService) ExportReceipts(command commands.ExportReceiptsCommand, receipts []models.Receipt) (structs.ReceiptExport, error) {
+	exporter, err := service.GetReceiptExporter(command, receipts)
+	if err != nil {
+		return structs.ReceiptExport{}, err
+	}
+
+	bytes, err := exporter.Export(receipts)
+	if err != nil {
+		return structs.ReceiptExport{}, err
+	}
+
+	return structs.ReceiptExport{
+		Bytes:       bytes,
+		FileName:    exporter.GetFileName(),
+		ContentType: exporter.GetContentType(),
+	}, nil
+}
+
+// GetReceiptExporter returns the exporter of a format, ledger formats use the ledger settings of the receipts' groups
+func (service ExportService) GetReceiptExporter(command commands.ExportReceiptsCommand, receipts []models.Receipt) (ReceiptExporter, error) {
+	format := command.Format
+	if len(format) == 0 || format == models.EXPORT_CSV {
+		receiptCsvService := NewReceiptCsvService()
+		receiptCsvService.Options = command.ReceiptCsvOptions
+		return &receiptCsvService, nil
+	}
+
+	if format == models.EXPORT_PDF {
+		return NewExpenseReportPdfExporter(service.TX), nil
+	}
+
+	groupIds := make([]uint, 0)
+	seenGroupIds := make(map[uint]bool)
+	for _, receipt := range receipts {
+		if !seenGroupIds[receipt.GroupId] {
+			seenGroupIds[receipt.GroupId] = true
+			groupIds = append(groupIds, receipt.GroupId)
+		}
+	}
+
+	groupLedgerSettingsRepository := repositories.NewGroupLedgerSettingsRepository(service.TX)
+	settingsByGroupId, err := groupLedgerSettingsRepository.GetGroupLedgerSettingsByGroupIds(groupIds)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case models.EXPORT_OFX:
+		return NewOfxReceiptExporter(settingsByGroupId), nil
+	case models.EXPORT_QIF:
+		return NewQifReceiptExporter(settingsByGroupId), nil
+	case models.EXPORT_BEANCOUNT:
+		return NewBeancountReceiptExporter(settingsByGroupId), nil
+	case models.EXPORT_HLEDGER:
+		return NewHledgerReceiptExporter(settingsByGroupId), nil
+	}
+
+	return nil, fmt.Errorf("unsupported export format %s", format)
+}
+
+// GetExportReceiptAssociations returns the associations every export format needs
+func GetExportReceiptAssociations() []string {
+	return []string{
+		"PaidByUser",
+		"Categories",
+		"Tags",
+		"ImageFiles",
+		"Comments",
+		"Comments.User",
+		"CustomFields",
+		"CustomFields.CustomField",
+		"CustomFields.CustomField.Options",
+		"ReceiptItems",
+		"ReceiptItems.Categories",
+		"ReceiptItems.Tags",
+		"ReceiptItems.ChargedToUser",
+		"ReceiptItems.Receipt",
+	}
+}
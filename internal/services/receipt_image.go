@@ -3,17 +3,18 @@ package services
 import (
 	"os"
 	"receipt-wrangler/api/internal/commands"
-	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/logging"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/structs"
 	"receipt-wrangler/api/internal/utils"
 	"sync"
+
+	"gorm.io/gorm"
 )
 
 func ReadReceiptImage(receiptImageId string) (commands.UpsertReceiptCommand, commands.ReceiptProcessingMetadata, error) {
 	var result commands.UpsertReceiptCommand
-	var pathToReadFrom string
 	receiptService := NewReceiptService(nil)
 
 	receipt, err := receiptService.GetReceiptByReceiptImageId(receiptImageId)
@@ -40,34 +41,21 @@ func ReadReceiptImage(receiptImageId string) (commands.UpsertReceiptCommand, com
 	}
 	fileRepository := repositories.NewFileRepository(nil)
 
-	receiptImagePath, err := fileRepository.BuildFilePath(utils.UintToString(receiptImage.ReceiptId), receiptImageId, receiptImage.Name)
+	receiptImagePath, err := fileRepository.WriteFileDataToTempFile(receiptImage)
 	if err != nil {
 		return result, commands.ReceiptProcessingMetadata{}, err
 	}
+	defer os.Remove(receiptImagePath)
 
-	receiptImageBytes, err := utils.ReadFile(receiptImagePath)
-	if err != nil {
-		return commands.UpsertReceiptCommand{}, commands.ReceiptProcessingMetadata{}, err
-	}
-
-	// TODO: make generic
-	if receiptImage.FileType == constants.ApplicationPdf {
-		bytes, err := fileRepository.ConvertPdfToJpg(receiptImageBytes)
-		if err != nil {
-			return commands.UpsertReceiptCommand{}, commands.ReceiptProcessingMetadata{}, err
-		}
-
-		pathToReadFrom, err = fileRepository.WriteTempFile(bytes)
-		if err != nil {
-			return commands.UpsertReceiptCommand{}, commands.ReceiptProcessingMetadata{}, err
-		}
+	// Pdfs are read as is, so each page is handled on its own
+	result, metadata, err := systemReceiptProcessingService.ReadReceiptImage(receiptImagePath)
 
-		defer os.Remove(pathToReadFrom)
-	} else {
-		pathToReadFrom = receiptImagePath
+	updateErr := receiptImageRepository.UpdateReceiptImageBarcodes(receiptImage.ID, metadata.Barcodes)
+	if updateErr != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, "Error storing barcodes: ", updateErr.Error())
 	}
 
-	return systemReceiptProcessingService.ReadReceiptImage(pathToReadFrom)
+	return result, metadata, err
 }
 
 func ReadReceiptImageFromFileOnly(path string, groupId string) (commands.UpsertReceiptCommand, commands.ReceiptProcessingMetadata, error) {
@@ -86,11 +74,20 @@ func MagicFillFromImage(command commands.MagicFillCommand, groupId string) (comm
 		return commands.UpsertReceiptCommand{}, commands.ReceiptProcessingMetadata{}, err
 	}
 
-	bytes, err := fileRepository.GetBytesFromImageBytes(command.ImageData)
+	isPdf, err := fileRepository.IsPdf(command.ImageData)
 	if err != nil {
 		return commands.UpsertReceiptCommand{}, commands.ReceiptProcessingMetadata{}, err
 	}
 
+	// Pdfs are read as is, so each page is handled on its own
+	bytes := command.ImageData
+	if !isPdf {
+		bytes, err = fileRepository.GetBytesFromImageBytes(command.ImageData)
+		if err != nil {
+			return commands.UpsertReceiptCommand{}, commands.ReceiptProcessingMetadata{}, err
+		}
+	}
+
 	filePath, err := fileRepository.WriteTempFile(bytes)
 	if err != nil {
 		return commands.UpsertReceiptCommand{}, commands.ReceiptProcessingMetadata{}, err
@@ -180,7 +177,7 @@ func ReadAllReceiptImagesForGroup(groupId string, userId string) ([]structs.OcrE
 			// Acquire a semaphore slot
 			semaphore <- struct{}{}
 
-			filePath, err := fileRepository.BuildFilePath(utils.UintToString(fd.ReceiptId), utils.UintToString(fd.ID), fd.Name)
+			filePath, err := fileRepository.WriteFileDataToTempFile(fd)
 			if err != nil {
 				results <- structs.OcrExport{OcrText: "", Filename: "", Err: err}
 			} else {
@@ -191,6 +188,7 @@ func ReadAllReceiptImagesForGroup(groupId string, userId string) ([]structs.OcrE
 				}
 				ocrService := NewOcrService(nil, systemReceiptProcessingSettings.ReceiptProcessingSettings)
 				ocrText, _, err := ocrService.ReadImage(filePath)
+				os.Remove(filePath)
 				if err != nil {
 					results <- structs.OcrExport{OcrText: "", Filename: "", Err: err}
 				}
@@ -214,3 +212,67 @@ func ReadAllReceiptImagesForGroup(groupId string, userId string) ([]structs.OcrE
 
 	return ocrExportResults, nil
 }
+
+// BackfillReceiptImagePreviews generates the previews missing for images uploaded before previews existed
+func BackfillReceiptImagePreviews() (structs.ImagePreviewBackfillResult, error) {
+	db := repositories.GetDB()
+	fileRepository := repositories.NewFileRepository(nil)
+	receiptImageRepository := repositories.NewReceiptImageRepository(nil)
+	result := structs.ImagePreviewBackfillResult{}
+	fileDataBatch := make([]models.FileData, 0)
+
+	err := db.Model(&models.FileData{}).FindInBatches(&fileDataBatch, 100, func(tx *gorm.DB, batch int) error {
+		for _, fileData := range fileDataBatch {
+			for _, size := range models.GetPreviewImageSizes() {
+				exists, err := fileRepository.FileExists(fileRepository.BuildFileDataPreviewKey(fileData, size))
+				if err != nil {
+					return err
+				}
+
+				if exists {
+					continue
+				}
+
+				_, err = receiptImageRepository.GetReceiptImagePreview(fileData, size)
+				if err != nil {
+					logging.LogStd(logging.LOG_LEVEL_ERROR, "Error generating preview for file ", fileData.ID, ": ", err.Error())
+					result.FailedFiles++
+					break
+				}
+
+				result.GeneratedPreviews++
+			}
+		}
+
+		return nil
+	}).Error
+
+	logging.LogStd(
+		logging.LOG_LEVEL_INFO,
+		"Preview backfill finished, generated: ", result.GeneratedPreviews,
+		" failed: ", result.FailedFiles,
+	)
+
+	return result, err
+}
+
+// GetReceiptImageBlob returns the blob with the given hash, as long as one of the groups already has a receipt image using it
+func GetReceiptImageBlob(hash string, groupIds []uint) (models.FileBlob, error) {
+	db := repositories.GetDB()
+	fileBlobRepository := repositories.NewFileBlobRepository(nil)
+	var count int64
+
+	err := db.Model(&models.FileData{}).
+		Joins("inner join receipts on receipts.id = file_data.receipt_id").
+		Where("file_data.blob_hash = ? AND receipts.group_id IN ?", hash, groupIds).
+		Count(&count).Error
+	if err != nil {
+		return models.FileBlob{}, err
+	}
+
+	if count == 0 {
+		return models.FileBlob{}, gorm.ErrRecordNotFound
+	}
+
+	return fileBlobRepository.GetFileBlobByHash(hash)
+}
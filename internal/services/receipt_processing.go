@@ -6,6 +6,7 @@ import (
 	"gorm.io/gorm"
 	"os"
 	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/logging"
 	"receipt-wrangler/api/internal/models"
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/structs"
@@ -88,9 +89,19 @@ func (service ReceiptProcessingService) ReadReceiptImage(
 	var receipt commands.UpsertReceiptCommand
 	metadata := commands.ReceiptProcessingMetadata{}
 
+	// Barcodes only add information, the receipt is still processed without them
+	barcodeService := NewBarcodeService(service.TX)
+	barcodes, barcodeErr := barcodeService.ReadBarcodes(imagePath)
+	if barcodeErr != nil {
+		logging.LogStd(logging.LOG_LEVEL_INFO, "Could not read barcodes: ", barcodeErr.Error())
+		barcodes = []models.Barcode{}
+	}
+	metadata.Barcodes = barcodes
+
 	result, err := service.processImage(
 		imagePath,
 		service.ReceiptProcessingSettings,
+		barcodes,
 	)
 	metadata.OcrSystemTaskCommand = result.OcrSystemTaskCommand
 	metadata.PromptSystemTaskCommand = result.PromptSystemTaskCommand
@@ -104,6 +115,7 @@ func (service ReceiptProcessingService) ReadReceiptImage(
 			fallbackResult, fallbackErr := service.processImage(
 				imagePath,
 				service.FallbackReceiptProcessingSettings,
+				barcodes,
 			)
 			metadata.FallbackReceiptProcessingSettingsIdRan = service.FallbackReceiptProcessingSettings.ID
 			metadata.FallbackOcrSystemTaskCommand = fallbackResult.OcrSystemTaskCommand
@@ -126,45 +138,65 @@ func (service ReceiptProcessingService) ReadReceiptImage(
 		receipt = result.Receipt
 	}
 
+	if err == nil {
+		fiscalReceipt, ok := barcodeService.GetFiscalReceipt(barcodes)
+		if ok {
+			receipt = service.applyFiscalReceipt(receipt, fiscalReceipt)
+		}
+	}
+
 	return receipt, metadata, err
 }
 
+// applyFiscalReceipt overrides the model's answer with the fields read from a fiscal QR code, they are exact
+func (service ReceiptProcessingService) applyFiscalReceipt(
+	receipt commands.UpsertReceiptCommand,
+	fiscalReceipt structs.FiscalReceipt,
+) commands.UpsertReceiptCommand {
+	if len(fiscalReceipt.Merchant) > 0 {
+		receipt.Name = fiscalReceipt.Merchant
+	}
+
+	if fiscalReceipt.Amount != nil {
+		receipt.Amount = *fiscalReceipt.Amount
+	}
+
+	if fiscalReceipt.Date != nil {
+		date := *fiscalReceipt.Date
+		receipt.Date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	return receipt
+}
+
 func (service ReceiptProcessingService) processImage(
 	imagePath string,
 	receiptProcessingSettings models.ReceiptProcessingSettings,
+	barcodes []models.Barcode,
 ) (commands.ReceiptProcessingResult, error) {
 	aiMessages := []structs.AiClientMessage{}
 	receipt := commands.UpsertReceiptCommand{}
 	result := commands.ReceiptProcessingResult{}
 	ocrText := ""
-	base64Image := ""
+	lowConfidenceWords := []string{}
+	base64Images := []string{}
 
 	if receiptProcessingSettings.IsVisionModel {
-		if receiptProcessingSettings.AiType == models.OLLAMA {
-			ollamaImage, err := service.getOllamaBase64Image(imagePath)
-			if err != nil {
-				return result, err
-			}
-
-			base64Image = ollamaImage
+		visionImagePaths, err := service.getVisionImagePaths(imagePath)
+		if err != nil {
+			return result, err
 		}
+		defer service.removeTempPages(imagePath, visionImagePaths)
 
-		if receiptProcessingSettings.AiType == models.OPEN_AI_NEW || receiptProcessingSettings.AiType == models.OPEN_AI_CUSTOM {
-			openAiImage, err := service.getOpenAiBase64Image(imagePath)
+		for _, visionImagePath := range visionImagePaths {
+			base64Image, err := service.getBase64Image(visionImagePath, receiptProcessingSettings)
 			if err != nil {
 				return result, err
 			}
 
-			base64Image = openAiImage
-		}
-
-		if receiptProcessingSettings.AiType == models.GEMINI_NEW {
-			geminiImage, err := service.getGeminiImage(imagePath)
-			if err != nil {
-				return result, err
+			if len(base64Image) > 0 {
+				base64Images = append(base64Images, base64Image)
 			}
-
-			base64Image = geminiImage
 		}
 	} else {
 		ocrService := NewOcrService(service.TX, receiptProcessingSettings)
@@ -175,9 +207,10 @@ func (service ReceiptProcessingService) processImage(
 		}
 
 		ocrText = resultText
+		lowConfidenceWords = ocrService.GetLowConfidenceWords(ocrSystemTaskCommand.OcrWords)
 	}
 
-	prompt, promptSystemTask, err := service.buildPrompt(receiptProcessingSettings, ocrText)
+	prompt, promptSystemTask, err := service.buildPrompt(receiptProcessingSettings, ocrText, lowConfidenceWords, barcodes)
 	result.PromptSystemTaskCommand = promptSystemTask
 	if err != nil {
 		return result, err
@@ -187,8 +220,8 @@ func (service ReceiptProcessingService) processImage(
 		Role:    "user",
 		Content: prompt,
 	}
-	if len(base64Image) > 0 {
-		message.Images = []string{base64Image}
+	if len(base64Images) > 0 {
+		message.Images = base64Images
 	}
 
 	aiMessages = append(aiMessages, message)
@@ -224,6 +257,64 @@ func (service ReceiptProcessingService) cleanResponse(response string) string {
 	return response
 }
 
+// getVisionImagePaths returns the image itself, or one rendered image per page for pdfs
+func (service ReceiptProcessingService) getVisionImagePaths(imagePath string) ([]string, error) {
+	fileRepository := repositories.NewFileRepository(service.TX)
+	fileBytes, err := utils.ReadFile(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	isPdf, err := fileRepository.IsPdf(fileBytes)
+	if err != nil || !isPdf {
+		return []string{imagePath}, nil
+	}
+
+	pages, err := fileRepository.ConvertPdfToJpgPages(fileBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	pagePaths := make([]string, 0, len(pages))
+	for _, page := range pages {
+		pagePath, err := fileRepository.WriteTempFile(page)
+		if err != nil {
+			service.removeTempPages(imagePath, pagePaths)
+			return nil, err
+		}
+
+		pagePaths = append(pagePaths, pagePath)
+	}
+
+	return pagePaths, nil
+}
+
+func (service ReceiptProcessingService) removeTempPages(imagePath string, pagePaths []string) {
+	for _, pagePath := range pagePaths {
+		if pagePath != imagePath {
+			os.Remove(pagePath)
+		}
+	}
+}
+
+func (service ReceiptProcessingService) getBase64Image(imagePath string, receiptProcessingSettings models.ReceiptProcessingSettings) (string, error) {
+	if receiptProcessingSettings.AiType == models.OLLAMA {
+		return service.getOllamaBase64Image(imagePath)
+	}
+
+	if receiptProcessingSettings.AiType == models.OPEN_AI_NEW ||
+		receiptProcessingSettings.AiType == models.OPEN_AI_CUSTOM ||
+		receiptProcessingSettings.AiType == models.OPEN_AI_CUSTOM_NEW {
+		return service.getOpenAiBase64Image(imagePath)
+	}
+
+	if receiptProcessingSettings.AiType == models.GEMINI_NEW {
+		return service.getGeminiImage(imagePath)
+	}
+
+	return "", nil
+}
+
 // TODO: move to new ai client
 func (service ReceiptProcessingService) getOllamaBase64Image(imagePath string) (string, error) {
 	mw := imagick.NewMagickWand()
@@ -267,6 +358,8 @@ func (service ReceiptProcessingService) getGeminiImage(imagePath string) (string
 func (service ReceiptProcessingService) buildPrompt(
 	receiptProcessingSettings models.ReceiptProcessingSettings,
 	ocrText string,
+	lowConfidenceWords []string,
+	barcodes []models.Barcode,
 ) (string, commands.UpsertSystemTaskCommand, error) {
 	systemTaskCommand := commands.UpsertSystemTaskCommand{
 		Type:                 models.PROMPT_GENERATED,
@@ -290,7 +383,7 @@ func (service ReceiptProcessingService) buildPrompt(
 		return "", systemTaskCommand, err
 	}
 
-	templateVariableMap, err := service.buildTemplateVariableMap(ocrText)
+	templateVariableMap, err := service.buildTemplateVariableMap(ocrText, lowConfidenceWords, barcodes)
 	if err != nil {
 		systemTaskCommand.Status = models.SYSTEM_TASK_FAILED
 		systemTaskCommand.ResultDescription = err.Error()
@@ -313,7 +406,11 @@ func (service ReceiptProcessingService) buildPrompt(
 	return realPrompt, systemTaskCommand, nil
 }
 
-func (service ReceiptProcessingService) buildTemplateVariableMap(ocrText string) (map[structs.PromptTemplateVariable]string, error) {
+func (service ReceiptProcessingService) buildTemplateVariableMap(
+	ocrText string,
+	lowConfidenceWords []string,
+	barcodes []models.Barcode,
+) (map[structs.PromptTemplateVariable]string, error) {
 	result := make(map[structs.PromptTemplateVariable]string)
 
 	categoriesString, err := service.getCategoriesString()
@@ -328,17 +425,29 @@ func (service ReceiptProcessingService) buildTemplateVariableMap(ocrText string)
 
 	currentYearString := utils.UintToString(uint(time.Now().Year()))
 
+	lowConfidenceWordsBytes, err := json.Marshal(lowConfidenceWords)
+	if err != nil {
+		return result, err
+	}
+
+	barcodesBytes, err := json.Marshal(barcodes)
+	if err != nil {
+		return result, err
+	}
+
 	result[structs.CATEGORIES] = categoriesString
 	result[structs.TAGS] = tagsString
 	result[structs.OCR_TEXT] = ocrText
 	result[structs.CURRENT_YEAR] = currentYearString
+	result[structs.LOW_CONFIDENCE_WORDS] = string(lowConfidenceWordsBytes)
+	result[structs.BARCODES] = string(barcodesBytes)
 
 	return result, nil
 }
 
 func (service ReceiptProcessingService) getCategoriesString() (string, error) {
 	categoryRepository := repositories.NewCategoryRepository(nil)
-	categories, err := categoryRepository.GetAllCategories("id, name, description")
+	categories, err := categoryRepository.GetAllCategories("id, name, description", service.getScopeGroupIds())
 	if err != nil {
 		return "", err
 	}
@@ -353,7 +462,7 @@ func (service ReceiptProcessingService) getCategoriesString() (string, error) {
 
 func (service ReceiptProcessingService) getTagsString() (string, error) {
 	tagsRepository := repositories.NewTagsRepository(nil)
-	tags, err := tagsRepository.GetAllTags("id, name")
+	tags, err := tagsRepository.GetAllTags("id, name", service.getScopeGroupIds())
 	if err != nil {
 		return "", err
 	}
@@ -365,3 +474,12 @@ func (service ReceiptProcessingService) getTagsString() (string, error) {
 
 	return string(tagsBytes), nil
 }
+
+// Only global categories and tags, plus those of the receipt's group, are offered to the model
+func (service ReceiptProcessingService) getScopeGroupIds() []uint {
+	if service.Group.ID == 0 {
+		return []uint{}
+	}
+
+	return []uint{service.Group.ID}
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/jinzhu/copier"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -54,14 +56,15 @@ func (service ReceiptService) DeleteReceipt(id string) error {
 		return err
 	}
 
+	var imagesToDelete []string
 	err = db.Transaction(func(tx *gorm.DB) error {
-		var imagesToDelete []string
-		fileRepository := repositories.NewFileRepository(tx)
-		fileRepository.SetTransaction(tx)
-
+		receiptImageRepository := repositories.NewReceiptImageRepository(tx)
 		for _, f := range receipt.ImageFiles {
-			path, _ := fileRepository.BuildFilePath(utils.UintToString(f.ReceiptId), utils.UintToString(f.ID), f.Name)
-			imagesToDelete = append(imagesToDelete, path)
+			unusedKeys, err := receiptImageRepository.ReleaseFileData(f)
+			if err != nil {
+				return err
+			}
+			imagesToDelete = append(imagesToDelete, unusedKeys...)
 		}
 
 		for _, r := range receipt.ReceiptItems {
@@ -81,21 +84,13 @@ func (service ReceiptService) DeleteReceipt(id string) error {
 			return err
 		}
 
-		err = tx.Select(clause.Associations).Delete(&receipt).Error
-		if err != nil {
-			return err
-		}
-
-		for _, path := range imagesToDelete {
-			os.Remove(path)
-		}
-
-		return nil
+		return tx.Select(clause.Associations).Delete(&receipt).Error
 	})
 	if err != nil {
 		return err
 	}
 
+	repositories.NewReceiptImageRepository(nil).DeleteReleasedFiles(receipt.ImageFiles, imagesToDelete)
 	return nil
 }
 
@@ -199,6 +194,7 @@ func (service ReceiptService) QuickScan(
 			Size:      uint(fileInfo.Size()),
 			ReceiptId: createdReceipt.ID,
 			FileType:  validatedFileType,
+			Barcodes:  receiptProcessingMetadata.Barcodes,
 		}
 		_, err := receiptImageRepository.CreateReceiptImage(fileData, fileBytes)
 		if err != nil {
@@ -266,6 +262,7 @@ func (service ReceiptService) DuplicateReceipt(
 		newFileData.ID = 0
 		newFileData.ReceiptId = 0
 		newFileData.Receipt = models.Receipt{}
+		newFileData.Pages = nil
 		newReceipt.ImageFiles = append(newReceipt.ImageFiles, newFileData)
 	}
 
@@ -291,7 +288,36 @@ func (service ReceiptService) DuplicateReceipt(
 		newReceipt.Comments = append(newReceipt.Comments, newComment)
 	}
 
-	err = db.Create(&newReceipt).Error
+	// The copies reference the same blobs, a file stored before deduplication is copied into a blob and the original is left to the source receipt.
+	// References are added in the transaction creating the receipt, so a failed create doesn't leave them behind.
+	err = db.Transaction(func(tx *gorm.DB) error {
+		fileRepository := repositories.NewFileRepository(tx)
+		fileBlobRepository := repositories.NewFileBlobRepository(tx)
+		for i, fileData := range receipt.ImageFiles {
+			if len(fileData.BlobHash) > 0 {
+				err := fileBlobRepository.AddReference(fileData.BlobHash)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			srcImageBytes, err := fileRepository.GetRawBytesForFileData(fileData)
+			if err != nil {
+				return err
+			}
+
+			fileBlob, _, err := fileBlobRepository.StoreBlob(srcImageBytes)
+			if err != nil {
+				return err
+			}
+
+			newReceipt.ImageFiles[i].BlobHash = fileBlob.Hash
+			newReceipt.ImageFiles[i].Checksum = fileBlob.Hash
+		}
+
+		return tx.Create(&newReceipt).Error
+	})
 	if err != nil {
 		return models.Receipt{}, err
 	}
@@ -305,29 +331,143 @@ func (service ReceiptService) DuplicateReceipt(
 
 	systemTaskCommand.ResultDescription = resultString
 
-	// Copy receipt images
-	fileRepository := repositories.NewFileRepository(nil)
+	receiptImageRepository := repositories.NewReceiptImageRepository(nil)
 	for i, fileData := range newReceipt.ImageFiles {
-		srcFileData := receipt.ImageFiles[i]
-		srcImageBytes, err := fileRepository.GetBytesForFileData(srcFileData)
+		_, err = receiptImageRepository.CopyFileDataPages(receipt.ImageFiles[i], fileData)
 		if err != nil {
 			return models.Receipt{}, err
 		}
+	}
 
-		dstPath, err := fileRepository.BuildFilePath(
-			utils.UintToString(newReceipt.ID),
-			utils.UintToString(fileData.ID),
-			fileData.Name,
-		)
-		if err != nil {
-			return models.Receipt{}, err
+	return newReceipt, nil
+}
+
+// ValidateGroupScope makes sure the categories, tags and custom fields used by a receipt are global or belong to its group
+func (service ReceiptService) ValidateGroupScope(command commands.UpsertReceiptCommand) error {
+	categoryIds := make([]uint, 0)
+	tagIds := make([]uint, 0)
+	customFieldIds := make([]uint, 0)
+
+	var collectIds func(categories []commands.UpsertCategoryCommand, tags []commands.UpsertTagCommand, items []commands.UpsertItemCommand)
+	collectIds = func(categories []commands.UpsertCategoryCommand, tags []commands.UpsertTagCommand, items []commands.UpsertItemCommand) {
+		for _, category := range categories {
+			if category.Id != nil {
+				categoryIds = append(categoryIds, *category.Id)
+			}
 		}
 
-		err = utils.WriteFile(dstPath, srcImageBytes)
-		if err != nil {
-			return models.Receipt{}, err
+		for _, tag := range tags {
+			if tag.Id != nil {
+				tagIds = append(tagIds, *tag.Id)
+			}
+		}
+
+		for _, item := range items {
+			collectIds(item.Categories, item.Tags, item.LinkedItems)
 		}
 	}
+	collectIds(command.Categories, command.Tags, command.Items)
 
-	return newReceipt, nil
+	for _, customField := range command.CustomFields {
+		customFieldIds = append(customFieldIds, customField.CustomFieldId)
+	}
+
+	categoryCount, err := repositories.NewCategoryRepository(service.TX).CountCategoriesOutsideGroup(categoryIds, command.GroupId)
+	if err != nil {
+		return err
+	}
+
+	tagCount, err := repositories.NewTagsRepository(service.TX).CountTagsOutsideGroup(tagIds, command.GroupId)
+	if err != nil {
+		return err
+	}
+
+	customFieldCount, err := repositories.NewCustomFieldRepository(service.TX).CountCustomFieldsOutsideGroup(customFieldIds, command.GroupId)
+	if err != nil {
+		return err
+	}
+
+	if categoryCount+tagCount+customFieldCount > 0 {
+		return errors.New("categories, tags and custom fields must be global or belong to the receipt's group")
+	}
+
+	return nil
+}
+
+// ReprocessReceipt reads the receipt's first image again and updates the receipt with what was read.
+// Fields that could not be read keep their current values, comments and custom fields are left alone.
+func (service ReceiptService) ReprocessReceipt(receiptId string, userId uint) (models.Receipt, error) {
+	receiptRepository := repositories.NewReceiptRepository(service.TX)
+	systemTaskService := NewSystemTaskService(service.TX)
+
+	receipt, err := receiptRepository.GetFullyLoadedReceiptById(receiptId)
+	if err != nil {
+		return models.Receipt{}, err
+	}
+
+	if receipt.ID == 0 {
+		return models.Receipt{}, errors.New("receipt not found")
+	}
+
+	if len(receipt.ImageFiles) == 0 {
+		return models.Receipt{}, errors.New("receipt has no images to process")
+	}
+
+	startedAt := time.Now()
+	readCommand, metadata, readErr := ReadReceiptImage(utils.UintToString(receipt.ImageFiles[0].ID))
+	_, err = systemTaskService.CreateSystemTasksFromMetadata(
+		metadata,
+		startedAt,
+		time.Now(),
+		models.MAGIC_FILL,
+		&userId,
+		&receipt.GroupId,
+		"",
+		nil,
+	)
+	if err != nil {
+		return models.Receipt{}, err
+	}
+
+	if readErr != nil {
+		return models.Receipt{}, readErr
+	}
+
+	updateCommand := commands.UpsertReceiptCommand{}
+	receiptBytes, err := json.Marshal(receipt)
+	if err != nil {
+		return models.Receipt{}, err
+	}
+
+	err = json.Unmarshal(receiptBytes, &updateCommand)
+	if err != nil {
+		return models.Receipt{}, err
+	}
+	updateCommand.Comments = nil
+
+	if len(readCommand.Name) > 0 {
+		updateCommand.Name = readCommand.Name
+	}
+
+	if readCommand.Amount.IsPositive() {
+		updateCommand.Amount = readCommand.Amount
+	}
+
+	if !readCommand.Date.IsZero() {
+		updateCommand.Date = readCommand.Date
+	}
+
+	if len(readCommand.Categories) > 0 {
+		updateCommand.Categories = readCommand.Categories
+	}
+
+	if len(readCommand.Tags) > 0 {
+		updateCommand.Tags = readCommand.Tags
+	}
+
+	if len(readCommand.Items) > 0 {
+		updateCommand.Items = readCommand.Items
+	}
+
+	return receiptRepository.UpdateReceipt(receiptId, updateCommand, userId)
 }
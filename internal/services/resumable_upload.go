@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrResumableUploadOffsetMismatch = errors.New("upload offset does not match the received bytes")
+var ErrResumableUploadTooLarge = errors.New("upload is larger than its declared length")
+var ErrResumableUploadCompleted = errors.New("upload was already completed")
+
+// Chunks of the same upload are written one at a time
+var resumableUploadLocks sync.Map
+
+type ResumableUploadService struct {
+	BaseService
+}
+
+func NewResumableUploadService(tx *gorm.DB) ResumableUploadService {
+	service := ResumableUploadService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+func (service ResumableUploadService) CreateResumableUpload(command commands.CreateResumableUploadCommand, userId uint) (models.ResumableUpload, error) {
+	resumableUploadRepository := repositories.NewResumableUploadRepository(service.TX)
+
+	uploadId, err := utils.GetRandomString(24)
+	if err != nil {
+		return models.ResumableUpload{}, err
+	}
+
+	uploadPath := service.BuildResumableUploadPath(uploadId)
+	err = os.MkdirAll(filepath.Dir(uploadPath), 0755)
+	if err != nil {
+		return models.ResumableUpload{}, err
+	}
+
+	err = os.WriteFile(uploadPath, []byte{}, 0600)
+	if err != nil {
+		return models.ResumableUpload{}, err
+	}
+
+	upload, err := resumableUploadRepository.CreateResumableUpload(models.ResumableUpload{
+		UploadId:     uploadId,
+		UserId:       userId,
+		UploadLength: command.Length,
+		FileName:     command.FileName,
+		Metadata:     command.RawMetadata,
+		ReceiptId:    command.ReceiptId,
+		GroupId:      command.GroupId,
+		PaidByUserId: command.PaidByUserId,
+		Status:       command.Status,
+		ExpiresAt:    time.Now().Add(constants.ResumableUploadExpiration),
+	})
+	if err != nil {
+		os.Remove(uploadPath)
+		return models.ResumableUpload{}, err
+	}
+
+	return upload, nil
+}
+
+// GetResumableUpload returns an upload of the user, other users' uploads are reported as not found
+func (service ResumableUploadService) GetResumableUpload(uploadId string, userId uint) (models.ResumableUpload, error) {
+	resumableUploadRepository := repositories.NewResumableUploadRepository(service.TX)
+
+	upload, err := resumableUploadRepository.GetResumableUploadByUploadId(uploadId)
+	if err != nil {
+		return models.ResumableUpload{}, err
+	}
+
+	if upload.UserId != userId {
+		return models.ResumableUpload{}, gorm.ErrRecordNotFound
+	}
+
+	return upload, nil
+}
+
+func (service ResumableUploadService) BuildResumableUploadPath(uploadId string) string {
+	fileRepository := repositories.NewFileRepository(service.TX)
+	return filepath.Join(fileRepository.GetTempDirectoryPath(), "resumable-uploads", uploadId)
+}
+
+// WriteResumableUploadChunk appends a chunk at offset, which has to match the bytes received so far.
+// Bytes received before the connection dropped are kept, so the client can resume from the new offset.
+func (service ResumableUploadService) WriteResumableUploadChunk(upload models.ResumableUpload, offset int64, chunk io.Reader) (models.ResumableUpload, error) {
+	resumableUploadRepository := repositories.NewResumableUploadRepository(service.TX)
+
+	lock, _ := resumableUploadLocks.LoadOrStore(upload.UploadId, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	upload, err := resumableUploadRepository.GetResumableUploadByUploadId(upload.UploadId)
+	if err != nil {
+		return models.ResumableUpload{}, err
+	}
+
+	if offset != upload.UploadOffset || upload.CompletedAt != nil {
+		return upload, ErrResumableUploadOffsetMismatch
+	}
+
+	file, err := os.OpenFile(service.BuildResumableUploadPath(upload.UploadId), os.O_WRONLY, 0600)
+	if err != nil {
+		return upload, err
+	}
+	defer file.Close()
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return upload, err
+	}
+
+	written, copyErr := io.Copy(file, io.LimitReader(chunk, upload.UploadLength-upload.UploadOffset))
+
+	upload.UploadOffset += written
+	upload.ExpiresAt = time.Now().Add(constants.ResumableUploadExpiration)
+	err = resumableUploadRepository.UpdateResumableUploadProgress(upload)
+	if err != nil {
+		return upload, err
+	}
+
+	if copyErr != nil {
+		return upload, copyErr
+	}
+
+	extraBytes, _ := chunk.Read(make([]byte, 1))
+	if extraBytes > 0 {
+		return upload, ErrResumableUploadTooLarge
+	}
+
+	return upload, nil
+}
+
+func (service ResumableUploadService) ReadResumableUpload(upload models.ResumableUpload) ([]byte, error) {
+	if !upload.IsComplete() {
+		return nil, errors.New("upload is not complete")
+	}
+
+	return os.ReadFile(service.BuildResumableUploadPath(upload.UploadId))
+}
+
+// FinishResumableUpload marks the upload completed in the transaction handOff runs in, so a failed hand-off can be retried
+// and a finished one never runs twice. The assembled file is removed afterwards, the record stays until it expires,
+// so clients can still ask for its offset.
+func (service ResumableUploadService) FinishResumableUpload(upload models.ResumableUpload, handOff func(tx *gorm.DB) error) (models.ResumableUpload, error) {
+	completedAt := time.Now()
+	err := service.GetDB().Transaction(func(tx *gorm.DB) error {
+		completed, err := repositories.NewResumableUploadRepository(tx).CompleteResumableUpload(upload.ID, completedAt)
+		if err != nil {
+			return err
+		}
+
+		if !completed {
+			return ErrResumableUploadCompleted
+		}
+
+		return handOff(tx)
+	})
+	if err != nil {
+		return upload, err
+	}
+
+	upload.CompletedAt = &completedAt
+	err = service.removeResumableUploadFile(upload.UploadId)
+	if err != nil {
+		return upload, err
+	}
+
+	return upload, nil
+}
+
+func (service ResumableUploadService) DeleteResumableUpload(upload models.ResumableUpload) error {
+	resumableUploadRepository := repositories.NewResumableUploadRepository(service.TX)
+
+	err := resumableUploadRepository.DeleteResumableUpload(upload.ID)
+	if err != nil {
+		return err
+	}
+
+	return service.removeResumableUploadFile(upload.UploadId)
+}
+
+// DeleteExpiredResumableUploads removes stale partial uploads along with finished uploads past their expiry
+func (service ResumableUploadService) DeleteExpiredResumableUploads() (int64, error) {
+	resumableUploadRepository := repositories.NewResumableUploadRepository(service.TX)
+
+	uploads, err := resumableUploadRepository.GetResumableUploadsExpiredBefore(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var deletedCount int64
+	for _, upload := range uploads {
+		err = service.DeleteResumableUpload(upload)
+		if err != nil {
+			return deletedCount, err
+		}
+		deletedCount++
+	}
+
+	return deletedCount, nil
+}
+
+func (service ResumableUploadService) removeResumableUploadFile(uploadId string) error {
+	resumableUploadLocks.Delete(uploadId)
+
+	err := os.Remove(service.BuildResumableUploadPath(uploadId))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
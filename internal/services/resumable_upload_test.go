@@ -0,0 +1,204 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func setUpResumableUploadTest(t *testing.T) ResumableUploadService {
+	t.Setenv("BASE_PATH", t.TempDir())
+	repositories.TruncateTestDb()
+	repositories.CreateTestGroupWithUsers()
+
+	return NewResumableUploadService(nil)
+}
+
+func TestShouldAssembleResumableUploadFromChunks(t *testing.T) {
+	service := setUpResumableUploadTest(t)
+	receiptId := uint(1)
+
+	upload, err := service.CreateResumableUpload(commands.CreateResumableUploadCommand{
+		Length:    10,
+		FileName:  "scan.pdf",
+		ReceiptId: &receiptId,
+	}, 1)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	upload, err = service.WriteResumableUploadChunk(upload, 0, strings.NewReader("hello"))
+	if err != nil || upload.UploadOffset != 5 {
+		utils.PrintTestError(t, upload.UploadOffset, 5)
+		return
+	}
+
+	_, err = service.WriteResumableUploadChunk(upload, 2, strings.NewReader("again"))
+	if !errors.Is(err, ErrResumableUploadOffsetMismatch) {
+		utils.PrintTestError(t, err, ErrResumableUploadOffsetMismatch)
+	}
+
+	_, err = service.ReadResumableUpload(upload)
+	if err == nil {
+		utils.PrintTestError(t, err, "incomplete upload error")
+	}
+
+	upload, err = service.WriteResumableUploadChunk(upload, 5, strings.NewReader("world"))
+	if err != nil || !upload.IsComplete() {
+		utils.PrintTestError(t, err, "complete upload")
+		return
+	}
+
+	fileBytes, err := service.ReadResumableUpload(upload)
+	if err != nil || string(fileBytes) != "helloworld" {
+		utils.PrintTestError(t, string(fileBytes), "helloworld")
+	}
+
+	upload, err = service.FinishResumableUpload(upload, func(tx *gorm.DB) error { return nil })
+	if err != nil || upload.CompletedAt == nil {
+		utils.PrintTestError(t, err, "completed upload")
+	}
+
+	_, err = os.Stat(service.BuildResumableUploadPath(upload.UploadId))
+	if !os.IsNotExist(err) {
+		utils.PrintTestError(t, err, "removed upload file")
+	}
+}
+
+func TestShouldRetryFailedResumableUploadHandOffOnce(t *testing.T) {
+	service := setUpResumableUploadTest(t)
+	receiptId := uint(1)
+
+	upload, err := service.CreateResumableUpload(commands.CreateResumableUploadCommand{
+		Length:    5,
+		FileName:  "scan.pdf",
+		ReceiptId: &receiptId,
+	}, 1)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	upload, err = service.WriteResumableUploadChunk(upload, 0, strings.NewReader("hello"))
+	if err != nil || !upload.IsComplete() {
+		utils.PrintTestError(t, err, "complete upload")
+		return
+	}
+
+	handOffs := 0
+	_, err = service.FinishResumableUpload(upload, func(tx *gorm.DB) error {
+		handOffs++
+		return errors.New("receipt could not be saved")
+	})
+	if err == nil {
+		utils.PrintTestError(t, err, "hand-off error")
+	}
+
+	// A request without a body at the final offset retries the hand-off
+	upload, err = service.WriteResumableUploadChunk(upload, 5, strings.NewReader(""))
+	if err != nil || upload.CompletedAt != nil {
+		utils.PrintTestError(t, err, "incomplete upload accepting the final offset")
+		return
+	}
+
+	fileBytes, err := service.ReadResumableUpload(upload)
+	if err != nil || string(fileBytes) != "hello" {
+		utils.PrintTestError(t, string(fileBytes), "hello")
+	}
+
+	handOff := func(tx *gorm.DB) error {
+		handOffs++
+		return nil
+	}
+	_, err = service.FinishResumableUpload(upload, handOff)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+	}
+
+	_, err = service.FinishResumableUpload(upload, handOff)
+	if !errors.Is(err, ErrResumableUploadCompleted) || handOffs != 2 {
+		utils.PrintTestError(t, handOffs, 2)
+	}
+}
+
+func TestShouldRejectChunkPastUploadLength(t *testing.T) {
+	service := setUpResumableUploadTest(t)
+	receiptId := uint(1)
+
+	upload, err := service.CreateResumableUpload(commands.CreateResumableUploadCommand{
+		Length:    4,
+		FileName:  "scan.pdf",
+		ReceiptId: &receiptId,
+	}, 1)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	upload, err = service.WriteResumableUploadChunk(upload, 0, strings.NewReader("too long"))
+	if !errors.Is(err, ErrResumableUploadTooLarge) {
+		utils.PrintTestError(t, err, ErrResumableUploadTooLarge)
+	}
+
+	if upload.UploadOffset != 4 {
+		utils.PrintTestError(t, upload.UploadOffset, 4)
+	}
+}
+
+func TestShouldNotReturnResumableUploadOfOtherUser(t *testing.T) {
+	service := setUpResumableUploadTest(t)
+	receiptId := uint(1)
+
+	upload, _ := service.CreateResumableUpload(commands.CreateResumableUploadCommand{
+		Length:    4,
+		FileName:  "scan.pdf",
+		ReceiptId: &receiptId,
+	}, 1)
+
+	_, err := service.GetResumableUpload(upload.UploadId, 4)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.PrintTestError(t, err, gorm.ErrRecordNotFound)
+	}
+}
+
+func TestShouldDeleteExpiredResumableUploads(t *testing.T) {
+	service := setUpResumableUploadTest(t)
+	receiptId := uint(1)
+
+	expired, _ := service.CreateResumableUpload(commands.CreateResumableUploadCommand{
+		Length:    4,
+		FileName:  "expired.pdf",
+		ReceiptId: &receiptId,
+	}, 1)
+	active, _ := service.CreateResumableUpload(commands.CreateResumableUploadCommand{
+		Length:    4,
+		FileName:  "active.pdf",
+		ReceiptId: &receiptId,
+	}, 1)
+
+	expired.ExpiresAt = time.Now().Add(-time.Hour)
+	repositories.NewResumableUploadRepository(nil).UpdateResumableUploadProgress(expired)
+
+	deletedCount, err := service.DeleteExpiredResumableUploads()
+	if err != nil || deletedCount != 1 {
+		utils.PrintTestError(t, deletedCount, 1)
+	}
+
+	_, err = os.Stat(service.BuildResumableUploadPath(expired.UploadId))
+	if !os.IsNotExist(err) {
+		utils.PrintTestError(t, err, "removed upload file")
+	}
+
+	_, err = service.GetResumableUpload(active.UploadId, 1)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+type ScheduledExportService struct {
+	BaseService
+}
+
+func NewScheduledExportService(tx *gorm.DB) ScheduledExportService {
+	service := ScheduledExportService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+// RunScheduledExport exports and delivers the receipts of a scheduled export, failures are notified to its owner
+func (service ScheduledExportService) RunScheduledExport(
+	scheduledExport models.ScheduledExport,
+	asynqTaskId string,
+) (models.SystemTask, error) {
+	startedAt := time.Now()
+	systemTaskService := NewSystemTaskService(service.TX)
+	systemTaskCommand := commands.UpsertSystemTaskCommand{
+		Type:                 models.SCHEDULED_EXPORT,
+		AssociatedEntityType: models.NOOP_ENTITY_TYPE,
+		AssociatedEntityId:   scheduledExport.ID,
+		StartedAt:            startedAt,
+		RanByUserId:          &scheduledExport.UserId,
+		GroupId:              &scheduledExport.GroupId,
+		AsynqTaskId:          asynqTaskId,
+	}
+
+	fileName, receiptCount, err := service.runScheduledExport(scheduledExport, startedAt)
+	if err != nil {
+		notificationBody := fmt.Sprintf("Scheduled export %s failed: %s", scheduledExport.Name, err.Error())
+		notifyErr := repositories.NewNotificationRepository(service.TX).
+			SendNotificationToUsers([]uint{scheduledExport.UserId}, "Scheduled Export Failed", notificationBody, models.NOTIFICATION_TYPE_NORMAL, []interface{}{})
+		if notifyErr != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, notifyErr.Error())
+		}
+
+		systemTask, taskErr := systemTaskService.CreateSystemTaskFromError(systemTaskCommand, err)
+		if taskErr != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, taskErr.Error())
+		}
+		return systemTask, err
+	}
+
+	err = repositories.NewScheduledExportRepository(service.TX).SetScheduledExportLastRunAt(scheduledExport.ID, startedAt)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
+	}
+
+	systemTaskCommand.ResultDescription = fmt.Sprintf("Exported %d receipts to %s", receiptCount, fileName)
+	return systemTaskService.CreateSystemTaskFromError(systemTaskCommand, nil)
+}
+
+func (service ScheduledExportService) runScheduledExport(scheduledExport models.ScheduledExport, startedAt time.Time) (string, int, error) {
+	groupId := utils.UintToString(scheduledExport.GroupId)
+	userId := utils.UintToString(scheduledExport.UserId)
+
+	// The owner may have lost access to the group since saving the export
+	err := NewGroupService(service.TX).ValidateGroupRole(models.OWNER, groupId, userId)
+	if err != nil {
+		return "", 0, fmt.Errorf("user %s is no longer an owner of the group", userId)
+	}
+
+	pagedRequest := commands.ReceiptPagedRequestCommand{}
+	pagedRequest.OrderBy = "date"
+	pagedRequest.SortDirection = commands.ASCENDING
+	if len(scheduledExport.Filter) > 0 {
+		err = json.Unmarshal(scheduledExport.Filter, &pagedRequest.Filter)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	pagedRequest.Filter.SetDefaults()
+
+	receipts, _, err := repositories.NewReceiptRepository(service.TX).GetPagedReceiptsByGroupId(
+		scheduledExport.UserId,
+		groupId,
+		pagedRequest,
+		GetExportReceiptAssociations(),
+		nil,
+	)
+	if err != nil {
+		return "", 0, err
+	}
+
+	exportCommand := commands.ExportReceiptsCommand{
+		Format: scheduledExport.Format,
+		ReceiptCsvOptions: structs.ReceiptCsvOptions{
+			IncludeCustomFields: scheduledExport.IncludeCustomFields,
+			IncludeComments:     scheduledExport.IncludeComments,
+			IncludeImages:       scheduledExport.IncludeImages,
+		},
+	}
+	export, err := NewExportService(service.TX).ExportReceipts(exportCommand, receipts)
+	if err != nil {
+		return "", 0, err
+	}
+
+	destination, err := GetExportDestination(scheduledExport)
+	if err != nil {
+		return "", 0, err
+	}
+
+	fileName := BuildScheduledExportFileName(scheduledExport, export, startedAt)
+	err = destination.Write(fileName, export.Bytes)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return fileName, len(receipts), nil
+}
+
+// BuildScheduledExportFileName names files after the export and the time of the run, so runs don't overwrite each other
+func BuildScheduledExportFileName(scheduledExport models.ScheduledExport, export structs.ReceiptExport, ranAt time.Time) string {
+	name := strings.Map(func(character rune) rune {
+		if unicode.IsLetter(character) || unicode.IsDigit(character) || character == '-' || character == '_' {
+			return character
+		}
+		return '-'
+	}, scheduledExport.Name)
+
+	name = strings.Trim(name, "-")
+	if len(name) == 0 {
+		name = "export-" + utils.UintToString(scheduledExport.ID)
+	}
+
+	return name + "-" + ranAt.UTC().Format("2006-01-02-150405") + path.Ext(export.FileName)
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func setUpScheduledExportTest(t *testing.T, command commands.UpsertScheduledExportCommand) models.ScheduledExport {
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+	db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", 1, 1).Update("group_role", models.OWNER)
+	// WebDAV exports only run when saved by an admin
+	db.Model(&models.User{}).Where("id = ?", 1).Update("user_role", models.ADMIN)
+
+	for i, name := range []string{"Groceries", "Rent"} {
+		db.Create(&models.Receipt{
+			Name:         name,
+			Amount:       decimal.NewFromInt(int64(10 * (i + 1))),
+			Date:         time.Date(2025, 3, i+1, 0, 0, 0, 0, time.UTC),
+			PaidByUserID: 1,
+			Status:       models.OPEN,
+			GroupId:      1,
+		})
+	}
+
+	command.Filter.SetDefaults()
+	scheduledExport, err := repositories.NewScheduledExportRepository(nil).CreateScheduledExport(1, 1, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return scheduledExport
+}
+
+func TestShouldRunScheduledExportToLocalDirectory(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	exportDirectory := t.TempDir()
+	t.Setenv("EXPORT_DIRECTORY", exportDirectory)
+
+	command := commands.UpsertScheduledExportCommand{
+		Name:            "Monthly accountant",
+		Format:          models.EXPORT_CSV,
+		Schedule:        "@monthly",
+		DestinationType: models.LOCAL_DIRECTORY,
+		Destination:     "accountant",
+		Enabled:         true,
+	}
+	command.Filter.Name = commands.PagedRequestField{Operation: commands.CONTAINS, Value: "Rent"}
+	scheduledExport := setUpScheduledExportTest(t, command)
+
+	systemTask, err := NewScheduledExportService(nil).RunScheduledExport(scheduledExport, "")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if systemTask.Type != models.SCHEDULED_EXPORT || systemTask.Status != models.SYSTEM_TASK_SUCCEEDED || systemTask.AssociatedEntityId != scheduledExport.ID {
+		utils.PrintTestError(t, systemTask, "succeeded scheduled export task")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(exportDirectory, "1", "accountant", "Monthly-accountant-*.zip"))
+	if len(files) != 1 {
+		utils.PrintTestError(t, files, "one zip in the accountant directory of group 1")
+		return
+	}
+
+	// Only the receipt matching the filter is exported
+	if !strings.Contains(systemTask.ResultDescription, "Exported 1 receipts") {
+		utils.PrintTestError(t, systemTask.ResultDescription, "Exported 1 receipts")
+	}
+
+	updatedScheduledExport, _ := repositories.NewScheduledExportRepository(nil).GetScheduledExportById(utils.UintToString(scheduledExport.ID))
+	if updatedScheduledExport.LastRunAt == nil {
+		utils.PrintTestError(t, updatedScheduledExport.LastRunAt, "last run time")
+	}
+}
+
+func TestShouldRunScheduledExportToWebDav(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	t.Setenv("ENCRYPTION_KEY", "test-key")
+
+	var uploadedPath string
+	var uploadedBody string
+	var username, password string
+	webDavServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		uploadedPath = r.URL.Path
+		username, password, _ = r.BasicAuth()
+		body, _ := io.ReadAll(r.Body)
+		uploadedBody = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer webDavServer.Close()
+
+	scheduledExport := setUpScheduledExportTest(t, commands.UpsertScheduledExportCommand{
+		Name:            "Ledger",
+		Format:          models.EXPORT_HLEDGER,
+		Schedule:        "0 6 1 * *",
+		DestinationType: models.WEBDAV,
+		Destination:     webDavServer.URL + "/remote.php/dav/files/accountant/",
+		WebDavUsername:  "accountant",
+		WebDavPassword:  "secret",
+		Enabled:         true,
+	})
+
+	if scheduledExport.WebDavPassword == "secret" {
+		utils.PrintTestError(t, scheduledExport.WebDavPassword, "encrypted password")
+	}
+
+	_, err := NewScheduledExportService(nil).RunScheduledExport(scheduledExport, "")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if !strings.HasPrefix(uploadedPath, "/remote.php/dav/files/accountant/Ledger-") || !strings.HasSuffix(uploadedPath, ".journal") {
+		utils.PrintTestError(t, uploadedPath, "journal in the accountant collection")
+	}
+
+	if username != "accountant" || password != "secret" {
+		utils.PrintTestError(t, username+":"+password, "accountant:secret")
+	}
+
+	if !strings.Contains(uploadedBody, "Groceries") || !strings.Contains(uploadedBody, "Rent") {
+		utils.PrintTestError(t, uploadedBody, "both receipts")
+	}
+}
+
+func TestShouldNotifyOwnerWhenScheduledExportFails(t *testing.T) {
+	defer repositories.TruncateTestDb()
+
+	webDavServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInsufficientStorage)
+	}))
+	defer webDavServer.Close()
+
+	scheduledExport := setUpScheduledExportTest(t, commands.UpsertScheduledExportCommand{
+		Name:            "Full",
+		Format:          models.EXPORT_CSV,
+		Schedule:        "@daily",
+		DestinationType: models.WEBDAV,
+		Destination:     webDavServer.URL,
+		Enabled:         true,
+	})
+
+	systemTask, err := NewScheduledExportService(nil).RunScheduledExport(scheduledExport, "")
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	if systemTask.Status != models.SYSTEM_TASK_FAILED {
+		utils.PrintTestError(t, systemTask.Status, models.SYSTEM_TASK_FAILED)
+	}
+
+	var notification models.Notification
+	repositories.GetDB().Model(&models.Notification{}).Where("user_id = ?", 1).First(&notification)
+	if notification.Title != "Scheduled Export Failed" || !strings.Contains(notification.Body, "507") {
+		utils.PrintTestError(t, notification, "failure notification with the status")
+	}
+}
+
+func TestShouldNotWriteOutsideOfExportDirectory(t *testing.T) {
+	exportDirectory := t.TempDir()
+	destination := LocalDirectoryExportDestination{ExportDirectory: exportDirectory, GroupId: 1, Directory: "../escaped"}
+
+	err := destination.Write("receipts.zip", []byte("zip"))
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	_, statErr := os.Stat(filepath.Join(exportDirectory, "escaped"))
+	if statErr == nil {
+		utils.PrintTestError(t, "escaped directory created", "no directory")
+	}
+
+	// Another group's directory is out of reach too
+	destination.Directory = "../2"
+	err = destination.Write("receipts.zip", []byte("zip"))
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+func TestShouldNotRunWebDavExportSavedByUser(t *testing.T) {
+	defer repositories.TruncateTestDb()
+
+	requestCount := 0
+	webDavServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer webDavServer.Close()
+
+	scheduledExport := setUpScheduledExportTest(t, commands.UpsertScheduledExportCommand{
+		Name:            "Internal",
+		Format:          models.EXPORT_CSV,
+		Schedule:        "@daily",
+		DestinationType: models.WEBDAV,
+		Destination:     webDavServer.URL,
+		Enabled:         true,
+	})
+	repositories.GetDB().Model(&models.User{}).Where("id = ?", 1).Update("user_role", models.USER)
+
+	_, err := NewScheduledExportService(nil).RunScheduledExport(scheduledExport, "")
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	if requestCount != 0 {
+		utils.PrintTestError(t, requestCount, 0)
+	}
+}
+
+func TestShouldBuildScheduledExportFileName(t *testing.T) {
+	ranAt := time.Date(2025, 4, 1, 6, 0, 0, 0, time.UTC)
+	scheduledExport := models.ScheduledExport{BaseModel: models.BaseModel{ID: 3}, Name: "Taxes / 2025"}
+
+	fileName := BuildScheduledExportFileName(scheduledExport, structs.ReceiptExport{FileName: "receipts.beancount"}, ranAt)
+	if fileName != "Taxes---2025-2025-04-01-060000.beancount" {
+		utils.PrintTestError(t, fileName, "Taxes---2025-2025-04-01-060000.beancount")
+	}
+
+	scheduledExport.Name = "//"
+	fileName = BuildScheduledExportFileName(scheduledExport, structs.ReceiptExport{FileName: "receipts.zip"}, ranAt)
+	if fileName != "export-3-2025-04-01-060000.zip" {
+		utils.PrintTestError(t, fileName, "export-3-2025-04-01-060000.zip")
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/structs"
+)
+
+var encryptedKeyPrefixes = []string{"receipts/", "blobs/", quarantinePrefix + "/"}
+
+// EncryptStoredFiles encrypts every receipt file in the backend that is still stored in plaintext.
+// Files already encrypted are skipped, so it is safe to run more than once.
+func EncryptStoredFiles(backend storage.Storage, masterKey string) (structs.StorageEncryptionMigrationResult, error) {
+	return convertStoredFiles(backend, func(data []byte) ([]byte, bool, error) {
+		if len(data) == 0 || storage.IsEncryptedObject(data) {
+			return nil, false, nil
+		}
+
+		encryptedData, err := storage.EncryptObject(masterKey, data)
+		return encryptedData, true, err
+	})
+}
+
+// DecryptStoredFiles writes every encrypted receipt file in the backend back in plaintext
+func DecryptStoredFiles(backend storage.Storage, masterKey string) (structs.StorageEncryptionMigrationResult, error) {
+	return convertStoredFiles(backend, func(data []byte) ([]byte, bool, error) {
+		if !storage.IsEncryptedObject(data) {
+			return nil, false, nil
+		}
+
+		decryptedData, err := storage.DecryptObject(masterKey, data)
+		return decryptedData, true, err
+	})
+}
+
+func convertStoredFiles(
+	backend storage.Storage,
+	convert func(data []byte) ([]byte, bool, error),
+) (structs.StorageEncryptionMigrationResult, error) {
+	result := structs.StorageEncryptionMigrationResult{}
+
+	for _, prefix := range encryptedKeyPrefixes {
+		keys, err := backend.List(prefix)
+		if err != nil {
+			return result, err
+		}
+
+		for _, key := range keys {
+			data, err := backend.Get(key)
+			if err != nil {
+				return result, err
+			}
+
+			convertedData, shouldWrite, err := convert(data)
+			if err != nil {
+				return result, err
+			}
+
+			if !shouldWrite {
+				result.SkippedFiles++
+				continue
+			}
+
+			err = backend.Put(key, convertedData)
+			if err != nil {
+				return result, err
+			}
+
+			result.ConvertedFiles++
+		}
+	}
+
+	logging.LogStd(
+		logging.LOG_LEVEL_INFO,
+		"Storage encryption migration finished, converted files: ",
+		result.ConvertedFiles,
+		", skipped files: ",
+		result.SkippedFiles,
+	)
+
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldEncryptAndDecryptStoredFiles(t *testing.T) {
+	backend := storage.NewLocalStorage(t.TempDir())
+	backend.Put("receipts/1/1-receipt.jpg", []byte("receipt"))
+	backend.Put("quarantine/20240101T000000Z/receipts/2/2-orphan.jpg", []byte("orphan"))
+	backend.Put("other/notes.txt", []byte("untouched"))
+
+	result, err := EncryptStoredFiles(backend, "test-key")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.ConvertedFiles != 2 || result.SkippedFiles != 0 {
+		utils.PrintTestError(t, result, "2 converted files")
+	}
+
+	stored, _ := backend.Get("receipts/1/1-receipt.jpg")
+	if !storage.IsEncryptedObject(stored) {
+		utils.PrintTestError(t, string(stored), "encrypted data")
+	}
+
+	notes, _ := backend.Get("other/notes.txt")
+	if string(notes) != "untouched" {
+		utils.PrintTestError(t, string(notes), "untouched")
+	}
+
+	result, err = EncryptStoredFiles(backend, "test-key")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.ConvertedFiles != 0 || result.SkippedFiles != 2 {
+		utils.PrintTestError(t, result, "2 skipped files")
+	}
+
+	result, err = DecryptStoredFiles(backend, "test-key")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.ConvertedFiles != 2 {
+		utils.PrintTestError(t, result.ConvertedFiles, 2)
+	}
+
+	stored, _ = backend.Get("quarantine/20240101T000000Z/receipts/2/2-orphan.jpg")
+	if string(stored) != "orphan" {
+		utils.PrintTestError(t, string(stored), "orphan")
+	}
+}
+
+func TestShouldNotDecryptStoredFilesWithWrongKey(t *testing.T) {
+	backend := storage.NewLocalStorage(t.TempDir())
+	encryptedData, _ := storage.EncryptObject("test-key", []byte("receipt"))
+	backend.Put("receipts/1/1-receipt.jpg", encryptedData)
+
+	_, err := DecryptStoredFiles(backend, "other-key")
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const quarantinePrefix = "quarantine"
+
+// Uploads insert the file_data row before writing the file, so rows and files younger than this are left alone
+const integrityCheckGracePeriod = 10 * time.Minute
+
+type StorageIntegrityService struct {
+	BaseService
+}
+
+func NewStorageIntegrityService(tx *gorm.DB) StorageIntegrityService {
+	service := StorageIntegrityService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+// RunIntegrityCheck checks storage and records the report as a system task, the task fails when issues are left unresolved
+func (service StorageIntegrityService) RunIntegrityCheck(
+	command commands.RunStorageIntegrityCheckCommand,
+	ranByUserId *uint,
+	asynqTaskId string,
+) (models.SystemTask, error) {
+	systemTaskService := NewSystemTaskService(service.TX)
+	systemTaskCommand := commands.UpsertSystemTaskCommand{
+		Type:                 models.STORAGE_INTEGRITY_CHECK,
+		AssociatedEntityType: models.NOOP_ENTITY_TYPE,
+		StartedAt:            time.Now(),
+		RanByUserId:          ranByUserId,
+		AsynqTaskId:          asynqTaskId,
+	}
+
+	report, err := service.CheckIntegrity(command)
+	if err != nil {
+		systemTask, taskErr := systemTaskService.CreateSystemTaskFromError(systemTaskCommand, err)
+		if taskErr != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, taskErr.Error())
+		}
+		return systemTask, err
+	}
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return models.SystemTask{}, err
+	}
+
+	endedAt := time.Now()
+	systemTaskCommand.EndedAt = &endedAt
+	systemTaskCommand.ResultDescription = string(reportBytes)
+	systemTaskCommand.Status = models.SYSTEM_TASK_SUCCEEDED
+	if report.HasUnresolvedIssues() {
+		systemTaskCommand.Status = models.SYSTEM_TASK_FAILED
+	}
+
+	systemTaskRepository := repositories.NewSystemTaskRepository(service.TX)
+	return systemTaskRepository.CreateSystemTask(systemTaskCommand)
+}
+
+// CheckIntegrity compares the files in storage against file_data and file_data_pages, then runs the actions the command asks for
+func (service StorageIntegrityService) CheckIntegrity(command commands.RunStorageIntegrityCheckCommand) (structs.StorageIntegrityReport, error) {
+	db := service.GetDB()
+	fileRepository := repositories.NewFileRepository(service.TX)
+	receiptImageRepository := repositories.NewReceiptImageRepository(service.TX)
+	fileBlobRepository := repositories.NewFileBlobRepository(service.TX)
+	report := structs.StorageIntegrityReport{
+		MissingFiles:       make([]structs.StorageIntegrityIssue, 0),
+		OrphanedFiles:      make([]structs.StorageIntegrityIssue, 0),
+		SizeMismatches:     make([]structs.StorageIntegrityIssue, 0),
+		ChecksumMismatches: make([]structs.StorageIntegrityIssue, 0),
+	}
+
+	storedKeys := make([]string, 0)
+	for _, prefix := range []string{"receipts/", "blobs/"} {
+		keys, listErr := storage.GetStorage().List(prefix)
+		if listErr != nil {
+			return report, listErr
+		}
+		storedKeys = append(storedKeys, keys...)
+	}
+
+	storedKeySet := make(map[string]bool, len(storedKeys))
+	for _, key := range storedKeys {
+		storedKeySet[key] = true
+	}
+
+	cutoff := time.Now().Add(-integrityCheckGracePeriod)
+	knownKeys := make(map[string]bool)
+	fileDataBatch := make([]models.FileData, 0)
+
+	err := db.Model(&models.FileData{}).Preload("Pages").FindInBatches(&fileDataBatch, 100, func(tx *gorm.DB, batch int) error {
+		for _, fileData := range fileDataBatch {
+			key := fileRepository.BuildFileDataKey(fileData)
+
+			isMissing := false
+			if !storedKeySet[key] {
+				var err error
+				isMissing, err = service.isFileMissing(fileData.CreatedAt, key, cutoff)
+				if err != nil {
+					return err
+				}
+			}
+
+			if isMissing {
+				report.MissingFiles = append(report.MissingFiles, structs.StorageIntegrityIssue{Key: key, FileDataId: fileData.ID})
+
+				// Derived files of removed records are left out of knownKeys, so they are reported as orphans
+				if command.RemoveDanglingRecords {
+					if len(fileData.BlobHash) > 0 {
+						err := fileBlobRepository.ReleaseReference(fileData.BlobHash)
+						if err != nil {
+							return err
+						}
+					}
+
+					err := db.Delete(&fileData).Error
+					if err != nil {
+						return err
+					}
+
+					if len(fileData.BlobHash) > 0 {
+						_, err = fileBlobRepository.DeleteBlobIfUnreferenced(fileData.BlobHash)
+						if err != nil {
+							return err
+						}
+					}
+					report.RemovedRecords++
+					continue
+				}
+			} else if storedKeySet[key] {
+				err := service.checkFile(fileData, key, command, &report)
+				if err != nil {
+					return err
+				}
+			}
+
+			knownKeys[key] = true
+			derivedKeys, err := receiptImageRepository.GetDerivedFileKeys(fileData)
+			if err != nil {
+				return err
+			}
+			for _, derivedKey := range derivedKeys {
+				knownKeys[derivedKey] = true
+			}
+
+			for _, page := range fileData.Pages {
+				pageKey := fileRepository.BuildFileDataPageKey(fileData, page)
+				if storedKeySet[pageKey] {
+					continue
+				}
+
+				isPageMissing, err := service.isFileMissing(page.CreatedAt, pageKey, cutoff)
+				if err != nil {
+					return err
+				}
+				if !isPageMissing {
+					continue
+				}
+
+				report.MissingFiles = append(report.MissingFiles, structs.StorageIntegrityIssue{
+					Key:            pageKey,
+					FileDataId:     fileData.ID,
+					FileDataPageId: page.ID,
+				})
+
+				if command.RemoveDanglingRecords {
+					err := db.Delete(&page).Error
+					if err != nil {
+						return err
+					}
+					report.RemovedRecords++
+				}
+			}
+		}
+
+		return nil
+	}).Error
+	if err != nil {
+		return report, err
+	}
+
+	if command.UpdateFileMetadata {
+		recountedBlobs, err := fileBlobRepository.RecountReferences()
+		if err != nil {
+			return report, err
+		}
+		report.UpdatedRecords += int(recountedBlobs)
+	}
+
+	quarantineKeyPrefix := path.Join(quarantinePrefix, time.Now().UTC().Format("20060102T150405Z"))
+	for _, key := range storedKeys {
+		if knownKeys[key] {
+			continue
+		}
+
+		modifiedAt, err := storage.GetStorage().ModifiedAt(key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		// The record of a recent file may not be committed yet
+		if modifiedAt.After(cutoff) {
+			continue
+		}
+
+		report.OrphanedFiles = append(report.OrphanedFiles, structs.StorageIntegrityIssue{Key: key})
+
+		if command.QuarantineOrphanedFiles {
+			err = service.quarantineFile(key, path.Join(quarantineKeyPrefix, key))
+			if err != nil {
+				return report, err
+			}
+			report.QuarantinedFiles++
+		}
+	}
+
+	return report, nil
+}
+
+// isFileMissing re-checks a file absent from the listing, files of records created within the grace period are never missing
+func (service StorageIntegrityService) isFileMissing(createdAt time.Time, key string, cutoff time.Time) (bool, error) {
+	if createdAt.After(cutoff) {
+		return false, nil
+	}
+
+	exists, err := repositories.NewFileRepository(service.TX).FileExists(key)
+	if err != nil {
+		return false, err
+	}
+
+	return !exists, nil
+}
+
+func (service StorageIntegrityService) checkFile(
+	fileData models.FileData,
+	key string,
+	command commands.RunStorageIntegrityCheckCommand,
+	report *structs.StorageIntegrityReport,
+) error {
+	fileRepository := repositories.NewFileRepository(service.TX)
+
+	fileBytes, err := fileRepository.ReadFile(key)
+	if err != nil {
+		return err
+	}
+	report.CheckedFiles++
+
+	size := uint(len(fileBytes))
+	checksum := utils.Sha256Hash(fileBytes)
+	metadataUpdates := map[string]interface{}{}
+
+	if fileData.Size == 0 {
+		metadataUpdates["size"] = size
+	} else if fileData.Size != size {
+		report.SizeMismatches = append(report.SizeMismatches, structs.StorageIntegrityIssue{
+			Key:        key,
+			FileDataId: fileData.ID,
+			Expected:   utils.UintToString(fileData.Size),
+			Actual:     utils.UintToString(size),
+		})
+	}
+
+	if len(fileData.Checksum) == 0 {
+		metadataUpdates["checksum"] = checksum
+	} else if fileData.Checksum != checksum {
+		report.ChecksumMismatches = append(report.ChecksumMismatches, structs.StorageIntegrityIssue{
+			Key:        key,
+			FileDataId: fileData.ID,
+			Expected:   fileData.Checksum,
+			Actual:     checksum,
+		})
+	}
+
+	if command.UpdateFileMetadata && len(metadataUpdates) > 0 {
+		err = service.GetDB().Model(&models.FileData{}).Where("id = ?", fileData.ID).Updates(metadataUpdates).Error
+		if err != nil {
+			return err
+		}
+		report.UpdatedRecords++
+	}
+
+	return nil
+}
+
+func (service StorageIntegrityService) quarantineFile(key string, quarantineKey string) error {
+	fileRepository := repositories.NewFileRepository(service.TX)
+
+	fileBytes, err := fileRepository.ReadFile(key)
+	if err != nil {
+		return err
+	}
+
+	err = fileRepository.WriteFile(quarantineKey, fileBytes)
+	if err != nil {
+		return err
+	}
+
+	return fileRepository.DeleteFile(key)
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type storageIntegrityTestData struct {
+	healthy        models.FileData
+	missing        models.FileData
+	sizeMismatch   models.FileData
+	checksumChange models.FileData
+	noMetadata     models.FileData
+	orphanKey      string
+}
+
+func setUpStorageIntegrityTest(t *testing.T) storageIntegrityTestData {
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+	storageRoot := t.TempDir()
+	storage.SetStorage(storage.NewLocalStorage(storageRoot))
+	fileRepository := repositories.NewFileRepository(nil)
+
+	receipt := models.Receipt{
+		Name:         "Test Receipt",
+		Amount:       decimal.NewFromFloat(10.00),
+		Date:         time.Now(),
+		PaidByUserID: 1,
+		Status:       models.OPEN,
+		GroupId:      1,
+	}
+	db.Create(&receipt)
+
+	createFileData := func(name string, storedBytes []byte, size uint, checksum string) models.FileData {
+		fileData := models.FileData{Name: name, FileType: "image/png", ReceiptId: receipt.ID, Size: size, Checksum: checksum}
+		db.Create(&fileData)
+		if storedBytes != nil {
+			fileRepository.WriteFile(fileRepository.BuildFileDataKey(fileData), storedBytes)
+		}
+		return fileData
+	}
+
+	data := storageIntegrityTestData{
+		healthy:        createFileData("healthy.png", []byte("healthy"), 7, utils.Sha256Hash([]byte("healthy"))),
+		missing:        createFileData("missing.png", nil, 7, ""),
+		sizeMismatch:   createFileData("size.png", []byte("size"), 10, ""),
+		checksumChange: createFileData("checksum.png", []byte("changed"), 7, utils.Sha256Hash([]byte("original"))),
+		noMetadata:     createFileData("metadata.png", []byte("metadata"), 0, ""),
+		orphanKey:      "receipts/999/1-orphan.png",
+	}
+	fileRepository.WriteFile(data.orphanKey, []byte("orphan"))
+
+	// Previews are derived files, they mustn't be reported as orphans
+	fileRepository.WriteFile(fileRepository.BuildFileDataPreviewKey(data.healthy, models.IMAGE_SIZE_THUMB), []byte("thumb"))
+
+	backdateStorageIntegrityTestData(storageRoot)
+	return data
+}
+
+// backdateStorageIntegrityTestData moves records and files out of the grace period, so the check looks at them
+func backdateStorageIntegrityTestData(storageRoot string) {
+	oldDate := time.Now().Add(-2 * integrityCheckGracePeriod)
+	repositories.GetDB().Model(&models.FileData{}).Where("1 = 1").UpdateColumn("created_at", oldDate)
+
+	filepath.WalkDir(storageRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			os.Chtimes(path, oldDate, oldDate)
+		}
+		return nil
+	})
+}
+
+func tearDownStorageIntegrityTest() {
+	repositories.TruncateTestDb()
+	storage.SetStorage(nil)
+}
+
+func TestShouldReportStorageIntegrityIssues(t *testing.T) {
+	defer tearDownStorageIntegrityTest()
+	data := setUpStorageIntegrityTest(t)
+
+	service := NewStorageIntegrityService(nil)
+	report, err := service.CheckIntegrity(commands.RunStorageIntegrityCheckCommand{})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if report.CheckedFiles != 4 {
+		utils.PrintTestError(t, report.CheckedFiles, 4)
+	}
+
+	if len(report.MissingFiles) != 1 || report.MissingFiles[0].FileDataId != data.missing.ID {
+		utils.PrintTestError(t, report.MissingFiles, "the missing file")
+	}
+
+	if len(report.OrphanedFiles) != 1 || report.OrphanedFiles[0].Key != data.orphanKey {
+		utils.PrintTestError(t, report.OrphanedFiles, data.orphanKey)
+	}
+
+	if len(report.SizeMismatches) != 1 || report.SizeMismatches[0].FileDataId != data.sizeMismatch.ID ||
+		report.SizeMismatches[0].Expected != "10" || report.SizeMismatches[0].Actual != "4" {
+		utils.PrintTestError(t, report.SizeMismatches, "size mismatch of 10 and 4")
+	}
+
+	if len(report.ChecksumMismatches) != 1 || report.ChecksumMismatches[0].FileDataId != data.checksumChange.ID {
+		utils.PrintTestError(t, report.ChecksumMismatches, "the changed file")
+	}
+
+	if report.RemovedRecords != 0 || report.QuarantinedFiles != 0 || report.UpdatedRecords != 0 {
+		utils.PrintTestError(t, report, "a report only check")
+	}
+}
+
+func TestShouldRepairStorageIntegrityIssues(t *testing.T) {
+	defer tearDownStorageIntegrityTest()
+	data := setUpStorageIntegrityTest(t)
+	db := repositories.GetDB()
+
+	service := NewStorageIntegrityService(nil)
+	report, err := service.CheckIntegrity(commands.RunStorageIntegrityCheckCommand{
+		QuarantineOrphanedFiles: true,
+		RemoveDanglingRecords:   true,
+		UpdateFileMetadata:      true,
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if report.RemovedRecords != 1 || report.QuarantinedFiles != 1 {
+		utils.PrintTestError(t, report, "1 removed record and 1 quarantined file")
+	}
+
+	var count int64
+	db.Model(&models.FileData{}).Where("id = ?", data.missing.ID).Count(&count)
+	if count != 0 {
+		utils.PrintTestError(t, count, 0)
+	}
+
+	exists, _ := storage.GetStorage().Exists(data.orphanKey)
+	if exists {
+		utils.PrintTestError(t, exists, false)
+	}
+
+	quarantined, _ := storage.GetStorage().List("quarantine/")
+	if len(quarantined) != 1 {
+		utils.PrintTestError(t, quarantined, "the quarantined orphan")
+	}
+
+	var noMetadata models.FileData
+	db.Model(&models.FileData{}).Where("id = ?", data.noMetadata.ID).First(&noMetadata)
+	if noMetadata.Size != 8 || noMetadata.Checksum != utils.Sha256Hash([]byte("metadata")) {
+		utils.PrintTestError(t, noMetadata, "size and checksum to be filled in")
+	}
+
+	// Mismatches are never overwritten
+	var checksumChange models.FileData
+	db.Model(&models.FileData{}).Where("id = ?", data.checksumChange.ID).First(&checksumChange)
+	if checksumChange.Checksum != data.checksumChange.Checksum {
+		utils.PrintTestError(t, checksumChange.Checksum, data.checksumChange.Checksum)
+	}
+}
+
+func TestShouldRecordStorageIntegrityCheckAsSystemTask(t *testing.T) {
+	defer tearDownStorageIntegrityTest()
+	setUpStorageIntegrityTest(t)
+	userId := uint(1)
+
+	service := NewStorageIntegrityService(nil)
+	systemTask, err := service.RunIntegrityCheck(commands.RunStorageIntegrityCheckCommand{}, &userId, "")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if systemTask.Type != models.STORAGE_INTEGRITY_CHECK || systemTask.Status != models.SYSTEM_TASK_FAILED {
+		utils.PrintTestError(t, systemTask, "a failed storage integrity check task")
+	}
+
+	var report structs.StorageIntegrityReport
+	err = json.Unmarshal([]byte(systemTask.ResultDescription), &report)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(report.MissingFiles) != 1 {
+		utils.PrintTestError(t, report.MissingFiles, "1 missing file")
+	}
+}
+
+func TestShouldCheckBlobsAndRecountReferences(t *testing.T) {
+	defer tearDownStorageIntegrityTest()
+	data := setUpStorageIntegrityTest(t)
+	db := repositories.GetDB()
+	fileBlobRepository := repositories.NewFileBlobRepository(nil)
+
+	fileBlob, _, _ := fileBlobRepository.StoreBlob([]byte("shared"))
+	db.Create(&models.FileData{Name: "shared.png", ReceiptId: data.healthy.ReceiptId, Size: 6, Checksum: fileBlob.Hash, BlobHash: fileBlob.Hash})
+	db.Create(&models.FileData{Name: "shared copy.png", ReceiptId: data.healthy.ReceiptId, Size: 6, Checksum: fileBlob.Hash, BlobHash: fileBlob.Hash})
+
+	service := NewStorageIntegrityService(nil)
+	report, err := service.CheckIntegrity(commands.RunStorageIntegrityCheckCommand{UpdateFileMetadata: true})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	for _, orphan := range report.OrphanedFiles {
+		if orphan.Key != data.orphanKey {
+			utils.PrintTestError(t, orphan.Key, data.orphanKey)
+		}
+	}
+
+	if len(report.ChecksumMismatches) != 1 {
+		utils.PrintTestError(t, len(report.ChecksumMismatches), 1)
+	}
+
+	storedBlob, _ := fileBlobRepository.GetFileBlobByHash(fileBlob.Hash)
+	if storedBlob.RefCount != 2 {
+		utils.PrintTestError(t, storedBlob.RefCount, 2)
+	}
+}
+
+func TestShouldSkipRecentRecordsAndFiles(t *testing.T) {
+	defer tearDownStorageIntegrityTest()
+	data := setUpStorageIntegrityTest(t)
+	db := repositories.GetDB()
+	fileRepository := repositories.NewFileRepository(nil)
+
+	// An upload in progress has its row but not its file yet, and a new file may not have its row committed yet
+	uploading := models.FileData{Name: "uploading.png", FileType: "image/png", ReceiptId: data.healthy.ReceiptId}
+	db.Create(&uploading)
+	recentKey := "receipts/999/2-recent.png"
+	fileRepository.WriteFile(recentKey, []byte("recent"))
+
+	service := NewStorageIntegrityService(nil)
+	report, err := service.CheckIntegrity(commands.RunStorageIntegrityCheckCommand{
+		QuarantineOrphanedFiles: true,
+		RemoveDanglingRecords:   true,
+	})
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if len(report.MissingFiles) != 1 || report.MissingFiles[0].FileDataId != data.missing.ID {
+		utils.PrintTestError(t, report.MissingFiles, "only the old missing file")
+	}
+
+	if len(report.OrphanedFiles) != 1 || report.OrphanedFiles[0].Key != data.orphanKey {
+		utils.PrintTestError(t, report.OrphanedFiles, data.orphanKey)
+	}
+
+	var count int64
+	db.Model(&models.FileData{}).Where("id = ?", uploading.ID).Count(&count)
+	if count != 1 {
+		utils.PrintTestError(t, count, 1)
+	}
+
+	exists, _ := storage.GetStorage().Exists(recentKey)
+	if !exists {
+		utils.PrintTestError(t, exists, true)
+	}
+}
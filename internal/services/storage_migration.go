@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/structs"
+	"receipt-wrangler/api/internal/utils"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+var legacyGroupDirectoryRegex = regexp.MustCompile(`^[0-9]+-`)
+
+type StorageMigrationService struct {
+	BaseService
+}
+
+func NewStorageMigrationService(tx *gorm.DB) StorageMigrationService {
+	service := StorageMigrationService{BaseService: BaseService{
+		DB: repositories.GetDB(),
+		TX: tx,
+	}}
+	return service
+}
+
+// MigrateLegacyFiles moves files out of the old data/{groupId}-{groupName} directories into storage under their stable keys.
+// Files already moved are skipped, so it is safe to run more than once.
+func (service StorageMigrationService) MigrateLegacyFiles() (structs.StorageMigrationResult, error) {
+	db := service.GetDB()
+	fileRepository := repositories.NewFileRepository(service.TX)
+	result := structs.StorageMigrationResult{}
+	groups := make([]models.Group, 0)
+
+	err := db.Model(&models.Group{}).Select("id", "name").Find(&groups).Error
+	if err != nil {
+		return result, err
+	}
+
+	for _, group := range groups {
+		groupPath, err := utils.BuildGroupPathString(utils.UintToString(group.ID), group.Name)
+		if err != nil {
+			return result, err
+		}
+
+		fileDataList := make([]models.FileData, 0)
+		err = db.Model(&models.FileData{}).
+			Where("receipt_id IN (?)", db.Model(&models.Receipt{}).Select("id").Where("group_id = ?", group.ID)).
+			Preload("Pages").
+			Find(&fileDataList).Error
+		if err != nil {
+			return result, err
+		}
+
+		for _, fileData := range fileDataList {
+			receiptId := utils.UintToString(fileData.ReceiptId)
+			fileDataId := utils.UintToString(fileData.ID)
+
+			legacyPath := filepath.Join(groupPath, utils.BuildFileName(receiptId, fileDataId, fileData.Name))
+			err = service.migrateLegacyFile(legacyPath, fileRepository.BuildFileDataKey(fileData), &result)
+			if err != nil {
+				return result, err
+			}
+
+			for _, page := range fileData.Pages {
+				legacyPagePath := filepath.Join(groupPath, utils.BuildFileName(receiptId, fileDataId, page.Name))
+				err = service.migrateLegacyFile(legacyPagePath, fileRepository.BuildFileDataPageKey(fileData, page), &result)
+				if err != nil {
+					return result, err
+				}
+			}
+		}
+
+		// Only succeeds once the directory is empty, anything left over is kept for manual review
+		os.Remove(groupPath)
+	}
+
+	logging.LogStd(
+		logging.LOG_LEVEL_INFO,
+		"Storage migration finished, migrated: ", result.MigratedFiles,
+		" already migrated: ", result.AlreadyMigratedFiles,
+		" missing: ", result.MissingFiles,
+	)
+
+	return result, nil
+}
+
+// HasLegacyDirectories reports whether any data/{groupId}-{groupName} directory is left, so startup only scans receipts when there is something to move
+func (service StorageMigrationService) HasLegacyDirectories() (bool, error) {
+	groupPath, err := utils.BuildGroupPathString("0", "")
+	if err != nil {
+		return false, err
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(groupPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && legacyGroupDirectoryRegex.MatchString(entry.Name()) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (service StorageMigrationService) migrateLegacyFile(legacyPath string, key string, result *structs.StorageMigrationResult) error {
+	fileRepository := repositories.NewFileRepository(service.TX)
+
+	fileBytes, err := os.ReadFile(legacyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		exists, err := fileRepository.FileExists(key)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			result.AlreadyMigratedFiles++
+		} else {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, "Missing file during storage migration: ", legacyPath)
+			result.MissingFiles++
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = fileRepository.WriteFile(key, fileBytes)
+	if err != nil {
+		return err
+	}
+
+	result.MigratedFiles++
+	return os.Remove(legacyPath)
+}
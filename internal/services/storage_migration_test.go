@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestShouldMigrateLegacyFilesToStableKeys(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	defer storage.SetStorage(nil)
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+
+	var group models.Group
+	db.Model(&models.Group{}).Where("id = ?", 1).First(&group)
+
+	receipt := models.Receipt{
+		Name:         "Test Receipt",
+		Amount:       decimal.NewFromFloat(10.00),
+		Date:         time.Now(),
+		PaidByUserID: 1,
+		Status:       models.OPEN,
+		GroupId:      group.ID,
+	}
+	db.Create(&receipt)
+
+	fileData := models.FileData{Name: "receipt.pdf", FileType: "application/pdf", ReceiptId: receipt.ID}
+	db.Create(&fileData)
+	page := models.FileDataPage{FileDataId: fileData.ID, PageNumber: 1, Name: "page-1.jpg", FileType: "image/jpeg"}
+	db.Create(&page)
+	missingFileData := models.FileData{Name: "missing.jpg", FileType: "image/jpeg", ReceiptId: receipt.ID}
+	db.Create(&missingFileData)
+
+	groupPath, err := utils.BuildGroupPathString(utils.UintToString(group.ID), group.Name)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(groupPath))
+
+	receiptId := utils.UintToString(receipt.ID)
+	fileDataId := utils.UintToString(fileData.ID)
+	legacyFilePath := filepath.Join(groupPath, utils.BuildFileName(receiptId, fileDataId, fileData.Name))
+	legacyPagePath := filepath.Join(groupPath, utils.BuildFileName(receiptId, fileDataId, page.Name))
+	os.MkdirAll(groupPath, os.ModePerm)
+	utils.WriteFile(legacyFilePath, []byte("pdf"))
+	utils.WriteFile(legacyPagePath, []byte("page"))
+
+	service := NewStorageMigrationService(nil)
+	result, err := service.MigrateLegacyFiles()
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.MigratedFiles != 2 || result.MissingFiles != 1 || result.AlreadyMigratedFiles != 0 {
+		utils.PrintTestError(t, result, "2 migrated, 1 missing")
+	}
+
+	fileRepository := repositories.NewFileRepository(nil)
+	fileBytes, err := fileRepository.GetRawBytesForFileData(fileData)
+	if err != nil || string(fileBytes) != "pdf" {
+		utils.PrintTestError(t, string(fileBytes), "pdf")
+	}
+
+	pageBytes, err := fileRepository.ReadFile(fileRepository.BuildFileDataPageKey(fileData, page))
+	if err != nil || string(pageBytes) != "page" {
+		utils.PrintTestError(t, string(pageBytes), "page")
+	}
+
+	_, err = os.Stat(groupPath)
+	if !os.IsNotExist(err) {
+		utils.PrintTestError(t, err, "legacy group directory to be removed")
+	}
+
+	result, err = service.MigrateLegacyFiles()
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if result.MigratedFiles != 0 || result.AlreadyMigratedFiles != 2 {
+		utils.PrintTestError(t, result, "2 already migrated")
+	}
+}
+
+func TestShouldReadLegacyFilesBeforeTheyAreMigrated(t *testing.T) {
+	defer repositories.TruncateTestDb()
+	defer storage.SetStorage(nil)
+	db := repositories.GetDB()
+	repositories.CreateTestGroupWithUsers()
+	storage.SetStorage(storage.NewLocalStorage(t.TempDir()))
+
+	var group models.Group
+	db.Model(&models.Group{}).Where("id = ?", 1).First(&group)
+
+	receipt := models.Receipt{
+		Name:         "Test Receipt",
+		Amount:       decimal.NewFromFloat(10.00),
+		Date:         time.Now(),
+		PaidByUserID: 1,
+		Status:       models.OPEN,
+		GroupId:      group.ID,
+	}
+	db.Create(&receipt)
+
+	fileData := models.FileData{Name: "receipt.pdf", FileType: "application/pdf", ReceiptId: receipt.ID}
+	db.Create(&fileData)
+	page := models.FileDataPage{FileDataId: fileData.ID, PageNumber: 1, Name: "page-1.jpg", FileType: "image/jpeg"}
+	db.Create(&page)
+
+	service := NewStorageMigrationService(nil)
+	hasLegacyDirectories, err := service.HasLegacyDirectories()
+	if err != nil || hasLegacyDirectories {
+		utils.PrintTestError(t, hasLegacyDirectories, false)
+	}
+
+	groupPath, err := utils.BuildGroupPathString(utils.UintToString(group.ID), group.Name)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(groupPath))
+
+	receiptId := utils.UintToString(receipt.ID)
+	fileDataId := utils.UintToString(fileData.ID)
+	os.MkdirAll(groupPath, os.ModePerm)
+	utils.WriteFile(filepath.Join(groupPath, utils.BuildFileName(receiptId, fileDataId, fileData.Name)), []byte("pdf"))
+	utils.WriteFile(filepath.Join(groupPath, utils.BuildFileName(receiptId, fileDataId, page.Name)), []byte("page"))
+
+	hasLegacyDirectories, err = service.HasLegacyDirectories()
+	if err != nil || !hasLegacyDirectories {
+		utils.PrintTestError(t, hasLegacyDirectories, true)
+	}
+
+	fileRepository := repositories.NewFileRepository(nil)
+	fileBytes, err := fileRepository.GetRawBytesForFileData(fileData)
+	if err != nil || string(fileBytes) != "pdf" {
+		utils.PrintTestError(t, string(fileBytes), "pdf")
+	}
+
+	pageBytes, err := fileRepository.ReadFileDataPage(fileData, page)
+	if err != nil || string(pageBytes) != "page" {
+		utils.PrintTestError(t, string(pageBytes), "page")
+	}
+
+	missingFileData := models.FileData{Name: "missing.jpg", FileType: "image/jpeg", ReceiptId: receipt.ID}
+	db.Create(&missingFileData)
+	_, err = fileRepository.GetRawBytesForFileData(missingFileData)
+	if !errors.Is(err, storage.ErrNotFound) {
+		utils.PrintTestError(t, err, storage.ErrNotFound)
+	}
+}
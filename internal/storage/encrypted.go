@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"receipt-wrangler/api/internal/utils"
+	"time"
+)
+
+// Encrypted objects start with this header, followed by the length of the wrapped data key, the wrapped data key and the ciphertext
+var encryptedObjectHeader = []byte("RWENC1")
+
+const dataKeyLength = 32
+
+// EncryptedStorage envelope encrypts objects before handing them to the wrapped storage.
+// Every object gets its own random data key, which is stored next to the ciphertext encrypted with the master key.
+// Objects without the header are returned as is, so a data directory can be encrypted or decrypted while the app is running.
+type EncryptedStorage struct {
+	Storage       Storage
+	MasterKey     string
+	EncryptWrites bool
+}
+
+func NewEncryptedStorage(storage Storage, masterKey string, encryptWrites bool) (EncryptedStorage, error) {
+	if len(masterKey) == 0 {
+		return EncryptedStorage{}, errors.New("encryption key cannot be empty, please set the environment variable: ENCRYPTION_KEY")
+	}
+
+	return EncryptedStorage{Storage: storage, MasterKey: masterKey, EncryptWrites: encryptWrites}, nil
+}
+
+func (encryptedStorage EncryptedStorage) Put(key string, data []byte) error {
+	if !encryptedStorage.EncryptWrites {
+		return encryptedStorage.Storage.Put(key, data)
+	}
+
+	encryptedData, err := EncryptObject(encryptedStorage.MasterKey, data)
+	if err != nil {
+		return err
+	}
+
+	return encryptedStorage.Storage.Put(key, encryptedData)
+}
+
+func (encryptedStorage EncryptedStorage) Get(key string) ([]byte, error) {
+	data, err := encryptedStorage.Storage.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecryptObject(encryptedStorage.MasterKey, data)
+}
+
+func (encryptedStorage EncryptedStorage) Delete(key string) error {
+	return encryptedStorage.Storage.Delete(key)
+}
+
+func (encryptedStorage EncryptedStorage) Exists(key string) (bool, error) {
+	return encryptedStorage.Storage.Exists(key)
+}
+
+func (encryptedStorage EncryptedStorage) ModifiedAt(key string) (time.Time, error) {
+	return encryptedStorage.Storage.ModifiedAt(key)
+}
+
+func (encryptedStorage EncryptedStorage) List(prefix string) ([]string, error) {
+	return encryptedStorage.Storage.List(prefix)
+}
+
+func IsEncryptedObject(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedObjectHeader)
+}
+
+// EncryptObject encrypts data with a new data key, empty objects have nothing to protect and are left alone
+func EncryptObject(masterKey string, data []byte) ([]byte, error) {
+	if len(data) == 0 || IsEncryptedObject(data) {
+		return data, nil
+	}
+
+	keyBytes := make([]byte, dataKeyLength)
+	_, err := rand.Read(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	dataKey := hex.EncodeToString(keyBytes)
+
+	wrappedKey, err := utils.EncryptData(masterKey, []byte(dataKey))
+	if err != nil {
+		return nil, err
+	}
+
+	cipherText, err := utils.EncryptData(dataKey, data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, len(encryptedObjectHeader)+2+len(wrappedKey)+len(cipherText))
+	result = append(result, encryptedObjectHeader...)
+	result = binary.BigEndian.AppendUint16(result, uint16(len(wrappedKey)))
+	result = append(result, wrappedKey...)
+	result = append(result, cipherText...)
+
+	return result, nil
+}
+
+// DecryptObject returns data as is when it was stored before encryption was turned on
+func DecryptObject(masterKey string, data []byte) ([]byte, error) {
+	if !IsEncryptedObject(data) {
+		return data, nil
+	}
+
+	dataKey, cipherText, err := unwrapDataKey(masterKey, data)
+	if err != nil {
+		return nil, err
+	}
+
+	clearText, err := utils.DecryptData(dataKey, cipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(clearText), nil
+}
+
+func unwrapDataKey(masterKey string, data []byte) (string, []byte, error) {
+	body := data[len(encryptedObjectHeader):]
+	if len(body) < 2 {
+		return "", nil, errors.New("encrypted object is truncated")
+	}
+
+	wrappedKeyLength := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	if len(body) < wrappedKeyLength {
+		return "", nil, errors.New("encrypted object is truncated")
+	}
+
+	dataKey, err := utils.DecryptData(masterKey, body[:wrappedKeyLength])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return dataKey, body[wrappedKeyLength:], nil
+}
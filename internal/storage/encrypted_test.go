@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"bytes"
+	"receipt-wrangler/api/internal/utils"
+	"testing"
+)
+
+func TestShouldPutGetAndDeleteEncryptedFiles(t *testing.T) {
+	encryptedStorage, err := NewEncryptedStorage(NewLocalStorage(t.TempDir()), "test-key", true)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	testStorage(t, encryptedStorage)
+}
+
+func TestShouldEncryptEachFileWithItsOwnDataKey(t *testing.T) {
+	localStorage := NewLocalStorage(t.TempDir())
+	encryptedStorage, err := NewEncryptedStorage(localStorage, "test-key", true)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	data := []byte("card ending 1234")
+	for _, key := range []string{"receipts/1/1-a.jpg", "receipts/1/2-b.jpg"} {
+		err = encryptedStorage.Put(key, data)
+		if err != nil {
+			utils.PrintTestError(t, err, nil)
+			return
+		}
+	}
+
+	first, _ := localStorage.Get("receipts/1/1-a.jpg")
+	second, _ := localStorage.Get("receipts/1/2-b.jpg")
+
+	if !IsEncryptedObject(first) || bytes.Contains(first, data) {
+		utils.PrintTestError(t, string(first), "encrypted data")
+	}
+
+	firstDataKey, _, err := unwrapDataKey("test-key", first)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	secondDataKey, _, err := unwrapDataKey("test-key", second)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if firstDataKey == secondDataKey {
+		utils.PrintTestError(t, firstDataKey, "different data keys")
+	}
+
+	decrypted, err := encryptedStorage.Get("receipts/1/2-b.jpg")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		utils.PrintTestError(t, string(decrypted), string(data))
+	}
+}
+
+func TestShouldReadPlaintextFilesThroughEncryptedStorage(t *testing.T) {
+	localStorage := NewLocalStorage(t.TempDir())
+	encryptedStorage, err := NewEncryptedStorage(localStorage, "test-key", false)
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	err = localStorage.Put("receipts/1/1-a.jpg", []byte("plain"))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	data, err := encryptedStorage.Get("receipts/1/1-a.jpg")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if string(data) != "plain" {
+		utils.PrintTestError(t, string(data), "plain")
+	}
+
+	err = encryptedStorage.Put("receipts/1/2-b.jpg", []byte("still plain"))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	stored, _ := localStorage.Get("receipts/1/2-b.jpg")
+	if string(stored) != "still plain" {
+		utils.PrintTestError(t, string(stored), "still plain")
+	}
+}
+
+func TestShouldNotDecryptWithWrongMasterKey(t *testing.T) {
+	encryptedData, err := EncryptObject("test-key", []byte("receipt"))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	_, err = DecryptObject("other-key", encryptedData)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+
+	_, err = DecryptObject("test-key", encryptedData[:len(encryptedObjectHeader)+1])
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+func TestShouldRequireMasterKeyForEncryptedStorage(t *testing.T) {
+	_, err := NewEncryptedStorage(NewLocalStorage(t.TempDir()), "", true)
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type LocalStorage struct {
+	Root string
+}
+
+func NewLocalStorage(root string) LocalStorage {
+	return LocalStorage{Root: root}
+}
+
+func (localStorage LocalStorage) Put(key string, data []byte) error {
+	path, err := localStorage.buildPath(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func (localStorage LocalStorage) Get(key string) ([]byte, error) {
+	path, err := localStorage.buildPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+func (localStorage LocalStorage) Delete(key string) error {
+	path, err := localStorage.buildPath(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (localStorage LocalStorage) Exists(key string) (bool, error) {
+	path, err := localStorage.buildPath(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (localStorage LocalStorage) ModifiedAt(key string) (time.Time, error) {
+	path, err := localStorage.buildPath(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return info.ModTime(), nil
+}
+
+func (localStorage LocalStorage) List(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+
+	err := filepath.WalkDir(localStorage.Root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(localStorage.Root, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(relativePath)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// buildPath maps a key into the root directory, keys that would escape it are rejected
+func (localStorage LocalStorage) buildPath(key string) (string, error) {
+	path := filepath.Join(localStorage.Root, filepath.FromSlash(key))
+
+	relativePath, err := filepath.Rel(localStorage.Root, path)
+	if err != nil {
+		return "", err
+	}
+
+	if len(key) == 0 || relativePath == "." || strings.HasPrefix(relativePath, "..") {
+		return "", errors.New("invalid storage key")
+	}
+
+	return path, nil
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"errors"
+	"receipt-wrangler/api/internal/utils"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestShouldPutGetAndDeleteLocalFiles(t *testing.T) {
+	localStorage := NewLocalStorage(t.TempDir())
+	testStorage(t, localStorage)
+}
+
+func TestShouldRejectKeysOutsideLocalRoot(t *testing.T) {
+	localStorage := NewLocalStorage(t.TempDir())
+
+	err := localStorage.Put("../escape.txt", []byte("data"))
+	if err == nil {
+		utils.PrintTestError(t, err, "error")
+	}
+}
+
+// testStorage runs the same checks against every backend
+func testStorage(t *testing.T, storageToTest Storage) {
+	err := storageToTest.Put("receipts/1/2-receipt.jpg", []byte("receipt"))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	err = storageToTest.Put("receipts/1/2-page-1.jpg", []byte("page"))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	err = storageToTest.Put("receipts/10/3-other receipt (1).jpg", []byte("other"))
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	data, err := storageToTest.Get("receipts/1/2-receipt.jpg")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if string(data) != "receipt" {
+		utils.PrintTestError(t, string(data), "receipt")
+	}
+
+	data, err = storageToTest.Get("receipts/10/3-other receipt (1).jpg")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if string(data) != "other" {
+		utils.PrintTestError(t, string(data), "other")
+	}
+
+	modifiedAt, err := storageToTest.ModifiedAt("receipts/1/2-page-1.jpg")
+	if err != nil || time.Since(modifiedAt) > time.Minute {
+		utils.PrintTestError(t, modifiedAt, "a recent modification time")
+	}
+
+	_, err = storageToTest.ModifiedAt("receipts/1/missing.jpg")
+	if !errors.Is(err, ErrNotFound) {
+		utils.PrintTestError(t, err, ErrNotFound)
+	}
+
+	keys, err := storageToTest.List("receipts/1/")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+	sort.Strings(keys)
+
+	if len(keys) != 2 || keys[0] != "receipts/1/2-page-1.jpg" || keys[1] != "receipts/1/2-receipt.jpg" {
+		utils.PrintTestError(t, keys, []string{"receipts/1/2-page-1.jpg", "receipts/1/2-receipt.jpg"})
+	}
+
+	err = storageToTest.Delete("receipts/1/2-receipt.jpg")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	exists, err := storageToTest.Exists("receipts/1/2-receipt.jpg")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+		return
+	}
+
+	if exists {
+		utils.PrintTestError(t, exists, false)
+	}
+
+	_, err = storageToTest.Get("receipts/1/2-receipt.jpg")
+	if !errors.Is(err, ErrNotFound) {
+		utils.PrintTestError(t, err, ErrNotFound)
+	}
+
+	// Deleting missing files isn't an error
+	err = storageToTest.Delete("receipts/1/2-receipt.jpg")
+	if err != nil {
+		utils.PrintTestError(t, err, nil)
+	}
+}
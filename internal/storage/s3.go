@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"receipt-wrangler/api/internal/structs"
+	"sort"
+	"strings"
+	"time"
+)
+
+const s3Service = "s3"
+const s3Algorithm = "AWS4-HMAC-SHA256"
+const s3DateFormat = "20060102T150405Z"
+
+// S3Storage talks to any S3 compatible api (AWS, MinIO, Garage, R2...) with signature v4 signed requests
+type S3Storage struct {
+	Config   structs.S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type s3SigningRequest struct {
+	Method      string
+	Path        string
+	Query       url.Values
+	Headers     map[string]string
+	PayloadHash string
+}
+
+func NewS3Storage(s3Config structs.S3Config) (S3Storage, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(s3Config.Endpoint, "/"))
+	if err != nil {
+		return S3Storage{}, err
+	}
+
+	if len(endpoint.Scheme) == 0 || len(endpoint.Host) == 0 {
+		return S3Storage{}, errors.New("s3 endpoint must be an absolute url")
+	}
+
+	return S3Storage{
+		Config:   s3Config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (s3Storage S3Storage) Put(key string, data []byte) error {
+	response, err := s3Storage.do(http.MethodPut, key, nil, data)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return buildS3Error(http.MethodPut, key, response)
+	}
+
+	return nil
+}
+
+func (s3Storage S3Storage) Get(key string) ([]byte, error) {
+	response, err := s3Storage.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, buildS3Error(http.MethodGet, key, response)
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+func (s3Storage S3Storage) Delete(key string) error {
+	response, err := s3Storage.do(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusNoContent &&
+		response.StatusCode != http.StatusOK &&
+		response.StatusCode != http.StatusNotFound {
+		return buildS3Error(http.MethodDelete, key, response)
+	}
+
+	return nil
+}
+
+func (s3Storage S3Storage) Exists(key string) (bool, error) {
+	response, err := s3Storage.do(http.MethodHead, key, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+
+	return false, buildS3Error(http.MethodHead, key, response)
+}
+
+func (s3Storage S3Storage) ModifiedAt(key string) (time.Time, error) {
+	response, err := s3Storage.do(http.MethodHead, key, nil, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return http.ParseTime(response.Header.Get("Last-Modified"))
+	case http.StatusNotFound:
+		return time.Time{}, ErrNotFound
+	}
+
+	return time.Time{}, buildS3Error(http.MethodHead, key, response)
+}
+
+func (s3Storage S3Storage) List(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	continuationToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if len(continuationToken) > 0 {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		response, err := s3Storage.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if response.StatusCode != http.StatusOK {
+			err = buildS3Error(http.MethodGet, prefix, response)
+			response.Body.Close()
+			return nil, err
+		}
+
+		var result s3ListBucketResult
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			keys = append(keys, content.Key)
+		}
+
+		if !result.IsTruncated || len(result.NextContinuationToken) == 0 {
+			return keys, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+func (s3Storage S3Storage) do(method string, key string, query url.Values, body []byte) (*http.Response, error) {
+	host := s3Storage.endpoint.Host
+	path := s3Storage.endpoint.Path
+	if s3Storage.Config.UsePathStyle {
+		path += "/" + s3UriEncode(s3Storage.Config.Bucket, true)
+	} else {
+		host = s3Storage.Config.Bucket + "." + host
+	}
+	path += "/" + s3UriEncode(key, false)
+
+	requestUrl := s3Storage.endpoint.Scheme + "://" + host + path
+	if len(query) > 0 {
+		requestUrl += "?" + buildS3CanonicalQuery(query)
+	}
+
+	request, err := http.NewRequest(method, requestUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	payloadHash := hashHex(body)
+	request.Header.Set("x-amz-date", now.Format(s3DateFormat))
+	request.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signRequest(request, payloadHash, s3Storage.Config.AccessKeyId, s3Storage.Config.SecretAccessKey, s3Storage.Config.Region, now)
+
+	return s3Storage.client.Do(request)
+}
+
+func buildS3Error(method string, key string, response *http.Response) error {
+	var responseError s3Error
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+	xml.Unmarshal(body, &responseError)
+
+	if len(responseError.Code) > 0 {
+		return fmt.Errorf("s3 %s %s failed with %d: %s %s", method, key, response.StatusCode, responseError.Code, responseError.Message)
+	}
+
+	return fmt.Errorf("s3 %s %s failed with %d", method, key, response.StatusCode)
+}
+
+// signRequest adds a signature v4 Authorization header covering the host and every header already set on the request
+func signRequest(request *http.Request, payloadHash string, accessKeyId string, secretAccessKey string, region string, now time.Time) {
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	signingRequest := s3SigningRequest{
+		Method:      request.Method,
+		Path:        request.URL.EscapedPath(),
+		Query:       request.URL.Query(),
+		Headers:     headers,
+		PayloadHash: payloadHash,
+	}
+
+	signedHeaders, signature := buildS3Signature(signingRequest, secretAccessKey, region, now)
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm,
+		accessKeyId,
+		buildS3Scope(region, now),
+		signedHeaders,
+		signature,
+	))
+}
+
+func buildS3Signature(signingRequest s3SigningRequest, secretAccessKey string, region string, now time.Time) (string, string) {
+	headerNames := make([]string, 0, len(signingRequest.Headers))
+	for name := range signingRequest.Headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	canonicalHeaders := ""
+	for _, name := range headerNames {
+		canonicalHeaders += name + ":" + strings.TrimSpace(signingRequest.Headers[name]) + "\n"
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	path := signingRequest.Path
+	if len(path) == 0 {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		signingRequest.Method,
+		path,
+		buildS3CanonicalQuery(signingRequest.Query),
+		canonicalHeaders,
+		signedHeaders,
+		signingRequest.PayloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3DateFormat),
+		buildS3Scope(region, now),
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+secretAccessKey), now.Format("20060102"))
+	signingKey = hmacSha256(signingKey, region)
+	signingKey = hmacSha256(signingKey, s3Service)
+	signingKey = hmacSha256(signingKey, "aws4_request")
+
+	return signedHeaders, hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+}
+
+func buildS3Scope(region string, now time.Time) string {
+	return now.Format("20060102") + "/" + region + "/" + s3Service + "/aws4_request"
+}
+
+func buildS3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3UriEncode(key, true)+"="+s3UriEncode(value, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// s3UriEncode percent encodes everything but unreserved characters, as the signature v4 spec requires
+func s3UriEncode(value string, encodeSlash bool) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		isUnreserved := (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~'
+
+		if isUnreserved || (b == '/' && !encodeSlash) {
+			builder.WriteByte(b)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+
+	return builder.String()
+}
+
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"receipt-wrangler/api/internal/constants"
+	"receipt-wrangler/api/internal/structs"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores files by object key, keys use / as separator regardless of backend
+type Storage interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+	Exists(key string) (bool, error)
+	ModifiedAt(key string) (time.Time, error)
+	List(prefix string) ([]string, error)
+}
+
+var storage Storage
+var storageLock sync.RWMutex
+
+func InitStorage(storageConfig structs.StorageConfig) error {
+	newStorage, err := NewStorage(storageConfig)
+	if err != nil {
+		return err
+	}
+
+	SetStorage(newStorage)
+	return nil
+}
+
+func NewStorage(storageConfig structs.StorageConfig) (Storage, error) {
+	backendStorage, err := NewBackendStorage(storageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files encrypted earlier stay readable as long as the key is set, even with encryption turned off
+	if storageConfig.Encrypt || len(storageConfig.EncryptionKey) > 0 {
+		return NewEncryptedStorage(backendStorage, storageConfig.EncryptionKey, storageConfig.Encrypt)
+	}
+
+	return backendStorage, nil
+}
+
+// NewBackendStorage returns the configured backend without encryption, objects are read and written as stored
+func NewBackendStorage(storageConfig structs.StorageConfig) (Storage, error) {
+	switch storageConfig.Backend {
+	case constants.S3StorageBackend:
+		return NewS3Storage(storageConfig.S3)
+	case constants.LocalStorageBackend, "":
+		path := storageConfig.Path
+		if len(path) == 0 {
+			defaultPath, err := GetDefaultLocalPath()
+			if err != nil {
+				return nil, err
+			}
+			path = defaultPath
+		}
+
+		return NewLocalStorage(path), nil
+	}
+
+	return nil, errors.New("invalid storage backend")
+}
+
+// GetStorage returns the configured storage, falling back to local storage in the data directory
+func GetStorage() Storage {
+	storageLock.RLock()
+	currentStorage := storage
+	storageLock.RUnlock()
+
+	if currentStorage != nil {
+		return currentStorage
+	}
+
+	path, err := GetDefaultLocalPath()
+	if err != nil {
+		path = "data"
+	}
+
+	storageLock.Lock()
+	defer storageLock.Unlock()
+	if storage == nil {
+		storage = NewLocalStorage(path)
+	}
+
+	return storage
+}
+
+func SetStorage(newStorage Storage) {
+	storageLock.Lock()
+	defer storageLock.Unlock()
+
+	storage = newStorage
+}
+
+func GetDefaultLocalPath() (string, error) {
+	basePath, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(basePath, "data"), nil
+}
@@ -0,0 +1,38 @@
+package structs
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"time"
+)
+
+// Backup is the content of backup.json in a backup archive. Ids are the ones of the backed up instance and are remapped on restore.
+type Backup struct {
+	Version      int                  `json:"version"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	Users        []BackupUser         `json:"users"`
+	Categories   []models.Category    `json:"categories"`
+	Tags         []models.Tag         `json:"tags"`
+	CustomFields []models.CustomField `json:"customFields"`
+	Groups       []GroupBackup        `json:"groups"`
+}
+
+// Users are matched by username on restore
+type BackupUser struct {
+	Id          uint   `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	IsDummyUser bool   `json:"isDummyUser"`
+}
+
+type GroupBackup struct {
+	Group      models.Group       `json:"group"`
+	Receipts   []models.Receipt   `json:"receipts"`
+	Dashboards []models.Dashboard `json:"dashboards"`
+}
+
+type BackupRestoreResult struct {
+	Groups       []models.Group `json:"groups"`
+	Receipts     int            `json:"receipts"`
+	Files        int            `json:"files"`
+	CreatedUsers []string       `json:"createdUsers"`
+}
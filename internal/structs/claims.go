@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"receipt-wrangler/api/internal/models"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -17,6 +18,8 @@ type Claims struct {
 	Username           string             `json:"username"`
 	UserRole           models.UserRole    `json:"userRole"`
 	ApiKeyScope        models.ApiKeyScope `json:"apiKeyScope"`
+	ApiKeyId           string             `json:"-"`
+	ApiKeyGroupIds     []uint             `json:"-"`
 	jwt.RegisteredClaims
 }
 
@@ -66,3 +69,24 @@ func (claim *Claims) Validate(ctx context.Context) error {
 
 	return nil
 }
+
+// CanAccessGroup is false for groups an api key is not restricted to, unrestricted keys and users may access any of their groups
+func (claim *Claims) CanAccessGroup(groupId uint) bool {
+	return len(claim.ApiKeyGroupIds) == 0 || slices.Contains(claim.ApiKeyGroupIds, groupId)
+}
+
+// FilterAccessibleGroupIds narrows queries spanning several groups down to the groups an api key is restricted to
+func (claim *Claims) FilterAccessibleGroupIds(groupIds []uint) []uint {
+	if len(claim.ApiKeyGroupIds) == 0 {
+		return groupIds
+	}
+
+	accessibleGroupIds := make([]uint, 0, len(groupIds))
+	for _, groupId := range groupIds {
+		if claim.CanAccessGroup(groupId) {
+			accessibleGroupIds = append(accessibleGroupIds, groupId)
+		}
+	}
+
+	return accessibleGroupIds
+}
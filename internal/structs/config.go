@@ -40,6 +40,31 @@ type RedisConfig struct {
 	Password string `json:"password"`
 }
 
+type SmtpConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+type S3Config struct {
+	Endpoint        string `json:"endpoint"`
+	Bucket          string `json:"bucket"`
+	Region          string `json:"region"`
+	AccessKeyId     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	UsePathStyle    bool   `json:"usePathStyle"`
+}
+
+type StorageConfig struct {
+	Backend       string   `json:"backend"`
+	Path          string   `json:"path"`
+	S3            S3Config `json:"s3"`
+	Encrypt       bool     `json:"encrypt"`
+	EncryptionKey string   `json:"-"`
+}
+
 type DebugConfig struct {
 	DebugOcr bool `json:"debugOcr"`
 }
@@ -53,3 +78,19 @@ type Config struct {
 	Database             DatabaseConfig  `json:"database"`
 	Debug                DebugConfig     `json:"debug"`
 }
+
+type StorageMigrationResult struct {
+	MigratedFiles        int `json:"migratedFiles"`
+	AlreadyMigratedFiles int `json:"alreadyMigratedFiles"`
+	MissingFiles         int `json:"missingFiles"`
+}
+
+type StorageEncryptionMigrationResult struct {
+	ConvertedFiles int `json:"convertedFiles"`
+	SkippedFiles   int `json:"skippedFiles"`
+}
+
+type ImagePreviewBackfillResult struct {
+	GeneratedPreviews int `json:"generatedPreviews"`
+	FailedFiles       int `json:"failedFiles"`
+}
@@ -0,0 +1,12 @@
+package structs
+
+type DatabaseTransferResult struct {
+	DryRun bool                          `json:"dryRun"`
+	Tables []DatabaseTransferTableResult `json:"tables"`
+}
+
+type DatabaseTransferTableResult struct {
+	Table    string `json:"table"`
+	Rows     int64  `json:"rows"`
+	Checksum string `json:"checksum"`
+}
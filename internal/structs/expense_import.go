@@ -0,0 +1,16 @@
+package structs
+
+import "receipt-wrangler/api/internal/models"
+
+type ExpenseImportResult struct {
+	Group models.Group `json:"group"`
+	// Receipts created by this run
+	Receipts int `json:"receipts"`
+	// Expenses imported by an earlier run
+	SkippedExpenses int      `json:"skippedExpenses"`
+	CreatedUsers    []string `json:"createdUsers"`
+	// Participants without a user, only admins create dummy users for them
+	UnassignedParticipants []string `json:"unassignedParticipants"`
+	// Participants added to the group by this run
+	AddedMembers []string `json:"addedMembers"`
+}
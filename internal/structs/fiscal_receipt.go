@@ -0,0 +1,25 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type FiscalQrFormat string
+
+const (
+	SWISS_QR_BILL  FiscalQrFormat = "SWISS_QR_BILL"
+	EPC_QR         FiscalQrFormat = "EPC_QR"
+	AT_RKSV        FiscalQrFormat = "AT_RKSV"
+	DE_KASSENSICHV FiscalQrFormat = "DE_KASSENSICHV"
+	RU_FNS         FiscalQrFormat = "RU_FNS"
+)
+
+// FiscalReceipt holds the receipt fields encoded in a fiscal or payment QR code, nil and empty fields were not present
+type FiscalReceipt struct {
+	Format   FiscalQrFormat
+	Merchant string
+	Amount   *decimal.Decimal
+	Date     *time.Time
+}
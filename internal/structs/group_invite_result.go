@@ -0,0 +1,20 @@
+package structs
+
+import (
+	"receipt-wrangler/api/internal/models"
+	"time"
+)
+
+type GroupInviteResult struct {
+	Invite models.GroupInvite `json:"invite"`
+	// Only returned once, the invite stores a hash of it
+	Token string `json:"token"`
+	Url   string `json:"url"`
+}
+
+// Public details of an invite, shown before it is accepted
+type GroupInviteInfo struct {
+	GroupName string           `json:"groupName"`
+	GroupRole models.GroupRole `json:"groupRole"`
+	ExpiresAt time.Time        `json:"expiresAt"`
+}
@@ -7,4 +7,29 @@ const (
 	TAGS         PromptTemplateVariable = "@tags"
 	OCR_TEXT     PromptTemplateVariable = "@ocrText"
 	CURRENT_YEAR PromptTemplateVariable = "@currentYear"
+	// Words the OCR engine recognized below the settings' confidence threshold
+	LOW_CONFIDENCE_WORDS PromptTemplateVariable = "@lowConfidenceWords"
+	// Barcodes and QR codes decoded from the image, as json
+	BARCODES PromptTemplateVariable = "@barcodes"
 )
+
+func GetPromptTemplateVariables() []PromptTemplateVariable {
+	return []PromptTemplateVariable{
+		CATEGORIES,
+		TAGS,
+		OCR_TEXT,
+		CURRENT_YEAR,
+		LOW_CONFIDENCE_WORDS,
+		BARCODES,
+	}
+}
+
+func (self PromptTemplateVariable) IsValid() bool {
+	for _, variable := range GetPromptTemplateVariables() {
+		if self == variable {
+			return true
+		}
+	}
+
+	return false
+}
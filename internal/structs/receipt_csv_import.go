@@ -0,0 +1,16 @@
+package structs
+
+import "receipt-wrangler/api/internal/models"
+
+type ReceiptCsvImportRow struct {
+	// Line of the row in the csv, the header is line 1
+	Line    int            `json:"line"`
+	Receipt models.Receipt `json:"receipt"`
+	Errors  ValidatorError `json:"errors"`
+}
+
+type ReceiptCsvImportPreview struct {
+	Rows        []ReceiptCsvImportRow `json:"rows"`
+	ValidRows   int                   `json:"validRows"`
+	InvalidRows int                   `json:"invalidRows"`
+}
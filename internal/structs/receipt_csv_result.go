@@ -3,4 +3,13 @@ package structs
 type ReceiptCsvResult struct {
 	ReceiptCsvBytes     []byte
 	ReceiptItemCsvBytes []byte
+	// Only set when comments are included
+	CommentCsvBytes []byte
+}
+
+// ReceiptCsvOptions selects the optional parts of a csv export
+type ReceiptCsvOptions struct {
+	IncludeCustomFields bool `json:"includeCustomFields"`
+	IncludeComments     bool `json:"includeComments"`
+	IncludeImages       bool `json:"includeImages"`
 }
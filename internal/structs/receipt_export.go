@@ -0,0 +1,7 @@
+package structs
+
+type ReceiptExport struct {
+	Bytes       []byte
+	FileName    string
+	ContentType string
+}
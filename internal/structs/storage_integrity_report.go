@@ -0,0 +1,28 @@
+package structs
+
+type StorageIntegrityIssue struct {
+	Key            string `json:"key"`
+	FileDataId     uint   `json:"fileDataId,omitempty"`
+	FileDataPageId uint   `json:"fileDataPageId,omitempty"`
+	Expected       string `json:"expected,omitempty"`
+	Actual         string `json:"actual,omitempty"`
+}
+
+type StorageIntegrityReport struct {
+	CheckedFiles       int                     `json:"checkedFiles"`
+	MissingFiles       []StorageIntegrityIssue `json:"missingFiles"`
+	OrphanedFiles      []StorageIntegrityIssue `json:"orphanedFiles"`
+	SizeMismatches     []StorageIntegrityIssue `json:"sizeMismatches"`
+	ChecksumMismatches []StorageIntegrityIssue `json:"checksumMismatches"`
+	QuarantinedFiles   int                     `json:"quarantinedFiles"`
+	RemovedRecords     int                     `json:"removedRecords"`
+	UpdatedRecords     int                     `json:"updatedRecords"`
+}
+
+// HasUnresolvedIssues is true when problems are left over after the requested actions ran
+func (report StorageIntegrityReport) HasUnresolvedIssues() bool {
+	return len(report.MissingFiles) > report.RemovedRecords ||
+		len(report.OrphanedFiles) > report.QuarantinedFiles ||
+		len(report.SizeMismatches) > 0 ||
+		len(report.ChecksumMismatches) > 0
+}
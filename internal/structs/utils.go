@@ -14,6 +14,21 @@ func GetClaims(r *http.Request) *Claims {
 	return r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims).CustomClaims.(*Claims)
 }
 
+// Returns nil when the request has not been through an auth middleware, e.g. on login
+func GetClaimsIfPresent(r *http.Request) *Claims {
+	validatedClaims, ok := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	if !ok || validatedClaims == nil {
+		return nil
+	}
+
+	claims, ok := validatedClaims.CustomClaims.(*Claims)
+	if !ok {
+		return nil
+	}
+
+	return claims
+}
+
 func WriteValidatorErrorResponse(w http.ResponseWriter, err ValidatorError, responseCode int) {
 	bytes, marshalErr := json.Marshal(err.Errors)
 	if marshalErr != nil {
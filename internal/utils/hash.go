@@ -20,3 +20,13 @@ func Sha256Hash128Bit(valueToHash string) []byte {
 	hashBytes := hasher.Sum(nil)
 	return hashBytes[:16]
 }
+
+// IsSha256Hash reports whether value looks like a hex encoded sha256, as returned by Sha256Hash
+func IsSha256Hash(value string) bool {
+	if len(value) != sha256.Size*2 {
+		return false
+	}
+
+	decoded, err := hex.DecodeString(value)
+	return err == nil && value == hex.EncodeToString(decoded)
+}
@@ -17,3 +17,17 @@ func TestSha256Hash128Bit(t *testing.T) {
 		PrintTestError(t, len(hashedValue), 16)
 	}
 }
+
+func TestShouldRecognizeSha256Hashes(t *testing.T) {
+	hash := Sha256Hash([]byte("receipt"))
+
+	if !IsSha256Hash(hash) {
+		PrintTestError(t, IsSha256Hash(hash), true)
+	}
+
+	for _, value := range []string{"", "abc", hash[:63] + "g", "../" + hash[3:]} {
+		if IsSha256Hash(value) {
+			PrintTestError(t, value, "not a sha256 hash")
+		}
+	}
+}
@@ -3,7 +3,10 @@ package utils
 import (
 	"encoding/json"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"receipt-wrangler/api/internal/constants"
 	"strings"
 )
 
@@ -57,3 +60,76 @@ func IsMobileApp(r *http.Request) bool {
 	userAgent := r.UserAgent()
 	return strings.Contains(userAgent, "(dart:io)")
 }
+
+// Proxy headers are only used when the request comes from one of the comma separated TRUSTED_PROXIES ips or cidrs,
+// otherwise any client could forge the address recorded in the audit log
+func GetRequestIpAddress(r *http.Request) string {
+	remoteIp, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIp = r.RemoteAddr
+	}
+
+	trustedProxies := getTrustedProxies()
+	if !isTrustedProxy(remoteIp, trustedProxies) {
+		return remoteIp
+	}
+
+	// The closest untrusted hop is the client, anything left of it could have been sent by the client itself
+	forwardedFor := r.Header.Get("X-Forwarded-For")
+	if len(forwardedFor) > 0 {
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if len(hop) > 0 && !isTrustedProxy(hop, trustedProxies) {
+				return hop
+			}
+		}
+	}
+
+	realIp := r.Header.Get("X-Real-Ip")
+	if len(realIp) > 0 {
+		return strings.TrimSpace(realIp)
+	}
+
+	return remoteIp
+}
+
+func getTrustedProxies() []*net.IPNet {
+	trustedProxies := make([]*net.IPNet, 0)
+	for _, value := range strings.Split(os.Getenv(string(constants.TrustedProxies)), ",") {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			if strings.Contains(value, ":") {
+				value += "/128"
+			} else {
+				value += "/32"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err == nil {
+			trustedProxies = append(trustedProxies, network)
+		}
+	}
+
+	return trustedProxies
+}
+
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(parsedIp) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -96,3 +96,38 @@ func TestWriteCustomErrorResponseWritesResponse(t *testing.T) {
 // 		PrintTestError(t, vErr, bodyVErr)
 // 	}
 // }
+
+func TestGetRequestIpAddressPrefersForwardedForFromTrustedProxy(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+	r := httptest.NewRequest(http.MethodGet, "/api", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7, 10.0.0.2")
+
+	result := GetRequestIpAddress(r)
+	if result != "203.0.113.7" {
+		PrintTestError(t, result, "203.0.113.7")
+	}
+}
+
+func TestGetRequestIpAddressIgnoresHeadersFromUntrustedClients(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1")
+	r := httptest.NewRequest(http.MethodGet, "/api", nil)
+	r.RemoteAddr = "198.51.100.1:4321"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	r.Header.Set("X-Real-Ip", "203.0.113.7")
+
+	result := GetRequestIpAddress(r)
+	if result != "198.51.100.1" {
+		PrintTestError(t, result, "198.51.100.1")
+	}
+}
+
+func TestGetRequestIpAddressFallsBackToRemoteAddr(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+
+	result := GetRequestIpAddress(r)
+	if result != "10.0.0.1" {
+		PrintTestError(t, result, "10.0.0.1")
+	}
+}
@@ -0,0 +1,27 @@
+package wranglerasynq
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/services"
+	"time"
+)
+
+// Owners are notified once a key is within this window of its expiry
+const apiKeyExpiryNotificationWindow = 7 * 24 * time.Hour
+
+func HandleApiKeyExpiryNotifyTask(context context.Context, task *asynq.Task) error {
+	apiKeyService := services.NewApiKeyService(nil)
+	notifiedCount, err := apiKeyService.NotifyExpiringApiKeys(apiKeyExpiryNotificationWindow)
+	if err != nil {
+		return err
+	}
+
+	if notifiedCount > 0 {
+		logging.LogStd(logging.LOG_LEVEL_INFO, fmt.Sprintf("Sent expiry notifications for %d API keys", notifiedCount))
+	}
+
+	return nil
+}
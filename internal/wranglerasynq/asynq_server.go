@@ -70,6 +70,14 @@ func BuildMux() *asynq.ServeMux {
 	mux.HandleFunc(EmailProcess, HandleEmailProcessTask)
 	mux.HandleFunc(EmailProcessImageCleanUp, HandleEmailProcessImageCleanUpTask)
 	mux.HandleFunc(RefreshTokenCleanUp, HandleRefreshTokenCleanupTask)
+	mux.HandleFunc(AuditLogCleanUp, HandleAuditLogCleanUpTask)
+	mux.HandleFunc(ApiKeyExpiryNotify, HandleApiKeyExpiryNotifyTask)
+	mux.HandleFunc(StorageIntegrityCheck, HandleStorageIntegrityCheckTask)
+	mux.HandleFunc(ResumableUploadCleanUp, HandleResumableUploadCleanUpTask)
+	mux.HandleFunc(ReceiptCsvImport, HandleReceiptCsvImportTask)
+	mux.HandleFunc(ExpenseReport, HandleExpenseReportTask)
+	mux.HandleFunc(ExpenseReportCleanUp, HandleExpenseReportCleanUpTask)
+	mux.HandleFunc(ScheduledExport, HandleScheduledExportTask)
 
 	return mux
 }
@@ -0,0 +1,23 @@
+package wranglerasynq
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/services"
+)
+
+func HandleAuditLogCleanUpTask(context context.Context, task *asynq.Task) error {
+	auditLogService := services.NewAuditLogService(nil)
+	deletedCount, err := auditLogService.DeleteExpiredAuditLogs()
+	if err != nil {
+		return err
+	}
+
+	if deletedCount > 0 {
+		logging.LogStd(logging.LOG_LEVEL_INFO, fmt.Sprintf("Deleted %d expired audit logs", deletedCount))
+	}
+
+	return nil
+}
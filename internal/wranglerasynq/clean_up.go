@@ -17,6 +17,37 @@ func StartSystemCleanUpTasks() error {
 	inspector.DeleteAllScheduledTasks(string(cleanUpQueue))
 	refreshTokenTask := asynq.NewTask(RefreshTokenCleanUp, nil)
 	_, err = RegisterTask("@every 24h", refreshTokenTask, cleanUpQueue, 0)
+	if err != nil {
+		return err
+	}
+
+	auditLogTask := asynq.NewTask(AuditLogCleanUp, nil)
+	_, err = RegisterTask("@every 24h", auditLogTask, cleanUpQueue, 0)
+	if err != nil {
+		return err
+	}
+
+	apiKeyExpiryTask := asynq.NewTask(ApiKeyExpiryNotify, nil)
+	_, err = RegisterTask("@every 24h", apiKeyExpiryTask, cleanUpQueue, 0)
+	if err != nil {
+		return err
+	}
+
+	// Scheduled checks only report, every file is read so it runs weekly
+	storageIntegrityTask := asynq.NewTask(StorageIntegrityCheck, nil)
+	_, err = RegisterTask("@weekly", storageIntegrityTask, cleanUpQueue, 0)
+	if err != nil {
+		return err
+	}
+
+	resumableUploadTask := asynq.NewTask(ResumableUploadCleanUp, nil)
+	_, err = RegisterTask("@every 1h", resumableUploadTask, cleanUpQueue, 0)
+	if err != nil {
+		return err
+	}
+
+	expenseReportTask := asynq.NewTask(ExpenseReportCleanUp, nil)
+	_, err = RegisterTask("@every 24h", expenseReportTask, cleanUpQueue, 0)
 
 	return err
 }
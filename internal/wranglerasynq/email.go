@@ -69,16 +69,20 @@ func GetPollTimeString(pollingInterval int) string {
 func CallClient(pollAllGroups bool, groupIds []string) error {
 	groupSettingsRepository := repositories.NewGroupSettingsRepository(nil)
 	var groupSettings []models.GroupSettings
+	// Archived groups are read-only, so their inboxes are not polled.
+	// A subquery lets gorm quote the groups table, which is a reserved word in MySQL.
+	activeGroupQuery := "group_id IN (?)"
+	activeGroupIds := repositories.GetDB().Model(&models.Group{}).Select("id").Where("status = ?", models.GROUP_ACTIVE)
 
 	if pollAllGroups {
-		allGroupSettings, err := groupSettingsRepository.GetAllGroupSettings("email_integration_enabled = ?", true)
+		allGroupSettings, err := groupSettingsRepository.GetAllGroupSettings("email_integration_enabled = ? AND "+activeGroupQuery, true, activeGroupIds)
 		if err != nil {
 			logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
 			return err
 		}
 		groupSettings = allGroupSettings
 	} else {
-		someGroupSettings, err := groupSettingsRepository.GetAllGroupSettings("email_integration_enabled = ? AND group_id IN ?", true, groupIds)
+		someGroupSettings, err := groupSettingsRepository.GetAllGroupSettings("email_integration_enabled = ? AND group_id IN ? AND "+activeGroupQuery, true, groupIds, activeGroupIds)
 		if err != nil {
 			logging.LogStd(logging.LOG_LEVEL_ERROR, err.Error())
 			return err
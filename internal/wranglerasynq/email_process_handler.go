@@ -156,6 +156,7 @@ func HandleEmailProcessTask(context context.Context, task *asynq.Task) error {
 			Name:      payload.Attachment.Filename,
 			FileType:  payload.Attachment.FileType,
 			Size:      payload.Attachment.Size,
+			Barcodes:  processingMetadata.Barcodes,
 		}
 
 		_, err = receiptImageRepository.CreateReceiptImage(fileData, fileBytes)
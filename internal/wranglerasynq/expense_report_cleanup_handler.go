@@ -0,0 +1,23 @@
+package wranglerasynq
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/services"
+)
+
+func HandleExpenseReportCleanUpTask(context context.Context, task *asynq.Task) error {
+	expenseReportService := services.NewExpenseReportService(nil)
+	deletedCount, err := expenseReportService.DeleteExpiredExpenseReports()
+	if err != nil {
+		return err
+	}
+
+	if deletedCount > 0 {
+		logging.LogStd(logging.LOG_LEVEL_INFO, fmt.Sprintf("Deleted %d expired expense reports", deletedCount))
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package wranglerasynq
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hibiken/asynq"
+	"receipt-wrangler/api/internal/services"
+)
+
+type ExpenseReportTaskPayload struct {
+	ReceiptIds  []string
+	GroupId     *uint
+	RanByUserId uint
+}
+
+func HandleExpenseReportTask(context context.Context, task *asynq.Task) error {
+	taskId, err := GetTaskIdFromContext(context)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	var payload ExpenseReportTaskPayload
+	err = json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	expenseReportService := services.NewExpenseReportService(nil)
+	_, err = expenseReportService.CreateExpenseReport(payload.ReceiptIds, payload.GroupId, payload.RanByUserId, taskId)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package wranglerasynq
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hibiken/asynq"
+	"os"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/utils"
+)
+
+type ReceiptCsvImportTaskPayload struct {
+	Command     commands.ImportReceiptCsvCommand
+	TempPath    string
+	RanByUserId uint
+}
+
+func HandleReceiptCsvImportTask(context context.Context, task *asynq.Task) error {
+	taskId, err := GetTaskIdFromContext(context)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	var payload ReceiptCsvImportTaskPayload
+	err = json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	csvBytes, err := utils.ReadFile(payload.TempPath)
+	if err != nil {
+		return HandleError(err)
+	}
+	payload.Command.Csv = csvBytes
+
+	receiptCsvImportService := services.NewReceiptCsvImportService(nil)
+	_, err = receiptCsvImportService.ImportReceiptCsv(payload.Command, payload.RanByUserId, taskId)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	// The file is kept on failure so retries can read it again
+	os.Remove(payload.TempPath)
+	return nil
+}
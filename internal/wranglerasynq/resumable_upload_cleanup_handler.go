@@ -0,0 +1,23 @@
+package wranglerasynq
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/services"
+)
+
+func HandleResumableUploadCleanUpTask(context context.Context, task *asynq.Task) error {
+	resumableUploadService := services.NewResumableUploadService(nil)
+	deletedCount, err := resumableUploadService.DeleteExpiredResumableUploads()
+	if err != nil {
+		return err
+	}
+
+	if deletedCount > 0 {
+		logging.LogStd(logging.LOG_LEVEL_INFO, fmt.Sprintf("Deleted %d expired resumable uploads", deletedCount))
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package wranglerasynq
+
+import (
+	"encoding/json"
+	"errors"
+	"receipt-wrangler/api/internal/models"
+	"receipt-wrangler/api/internal/repositories"
+	"sync"
+
+	"github.com/hibiken/asynq"
+)
+
+// Scheduler entries of scheduled exports by export id, entries only live in this process
+var scheduledExportEntryIds = make(map[uint]string)
+var scheduledExportEntryIdsMutex sync.Mutex
+
+func StartScheduledExports() error {
+	scheduledExports, err := repositories.NewScheduledExportRepository(nil).GetEnabledScheduledExports()
+	if err != nil {
+		return err
+	}
+
+	for _, scheduledExport := range scheduledExports {
+		err = ScheduleExport(scheduledExport)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScheduleExport replaces the scheduler entry of an export, disabled exports are only unscheduled
+func ScheduleExport(scheduledExport models.ScheduledExport) error {
+	if scheduler == nil {
+		return errors.New("asynq scheduler is not running")
+	}
+
+	err := UnscheduleExport(scheduledExport.ID)
+	if err != nil {
+		return err
+	}
+
+	if !scheduledExport.Enabled {
+		return nil
+	}
+
+	payloadBytes, err := json.Marshal(ScheduledExportTaskPayload{ScheduledExportId: scheduledExport.ID})
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(ScheduledExport, payloadBytes)
+	entryId, err := RegisterTask(scheduledExport.Schedule, task, models.QuickScanQueue, 0)
+	if err != nil {
+		return err
+	}
+
+	scheduledExportEntryIdsMutex.Lock()
+	scheduledExportEntryIds[scheduledExport.ID] = entryId
+	scheduledExportEntryIdsMutex.Unlock()
+
+	return nil
+}
+
+func UnscheduleExport(scheduledExportId uint) error {
+	scheduledExportEntryIdsMutex.Lock()
+	defer scheduledExportEntryIdsMutex.Unlock()
+
+	entryId, ok := scheduledExportEntryIds[scheduledExportId]
+	if !ok {
+		return nil
+	}
+
+	err := scheduler.Unregister(entryId)
+	if err != nil {
+		return err
+	}
+
+	delete(scheduledExportEntryIds, scheduledExportId)
+	return nil
+}
@@ -0,0 +1,47 @@
+package wranglerasynq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/hibiken/asynq"
+	"receipt-wrangler/api/internal/repositories"
+	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type ScheduledExportTaskPayload struct {
+	ScheduledExportId uint
+}
+
+func HandleScheduledExportTask(context context.Context, task *asynq.Task) error {
+	taskId, err := GetTaskIdFromContext(context)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	var payload ScheduledExportTaskPayload
+	err = json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	scheduledExport, err := repositories.NewScheduledExportRepository(nil).
+		GetScheduledExportById(utils.UintToString(payload.ScheduledExportId))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Deleted with its group, nothing left to export
+		return UnscheduleExport(payload.ScheduledExportId)
+	}
+	if err != nil {
+		return HandleError(err)
+	}
+
+	_, err = services.NewScheduledExportService(nil).RunScheduledExport(scheduledExport, taskId)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package wranglerasynq
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hibiken/asynq"
+	"receipt-wrangler/api/internal/commands"
+	"receipt-wrangler/api/internal/services"
+)
+
+type StorageIntegrityCheckTaskPayload struct {
+	Command     commands.RunStorageIntegrityCheckCommand
+	RanByUserId *uint
+}
+
+func HandleStorageIntegrityCheckTask(context context.Context, task *asynq.Task) error {
+	taskId, err := GetTaskIdFromContext(context)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	// Scheduled checks have no payload and only report
+	var payload StorageIntegrityCheckTaskPayload
+	if len(task.Payload()) > 0 {
+		err = json.Unmarshal(task.Payload(), &payload)
+		if err != nil {
+			return HandleError(err)
+		}
+	}
+
+	storageIntegrityService := services.NewStorageIntegrityService(nil)
+	_, err = storageIntegrityService.RunIntegrityCheck(payload.Command, payload.RanByUserId, taskId)
+	if err != nil {
+		return HandleError(err)
+	}
+
+	return nil
+}
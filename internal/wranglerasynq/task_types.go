@@ -6,4 +6,12 @@ const (
 	EmailProcess             = "email:process"
 	EmailProcessImageCleanUp = "email:process_image_cleanup"
 	RefreshTokenCleanUp      = "system_clean_up:refresh_token"
+	AuditLogCleanUp          = "system_clean_up:audit_log"
+	ApiKeyExpiryNotify       = "system_clean_up:api_key_expiry_notify"
+	StorageIntegrityCheck    = "system_clean_up:storage_integrity_check"
+	ResumableUploadCleanUp   = "system_clean_up:resumable_upload"
+	ReceiptCsvImport         = "receipt:csv_import"
+	ExpenseReport            = "export:expense_report"
+	ExpenseReportCleanUp     = "system_clean_up:expense_report"
+	ScheduledExport          = "export:scheduled"
 )
@@ -2,16 +2,22 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"receipt-wrangler/api/internal/cli"
 	config "receipt-wrangler/api/internal/env"
 	"receipt-wrangler/api/internal/logging"
+	"receipt-wrangler/api/internal/migrations"
 	"receipt-wrangler/api/internal/repositories"
 	"receipt-wrangler/api/internal/routers"
 	"receipt-wrangler/api/internal/services"
+	"receipt-wrangler/api/internal/storage"
+	"receipt-wrangler/api/internal/utils"
 	"receipt-wrangler/api/internal/wranglerasynq"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -35,13 +41,41 @@ func main() {
 		logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
 	}
 
+	// Validating reports every problem instead of stopping at the first missing variable
+	if flag.Arg(0) == "config" {
+		err = cli.RunAdminCommand(flag.Args(), os.Stdin, os.Stdout)
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+		return
+	}
+
 	config.CheckRequiredEnvironmentVariables()
 
+	storageConfig, err := config.GetStorageConfig()
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+	}
+
+	err = storage.InitStorage(storageConfig)
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+	}
+
 	err = repositories.Connect()
 	if err != nil {
 		logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
 	}
 
+	// Migrations are managed before the schema is checked, so a newer schema can still be inspected
+	if flag.Arg(0) == "migrate" {
+		err = runMigrateCommand(flag.Arg(1), flag.Arg(2))
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+		return
+	}
+
 	err = repositories.MakeMigrations()
 	if err != nil {
 		logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
@@ -52,6 +86,115 @@ func main() {
 		logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
 	}
 
+	// Maintenance commands run once and exit
+	switch flag.Arg(0) {
+	case "migrate-storage":
+		_, err = services.NewStorageMigrationService(nil).MigrateLegacyFiles()
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+		return
+	case "encrypt-storage", "decrypt-storage":
+		backendStorage, err := storage.NewBackendStorage(storageConfig)
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+
+		if flag.Arg(0) == "encrypt-storage" {
+			_, err = services.EncryptStoredFiles(backendStorage, storageConfig.EncryptionKey)
+		} else {
+			_, err = services.DecryptStoredFiles(backendStorage, storageConfig.EncryptionKey)
+		}
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+		return
+	case "backup":
+		var archive []byte
+		backupService := services.NewBackupService(nil)
+		if flag.NArg() > 2 {
+			groupIds := make([]uint, 0, flag.NArg()-2)
+			for _, arg := range flag.Args()[2:] {
+				groupId, err := utils.StringToUint(arg)
+				if err != nil {
+					logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+				}
+				groupIds = append(groupIds, groupId)
+			}
+
+			archive, err = backupService.CreateGroupBackup(groupIds)
+		} else {
+			archive, err = backupService.CreateInstanceBackup()
+		}
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+
+		err = os.WriteFile(flag.Arg(1), archive, 0600)
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+		return
+	case "restore-backup":
+		imagick.Initialize()
+		defer imagick.Terminate()
+
+		archive, err := os.ReadFile(flag.Arg(1))
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+
+		adminUserId, err := repositories.NewUserRepository(nil).GetFirstAdminUserId()
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+
+		result, err := services.NewBackupService(nil).RestoreBackup(archive, adminUserId)
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+
+		logging.LogStd(logging.LOG_LEVEL_INFO, fmt.Sprintf("Restored %d groups with %d receipts", len(result.Groups), result.Receipts))
+		return
+	case "user", "group", "receipts", "tasks", "apikey":
+		imagick.Initialize()
+		defer imagick.Terminate()
+
+		err = cli.RunAdminCommand(flag.Args(), os.Stdin, os.Stdout)
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+		return
+	case "transfer-database":
+		err = runTransferDatabaseCommand(flag.Arg(1))
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+		return
+	case "generate-previews":
+		imagick.Initialize()
+		defer imagick.Terminate()
+
+		_, err = services.BackfillReceiptImagePreviews()
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+		}
+		return
+	}
+
+	// Existing files are moved on every start until none are left, reads fall back to the old directories meanwhile
+	storageMigrationService := services.NewStorageMigrationService(nil)
+	hasLegacyDirectories, err := storageMigrationService.HasLegacyDirectories()
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_ERROR, "Failed to check for legacy files: ", err.Error())
+	}
+	if hasLegacyDirectories {
+		_, err = storageMigrationService.MigrateLegacyFiles()
+		if err != nil {
+			logging.LogStd(logging.LOG_LEVEL_ERROR, "Storage migration failed: ", err.Error())
+		}
+	}
+
 	err = repositories.ConnectToRedis()
 	if err != nil {
 		logging.LogStd(logging.LOG_LEVEL_FATAL, fmt.Errorf("redis connection error: %w", err))
@@ -99,6 +242,11 @@ func main() {
 		logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
 	}
 
+	err = wranglerasynq.StartScheduledExports()
+	if err != nil {
+		logging.LogStd(logging.LOG_LEVEL_FATAL, err.Error())
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
@@ -119,6 +267,93 @@ func main() {
 	}
 }
 
+// runMigrateCommand runs migrate up, migrate down [steps] or migrate status
+func runMigrateCommand(action string, steps string) error {
+	db := repositories.GetDB()
+
+	switch action {
+	case "up":
+		ranMigrations, err := migrations.Up(db)
+		for _, migration := range ranMigrations {
+			fmt.Printf("Applied %d %s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "down":
+		stepCount := 1
+		if len(steps) > 0 {
+			parsedSteps, err := strconv.Atoi(steps)
+			if err != nil || parsedSteps < 1 {
+				return fmt.Errorf("invalid number of steps: %s", steps)
+			}
+			stepCount = parsedSteps
+		}
+
+		rolledBackMigrations, err := migrations.Down(db, stepCount)
+		for _, migration := range rolledBackMigrations {
+			fmt.Printf("Rolled back %d %s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrations.GetStatus(db)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Unknown {
+				state += " (newer than this binary)"
+			}
+			fmt.Printf("%d %s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate action %q, use up, down [steps] or status", action)
+}
+
+// runTransferDatabaseCommand copies the database into the one configured by the TARGET_DB_* variables
+func runTransferDatabaseCommand(mode string) error {
+	if len(mode) > 0 && mode != "dry-run" {
+		return fmt.Errorf("unknown transfer-database option %q, use dry-run or nothing", mode)
+	}
+
+	sourceConfig, err := config.GetDatabaseConfig()
+	if err != nil {
+		return err
+	}
+
+	targetConfig, err := config.GetTargetDatabaseConfig()
+	if err != nil {
+		return err
+	}
+
+	if sourceConfig == targetConfig {
+		return errors.New("target database is the same as the source database")
+	}
+
+	target, err := repositories.OpenDatabase(targetConfig)
+	if err != nil {
+		return err
+	}
+
+	result, err := services.NewDatabaseTransferService(nil, target).TransferDatabase(mode == "dry-run")
+	if err != nil {
+		return err
+	}
+
+	for _, table := range result.Tables {
+		fmt.Printf("%s %d rows %s\n", table.Table, table.Rows, table.Checksum)
+	}
+	if result.DryRun {
+		fmt.Println("Dry run, nothing was written to the target database")
+	}
+	return nil
+}
+
 func startHttpServer(router *chi.Mux) *http.Server {
 	srv := &http.Server{
 		Handler:      router,
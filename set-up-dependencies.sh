@@ -22,6 +22,12 @@ apt-get install -y -qq libtesseract-dev libleptonica-dev
 # Make sure english is installed
 apt-get install -y -qq tesseract-ocr-eng
 
+# For reading the text layer of pdfs
+apt-get install -y -qq poppler-utils
+
+# For barcode and QR code decoding
+apt-get install -y -qq zbar-tools
+
 # For HEIC support
 apt-get install -y -qq libde265-dev libheif-dev
 